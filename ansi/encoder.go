@@ -0,0 +1,334 @@
+package ansi
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Terminator selects how control strings (OSC, DCS and APC) are terminated
+type Terminator int
+
+const (
+	// TerminatorST terminates control strings with the 7-bit form of ST
+	// (ESC \)
+	TerminatorST Terminator = iota
+	// TerminatorBEL terminates OSC strings with BEL (0x07). This is
+	// commonly understood by terminals for OSC only, DCS and APC strings
+	// are always terminated with ST
+	TerminatorBEL
+)
+
+// Encoder builds escape sequences from the typed values emitted by the
+// Parser. Encoding a sequence and parsing the result yields the original
+// sequence, provided the sequence is representable: a CSI may carry at most
+// MaxCSIParams parameters, and control string payloads may not contain the
+// controls which would cancel them. Such controls are dropped from
+// payloads when encoding.
+//
+// The zero value is ready to use and terminates control strings with ST
+type Encoder struct {
+	Terminator Terminator
+}
+
+// Encode returns the escape sequence for seq using the zero value Encoder
+func Encode(seq Sequence) string {
+	return Encoder{}.Encode(seq)
+}
+
+// Append appends the escape sequence for seq to dst using the zero value
+// Encoder
+func Append(dst []byte, seq Sequence) []byte {
+	return Encoder{}.Append(dst, seq)
+}
+
+// Encode returns the escape sequence for seq
+func (e Encoder) Encode(seq Sequence) string {
+	return string(e.Append(nil, seq))
+}
+
+// Append appends the escape sequence for seq to dst and returns the
+// extended buffer. Sequences of an unknown type are ignored
+func (e Encoder) Append(dst []byte, seq Sequence) []byte {
+	switch seq := seq.(type) {
+	case Print:
+		return append(dst, seq.Grapheme...)
+	case C0:
+		return append(dst, byte(seq))
+	case ESC:
+		dst = append(dst, 0x1B)
+		dst = appendRunes(dst, seq.Intermediates())
+		return utf8.AppendRune(dst, seq.Final)
+	case SS3:
+		dst = append(dst, 0x1B, 'O')
+		return utf8.AppendRune(dst, rune(seq))
+	case CSI:
+		return e.appendCSI(dst, seq)
+	case OSC:
+		return e.appendOSC(dst, seq)
+	case DCS:
+		return e.appendDCS(dst, seq)
+	case APC:
+		dst = append(dst, 0x1B, '_')
+		for _, r := range seq.Data {
+			if isControl(r) {
+				continue
+			}
+			dst = utf8.AppendRune(dst, r)
+		}
+		return append(dst, 0x1B, '\\')
+	default:
+		return dst
+	}
+}
+
+func (e Encoder) appendCSI(dst []byte, seq CSI) []byte {
+	dst = append(dst, 0x1B, '[')
+	// The parser collects private markers as intermediates. They are only
+	// valid as the first character of the sequence, while true
+	// intermediates follow the parameters
+	intermediates := seq.Intermediates()
+	for len(intermediates) > 0 && isPrivateMarker(intermediates[0]) {
+		dst = utf8.AppendRune(dst, intermediates[0])
+		intermediates = intermediates[1:]
+	}
+	for i, p := range seq.Params() {
+		if i > 0 {
+			if seq.ColonAfter(i - 1) {
+				dst = append(dst, ':')
+			} else {
+				dst = append(dst, ';')
+			}
+		}
+		dst = strconv.AppendUint(dst, uint64(p), 10)
+	}
+	dst = appendRunes(dst, intermediates)
+	return utf8.AppendRune(dst, seq.Final)
+}
+
+func (e Encoder) appendOSC(dst []byte, seq OSC) []byte {
+	dst = append(dst, 0x1B, ']')
+	for _, r := range seq.Payload {
+		if isControl(r) {
+			continue
+		}
+		dst = utf8.AppendRune(dst, r)
+	}
+	if e.Terminator == TerminatorBEL {
+		return append(dst, 0x07)
+	}
+	return append(dst, 0x1B, '\\')
+}
+
+func (e Encoder) appendDCS(dst []byte, seq DCS) []byte {
+	dst = append(dst, 0x1B, 'P')
+	intermediates := seq.Intermediates()
+	for len(intermediates) > 0 && isPrivateMarker(intermediates[0]) {
+		dst = utf8.AppendRune(dst, intermediates[0])
+		intermediates = intermediates[1:]
+	}
+	for i, p := range seq.Params() {
+		if i > 0 {
+			dst = append(dst, ';')
+		}
+		dst = strconv.AppendUint(dst, uint64(p), 10)
+	}
+	dst = appendRunes(dst, intermediates)
+	dst = utf8.AppendRune(dst, seq.Final)
+	for _, r := range seq.Data {
+		switch {
+		// CAN, SUB and ESC cancel the string, DEL is discarded and C1
+		// controls cancel the string. Other C0 controls are passed
+		// through to the handler
+		case r == 0x18, r == 0x1A, r == 0x1B, r == 0x7F, in(r, 0x80, 0x9F):
+			continue
+		}
+		dst = utf8.AppendRune(dst, r)
+	}
+	return append(dst, 0x1B, '\\')
+}
+
+func appendRunes(dst []byte, runes []rune) []byte {
+	for _, r := range runes {
+		dst = utf8.AppendRune(dst, r)
+	}
+	return dst
+}
+
+func isPrivateMarker(r rune) bool {
+	return in(r, 0x3C, 0x3F)
+}
+
+// isControl reports whether r is a C0 or C1 control, which are either ignored
+// or terminate OSC and APC strings
+func isControl(r rune) bool {
+	return in(r, 0x00, 0x1F) || in(r, 0x80, 0x9F)
+}
+
+// NewCSI returns a CSI sequence with the given final character and
+// intermediates. A leading private marker (one of < = > ?) is given as the
+// first intermediate, the same as the Parser reports it. Each group is a
+// parameter followed by its colon separated sub-parameters:
+//
+//	NewCSI('m', "", []uint32{38, 2, 255, 0, 0}) // CSI 38:2:255:0:0 m
+//	NewCSI('h', "?", []uint32{2004})            // CSI ? 2004 h
+func NewCSI(final rune, intermediates string, groups ...[]uint32) CSI {
+	seq := CSI{Final: final}
+	seq.NumIntermediate = copy(seq.Intermediate[:], []rune(intermediates))
+	params := make([]uint32, 0, InlineCSIParams)
+	for _, group := range groups {
+		if len(group) == 0 {
+			// An empty group is a single default parameter
+			group = []uint32{0}
+		}
+		for i, p := range group {
+			if i < len(group)-1 && len(params) < MaxCSIParams {
+				seq.ColonSeparators |= 1 << uint(len(params))
+			}
+			params = append(params, p)
+		}
+	}
+	seq.NumParameters = len(params)
+	if len(params) <= InlineCSIParams {
+		copy(seq.Parameters[:], params)
+	} else {
+		seq.ExtraParameters = params
+	}
+	return seq
+}
+
+// NewDCS returns a DCS sequence with the given final character,
+// intermediates, parameters and data string. A leading private marker is
+// given as the first intermediate
+func NewDCS(final rune, intermediates string, params []uint32, data string) DCS {
+	seq := DCS{
+		Final:         final,
+		NumParameters: len(params),
+		Data:          []rune(data),
+	}
+	seq.NumIntermediate = copy(seq.Intermediate[:], []rune(intermediates))
+	if len(params) <= InlineCSIParams {
+		copy(seq.Parameters[:], params)
+	} else {
+		seq.ExtraParameters = append([]uint32(nil), params...)
+	}
+	return seq
+}
+
+// NewOSC returns an OSC sequence whose payload is fields joined by
+// semicolons:
+//
+//	NewOSC("8", "", "https://example.com") // OSC 8 ; ; https://example.com ST
+func NewOSC(fields ...string) OSC {
+	return OSC{Payload: []rune(strings.Join(fields, ";"))}
+}
+
+// SGRParam is a single parameter of a Select Graphic Rendition sequence.
+// Values after the first are sub-parameters, and are encoded with colon
+// separators
+type SGRParam []uint32
+
+var (
+	SGRReset               = SGRParam{0}
+	SGRBold                = SGRParam{1}
+	SGRDim                 = SGRParam{2}
+	SGRItalic              = SGRParam{3}
+	SGRUnderline           = SGRParam{4}
+	SGRBlink               = SGRParam{5}
+	SGRReverse             = SGRParam{7}
+	SGRHidden              = SGRParam{8}
+	SGRStrikethrough       = SGRParam{9}
+	SGRBoldDimReset        = SGRParam{22}
+	SGRItalicReset         = SGRParam{23}
+	SGRUnderlineReset      = SGRParam{24}
+	SGRBlinkReset          = SGRParam{25}
+	SGRReverseReset        = SGRParam{27}
+	SGRHiddenReset         = SGRParam{28}
+	SGRStrikethroughReset  = SGRParam{29}
+	SGRForegroundReset     = SGRParam{39}
+	SGRBackgroundReset     = SGRParam{49}
+	SGROverline            = SGRParam{53}
+	SGROverlineReset       = SGRParam{55}
+	SGRUnderlineColorReset = SGRParam{59}
+)
+
+// SGRUnderlineStyle returns the extended underline style parameter (4:n).
+// Styles are 0 (none), 1 (single), 2 (double), 3 (curly), 4 (dotted) and 5
+// (dashed)
+func SGRUnderlineStyle(style uint32) SGRParam {
+	return SGRParam{4, style}
+}
+
+// SGRForegroundIndex returns the parameter setting the foreground to an
+// indexed color. Indexes 0-7 and 8-15 use the short forms (30-37 and 90-97)
+func SGRForegroundIndex(index uint8) SGRParam {
+	switch {
+	case index < 8:
+		return SGRParam{30 + uint32(index)}
+	case index < 16:
+		return SGRParam{90 + uint32(index) - 8}
+	default:
+		return SGRParam{38, 5, uint32(index)}
+	}
+}
+
+// SGRBackgroundIndex returns the parameter setting the background to an
+// indexed color. Indexes 0-7 and 8-15 use the short forms (40-47 and
+// 100-107)
+func SGRBackgroundIndex(index uint8) SGRParam {
+	switch {
+	case index < 8:
+		return SGRParam{40 + uint32(index)}
+	case index < 16:
+		return SGRParam{100 + uint32(index) - 8}
+	default:
+		return SGRParam{48, 5, uint32(index)}
+	}
+}
+
+// SGRUnderlineColorIndex returns the parameter setting the underline color
+// to an indexed color
+func SGRUnderlineColorIndex(index uint8) SGRParam {
+	return SGRParam{58, 5, uint32(index)}
+}
+
+// SGRForegroundRGB returns the parameter setting the foreground to an RGB
+// color
+func SGRForegroundRGB(r uint8, g uint8, b uint8) SGRParam {
+	return SGRParam{38, 2, uint32(r), uint32(g), uint32(b)}
+}
+
+// SGRBackgroundRGB returns the parameter setting the background to an RGB
+// color
+func SGRBackgroundRGB(r uint8, g uint8, b uint8) SGRParam {
+	return SGRParam{48, 2, uint32(r), uint32(g), uint32(b)}
+}
+
+// SGRUnderlineColorRGB returns the parameter setting the underline color to
+// an RGB color
+func SGRUnderlineColorRGB(r uint8, g uint8, b uint8) SGRParam {
+	return SGRParam{58, 2, uint32(r), uint32(g), uint32(b)}
+}
+
+// SGR returns a CSI m sequence setting each of the parameters.
+// Sub-parameters are separated by colons. Calling SGR with no parameters
+// returns CSI m, which resets all attributes
+func SGR(params ...SGRParam) CSI {
+	groups := make([][]uint32, 0, len(params))
+	for _, p := range params {
+		groups = append(groups, p)
+	}
+	return NewCSI('m', "", groups...)
+}
+
+// SGRLegacy is like SGR but separates sub-parameters with semicolons, for
+// terminals which do not understand the colon form
+func SGRLegacy(params ...SGRParam) CSI {
+	groups := make([][]uint32, 0, len(params))
+	for _, p := range params {
+		for _, v := range p {
+			groups = append(groups, []uint32{v})
+		}
+	}
+	return NewCSI('m', "", groups...)
+}
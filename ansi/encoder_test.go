@@ -0,0 +1,136 @@
+package ansi
+
+import (
+	"strings"
+	"testing"
+)
+
+func parseAll(t *testing.T, input string) []Sequence {
+	t.Helper()
+	parse := NewParser(strings.NewReader(input))
+	seqs := []Sequence{}
+	for seq := range parse.Next() {
+		if _, ok := seq.(EOF); ok {
+			break
+		}
+		seqs = append(seqs, seq)
+	}
+	return seqs
+}
+
+func TestEncoderRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		seq  Sequence
+	}{
+		{name: "Print", seq: Print{"a", 1}},
+		{name: "C0", seq: C0(0x07)},
+		{name: "ESC", seq: escSeq('7', "")},
+		{name: "ESC with intermediate", seq: escSeq('B', "(")},
+		{name: "SS3", seq: SS3('P')},
+		{name: "CSI no params", seq: csiSeq('c', "", nil)},
+		{name: "CSI params", seq: csiSeq('H', "", []int{3, 12})},
+		{name: "CSI private marker", seq: csiSeq('h', "?", []int{2004})},
+		{name: "CSI private marker and intermediate", seq: csiSeq('p', "?$", []int{2026})},
+		{name: "CSI intermediate", seq: csiSeq('q', " ", []int{5})},
+		{name: "CSI kitty keyboard", seq: csiSeq('u', ">", []int{31})},
+		{name: "CSI sub-params", seq: csiSeq('m', "", []int{38, 2, 0, 10, 20, 30, 1}, 0, 1, 2, 3, 4)},
+		{name: "CSI many params", seq: csiSeq('m', "", []int{1, 2, 3, 4, 5, 7, 8, 9, 30, 40, 53, 58, 5, 3})},
+		{name: "OSC", seq: NewOSC("8", "id=1", "https://example.com")},
+		{name: "DCS", seq: dcsSeq('q', "$", nil, " q")},
+		{name: "DCS with params", seq: dcsSeq('r', "", []int{1}, "1$r0m")},
+		{name: "DCS XTGETTCAP", seq: dcsSeq('q', "+", nil, "544E")},
+		{name: "APC", seq: APC{Data: "Gi=1,a=q"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseAll(t, Encode(test.seq))
+			requireEqual(t, []Sequence{test.seq}, got)
+		})
+	}
+}
+
+func TestEncoderBELTerminator(t *testing.T) {
+	enc := Encoder{Terminator: TerminatorBEL}
+	seq := NewOSC("11", "?")
+	requireEqual(t, "\x1b]11;?\x07", enc.Encode(seq))
+	requireEqual(t, []Sequence{seq}, parseAll(t, enc.Encode(seq)))
+
+	// DCS and APC are always terminated with ST
+	requireEqual(t, "\x1b_Ga=q\x1b\\", enc.Encode(APC{Data: "Ga=q"}))
+}
+
+func TestEncoderEscapesControlStrings(t *testing.T) {
+	tests := []struct {
+		name     string
+		seq      Sequence
+		encoded  string
+		expected Sequence
+	}{
+		{
+			name:     "OSC drops ESC and BEL",
+			seq:      NewOSC("2", "a\x1b\\b\x07c"),
+			encoded:  "\x1b]2;a\\bc\x1b\\",
+			expected: NewOSC("2", "a\\bc"),
+		},
+		{
+			name:     "OSC drops C1 controls",
+			seq:      NewOSC("2", "a\u009cb"),
+			encoded:  "\x1b]2;ab\x1b\\",
+			expected: NewOSC("2", "ab"),
+		},
+		{
+			name:     "APC drops controls",
+			seq:      APC{Data: "a\x1bb\nc"},
+			encoded:  "\x1b_abc\x1b\\",
+			expected: APC{Data: "abc"},
+		},
+		{
+			name:     "DCS keeps C0 but drops cancel controls",
+			seq:      dcsSeq('q', "", nil, "a\nb\x1b\x18c"),
+			encoded:  "\x1bPqa\nbc\x1b\\",
+			expected: dcsSeq('q', "", nil, "a\nbc"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded := Encode(test.seq)
+			requireEqual(t, test.encoded, encoded)
+			requireEqual(t, []Sequence{test.expected}, parseAll(t, encoded))
+		})
+	}
+}
+
+func TestNewCSI(t *testing.T) {
+	requireEqual(t, csiSeq('h', "?", []int{2004}), NewCSI('h', "?", []uint32{2004}))
+	requireEqual(t, csiSeq('H', "", []int{0, 5}), NewCSI('H', "", nil, []uint32{5}))
+	requireEqual(t, "\x1b[0;5H", Encode(NewCSI('H', "", nil, []uint32{5})))
+	requireEqual(t,
+		csiSeq('m', "", []int{4, 3, 58, 2, 1, 2, 3}, 0, 2, 3, 4, 5),
+		NewCSI('m', "", []uint32{4, 3}, []uint32{58, 2, 1, 2, 3}),
+	)
+}
+
+func TestSGR(t *testing.T) {
+	tests := []struct {
+		name     string
+		seq      CSI
+		expected string
+	}{
+		{name: "reset", seq: SGR(), expected: "\x1b[m"},
+		{name: "attributes", seq: SGR(SGRBold, SGRItalic), expected: "\x1b[1;3m"},
+		{name: "low index", seq: SGR(SGRForegroundIndex(1), SGRBackgroundIndex(4)), expected: "\x1b[31;44m"},
+		{name: "bright index", seq: SGR(SGRForegroundIndex(9), SGRBackgroundIndex(12)), expected: "\x1b[91;104m"},
+		{name: "extended index", seq: SGR(SGRForegroundIndex(200)), expected: "\x1b[38:5:200m"},
+		{name: "rgb", seq: SGR(SGRForegroundRGB(1, 2, 3), SGRBackgroundRGB(4, 5, 6)), expected: "\x1b[38:2:1:2:3;48:2:4:5:6m"},
+		{name: "underline", seq: SGR(SGRUnderlineStyle(3), SGRUnderlineColorRGB(7, 8, 9)), expected: "\x1b[4:3;58:2:7:8:9m"},
+		{name: "legacy", seq: SGRLegacy(SGRForegroundRGB(1, 2, 3), SGRUnderlineColorIndex(5)), expected: "\x1b[38;2;1;2;3;58;5;5m"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded := Encode(test.seq)
+			requireEqual(t, test.expected, encoded)
+			requireEqual(t, []Sequence{test.seq}, parseAll(t, encoded))
+		})
+	}
+}
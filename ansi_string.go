@@ -0,0 +1,214 @@
+package vaxis
+
+import (
+	"strings"
+
+	"go.rockorager.dev/vaxis/ansi"
+)
+
+// StripANSI removes all escape sequences from s, leaving only the printable
+// text. C0 controls such as newlines and tabs are kept
+func StripANSI(s string) string {
+	if !strings.ContainsAny(s, "\x1b\u009b\u009d\u0090\u009f") {
+		return s
+	}
+	bldr := strings.Builder{}
+	bldr.Grow(len(s))
+	parser := ansi.NewParser(strings.NewReader(s), ansi.ParserModeOutput)
+	defer parser.Close()
+	for seq := range parser.Next() {
+		switch seq := seq.(type) {
+		case ansi.Print:
+			bldr.WriteString(seq.Grapheme)
+		case ansi.C0:
+			if seq == 0x1B {
+				continue
+			}
+			bldr.WriteByte(byte(seq))
+		}
+	}
+	return bldr.String()
+}
+
+// ANSIWidth returns the display width of s, ignoring any escape sequences.
+// When s contains multiple lines, the width of the widest line is returned.
+// Graphemes are measured using unicode standard widths, the same as
+// [ParseStyledString], and tabs advance to the next tab stop, every eight
+// columns
+func ANSIWidth(s string) int {
+	max := 0
+	for _, line := range parseANSILines(s) {
+		w := cellsWidth(line)
+		if w > max {
+			max = w
+		}
+	}
+	return max
+}
+
+// TruncateANSI truncates each line of s to at most width columns. If a line
+// is truncated, tail is appended to it (within width) in the style active
+// at the cut point. When every line fits, s is returned unchanged. Otherwise
+// the lines are re-encoded so that each is self-contained, and any style or
+// hyperlink active at a cut point is reset at the end of its line
+func TruncateANSI(s string, width int, tail string) string {
+	if width < 0 {
+		width = 0
+	}
+	lines := parseANSILines(s)
+	truncated := false
+	for _, line := range lines {
+		if cellsWidth(line) > width {
+			truncated = true
+			break
+		}
+	}
+	if !truncated {
+		return s
+	}
+	tailRow := flattenANSILines(parseANSILines(StripANSI(tail)))
+	tailWidth := cellsWidth(tailRow)
+	if tailWidth > width {
+		// The tail can't fit. Truncate without it
+		tailRow = nil
+		tailWidth = 0
+	}
+	encoded := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if cellsWidth(line) > width {
+			line, _ = splitCellsAtWidth(line, width-tailWidth)
+			style := Style{}
+			if len(line) > 0 {
+				style = line[len(line)-1].Style
+			}
+			for _, cell := range tailRow {
+				cell.Style = style
+				line = append(line, cell)
+			}
+		}
+		encoded = append(encoded, EncodeCells(line))
+	}
+	return strings.Join(encoded, "\n")
+}
+
+// WrapANSI hard-wraps s at width columns, breaking between graphemes.
+// Existing newlines are respected. Each returned line is self-contained: it
+// begins by restoring the style and hyperlink active at its start and ends
+// by resetting them. A grapheme wider than width is placed on a line of its
+// own
+func WrapANSI(s string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+	result := []string{}
+	for _, line := range parseANSILines(s) {
+		if len(line) == 0 {
+			result = append(result, "")
+			continue
+		}
+		for len(line) > 0 {
+			cut, rest := splitCellsAtWidth(line, width)
+			if len(cut) == 0 {
+				cut, rest = line[:1], line[1:]
+			}
+			result = append(result, EncodeCells(cut))
+			line = rest
+		}
+	}
+	return result
+}
+
+// parseANSILines parses s into lines of styled cells. Newlines end a line
+// but do not reset the style, which carries over to the next line the same
+// as it would in a terminal. Tabs are expanded with spaces to the next tab
+// stop, every eight columns, and other controls are dropped
+func parseANSILines(s string) [][]Cell {
+	lines := [][]Cell{}
+	line := []Cell{}
+	col := 0
+	style := Style{}
+	parser := ansi.NewParser(strings.NewReader(s), ansi.ParserModeOutput)
+	defer parser.Close()
+	for seq := range parser.Next() {
+		switch seq := seq.(type) {
+		case ansi.Print:
+			line = append(line, Cell{
+				Character: Character{
+					Grapheme: seq.Grapheme,
+					Width:    seq.Width,
+				},
+				Style: style,
+			})
+			col += seq.Width
+		case ansi.C0:
+			switch seq {
+			case '\n':
+				lines = append(lines, line)
+				line = []Cell{}
+				col = 0
+			case '\t':
+				for next := (col/8 + 1) * 8; col < next; col += 1 {
+					line = append(line, Cell{
+						Character: Character{" ", 1},
+						Style:     style,
+					})
+				}
+			}
+		case ansi.CSI:
+			if seq.Final == 'm' && seq.NumIntermediate == 0 {
+				parseSGR(seq.ParameterGroups(), &style)
+			}
+		case ansi.OSC:
+			parseOSC8(string(seq.Payload), &style)
+		}
+	}
+	return append(lines, line)
+}
+
+// parseOSC8 applies an OSC 8 hyperlink payload to style. Payloads for other
+// OSC commands are ignored
+func parseOSC8(payload string, style *Style) {
+	payload, ok := strings.CutPrefix(payload, "8;")
+	if !ok {
+		return
+	}
+	params, uri, ok := strings.Cut(payload, ";")
+	if !ok {
+		return
+	}
+	style.Hyperlink = uri
+	style.HyperlinkParams = params
+	if uri == "" {
+		style.HyperlinkParams = ""
+	}
+}
+
+func flattenANSILines(lines [][]Cell) []Cell {
+	row := []Cell{}
+	for _, line := range lines {
+		row = append(row, line...)
+	}
+	return row
+}
+
+func cellsWidth(cells []Cell) int {
+	total := 0
+	for _, cell := range cells {
+		total += cell.Width
+	}
+	return total
+}
+
+// splitCellsAtWidth splits cells so that the first part is at most width
+// columns wide. A wide grapheme which would straddle the boundary is moved
+// to the second part
+func splitCellsAtWidth(cells []Cell, width int) ([]Cell, []Cell) {
+	total := 0
+	for i, cell := range cells {
+		if total+cell.Width > width {
+			return cells[:i:i], cells[i:]
+		}
+		total += cell.Width
+	}
+	return cells, nil
+}
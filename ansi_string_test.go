@@ -0,0 +1,216 @@
+package vaxis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain", input: "hello", want: "hello"},
+		{name: "sgr", input: "\x1b[1;31mred\x1b[m text", want: "red text"},
+		{name: "hyperlink", input: "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", want: "link"},
+		{name: "keeps newlines and tabs", input: "a\x1b[2m\tb\nc", want: "a\tb\nc"},
+		{name: "other sequences", input: "\x1b[2J\x1b]2;title\x07\x1bPq#0\x1b\\x", want: "x"},
+		{name: "wide", input: "\x1b[31m🔥\x1b[0m", want: "🔥"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := StripANSI(test.input); got != test.want {
+				t.Fatalf("StripANSI(%q) = %q, want %q", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestANSIWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{name: "empty", input: "", want: 0},
+		{name: "plain", input: "hello", want: 5},
+		{name: "sgr", input: "\x1b[38:2:1:2:3mab\x1b[m", want: 2},
+		{name: "wide", input: "🔥a", want: 3},
+		{name: "multiline uses widest", input: "ab\n\x1b[1mabcd\x1b[m\nc", want: 4},
+		{name: "tab", input: "\ta", want: 9},
+		{name: "tab to next stop", input: "abc\td", want: 9},
+		{name: "tab at stop", input: "abcdefgh\ti", want: 17},
+		{name: "tab after wide", input: "🔥\x1b[1m\ta", want: 9},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ANSIWidth(test.input); got != test.want {
+				t.Fatalf("ANSIWidth(%q) = %d, want %d", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTruncateANSI(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		width int
+		tail  string
+		want  string
+	}{
+		{
+			name:  "fits is unchanged",
+			input: "\x1b[1mhello\x1b[22m",
+			width: 5,
+			want:  "\x1b[1mhello\x1b[22m",
+		},
+		{
+			name:  "plain",
+			input: "hello world",
+			width: 5,
+			want:  "hello",
+		},
+		{
+			name:  "ellipsis",
+			input: "hello world",
+			width: 6,
+			tail:  "…",
+			want:  "hello…",
+		},
+		{
+			name:  "style reset at cut",
+			input: "\x1b[31mhello world",
+			width: 3,
+			want:  "\x1b[31mhel\x1b[m",
+		},
+		{
+			name:  "ellipsis takes style at cut",
+			input: "ab\x1b[1mcdef",
+			width: 4,
+			tail:  "…",
+			want:  "ab\x1b[1mc…\x1b[m",
+		},
+		{
+			name:  "hyperlink closed at cut",
+			input: "\x1b]8;;http://x\x1b\\link text\x1b]8;;\x1b\\",
+			width: 4,
+			want:  "\x1b]8;;http://x\x1b\\link\x1b]8;;\x1b\\",
+		},
+		{
+			name:  "wide grapheme at boundary",
+			input: "a🔥b",
+			width: 2,
+			want:  "a",
+		},
+		{
+			name:  "tail wider than width is dropped",
+			input: "abcdef",
+			width: 2,
+			tail:  "...",
+			want:  "ab",
+		},
+		{
+			name:  "style carries across lines",
+			input: "\x1b[31mabcdef\nxy",
+			width: 3,
+			want:  "\x1b[31mabc\x1b[m\n\x1b[31mxy\x1b[m",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := TruncateANSI(test.input, test.width, test.tail)
+			if got != test.want {
+				t.Fatalf("TruncateANSI(%q, %d, %q) = %q, want %q", test.input, test.width, test.tail, got, test.want)
+			}
+			if w := ANSIWidth(got); w > test.width {
+				t.Fatalf("truncated width = %d, want <= %d", w, test.width)
+			}
+		})
+	}
+}
+
+func TestWrapANSI(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		width int
+		want  []string
+	}{
+		{
+			name:  "plain",
+			input: "abcdefg",
+			width: 3,
+			want:  []string{"abc", "def", "g"},
+		},
+		{
+			name:  "respects newlines",
+			input: "ab\n\ncdef",
+			width: 3,
+			want:  []string{"ab", "", "cde", "f"},
+		},
+		{
+			name:  "style restored on each line",
+			input: "\x1b[1mabcd\x1b[22me",
+			width: 2,
+			want:  []string{"\x1b[1mab\x1b[m", "\x1b[1mcd\x1b[m", "e"},
+		},
+		{
+			name:  "hyperlink restored on each line",
+			input: "\x1b]8;id=1;http://x\x1b\\abcd\x1b]8;;\x1b\\",
+			width: 2,
+			want: []string{
+				"\x1b]8;id=1;http://x\x1b\\ab\x1b]8;;\x1b\\",
+				"\x1b]8;id=1;http://x\x1b\\cd\x1b]8;;\x1b\\",
+			},
+		},
+		{
+			name:  "wide grapheme wraps",
+			input: "ab🔥",
+			width: 3,
+			want:  []string{"ab", "🔥"},
+		},
+		{
+			name:  "tab stops",
+			input: "ab\tc",
+			width: 4,
+			want:  []string{"ab  ", "    ", "c"},
+		},
+		{
+			name:  "grapheme wider than width",
+			input: "🔥🔥",
+			width: 1,
+			want:  []string{"🔥", "🔥"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := WrapANSI(test.input, test.width)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("WrapANSI(%q, %d) = %q, want %q", test.input, test.width, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseStyledStringHyperlink(t *testing.T) {
+	cells := ParseStyledString("\x1b]8;id=2;http://x\x1b\\a\x1b]8;;\x1b\\b")
+	if len(cells) != 2 {
+		t.Fatalf("cells len = %d, want 2", len(cells))
+	}
+	if cells[0].Hyperlink != "http://x" || cells[0].HyperlinkParams != "id=2" {
+		t.Fatalf("first cell link = %q %q", cells[0].Hyperlink, cells[0].HyperlinkParams)
+	}
+	if cells[1].Hyperlink != "" {
+		t.Fatalf("second cell link = %q, want none", cells[1].Hyperlink)
+	}
+}
+
+func TestEncodeCellsClosesHyperlink(t *testing.T) {
+	cells := ParseStyledString("\x1b]8;;http://x\x1b\\ab")
+	want := "\x1b]8;;http://x\x1b\\ab\x1b]8;;\x1b\\"
+	if got := EncodeCells(cells); got != want {
+		t.Fatalf("EncodeCells = %q, want %q", got, want)
+	}
+}
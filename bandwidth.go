@@ -0,0 +1,250 @@
+package vaxis
+
+import (
+	"time"
+
+	"go.rockorager.dev/vaxis/log"
+)
+
+const (
+	// slowWrite is how long a write to the terminal must block before we
+	// consider the link busy, when the output queue can't be inspected
+	slowWrite = 5 * time.Millisecond
+	// maxCoalesce is the longest we skip frames for while output drains
+	maxCoalesce = time.Second
+)
+
+// BandwidthOptions configures adapting the output to a slow link, such as SSH
+// over a poor connection. Vaxis measures how fast output drains to the
+// terminal. While the last frame is still draining, Render skips the frame and
+// posts a [Redraw] event once the output has drained, so frames coalesce
+// instead of queueing. While the link is saturated, RGB colors are encoded as
+// 256 colors and new images aren't drawn
+type BandwidthOptions struct {
+	// SaturatedBelow is the throughput, in bytes per second, below which
+	// the link is considered saturated. Defaults to 64 KiB/s
+	SaturatedBelow int
+	// PollInterval is how often the output queue is checked while output
+	// drains. Defaults to 10ms
+	PollInterval time.Duration
+}
+
+// BandwidthState describes the measured state of the output link
+type BandwidthState struct {
+	// BytesPerSecond is the measured throughput. It is zero until output
+	// has had to wait on the link
+	BytesPerSecond float64
+	// Queued is the number of bytes written to the terminal which haven't
+	// been sent yet, or -1 if the platform can't report it
+	Queued int
+	// Draining reports whether the last frame is still being sent
+	Draining bool
+	// Saturated reports whether the link is slower than
+	// [BandwidthOptions.SaturatedBelow]
+	Saturated bool
+	// CoalescedFrames is the number of Render calls skipped because output
+	// was still draining
+	CoalescedFrames int
+}
+
+// BandwidthUpdate is sent when the output link becomes saturated or recovers.
+// Applications may lower their refresh rate and skip animations while the link
+// is saturated. Images which weren't drawn while saturated are drawn on the
+// next Render after recovering. It is only sent when [Options.Bandwidth] is set
+type BandwidthUpdate struct {
+	Saturated      bool
+	BytesPerSecond float64
+}
+
+type bandwidth struct {
+	opts  BandwidthOptions
+	state BandwidthState
+	// pending is the number of bytes written since the last observation
+	pending      int
+	lastObserved time.Time
+	lastFlush    time.Time
+	slowFlush    bool
+	waiting      bool
+}
+
+func newBandwidth(opts BandwidthOptions) *bandwidth {
+	if opts.SaturatedBelow <= 0 {
+		opts.SaturatedBelow = 64 * 1024
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 10 * time.Millisecond
+	}
+	return &bandwidth{
+		opts:  opts,
+		state: BandwidthState{Queued: -1},
+	}
+}
+
+// Bandwidth returns the measured state of the output link. The zero value is
+// returned when [Options.Bandwidth] isn't set
+func (vx *Vaxis) Bandwidth() BandwidthState {
+	vx.mu.Lock()
+	defer vx.mu.Unlock()
+	if vx.bandwidth == nil {
+		return BandwidthState{}
+	}
+	return vx.bandwidth.state
+}
+
+// bandwidthSaturated reports whether cheaper encodings should be used. vx.mu
+// must be held
+func (vx *Vaxis) bandwidthSaturated() bool {
+	return vx.bandwidth != nil && vx.bandwidth.state.Saturated
+}
+
+// observe updates the throughput estimate from the number of bytes queued for
+// the terminal. It reports whether the saturation state changed
+func (b *bandwidth) observe(queued int, now time.Time) bool {
+	elapsed := now.Sub(b.lastObserved)
+	if queued >= 0 && !b.lastObserved.IsZero() && elapsed > 0 {
+		prev := max(b.state.Queued, 0)
+		sent := prev + b.pending - queued
+		if sent > 0 {
+			sample := float64(sent) / elapsed.Seconds()
+			switch {
+			case prev > 0 && queued > 0:
+				// The link was busy for the whole interval
+				b.sample(sample)
+			case sample > b.state.BytesPerSecond:
+				// The queue was empty for part of the interval,
+				// so the link is at least this fast
+				b.state.BytesPerSecond = sample
+			}
+		}
+	}
+	b.state.Queued = queued
+	b.pending = 0
+	b.lastObserved = now
+	return b.updateSaturated()
+}
+
+// flushed records a frame of n bytes which took flush to write. It reports
+// whether the saturation state changed
+func (b *bandwidth) flushed(n int, flush time.Duration, queued int, now time.Time) bool {
+	b.lastFlush = now
+	b.slowFlush = flush >= slowWrite
+	if queued < 0 && b.slowFlush && n > 0 {
+		// The write blocked until the link accepted the frame
+		b.sample(float64(n) / flush.Seconds())
+	}
+	b.pending += n
+	return b.observe(queued, now)
+}
+
+func (b *bandwidth) sample(bytesPerSecond float64) {
+	if b.state.BytesPerSecond == 0 {
+		b.state.BytesPerSecond = bytesPerSecond
+		return
+	}
+	b.state.BytesPerSecond = 0.7*b.state.BytesPerSecond + 0.3*bytesPerSecond
+}
+
+func (b *bandwidth) updateSaturated() bool {
+	rate := b.state.BytesPerSecond
+	saturated := rate > 0 && rate < float64(b.opts.SaturatedBelow)
+	changed := saturated != b.state.Saturated
+	b.state.Saturated = saturated
+	return changed
+}
+
+// draining reports whether the last frame is still being sent
+func (b *bandwidth) draining(now time.Time) bool {
+	if now.Sub(b.lastFlush) >= maxCoalesce {
+		return false
+	}
+	if b.state.Queued >= 0 {
+		return b.state.Queued > 0
+	}
+	return b.slowFlush && now.Sub(b.lastFlush) < b.opts.PollInterval
+}
+
+// coalesceFrame reports whether Render should skip this frame because the last
+// one is still draining. When it does, a Redraw event is posted once the
+// output has drained
+func (vx *Vaxis) coalesceFrame() bool {
+	b := vx.bandwidth
+	if b == nil {
+		return false
+	}
+	queued := vx.outputQueued()
+	vx.mu.Lock()
+	defer vx.mu.Unlock()
+	now := time.Now()
+	if b.observe(queued, now) {
+		vx.postBandwidthUpdate()
+	}
+	b.state.Draining = b.draining(now)
+	if !b.state.Draining {
+		return false
+	}
+	b.state.CoalescedFrames += 1
+	if !b.waiting {
+		b.waiting = true
+		go vx.waitForDrain(b)
+	}
+	return true
+}
+
+// waitForDrain polls the output queue until the last frame has been sent, then
+// asks the application to render again
+func (vx *Vaxis) waitForDrain(b *bandwidth) {
+	for {
+		select {
+		case <-vx.chQuit:
+			return
+		case <-time.After(b.opts.PollInterval):
+		}
+		queued := vx.outputQueued()
+		vx.mu.Lock()
+		now := time.Now()
+		if b.observe(queued, now) {
+			vx.postBandwidthUpdate()
+		}
+		b.state.Draining = b.draining(now)
+		if b.state.Draining {
+			vx.mu.Unlock()
+			continue
+		}
+		b.waiting = false
+		vx.mu.Unlock()
+		vx.PostEvent(Redraw{})
+		return
+	}
+}
+
+// finishBandwidth records the frame which was just flushed
+func (vx *Vaxis) finishBandwidth(n int, flush time.Duration) {
+	b := vx.bandwidth
+	if b == nil {
+		return
+	}
+	queued := vx.outputQueued()
+	vx.mu.Lock()
+	defer vx.mu.Unlock()
+	if b.flushed(n, flush, queued, time.Now()) {
+		vx.postBandwidthUpdate()
+	}
+}
+
+// postBandwidthUpdate notifies the application of a change in saturation.
+// vx.mu must be held
+func (vx *Vaxis) postBandwidthUpdate() {
+	state := vx.bandwidth.state
+	if state.Saturated {
+		log.Render.Info("[bandwidth] link saturated at %.0f bytes/s", state.BytesPerSecond)
+	} else {
+		log.Render.Info("[bandwidth] link recovered at %.0f bytes/s", state.BytesPerSecond)
+		// Cells drawn while saturated were sent as 256 colors, but the
+		// last screen holds their RGB colors, so only a refresh resends them
+		vx.refresh = true
+	}
+	vx.PostEvent(BandwidthUpdate{
+		Saturated:      state.Saturated,
+		BytesPerSecond: state.BytesPerSecond,
+	})
+}
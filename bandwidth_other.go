@@ -0,0 +1,9 @@
+//go:build !darwin && !freebsd && !linux && !netbsd && !openbsd
+
+package vaxis
+
+// outputQueued can't be determined on this platform, so throughput is
+// measured from how long writes block
+func (vx *Vaxis) outputQueued() int {
+	return -1
+}
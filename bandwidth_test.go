@@ -0,0 +1,158 @@
+package vaxis
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBandwidthEstimate(t *testing.T) {
+	b := newBandwidth(BandwidthOptions{SaturatedBelow: 10_000})
+	now := time.Unix(0, 0)
+	b.observe(0, now)
+
+	// 4000 bytes written, 3000 still queued after 100ms: 10 kB/s, but
+	// the queue was empty before, so it is only a lower bound
+	now = now.Add(100 * time.Millisecond)
+	if b.flushed(4000, time.Millisecond, 3000, now) {
+		t.Fatal("saturation changed from a lower bound")
+	}
+	if got := b.state.BytesPerSecond; got != 10_000 {
+		t.Fatalf("rate = %.0f, want 10000", got)
+	}
+
+	// The link stayed busy: 1000 bytes in 200ms is 5 kB/s
+	now = now.Add(200 * time.Millisecond)
+	if !b.observe(2000, now) {
+		t.Fatal("slow link didn't become saturated")
+	}
+	if !b.state.Saturated || b.state.BytesPerSecond >= 10_000 {
+		t.Fatalf("state = %+v, want saturated", b.state)
+	}
+
+	// Everything drains quickly: the link is at least 2 MB/s
+	now = now.Add(time.Millisecond)
+	if !b.observe(0, now) {
+		t.Fatal("fast link didn't recover")
+	}
+	if b.state.Saturated {
+		t.Fatalf("state = %+v, want recovered", b.state)
+	}
+}
+
+func TestBandwidthEstimateFromBlockingWrites(t *testing.T) {
+	b := newBandwidth(BandwidthOptions{SaturatedBelow: 10_000})
+	now := time.Unix(0, 0)
+	// Writes which don't block say nothing about the link
+	b.flushed(1000, time.Millisecond, -1, now)
+	if b.state.BytesPerSecond != 0 || b.draining(now) {
+		t.Fatalf("state = %+v after a fast write", b.state)
+	}
+	if !b.flushed(1000, 500*time.Millisecond, -1, now) {
+		t.Fatal("blocking write didn't saturate the link")
+	}
+	if got := b.state.BytesPerSecond; got != 2000 {
+		t.Fatalf("rate = %.0f, want 2000", got)
+	}
+	if !b.draining(now) || b.draining(now.Add(b.opts.PollInterval)) {
+		t.Fatal("draining should last one poll interval after a blocking write")
+	}
+}
+
+func TestBandwidthCoalescesFrames(t *testing.T) {
+	var out bytes.Buffer
+	vx := newWriterTestVaxis(&out)
+	vx.queue = make(chan Event, 4)
+	vx.chQuit = make(chan bool)
+	defer close(vx.chQuit)
+	vx.bandwidth = newBandwidth(BandwidthOptions{PollInterval: 20 * time.Millisecond})
+	vx.bandwidth.flushed(100, slowWrite, -1, time.Now())
+
+	vx.screenNext.setCell(0, 0, Cell{Character: Character{Grapheme: "a", Width: 1}})
+	vx.Render()
+	if out.Len() != 0 || vx.RenderStats().Frames != 0 {
+		t.Fatal("frame was drawn while output was draining")
+	}
+	vx.Render()
+	if got := vx.Bandwidth().CoalescedFrames; got != 2 {
+		t.Fatalf("coalesced %d frames, want 2", got)
+	}
+
+	select {
+	case ev := <-vx.queue:
+		if _, ok := ev.(Redraw); !ok {
+			t.Fatalf("got %T, want Redraw", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no Redraw after output drained")
+	}
+	vx.Render()
+	if !strings.Contains(out.String(), "a") {
+		t.Fatalf("frame wasn't drawn after draining: %q", out.String())
+	}
+}
+
+func TestBandwidthSaturatedEncoding(t *testing.T) {
+	var out bytes.Buffer
+	vx := newWriterTestVaxis(&out)
+	vx.caps.rgb = true
+	vx.bandwidth = newBandwidth(BandwidthOptions{})
+	vx.bandwidth.state.BytesPerSecond = 1000
+	vx.graphicsNext = []*placement{{
+		id:       1,
+		w:        1,
+		h:        1,
+		writeTo:  func(w io.Writer) { _, _ = io.WriteString(w, "image") },
+		deleteFn: func(io.Writer) {},
+	}}
+	vx.screenNext.setCell(0, 0, Cell{
+		Character: Character{Grapheme: "a", Width: 1},
+		Style:     Style{Foreground: RGBColor(0xff, 0, 0)},
+	})
+	vx.Render()
+	if s := out.String(); strings.Contains(s, "38:2") || strings.Contains(s, "image") {
+		t.Fatalf("saturated frame used RGB or drew an image: %q", s)
+	}
+	if !strings.Contains(out.String(), "38:5:196") {
+		t.Fatalf("saturated frame didn't use 256 colors: %q", out.String())
+	}
+
+	// Held back images are drawn once the link recovers
+	vx.bandwidth.state.BytesPerSecond = 1e9
+	out.Reset()
+	vx.Render()
+	if !strings.Contains(out.String(), "image") {
+		t.Fatalf("image wasn't drawn after recovering: %q", out.String())
+	}
+}
+
+func TestBandwidthRecoveryResendsTruecolor(t *testing.T) {
+	var out bytes.Buffer
+	vx := newWriterTestVaxis(&out)
+	vx.caps.rgb = true
+	vx.bandwidth = newBandwidth(BandwidthOptions{})
+	vx.bandwidth.state.BytesPerSecond = 1000
+	vx.screenNext.setCell(0, 0, Cell{
+		Character: Character{Grapheme: "a", Width: 1},
+		Style:     Style{Foreground: RGBColor(0xff, 0, 0)},
+	})
+	vx.Render()
+	if !strings.Contains(out.String(), "38:5:196") {
+		t.Fatalf("saturated frame didn't use 256 colors: %q", out.String())
+	}
+
+	// Nothing changed on screen, but the downgraded cell is sent again
+	vx.bandwidth.state.BytesPerSecond = 1e9
+	out.Reset()
+	vx.Render()
+	if !strings.Contains(out.String(), "38:2:255:0:0") {
+		t.Fatalf("cell wasn't resent in truecolor after recovering: %q", out.String())
+	}
+	out.Reset()
+	vx.Render()
+	if strings.Contains(out.String(), "a") {
+		t.Fatalf("frame after recovering was refreshed again: %q", out.String())
+	}
+}
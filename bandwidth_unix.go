@@ -0,0 +1,18 @@
+//go:build darwin || freebsd || linux || netbsd || openbsd
+
+package vaxis
+
+import "golang.org/x/sys/unix"
+
+// outputQueued returns the number of bytes written to the terminal which
+// haven't been read by the other end yet, or -1 if it can't be determined
+func (vx *Vaxis) outputQueued() int {
+	if vx.tty == nil {
+		return -1
+	}
+	n, err := unix.IoctlGetInt(int(vx.tty.Fd()), unix.TIOCOUTQ)
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,130 @@
+package vaxis
+
+import (
+	"io"
+	"strings"
+)
+
+// Canvas is an off-screen drawing surface. It is drawn on through a [Window]
+// exactly like the screen of a [Vaxis] instance, but needs no terminal: the
+// contents can be rendered to ANSI text with [Canvas.String], or exported to
+// HTML and SVG with [Canvas.HTML] and [Canvas.SVG]. This is useful for
+// printing styled output when stdout is not a tty, or for embedding rendered
+// snippets in logs, documentation and bug reports.
+//
+// Character widths are measured according to the Unicode standard
+type Canvas struct {
+	vx *Vaxis
+}
+
+// NewCanvas creates a blank Canvas of the given size
+func NewCanvas(cols int, rows int) *Canvas {
+	vx := &Vaxis{
+		screenNext: newScreen(),
+		charCache:  make(map[string]int),
+	}
+	vx.caps.unicodeCore = true
+	c := &Canvas{vx: vx}
+	c.Resize(cols, rows)
+	return c
+}
+
+// Window returns the root Window of the canvas
+func (c *Canvas) Window() Window {
+	return c.vx.Window()
+}
+
+// Size returns the size of the canvas
+func (c *Canvas) Size() (cols int, rows int) {
+	return c.vx.screenNext.size()
+}
+
+// Resize changes the size of the canvas. The contents are preserved where
+// they fit
+func (c *Canvas) Resize(cols int, rows int) {
+	if cols < 0 {
+		cols = 0
+	}
+	if rows < 0 {
+		rows = 0
+	}
+	old := c.vx.screenNext
+	next := newScreen()
+	next.resize(cols, rows)
+	for row := 0; row < min(rows, old.rows); row += 1 {
+		copy(next.row(row), old.row(row)[:min(cols, old.cols)])
+	}
+	c.vx.screenNext = next
+}
+
+// Cell returns the cell at the given location. Locations outside of the
+// canvas return the zero Cell
+func (c *Canvas) Cell(col int, row int) Cell {
+	cols, rows := c.Size()
+	if col < 0 || row < 0 || col >= cols || row >= rows {
+		return Cell{}
+	}
+	return c.vx.screenNext.cell(col, row)
+}
+
+// String renders the canvas to ANSI text, using the same SGR and OSC 8
+// encoding as [EncodeCells]. Rows are separated by "\n" and are
+// self-contained: styles are reset at the end of each row. Trailing blank
+// cells without a style are trimmed
+func (c *Canvas) String() string {
+	bldr := &strings.Builder{}
+	_, rows := c.Size()
+	for row := 0; row < rows; row += 1 {
+		if row > 0 {
+			bldr.WriteString("\n")
+		}
+		bldr.WriteString(EncodeCells(trimPrimaryRenderRow(c.printableRow(row))))
+	}
+	return bldr.String()
+}
+
+// WriteTo writes the ANSI text of the canvas, as returned by String, followed
+// by a newline to w
+func (c *Canvas) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, c.String()+"\n")
+	return int64(n), err
+}
+
+// PlainText returns the text of the canvas without any styling. Rows are
+// separated by "\n" and trailing whitespace is trimmed
+func (c *Canvas) PlainText() string {
+	bldr := &strings.Builder{}
+	_, rows := c.Size()
+	for row := 0; row < rows; row += 1 {
+		if row > 0 {
+			bldr.WriteString("\n")
+		}
+		line := &strings.Builder{}
+		for _, cell := range c.printableRow(row) {
+			line.WriteString(cell.Grapheme)
+		}
+		bldr.WriteString(strings.TrimRight(line.String(), " "))
+	}
+	return bldr.String()
+}
+
+// printableRow returns the cells of row as they would be drawn by the
+// renderer: cells hidden under a wide character are skipped and cells which
+// were never drawn are spaces
+func (c *Canvas) printableRow(row int) []Cell {
+	src := c.vx.screenNext.row(row)
+	cells := make([]Cell, 0, len(src))
+	for col := 0; col < len(src); col += 1 {
+		cell := printablePrimaryCell(src[col])
+		if cell.Width == 0 {
+			cell.Width = c.vx.characterWidth(cell.Grapheme)
+		}
+		// A wide character at the end of the row can't be drawn
+		if col+cell.Width > len(src) {
+			cell.Character = Character{Grapheme: " ", Width: 1}
+		}
+		cells = append(cells, cell)
+		col += c.vx.advance(cell)
+	}
+	return cells
+}
@@ -0,0 +1,376 @@
+package vaxis
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ExportOptions controls how colors and fonts are resolved when exporting a
+// [Canvas] to HTML or SVG. The zero value is usable
+type ExportOptions struct {
+	// Foreground is the color used for the default foreground. When
+	// unset, a light gray is used
+	Foreground Color
+	// Background is the color used for the default background. When unset,
+	// black is used
+	Background Color
+	// Palette overrides the colors of indexes 0-15 with RGB colors. Unset
+	// and non-RGB entries use the xterm defaults
+	Palette [16]Color
+	// FontFamily is the CSS font-family of the text. Defaults to
+	// "monospace"
+	FontFamily string
+	// FontSize is the font size in pixels. It determines the size of a
+	// cell in SVG exports. Defaults to 14
+	FontSize float64
+}
+
+// xtermPalette is the default palette of indexes 0-15
+var xtermPalette = [16]uint32{
+	0x000000, 0xCD0000, 0x00CD00, 0xCDCD00, 0x0000EE, 0xCD00CD, 0x00CDCD, 0xE5E5E5,
+	0x7F7F7F, 0xFF0000, 0x00FF00, 0xFFFF00, 0x5C5CFF, 0xFF00FF, 0x00FFFF, 0xFFFFFF,
+}
+
+func (opts ExportOptions) fontFamily() string {
+	if opts.FontFamily == "" {
+		return "monospace"
+	}
+	return opts.FontFamily
+}
+
+func (opts ExportOptions) fontSize() float64 {
+	if opts.FontSize <= 0 {
+		return 14
+	}
+	return opts.FontSize
+}
+
+// hex resolves c to an RGB value. ColorDefault resolves to def
+func (opts ExportOptions) hex(c Color, def uint32) uint32 {
+	switch {
+	case c&indexed != 0:
+		i := uint8(c)
+		switch {
+		case i < 16:
+			// Only RGB entries override the palette: an indexed entry
+			// would have to be resolved through the palette again
+			if r, g, b, ok := opts.Palette[i].RGB(); ok {
+				return uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+			}
+			return xtermPalette[i]
+		default:
+			return colorIndex[i-16]
+		}
+	case c&rgb != 0:
+		return uint32(c) & 0xFFFFFF
+	}
+	return def
+}
+
+func (opts ExportOptions) defaultForeground() uint32 {
+	return opts.hex(opts.Foreground, 0xE5E5E5)
+}
+
+func (opts ExportOptions) defaultBackground() uint32 {
+	return opts.hex(opts.Background, 0x000000)
+}
+
+// exportColors resolves the colors a style is drawn with, after applying
+// reverse video and dim
+func (opts ExportOptions) exportColors(style Style) (fg uint32, bg uint32, ul uint32) {
+	fg = opts.hex(style.Foreground, opts.defaultForeground())
+	bg = opts.hex(style.Background, opts.defaultBackground())
+	if style.Attribute&AttrReverse != 0 {
+		fg, bg = bg, fg
+	}
+	if style.Attribute&AttrDim != 0 {
+		r, g, b, _ := HexColor(fg).Blend(HexColor(bg), 128).RGB()
+		fg = uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+	}
+	ul = fg
+	if style.UnderlineColor != ColorDefault {
+		ul = opts.hex(style.UnderlineColor, fg)
+	}
+	return fg, bg, ul
+}
+
+// exportLink reports whether a hyperlink is exported as a link. Only web, mail
+// and file links are: captured output can hold any URI, and a javascript: link
+// would run in the page showing the export
+func exportLink(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto", "file":
+		return true
+	}
+	return false
+}
+
+func cssHex(v uint32) string {
+	return fmt.Sprintf("#%06x", v)
+}
+
+// exportRun is a horizontal run of cells sharing a style
+type exportRun struct {
+	col   int
+	width int
+	text  string
+	style Style
+}
+
+// exportRuns splits a row of the canvas into styled runs. When trim is set,
+// trailing blank cells without a style are dropped
+func (c *Canvas) exportRuns(row int, trim bool) []exportRun {
+	cells := c.printableRow(row)
+	if trim {
+		cells = trimPrimaryRenderRow(cells)
+	}
+	runs := []exportRun{}
+	text := &strings.Builder{}
+	col := 0
+	for _, cell := range cells {
+		last := len(runs) - 1
+		if last < 0 || runs[last].style != cell.Style {
+			if last >= 0 {
+				runs[last].text = text.String()
+				text.Reset()
+			}
+			runs = append(runs, exportRun{col: col, style: cell.Style})
+			last += 1
+		}
+		text.WriteString(cell.Grapheme)
+		runs[last].width += cell.Width
+		col += cell.Width
+	}
+	if len(runs) > 0 {
+		runs[len(runs)-1].text = text.String()
+	}
+	return runs
+}
+
+// HTML exports the canvas as a <pre> element. Colors, attributes, underline
+// styles and hyperlinks are preserved using inline CSS and <a> elements
+func (c *Canvas) HTML(opts ExportOptions) string {
+	_, rows := c.Size()
+	body := &strings.Builder{}
+	blink := false
+	for row := 0; row < rows; row += 1 {
+		if row > 0 {
+			body.WriteString("\n")
+		}
+		for _, run := range c.exportRuns(row, true) {
+			text := html.EscapeString(run.text)
+			if run.style.Attribute&AttrBlink != 0 {
+				blink = true
+			}
+			css := opts.htmlStyle(run.style)
+			if css != "" {
+				attrs := fmt.Sprintf(" style=\"%s\"", css)
+				if run.style.Attribute&AttrBlink != 0 {
+					attrs = " class=\"vaxis-blink\"" + attrs
+				}
+				text = fmt.Sprintf("<span%s>%s</span>", attrs, text)
+			}
+			if exportLink(run.style.Hyperlink) {
+				text = fmt.Sprintf("<a href=\"%s\" style=\"color:inherit\">%s</a>",
+					html.EscapeString(run.style.Hyperlink), text)
+			}
+			body.WriteString(text)
+		}
+	}
+
+	bldr := &strings.Builder{}
+	if blink {
+		bldr.WriteString("<style>.vaxis-blink{animation:vaxis-blink 1s step-end infinite}")
+		bldr.WriteString("@keyframes vaxis-blink{50%{opacity:0}}</style>\n")
+	}
+	fmt.Fprintf(bldr,
+		"<pre style=\"margin:0;padding:0;line-height:1.2;font-family:%s;color:%s;background-color:%s\">",
+		html.EscapeString(opts.fontFamily()),
+		cssHex(opts.defaultForeground()),
+		cssHex(opts.defaultBackground()),
+	)
+	bldr.WriteString(body.String())
+	bldr.WriteString("</pre>")
+	return bldr.String()
+}
+
+// htmlStyle returns the inline CSS for style, or an empty string when the
+// style is drawn with the defaults
+func (opts ExportOptions) htmlStyle(style Style) string {
+	decl := []string{}
+	fg, bg, ul := opts.exportColors(style)
+	if fg != opts.defaultForeground() {
+		decl = append(decl, "color:"+cssHex(fg))
+	}
+	if bg != opts.defaultBackground() {
+		decl = append(decl, "background-color:"+cssHex(bg))
+	}
+	if style.Attribute&AttrBold != 0 {
+		decl = append(decl, "font-weight:bold")
+	}
+	if style.Attribute&AttrItalic != 0 {
+		decl = append(decl, "font-style:italic")
+	}
+	if style.Attribute&AttrInvisible != 0 {
+		decl = append(decl, "visibility:hidden")
+	}
+	lines := []string{}
+	if style.UnderlineStyle != UnderlineOff {
+		lines = append(lines, "underline")
+	}
+	if style.Attribute&AttrStrikethrough != 0 {
+		lines = append(lines, "line-through")
+	}
+	if style.Attribute&AttrOverline != 0 {
+		lines = append(lines, "overline")
+	}
+	if len(lines) > 0 {
+		decl = append(decl, "text-decoration-line:"+strings.Join(lines, " "))
+	}
+	if style.UnderlineStyle != UnderlineOff {
+		switch style.UnderlineStyle {
+		case UnderlineDouble:
+			decl = append(decl, "text-decoration-style:double")
+		case UnderlineCurly:
+			decl = append(decl, "text-decoration-style:wavy")
+		case UnderlineDotted:
+			decl = append(decl, "text-decoration-style:dotted")
+		case UnderlineDashed:
+			decl = append(decl, "text-decoration-style:dashed")
+		}
+		if ul != fg {
+			decl = append(decl, "text-decoration-color:"+cssHex(ul))
+		}
+	}
+	return strings.Join(decl, ";")
+}
+
+// SVG exports the canvas as a standalone SVG image. Each cell is 0.6 by 1.2
+// times the font size. Colors, attributes, underline styles and hyperlinks are
+// preserved
+func (c *Canvas) SVG(opts ExportOptions) string {
+	cols, rows := c.Size()
+	size := opts.fontSize()
+	cellW := svgRound(size * 0.6)
+	cellH := svgRound(size * 1.2)
+	width := svgRound(float64(cols) * cellW)
+	height := svgRound(float64(rows) * cellH)
+
+	bldr := &strings.Builder{}
+	fmt.Fprintf(bldr,
+		"<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\" font-family=\"%s\" font-size=\"%s\">\n",
+		svgNum(width), svgNum(height), svgNum(width), svgNum(height),
+		html.EscapeString(opts.fontFamily()), svgNum(size),
+	)
+	fmt.Fprintf(bldr, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", cssHex(opts.defaultBackground()))
+	for row := 0; row < rows; row += 1 {
+		top := float64(row) * cellH
+		for _, run := range c.exportRuns(row, false) {
+			x := float64(run.col) * cellW
+			w := float64(run.width) * cellW
+			fg, bg, ul := opts.exportColors(run.style)
+			if bg != opts.defaultBackground() {
+				fmt.Fprintf(bldr, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" fill=\"%s\"/>\n",
+					svgNum(x), svgNum(top), svgNum(w), svgNum(cellH), cssHex(bg))
+			}
+			if run.style.Attribute&AttrInvisible != 0 {
+				continue
+			}
+
+			elem := &strings.Builder{}
+			if strings.TrimSpace(run.text) != "" {
+				fmt.Fprintf(elem, "<text x=\"%s\" y=\"%s\" fill=\"%s\" textLength=\"%s\" lengthAdjust=\"spacingAndGlyphs\" xml:space=\"preserve\"",
+					svgNum(x), svgNum(svgRound(top+cellH*0.8)), cssHex(fg), svgNum(w))
+				if run.style.Attribute&AttrBold != 0 {
+					elem.WriteString(" font-weight=\"bold\"")
+				}
+				if run.style.Attribute&AttrItalic != 0 {
+					elem.WriteString(" font-style=\"italic\"")
+				}
+				elem.WriteString(">")
+				elem.WriteString(html.EscapeString(run.text))
+				elem.WriteString("</text>\n")
+			}
+			svgDecorations(elem, run, x, w, top, cellH, fg, ul)
+			if elem.Len() == 0 {
+				continue
+			}
+
+			out := elem.String()
+			if run.style.Attribute&AttrBlink != 0 {
+				out = "<g>" + out + "<animate attributeName=\"opacity\" values=\"1;0\" dur=\"1s\" calcMode=\"discrete\" repeatCount=\"indefinite\"/></g>\n"
+			}
+			if exportLink(run.style.Hyperlink) {
+				out = fmt.Sprintf("<a href=\"%s\">%s</a>\n",
+					html.EscapeString(run.style.Hyperlink), strings.TrimSuffix(out, "\n"))
+			}
+			bldr.WriteString(out)
+		}
+	}
+	bldr.WriteString("</svg>\n")
+	return bldr.String()
+}
+
+// svgDecorations draws the underline, strikethrough and overline of a run
+func svgDecorations(bldr *strings.Builder, run exportRun, x float64, w float64, top float64, cellH float64, fg uint32, ul uint32) {
+	stroke := svgRound(cellH / 16)
+	if stroke < 1 {
+		stroke = 1
+	}
+	line := func(y float64, color uint32, extra string) {
+		y = svgRound(y)
+		fmt.Fprintf(bldr, "<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\" stroke=\"%s\" stroke-width=\"%s\"%s/>\n",
+			svgNum(x), svgNum(y), svgNum(svgRound(x+w)), svgNum(y), cssHex(color), svgNum(stroke), extra)
+	}
+
+	base := top + cellH*0.9
+	switch run.style.UnderlineStyle {
+	case UnderlineOff:
+	case UnderlineDouble:
+		line(base-stroke, ul, "")
+		line(base+stroke, ul, "")
+	case UnderlineCurly:
+		amp := svgRound(cellH / 16)
+		step := svgRound(cellH / 4)
+		path := &strings.Builder{}
+		fmt.Fprintf(path, "M%s %s", svgNum(x), svgNum(svgRound(base)))
+		for i, px := 0, x; px < x+w; i, px = i+1, px+step {
+			dy := amp
+			if i%2 == 1 {
+				dy = -amp
+			}
+			fmt.Fprintf(path, " q%s %s %s 0", svgNum(svgRound(step/2)), svgNum(dy*2), svgNum(step))
+		}
+		fmt.Fprintf(bldr, "<path d=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"%s\"/>\n",
+			path.String(), cssHex(ul), svgNum(stroke))
+	case UnderlineDotted:
+		line(base, ul, fmt.Sprintf(" stroke-dasharray=\"%s\"", svgNum(stroke)))
+	case UnderlineDashed:
+		line(base, ul, fmt.Sprintf(" stroke-dasharray=\"%s\"", svgNum(stroke*4)))
+	default:
+		line(base, ul, "")
+	}
+	if run.style.Attribute&AttrStrikethrough != 0 {
+		line(top+cellH*0.55, fg, "")
+	}
+	if run.style.Attribute&AttrOverline != 0 {
+		line(top+stroke, fg, "")
+	}
+}
+
+// svgRound rounds v to two decimal places to keep the output stable and small
+func svgRound(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func svgNum(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
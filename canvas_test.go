@@ -0,0 +1,139 @@
+package vaxis
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCanvasString(t *testing.T) {
+	c := NewCanvas(8, 2)
+	win := c.Window()
+	win.Print(
+		Segment{Text: "hi "},
+		Segment{Text: "you", Style: Style{Foreground: IndexColor(1), Attribute: AttrBold}},
+	)
+	win.Println(1, Segment{Text: "link", Style: Style{Hyperlink: "https://example.com"}})
+
+	want := "hi \x1b[31m\x1b[1myou\x1b[m\n" +
+		"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\"
+	if got := c.String(); got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+	if got, want := c.PlainText(), "hi you\nlink"; got != want {
+		t.Fatalf("PlainText() = %q, want %q", got, want)
+	}
+
+	buf := &bytes.Buffer{}
+	n, err := c.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) || buf.String() != want+"\n" {
+		t.Fatalf("WriteTo wrote %d bytes %q", n, buf.String())
+	}
+}
+
+func TestCanvasWideCharacters(t *testing.T) {
+	c := NewCanvas(5, 1)
+	c.Window().Print(Segment{Text: "a🔥b"})
+	if got, want := c.PlainText(), "a🔥b"; got != want {
+		t.Fatalf("PlainText() = %q, want %q", got, want)
+	}
+
+	// A wide character that doesn't fit in the last column is not drawn
+	c = NewCanvas(2, 1)
+	c.Window().SetCell(1, 0, Cell{Character: Character{"🔥", 2}})
+	if got, want := c.PlainText(), ""; got != want {
+		t.Fatalf("PlainText() = %q, want %q", got, want)
+	}
+}
+
+func TestCanvasResize(t *testing.T) {
+	c := NewCanvas(3, 1)
+	c.Window().Print(Segment{Text: "abc"})
+	c.Resize(2, 2)
+	if cols, rows := c.Size(); cols != 2 || rows != 2 {
+		t.Fatalf("size = %dx%d, want 2x2", cols, rows)
+	}
+	if got, want := c.PlainText(), "ab\n"; got != want {
+		t.Fatalf("PlainText() = %q, want %q", got, want)
+	}
+	if got := c.Cell(5, 5); got != (Cell{}) {
+		t.Fatalf("out of bounds cell = %+v", got)
+	}
+}
+
+func TestCanvasHTML(t *testing.T) {
+	c := NewCanvas(12, 1)
+	c.Window().Print(
+		Segment{Text: "<a>", Style: Style{Foreground: RGBColor(0x12, 0x34, 0x56)}},
+		Segment{Text: "u", Style: Style{UnderlineStyle: UnderlineCurly, UnderlineColor: IndexColor(9)}},
+		Segment{Text: "go", Style: Style{Hyperlink: "https://example.com/?a=1&b=2"}},
+		Segment{Text: "r", Style: Style{Attribute: AttrReverse}},
+		Segment{Text: "d", Style: Style{Attribute: AttrDim}},
+	)
+	got := c.HTML(ExportOptions{})
+	for _, want := range []string{
+		"<pre style=\"margin:0;padding:0;line-height:1.2;font-family:monospace;color:#e5e5e5;background-color:#000000\">",
+		"<span style=\"color:#123456\">&lt;a&gt;</span>",
+		"<span style=\"text-decoration-line:underline;text-decoration-style:wavy;text-decoration-color:#ff0000\">u</span>",
+		"<a href=\"https://example.com/?a=1&amp;b=2\" style=\"color:inherit\">go</a>",
+		"<span style=\"color:#000000;background-color:#e5e5e5\">r</span>",
+		"<span style=\"color:#727272\">d</span>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("HTML() = %q\nmissing %q", got, want)
+		}
+	}
+	if strings.Contains(got, "vaxis-blink") {
+		t.Fatalf("HTML() included blink styles without blinking text: %q", got)
+	}
+
+	// Indexed palette entries fall back to the defaults instead of
+	// resolving themselves
+	opts := ExportOptions{Background: HexColor(0xFFFFFF), Palette: [16]Color{1: IndexColor(1), 9: HexColor(0xAA0000)}}
+	if got := opts.hex(IndexColor(1), 0); got != xtermPalette[1] {
+		t.Fatalf("self-referencing palette entry = %06x, want %06x", got, xtermPalette[1])
+	}
+	got = c.HTML(opts)
+	if !strings.Contains(got, "background-color:#ffffff") || !strings.Contains(got, "text-decoration-color:#aa0000") {
+		t.Fatalf("HTML() ignored options: %q", got)
+	}
+}
+
+func TestCanvasSVG(t *testing.T) {
+	c := NewCanvas(4, 2)
+	win := c.Window()
+	win.Println(0, Segment{Text: "ab", Style: Style{Background: IndexColor(4), Attribute: AttrBold}})
+	win.Println(1, Segment{Text: "cd", Style: Style{UnderlineStyle: UnderlineSingle, Hyperlink: "https://example.com"}})
+
+	got := c.SVG(ExportOptions{FontSize: 10})
+	for _, want := range []string{
+		"<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"24\" height=\"24\" viewBox=\"0 0 24 24\" font-family=\"monospace\" font-size=\"10\">",
+		"<rect x=\"0\" y=\"0\" width=\"12\" height=\"12\" fill=\"#0000ee\"/>",
+		"<text x=\"0\" y=\"9.6\" fill=\"#e5e5e5\" textLength=\"12\" lengthAdjust=\"spacingAndGlyphs\" xml:space=\"preserve\" font-weight=\"bold\">ab</text>",
+		"<a href=\"https://example.com\"><text x=\"0\" y=\"21.6\"",
+		"<line x1=\"0\" y1=\"22.8\" x2=\"12\" y2=\"22.8\" stroke=\"#e5e5e5\" stroke-width=\"1\"/></a>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("SVG() = %q\nmissing %q", got, want)
+		}
+	}
+}
+
+func TestCanvasExportDropsUnsafeLinks(t *testing.T) {
+	c := NewCanvas(8, 1)
+	c.Window().Print(
+		Segment{Text: "bad", Style: Style{Hyperlink: "JavaScript:alert(1)"}},
+		Segment{Text: "mail", Style: Style{Hyperlink: "mailto:a@example.com"}},
+	)
+	for name, got := range map[string]string{"HTML": c.HTML(ExportOptions{}), "SVG": c.SVG(ExportOptions{})} {
+		if strings.Contains(strings.ToLower(got), "javascript") {
+			t.Fatalf("%s() kept a javascript link: %q", name, got)
+		}
+		if !strings.Contains(got, "bad") || !strings.Contains(got, "<a href=\"mailto:a@example.com\"") {
+			t.Fatalf("%s() = %q, want plain text and the mailto link", name, got)
+		}
+	}
+}
@@ -45,7 +45,7 @@ func ParseStyledString(s string) []Cell {
 				parseSGR(seq.ParameterGroups(), &style)
 			}
 		case ansi.OSC:
-			// TODO: OSC8 handling??
+			parseOSC8(string(seq.Payload), &style)
 		default:
 			// We don't handle anything else
 		}
@@ -201,6 +201,11 @@ func EncodeCells(cells []Cell) string {
 		cursor = next.Style
 		bldr.WriteString(next.Grapheme)
 	}
+	if cursor.Hyperlink != "" {
+		_, _ = bldr.WriteString(tparm(osc8, "", ""))
+		cursor.Hyperlink = ""
+		cursor.HyperlinkParams = ""
+	}
 	empty := Style{}
 	if cursor != empty {
 		bldr.WriteString(sgrReset)
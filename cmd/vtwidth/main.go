@@ -1,59 +1,153 @@
-// vtwidth is a utility to measure the width of a string as it will be rendered
-// in the terminal
+// vtwidth reports what Vaxis detects about the terminal it runs in. It prints
+// each startup query with the raw replies and how long the terminal took to
+// answer, the capabilities Vaxis detected, and the graphemes the terminal
+// renders with a different width than Vaxis expects. The report is meant to be
+// attached to bug reports.
+//
+// Usage:
+//
+//	vtwidth [-json] [-widths=false]
+//
+// vtwidth exits with status 1 when any grapheme width differs
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"go.rockorager.dev/vaxis"
 )
 
-type failure struct {
-	input    string
-	actual   int
-	expected int
+type report struct {
+	vaxis.Diagnostics
+	// Widths are the graphemes rendered with a different width than
+	// Vaxis computes
+	Widths []widthMismatch `json:"width_mismatches"`
+	// WidthError is set when the widths couldn't be measured
+	WidthError string `json:"width_error,omitempty"`
+}
+
+type widthMismatch struct {
+	Grapheme string `json:"grapheme"`
+	Terminal int    `json:"terminal"`
+	Vaxis    int    `json:"vaxis"`
 }
 
 func main() {
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	widths := flag.Bool("widths", true, "measure the width of graphemes in the vaxis width corpus")
+	flag.Parse()
+
 	vx, err := vaxis.New(vaxis.Options{})
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	defer vx.Close()
-	// Our test corpus
-	cases := []string{
-		"😀",
-		"\u26A0\uFE0F", // VS16 selector
-		"👩‍🚀",          // ZWJ
-		"👋🏿",           // skin tone selector
-		"🏳️‍🌈",         // VS16 and ZWJ
-		"⚠️",
-	}
-	failures := []failure{}
-	_, col := vx.CursorPosition()
-	for _, c := range cases {
-		w := vx.RenderedWidth(c)
-
-		// out := "|" + strings.Repeat("-", w) + "|"
-		fmt.Print(c)
-		// fmt.Println("|" + c + "|")
-		_, next := vx.CursorPosition()
-		if w != (next - col) {
-			failures = append(failures, failure{
-				input:    c,
-				actual:   next - col,
-				expected: w,
-			})
+	r := report{Diagnostics: vx.Diagnostics()}
+	if *widths {
+		r.Widths, err = measureWidths(vx)
+		if err != nil {
+			r.WidthError = err.Error()
 		}
-		fmt.Println("")
 	}
 	vx.Close()
-	for _, f := range failures {
-		fmt.Printf("Test fail: %q: actual=%d, expected=%d\n", f.input, f.actual, f.expected)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(r); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else {
+		printReport(r, *widths)
 	}
-	if len(failures) > 0 {
+	if len(r.Widths) > 0 {
 		os.Exit(1)
 	}
 }
+
+// measureWidths compares the width the terminal renders graphemes with to the
+// width Vaxis computes for them
+func measureWidths(vx *vaxis.Vaxis) ([]widthMismatch, error) {
+	table, err := vx.ProbeWidths()
+	if err != nil {
+		return nil, err
+	}
+	mismatches := []widthMismatch{}
+	for _, g := range vaxis.WidthCorpus {
+		w := vx.RenderedWidth(g)
+		if table.Widths[g] != w {
+			mismatches = append(mismatches, widthMismatch{
+				Grapheme: g,
+				Terminal: table.Widths[g],
+				Vaxis:    w,
+			})
+		}
+	}
+	return mismatches, nil
+}
+
+func printReport(r report, widths bool) {
+	id := r.TerminalID
+	if id == "" {
+		id = "unknown"
+	}
+	fmt.Printf("Terminal:     %s\n", id)
+	fmt.Printf("TERM:         %s\n", os.Getenv("TERM"))
+	fmt.Printf("Size:         %dx%d cells, %dx%d pixels\n", r.Size.Cols, r.Size.Rows, r.Size.XPixel, r.Size.YPixel)
+	fmt.Printf("Graphics:     %s\n", r.Graphics)
+	fmt.Printf("Startup time: %s\n", r.StartupTime.Round(time.Microsecond))
+
+	fmt.Println("\nCapabilities:")
+	for _, c := range r.Capabilities {
+		mark := " "
+		if c.Supported {
+			mark = "x"
+		}
+		fmt.Printf("  [%s] %s\n", mark, c.Name)
+	}
+
+	fmt.Println("\nQueries:")
+	for _, q := range r.Queries {
+		if !q.Answered() {
+			fmt.Printf("  %-30s %10s  %q\n", q.Name, "no reply", q.Query)
+			continue
+		}
+		fmt.Printf("  %-30s %10s  %q\n", q.Name, q.Elapsed.Round(time.Microsecond), q.Query)
+		for _, resp := range q.Responses {
+			fmt.Printf("  %-30s %10s  -> %q\n", "", "", resp)
+		}
+	}
+
+	var unknown []vaxis.ResponseReport
+	for _, resp := range r.Responses {
+		if resp.Query == "" {
+			unknown = append(unknown, resp)
+		}
+	}
+	if len(unknown) > 0 {
+		fmt.Println("\nUnmatched replies:")
+		for _, resp := range unknown {
+			fmt.Printf("  %10s  %q\n", resp.Elapsed.Round(time.Microsecond), resp.Sequence)
+		}
+	}
+
+	if !widths {
+		return
+	}
+	fmt.Println("\nGrapheme widths:")
+	switch {
+	case r.WidthError != "":
+		fmt.Printf("  %s\n", r.WidthError)
+	case len(r.Widths) == 0:
+		fmt.Printf("  all %d graphemes match\n", len(vaxis.WidthCorpus))
+	default:
+		for _, m := range r.Widths {
+			fmt.Printf("  %q: terminal=%d, vaxis=%d\n", m.Grapheme, m.Terminal, m.Vaxis)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package vaxis
+
+import (
+	"context"
+	"math"
+)
+
+// RGB returns the red, green and blue components of an RGB color. ok is false
+// for the default color and indexed colors; see [Palette.Resolve]
+func (c Color) RGB() (r uint8, g uint8, b uint8, ok bool) {
+	if c&rgb == 0 {
+		return 0, 0, 0, false
+	}
+	return uint8(c >> 16), uint8(c >> 8), uint8(c), true
+}
+
+// Blend composites c over bg with the given alpha, where 0 is entirely bg and
+// 255 is entirely c. Blending is done in sRGB, as terminals and browsers do.
+// If either color isn't an RGB color, c is returned
+func (c Color) Blend(bg Color, alpha uint8) Color {
+	fr, fg, fb, ok := c.RGB()
+	if !ok {
+		return c
+	}
+	br, bgr, bb, ok := bg.RGB()
+	if !ok {
+		return c
+	}
+	blend := func(f, b uint8) uint8 {
+		return uint8((int(f)*int(alpha) + int(b)*(255-int(alpha))) / 255)
+	}
+	return RGBColor(blend(fr, br), blend(fg, bgr), blend(fb, bb))
+}
+
+// Mix interpolates between c and other in OKLab, where t is 0 for c and 1 for
+// other. OKLab is perceptually uniform, so evenly spaced values of t make an
+// even gradient. If either color isn't an RGB color, c is returned
+func (c Color) Mix(other Color, t float64) Color {
+	a, ok := c.okLab()
+	if !ok {
+		return c
+	}
+	b, ok := other.okLab()
+	if !ok {
+		return c
+	}
+	return okLab{
+		l: a.l + (b.l-a.l)*t,
+		a: a.a + (b.a-a.a)*t,
+		b: a.b + (b.b-a.b)*t,
+	}.color()
+}
+
+// Lighten raises the OKLCH lightness of c by amount, from 0 to 1, keeping its
+// hue. If c isn't an RGB color, it is returned unchanged
+func (c Color) Lighten(amount float64) Color {
+	l, chroma, h, ok := c.OKLCH()
+	if !ok {
+		return c
+	}
+	return OKLCHColorInGamut(l+amount, chroma, h)
+}
+
+// Darken lowers the OKLCH lightness of c by amount, from 0 to 1, keeping its
+// hue. If c isn't an RGB color, it is returned unchanged
+func (c Color) Darken(amount float64) Color {
+	return c.Lighten(-amount)
+}
+
+// HSL returns the hue, in degrees, and the saturation and lightness, from 0 to
+// 1, of an RGB color
+func (c Color) HSL() (h float64, s float64, l float64, ok bool) {
+	r8, g8, b8, ok := c.RGB()
+	if !ok {
+		return 0, 0, 0, false
+	}
+	r := float64(r8) / 255
+	g := float64(g8) / 255
+	b := float64(b8) / 255
+	hi := math.Max(r, math.Max(g, b))
+	lo := math.Min(r, math.Min(g, b))
+	l = (hi + lo) / 2
+	d := hi - lo
+	if d == 0 {
+		return 0, 0, l, true
+	}
+	s = d / (1 - math.Abs(2*l-1))
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return normalizeHue(h * 60), s, l, true
+}
+
+// HSLColor creates an RGB color from a hue in degrees, and a saturation and
+// lightness from 0 to 1
+func HSLColor(h float64, s float64, l float64) Color {
+	h = normalizeHue(h)
+	s = clampUnit(s)
+	l = clampUnit(l)
+	chroma := (1 - math.Abs(2*l-1)) * s
+	x := chroma * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = chroma, x
+	case h < 120:
+		r, g = x, chroma
+	case h < 180:
+		g, b = chroma, x
+	case h < 240:
+		g, b = x, chroma
+	case h < 300:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+	m := l - chroma/2
+	return RGBColor(unitToByte(r+m), unitToByte(g+m), unitToByte(b+m))
+}
+
+// OKLCH returns the lightness, from 0 to 1, the chroma and the hue, in degrees,
+// of an RGB color in the OKLCH color space
+func (c Color) OKLCH() (l float64, chroma float64, h float64, ok bool) {
+	lab, ok := c.okLab()
+	if !ok {
+		return 0, 0, 0, false
+	}
+	chroma = math.Hypot(lab.a, lab.b)
+	h = normalizeHue(math.Atan2(lab.b, lab.a) * 180 / math.Pi)
+	return lab.l, chroma, h, true
+}
+
+// OKLCHColor creates an RGB color from a lightness, from 0 to 1, a chroma and a
+// hue in degrees. Channels outside of sRGB are clipped, which can shift the hue
+// of very saturated colors; see [OKLCHColorInGamut]
+func OKLCHColor(l float64, chroma float64, h float64) Color {
+	return oklchLab(l, chroma, h).color()
+}
+
+// OKLCHColorInGamut is like [OKLCHColor], but brings colors outside of sRGB
+// into gamut by reducing chroma, which keeps the lightness and hue
+func OKLCHColorInGamut(l float64, chroma float64, h float64) Color {
+	chroma = math.Max(chroma, 0)
+	if lab := oklchLab(l, chroma, h); lab.inGamut() {
+		return lab.color()
+	}
+	lo, hi := 0.0, chroma
+	for i := 0; i < 24; i += 1 {
+		mid := (lo + hi) / 2
+		if oklchLab(l, mid, h).inGamut() {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return oklchLab(l, lo, h).color()
+}
+
+// Luminance returns the WCAG relative luminance of an RGB color, from 0 for
+// black to 1 for white. Other colors have a luminance of 0
+func (c Color) Luminance() float64 {
+	r, g, b, ok := c.RGB()
+	if !ok {
+		return 0
+	}
+	return 0.2126*linearChannel(float64(r)/255) +
+		0.7152*linearChannel(float64(g)/255) +
+		0.0722*linearChannel(float64(b)/255)
+}
+
+// ContrastRatio returns the WCAG contrast ratio of two colors, from 1 for no
+// contrast to 21 for black on white. WCAG AA asks for 4.5 for body text and 3
+// for large text
+func ContrastRatio(a Color, b Color) float64 {
+	la := a.Luminance()
+	lb := b.Luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// EnsureContrast returns c, adjusted as little as possible in OKLCH lightness
+// to have at least the given contrast ratio against bg. The lightness is moved
+// away from bg, falling back to the other direction when that can't reach the
+// ratio. If no lightness reaches the ratio, the color with the most contrast is
+// returned. If either color isn't an RGB color, c is returned
+func (c Color) EnsureContrast(bg Color, ratio float64) Color {
+	l, chroma, h, ok := c.OKLCH()
+	if !ok {
+		return c
+	}
+	if _, _, _, ok := bg.RGB(); !ok {
+		return c
+	}
+	if ContrastRatio(c, bg) >= ratio {
+		return c
+	}
+	// Search for the smallest lightness change towards target which
+	// reaches the ratio
+	search := func(target float64) (Color, bool) {
+		at := func(t float64) Color {
+			return OKLCHColor(l+(target-l)*t, chroma, h)
+		}
+		if ContrastRatio(at(1), bg) < ratio {
+			return at(1), false
+		}
+		lo, hi := 0.0, 1.0
+		for i := 0; i < 24; i += 1 {
+			mid := (lo + hi) / 2
+			if ContrastRatio(at(mid), bg) >= ratio {
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+		return at(hi), true
+	}
+	first, second := 1.0, 0.0
+	if c.Luminance() < bg.Luminance() {
+		first, second = 0.0, 1.0
+	}
+	best, ok := search(first)
+	if ok {
+		return best
+	}
+	other, ok := search(second)
+	if ok || ContrastRatio(other, bg) > ContrastRatio(best, bg) {
+		return other
+	}
+	return best
+}
+
+// Palette maps the 256 indexed colors to RGB colors
+type Palette [256]Color
+
+// DefaultPalette returns the xterm default palette
+func DefaultPalette() Palette {
+	var p Palette
+	for i, v := range xtermPalette {
+		p[i] = HexColor(v)
+	}
+	for i, v := range colorIndex {
+		p[i+16] = HexColor(v)
+	}
+	return p
+}
+
+// Resolve returns the RGB value of an indexed color. Entries of the palette
+// which aren't RGB colors resolve to the xterm default. RGB colors and the
+// default color are returned unchanged
+func (p *Palette) Resolve(c Color) Color {
+	if c&indexed == 0 {
+		return c
+	}
+	i := uint8(c)
+	if _, _, _, ok := p[i].RGB(); ok {
+		return p[i]
+	}
+	if i < 16 {
+		return HexColor(xtermPalette[i])
+	}
+	return HexColor(colorIndex[i-16])
+}
+
+// QueryPalette queries the host terminal for the first 16 indexed colors, which
+// are the ones users commonly configure. The remaining entries, and any the
+// terminal doesn't report before ctx is done, are the xterm defaults. Make sure
+// not to run this in the same goroutine as Vaxis runs in or deadlock will occur
+func (vx *Vaxis) QueryPalette(ctx context.Context) Palette {
+	p := DefaultPalette()
+	for i := 0; i < 16; i += 1 {
+		if ctx.Err() != nil {
+			break
+		}
+		if c := vx.QueryColorContext(ctx, IndexColor(uint8(i))); c != 0 {
+			p[i] = c
+		}
+	}
+	return p
+}
+
+type okLab struct {
+	l float64
+	a float64
+	b float64
+}
+
+func oklchLab(l float64, chroma float64, h float64) okLab {
+	l = clampUnit(l)
+	chroma = math.Max(chroma, 0)
+	rad := h * math.Pi / 180
+	return okLab{l: l, a: math.Cos(rad) * chroma, b: math.Sin(rad) * chroma}
+}
+
+func (c Color) okLab() (okLab, bool) {
+	r8, g8, b8, ok := c.RGB()
+	if !ok {
+		return okLab{}, false
+	}
+	r := linearChannel(float64(r8) / 255)
+	g := linearChannel(float64(g8) / 255)
+	b := linearChannel(float64(b8) / 255)
+
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	return okLab{
+		l: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		a: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		b: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}, true
+}
+
+// linearRGB converts to linear sRGB, which may be outside of [0, 1]
+func (c okLab) linearRGB() (r float64, g float64, b float64) {
+	l := c.l + 0.3963377774*c.a + 0.2158037573*c.b
+	m := c.l - 0.1055613458*c.a - 0.0638541728*c.b
+	s := c.l - 0.0894841775*c.a - 1.2914855480*c.b
+
+	l = l * l * l
+	m = m * m * m
+	s = s * s * s
+
+	r = 4.0767416621*l - 3.3077115913*m + 0.2309699292*s
+	g = -1.2684380046*l + 2.6097574011*m - 0.3413193965*s
+	b = -0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+	return r, g, b
+}
+
+func (c okLab) inGamut() bool {
+	const epsilon = 1e-6
+	r, g, b := c.linearRGB()
+	return r >= -epsilon && r <= 1+epsilon &&
+		g >= -epsilon && g <= 1+epsilon &&
+		b >= -epsilon && b <= 1+epsilon
+}
+
+// color converts to an RGB color, clipping each channel to sRGB
+func (c okLab) color() Color {
+	r, g, b := c.linearRGB()
+	return RGBColor(
+		unitToByte(gammaChannel(r)),
+		unitToByte(gammaChannel(g)),
+		unitToByte(gammaChannel(b)),
+	)
+}
+
+func linearChannel(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func gammaChannel(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1.0/2.4) - 0.055
+}
+
+func unitToByte(v float64) uint8 {
+	return uint8(math.Round(clampUnit(v) * 255))
+}
+
+func clampUnit(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return math.Max(0, math.Min(1, v))
+}
+
+func normalizeHue(h float64) float64 {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
@@ -0,0 +1,178 @@
+package vaxis_test
+
+import (
+	"math"
+	"testing"
+
+	"go.rockorager.dev/vaxis"
+)
+
+func TestColorBlend(t *testing.T) {
+	white := vaxis.HexColor(0xFFFFFF)
+	black := vaxis.HexColor(0x000000)
+	if got := white.Blend(black, 255); got != white {
+		t.Fatalf("opaque blend = %06x", uint32(got))
+	}
+	if got := white.Blend(black, 0); got != black {
+		t.Fatalf("transparent blend = %06x", uint32(got))
+	}
+	if got := white.Blend(black, 128); got != vaxis.RGBColor(128, 128, 128) {
+		t.Fatalf("half blend = %v", got)
+	}
+	if got := vaxis.IndexColor(1).Blend(black, 128); got != vaxis.IndexColor(1) {
+		t.Fatalf("indexed blend = %v", got)
+	}
+}
+
+func TestColorMix(t *testing.T) {
+	red := vaxis.HexColor(0xFF0000)
+	blue := vaxis.HexColor(0x0000FF)
+	if got := red.Mix(blue, 0); got != red {
+		t.Fatalf("mix at 0 = %06x", uint32(got))
+	}
+	if got := red.Mix(blue, 1); got != blue {
+		t.Fatalf("mix at 1 = %06x", uint32(got))
+	}
+	// OKLab keeps the midpoint from going muddy, unlike sRGB's 0x800080
+	r, _, b, _ := red.Mix(blue, 0.5).RGB()
+	if r <= 0x80 || b <= 0x80 {
+		t.Fatalf("midpoint = %02x..%02x, want a bright purple", r, b)
+	}
+}
+
+func TestColorHSL(t *testing.T) {
+	for _, hex := range []uint32{0x000000, 0xFFFFFF, 0xFF0000, 0x336699, 0xC0FFEE, 0x808080} {
+		c := vaxis.HexColor(hex)
+		h, s, l, ok := c.HSL()
+		if !ok {
+			t.Fatalf("%06x has no HSL", hex)
+		}
+		if got := vaxis.HSLColor(h, s, l); got != c {
+			t.Fatalf("%06x round-tripped to %06x", hex, uint32(got)&0xFFFFFF)
+		}
+	}
+	h, s, l, _ := vaxis.HexColor(0x00FF00).HSL()
+	if h != 120 || s != 1 || l != 0.5 {
+		t.Fatalf("green = %v %v %v", h, s, l)
+	}
+	if _, _, _, ok := vaxis.ColorDefault.HSL(); ok {
+		t.Fatal("the default color has no HSL")
+	}
+}
+
+func TestColorOKLCH(t *testing.T) {
+	for _, hex := range []uint32{0x000000, 0xFFFFFF, 0xFF0000, 0x336699, 0xC0FFEE} {
+		c := vaxis.HexColor(hex)
+		l, chroma, h, ok := c.OKLCH()
+		if !ok {
+			t.Fatalf("%06x has no OKLCH", hex)
+		}
+		if got := vaxis.OKLCHColor(l, chroma, h); got != c {
+			t.Fatalf("%06x round-tripped to %06x", hex, uint32(got)&0xFFFFFF)
+		}
+	}
+	white, _, _, _ := vaxis.HexColor(0xFFFFFF).OKLCH()
+	if math.Abs(white-1) > 1e-3 {
+		t.Fatalf("white lightness = %v", white)
+	}
+
+	// Out of gamut colors are clipped, or keep their hue when mapped
+	if got, want := vaxis.OKLCHColor(0.7, 0.5, 145), vaxis.HexColor(0x00DA00); got != want {
+		t.Fatalf("clipped color = %06x, want %06x", uint32(got)&0xFFFFFF, uint32(want)&0xFFFFFF)
+	}
+	c := vaxis.OKLCHColorInGamut(0.7, 0.5, 145)
+	_, _, h, _ := c.OKLCH()
+	if math.Abs(h-145) > 3 {
+		t.Fatalf("gamut mapped hue = %v, want 145", h)
+	}
+}
+
+func TestColorLightenDarken(t *testing.T) {
+	c := vaxis.HexColor(0x336699)
+	l, _, _, _ := c.OKLCH()
+	lighter, _, _, _ := c.Lighten(0.1).OKLCH()
+	darker, _, _, _ := c.Darken(0.1).OKLCH()
+	if math.Abs(lighter-l-0.1) > 0.01 || math.Abs(l-darker-0.1) > 0.01 {
+		t.Fatalf("lightness %v: lighter %v, darker %v", l, lighter, darker)
+	}
+	if got := vaxis.HexColor(0xFFFFFF).Lighten(0.5); got != vaxis.HexColor(0xFFFFFF) {
+		t.Fatalf("lightened white = %06x", uint32(got)&0xFFFFFF)
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	black := vaxis.HexColor(0x000000)
+	white := vaxis.HexColor(0xFFFFFF)
+	if got := vaxis.ContrastRatio(black, white); math.Abs(got-21) > 1e-9 {
+		t.Fatalf("black on white = %v, want 21", got)
+	}
+	if got := vaxis.ContrastRatio(white, white); got != 1 {
+		t.Fatalf("white on white = %v, want 1", got)
+	}
+	if got := vaxis.ContrastRatio(vaxis.HexColor(0x777777), white); math.Abs(got-4.48) > 0.01 {
+		t.Fatalf("#777 on white = %v, want 4.48", got)
+	}
+}
+
+func TestEnsureContrast(t *testing.T) {
+	tests := []struct {
+		fg, bg uint32
+		ratio  float64
+	}{
+		{0x777777, 0xFFFFFF, 4.5},
+		{0x336699, 0x223344, 4.5},
+		{0xFF0000, 0xFF0000, 3},
+	}
+	for _, test := range tests {
+		fg := vaxis.HexColor(test.fg)
+		bg := vaxis.HexColor(test.bg)
+		got := fg.EnsureContrast(bg, test.ratio)
+		if ratio := vaxis.ContrastRatio(got, bg); ratio < test.ratio {
+			t.Fatalf("%06x on %06x: got %06x with ratio %.2f, want %.1f",
+				test.fg, test.bg, uint32(got)&0xFFFFFF, ratio, test.ratio)
+		}
+	}
+
+	// When the ratio can't be reached, the most contrast is used
+	if got := vaxis.HexColor(0x808080).EnsureContrast(vaxis.HexColor(0x808080), 7); got != vaxis.HexColor(0x000000) {
+		t.Fatalf("unreachable contrast on gray = %06x, want black", uint32(got)&0xFFFFFF)
+	}
+
+	// Colors which already have enough contrast are unchanged
+	fg := vaxis.HexColor(0x000000)
+	if got := fg.EnsureContrast(vaxis.HexColor(0xFFFFFF), 4.5); got != fg {
+		t.Fatalf("black on white changed to %06x", uint32(got)&0xFFFFFF)
+	}
+	// The ratio is reached with a small change
+	got := vaxis.HexColor(0x777777).EnsureContrast(vaxis.HexColor(0xFFFFFF), 4.5)
+	if r, _, _, _ := got.RGB(); r < 0x70 {
+		t.Fatalf("#777 was darkened to %06x", uint32(got)&0xFFFFFF)
+	}
+}
+
+func TestPaletteResolve(t *testing.T) {
+	p := vaxis.DefaultPalette()
+	if got := p.Resolve(vaxis.IndexColor(9)); got != vaxis.HexColor(0xFF0000) {
+		t.Fatalf("index 9 = %06x", uint32(got)&0xFFFFFF)
+	}
+	if got := p.Resolve(vaxis.IndexColor(196)); got != vaxis.HexColor(0xFF0000) {
+		t.Fatalf("index 196 = %06x", uint32(got)&0xFFFFFF)
+	}
+	if got := p.Resolve(vaxis.IndexColor(232)); got != vaxis.HexColor(0x080808) {
+		t.Fatalf("index 232 = %06x", uint32(got)&0xFFFFFF)
+	}
+
+	p[1] = vaxis.HexColor(0xCC241D)
+	p[2] = vaxis.ColorDefault
+	if got := p.Resolve(vaxis.IndexColor(1)); got != vaxis.HexColor(0xCC241D) {
+		t.Fatalf("queried index 1 = %06x", uint32(got)&0xFFFFFF)
+	}
+	if got := p.Resolve(vaxis.IndexColor(2)); got != vaxis.HexColor(0x00CD00) {
+		t.Fatalf("unknown index 2 = %06x", uint32(got)&0xFFFFFF)
+	}
+	for _, c := range []vaxis.Color{vaxis.ColorDefault, vaxis.HexColor(0x123456)} {
+		if got := p.Resolve(c); got != c {
+			t.Fatalf("Resolve(%v) = %v", c, got)
+		}
+	}
+}
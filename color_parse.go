@@ -0,0 +1,169 @@
+package vaxis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ansiColorNames are the names of indexes 0-15, as accepted by [ParseColor]
+// and returned by [Color.Spec]
+var ansiColorNames = [16]string{
+	"black",
+	"red",
+	"green",
+	"yellow",
+	"blue",
+	"magenta",
+	"cyan",
+	"white",
+	"bright-black",
+	"bright-red",
+	"bright-green",
+	"bright-yellow",
+	"bright-blue",
+	"bright-magenta",
+	"bright-cyan",
+	"bright-white",
+}
+
+// ParseColor parses a color specification, such as one from a configuration
+// file. The accepted forms are:
+//
+//	default               the default color
+//	#rgb, #rrggbb         hex RGB, as in CSS
+//	#rrrgggbbb and #rrrrggggbbbb
+//	rgb:r/g/b             X11 hex RGB with 1 to 4 digits per channel, as in
+//	                      OSC 10 and 11 replies
+//	rgbi:r/g/b            X11 intensities from 0 to 1
+//	red, bright-red       ANSI names of indexes 0-15
+//	color123              indexes 0-255
+//	rebeccapurple         CSS named colors
+//
+// Names are case insensitive and ignore spaces, hyphens and underscores. The
+// eight ANSI names, such as red, resolve to the terminal's palette rather than
+// to the CSS color of the same name; use a hex value for the CSS color
+func ParseColor(s string) (Color, error) {
+	spec := strings.ToLower(strings.TrimSpace(s))
+	var (
+		c  Color
+		ok bool
+	)
+	switch {
+	case strings.HasPrefix(spec, "#"):
+		c, ok = parseHexColor(spec[1:])
+	case strings.HasPrefix(spec, "rgb:"):
+		c, ok = parseX11Color(spec[4:], parseHexChannel)
+	case strings.HasPrefix(spec, "rgbi:"):
+		c, ok = parseX11Color(spec[5:], parseIntensityChannel)
+	default:
+		c, ok = parseNamedColor(spec)
+	}
+	if !ok {
+		return 0, fmt.Errorf("vaxis: invalid color %q", s)
+	}
+	return c, nil
+}
+
+// Spec returns the color in a form accepted by [ParseColor]: "default", an
+// ANSI name for indexes 0-15, "color" and the index for other indexes, or
+// "#rrggbb"
+func (c Color) Spec() string {
+	switch {
+	case c&indexed != 0:
+		i := uint8(c)
+		if i < 16 {
+			return ansiColorNames[i]
+		}
+		return "color" + strconv.Itoa(int(i))
+	case c&rgb != 0:
+		return fmt.Sprintf("#%06x", uint32(c)&0xFFFFFF)
+	default:
+		return "default"
+	}
+}
+
+func parseNamedColor(spec string) (Color, bool) {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, spec)
+	if name == "default" {
+		return ColorDefault, true
+	}
+	if digits, found := strings.CutPrefix(name, "color"); found && digits != "" {
+		i, err := strconv.ParseUint(digits, 10, 8)
+		if err != nil {
+			return 0, false
+		}
+		return IndexColor(uint8(i)), true
+	}
+	for i, ansi := range ansiColorNames {
+		if name == strings.ReplaceAll(ansi, "-", "") {
+			return IndexColor(uint8(i)), true
+		}
+	}
+	if v, found := cssColors[name]; found {
+		return HexColor(v), true
+	}
+	return 0, false
+}
+
+// parseHexColor parses #rgb, #rrggbb, #rrrgggbbb and #rrrrggggbbbb, without
+// the leading #
+func parseHexColor(s string) (Color, bool) {
+	if len(s) == 0 || len(s)%3 != 0 || len(s) > 12 {
+		return 0, false
+	}
+	size := len(s) / 3
+	var ch [3]uint8
+	for i := range ch {
+		v, ok := parseHexChannel(s[i*size : (i+1)*size])
+		if !ok {
+			return 0, false
+		}
+		ch[i] = v
+	}
+	return RGBColor(ch[0], ch[1], ch[2]), true
+}
+
+// parseX11Color parses the r/g/b part of an X11 rgb: or rgbi: specification
+func parseX11Color(s string, channel func(string) (uint8, bool)) (Color, bool) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	var ch [3]uint8
+	for i, part := range parts {
+		v, ok := channel(part)
+		if !ok {
+			return 0, false
+		}
+		ch[i] = v
+	}
+	return RGBColor(ch[0], ch[1], ch[2]), true
+}
+
+// parseHexChannel scales a channel of 1 to 4 hex digits to 8 bits
+func parseHexChannel(s string) (uint8, bool) {
+	if len(s) == 0 || len(s) > 4 {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(s, 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	max := uint64(1)<<(4*len(s)) - 1
+	return uint8(v * 0xFF / max), true
+}
+
+func parseIntensityChannel(s string) (uint8, bool) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || !(v >= 0 && v <= 1) {
+		return 0, false
+	}
+	return uint8(v * 255), true
+}
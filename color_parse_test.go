@@ -0,0 +1,96 @@
+package vaxis_test
+
+import (
+	"testing"
+
+	"go.rockorager.dev/vaxis"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		spec string
+		want vaxis.Color
+	}{
+		{"default", vaxis.ColorDefault},
+		{"#fff", vaxis.HexColor(0xFFFFFF)},
+		{"#1a2", vaxis.HexColor(0x11AA22)},
+		{"#C0FFEE", vaxis.HexColor(0xC0FFEE)},
+		{"#123456789", vaxis.HexColor(0x124578)},
+		{"#ffff00008080", vaxis.HexColor(0xFF0080)},
+		{"rgb:ff/00/80", vaxis.HexColor(0xFF0080)},
+		{"rgb:f/0/8", vaxis.HexColor(0xFF0088)},
+		{"rgb:ffff/0000/8080", vaxis.HexColor(0xFF0080)},
+		{"RGB:AB/CD/EF", vaxis.HexColor(0xABCDEF)},
+		{"rgbi:1/0/0.5", vaxis.HexColor(0xFF007F)},
+		{"red", vaxis.IndexColor(1)},
+		{"bright-red", vaxis.IndexColor(9)},
+		{"Bright Red", vaxis.IndexColor(9)},
+		{"bright_white", vaxis.IndexColor(15)},
+		{"color0", vaxis.IndexColor(0)},
+		{"color123", vaxis.IndexColor(123)},
+		{"color255", vaxis.IndexColor(255)},
+		{"rebeccapurple", vaxis.HexColor(0x663399)},
+		{"Dark Slate Gray", vaxis.HexColor(0x2F4F4F)},
+		{"  orange ", vaxis.HexColor(0xFFA500)},
+	}
+	for _, test := range tests {
+		got, err := vaxis.ParseColor(test.spec)
+		if err != nil {
+			t.Fatalf("ParseColor(%q): %v", test.spec, err)
+		}
+		if got != test.want {
+			t.Fatalf("ParseColor(%q) = %v, want %v", test.spec, got, test.want)
+		}
+	}
+}
+
+func TestParseColorInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"#",
+		"#ff",
+		"#fffff",
+		"#ggg",
+		"#1234567890abc",
+		"rgb:ff/00",
+		"rgb:fffff/0/0",
+		"rgb:/0/0",
+		"rgbi:2/0/0",
+		"rgbi:nan/0/0",
+		"color",
+		"color256",
+		"color1x",
+		"bright-orange",
+		"notacolor",
+	} {
+		if c, err := vaxis.ParseColor(spec); err == nil {
+			t.Fatalf("ParseColor(%q) = %v, want an error", spec, c)
+		}
+	}
+}
+
+func TestColorSpecRoundTrip(t *testing.T) {
+	colors := []vaxis.Color{vaxis.ColorDefault, vaxis.HexColor(0x000000), vaxis.HexColor(0xC0FFEE)}
+	for i := 0; i < 256; i += 1 {
+		colors = append(colors, vaxis.IndexColor(uint8(i)))
+	}
+	for _, c := range colors {
+		got, err := vaxis.ParseColor(c.Spec())
+		if err != nil {
+			t.Fatalf("ParseColor(%q): %v", c.Spec(), err)
+		}
+		if got != c {
+			t.Fatalf("%q round-tripped to %v", c.Spec(), got)
+		}
+	}
+	for c, want := range map[vaxis.Color]string{
+		vaxis.ColorDefault:       "default",
+		vaxis.ColorRed:           "bright-red",
+		vaxis.IndexColor(42):     "color42",
+		vaxis.HexColor(0x0A0B0C): "#0a0b0c",
+	} {
+		if got := c.Spec(); got != want {
+			t.Fatalf("Spec() = %q, want %q", got, want)
+		}
+	}
+}
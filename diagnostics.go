@@ -0,0 +1,286 @@
+package vaxis
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.rockorager.dev/vaxis/ansi"
+)
+
+// Diagnostics describes what Vaxis learned about the terminal when it started:
+// the queries it sent, the raw replies, and the capabilities it detected. It
+// is meant to be attached to bug reports
+type Diagnostics struct {
+	// TerminalID is the XTVERSION reply, if any
+	TerminalID string `json:"terminal_id"`
+	// Size is the size of the terminal when the report was made
+	Size Resize `json:"size"`
+	// Graphics is the graphics protocol in use: "none", "full block",
+	// "half block", "sixel" or "kitty"
+	Graphics string `json:"graphics"`
+	// CursorStyle is the cursor style the user has configured
+	CursorStyle CursorStyle `json:"cursor_style"`
+	// Capabilities are the features Vaxis checks for, in a fixed order
+	Capabilities []CapabilityReport `json:"capabilities"`
+	// Queries are the startup queries, in the order they were sent
+	Queries []QueryReport `json:"queries"`
+	// Responses are every reply received during startup, in the order
+	// they arrived
+	Responses []ResponseReport `json:"responses"`
+	// StartupTime is the time from sending the first query until the
+	// terminal answered the primary device attributes query, or until
+	// Vaxis gave up waiting
+	StartupTime time.Duration `json:"startup_time_ns"`
+}
+
+// CapabilityReport reports whether a capability was detected
+type CapabilityReport struct {
+	Name      string `json:"name"`
+	Supported bool   `json:"supported"`
+}
+
+// QueryReport describes a single startup query and the replies to it
+type QueryReport struct {
+	Name string `json:"name"`
+	// Query is the sequence which was sent
+	Query string `json:"query"`
+	// Responses are the replies to the query, re-encoded from the parsed
+	// sequences. A query which the terminal doesn't understand usually has
+	// none
+	Responses []string `json:"responses,omitempty"`
+	// Elapsed is the time from sending the query to receiving the first
+	// reply. It is zero when there was no reply
+	Elapsed time.Duration `json:"elapsed_ns,omitempty"`
+}
+
+// Answered reports whether the terminal replied to the query
+func (q QueryReport) Answered() bool {
+	return len(q.Responses) > 0
+}
+
+// ResponseReport is a single reply received during startup
+type ResponseReport struct {
+	// Elapsed is the time since the first query was sent
+	Elapsed time.Duration `json:"elapsed_ns"`
+	// Sequence is the reply, re-encoded from the parsed sequence
+	Sequence string `json:"sequence"`
+	// Query is the name of the query the reply answers, if it is known
+	Query string `json:"query,omitempty"`
+}
+
+// startupDiagnostics records the startup queries and their replies
+type startupDiagnostics struct {
+	recording atomic.Bool
+
+	mu        sync.Mutex
+	start     time.Time
+	end       time.Time
+	queries   []*startupQuery
+	responses []ResponseReport
+}
+
+type startupQuery struct {
+	report QueryReport
+	sent   time.Time
+	match  func(ansi.Sequence) bool
+}
+
+func newStartupDiagnostics() *startupDiagnostics {
+	d := &startupDiagnostics{start: time.Now()}
+	d.recording.Store(true)
+	return d
+}
+
+// addQuery records that a query was sent. match reports whether a sequence
+// is a reply to the query
+func (d *startupDiagnostics) addQuery(name string, query string, match func(ansi.Sequence) bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = append(d.queries, &startupQuery{
+		report: QueryReport{Name: name, Query: query},
+		sent:   time.Now(),
+		match:  match,
+	})
+}
+
+// record records a sequence received from the terminal, matching it to the
+// query it answers
+func (d *startupDiagnostics) record(seq ansi.Sequence) {
+	if !d.recording.Load() {
+		return
+	}
+	switch seq.(type) {
+	case ansi.CSI, ansi.DCS, ansi.OSC, ansi.APC:
+	default:
+		// Replies are never printable text or plain escapes, so
+		// those are keys the user typed
+		return
+	}
+	now := time.Now()
+	raw := ansi.Encode(seq)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	resp := ResponseReport{
+		Elapsed:  now.Sub(d.start),
+		Sequence: raw,
+	}
+	for _, q := range d.queries {
+		if !q.match(seq) {
+			continue
+		}
+		if !q.report.Answered() {
+			q.report.Elapsed = now.Sub(q.sent)
+		}
+		q.report.Responses = append(q.report.Responses, raw)
+		resp.Query = q.report.Name
+		break
+	}
+	d.responses = append(d.responses, resp)
+}
+
+// finish stops recording
+func (d *startupDiagnostics) finish() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.recording.Store(false)
+	d.end = time.Now()
+}
+
+// sendQuery writes a startup query to the terminal
+func (vx *Vaxis) sendQuery(name string, query string, match func(ansi.Sequence) bool) {
+	if d := vx.diag.Load(); d != nil {
+		d.addQuery(name, query, match)
+	}
+	_, _ = vx.tw.WriteControlString(query)
+}
+
+// Diagnostics returns a report of the startup queries, the terminal's
+// replies and the capabilities Vaxis detected
+func (vx *Vaxis) Diagnostics() Diagnostics {
+	vx.mu.Lock()
+	report := Diagnostics{
+		TerminalID:   string(vx.termID),
+		Size:         vx.winSize,
+		Graphics:     graphicsProtocolName(vx.graphicsProtocol),
+		CursorStyle:  vx.userCursorStyle,
+		Capabilities: vx.caps.report(),
+	}
+	vx.mu.Unlock()
+
+	d := vx.diag.Load()
+	if d == nil {
+		return report
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, q := range d.queries {
+		qr := q.report
+		qr.Responses = append([]string(nil), qr.Responses...)
+		report.Queries = append(report.Queries, qr)
+	}
+	report.Responses = append([]ResponseReport(nil), d.responses...)
+	if !d.end.IsZero() {
+		report.StartupTime = d.end.Sub(d.start)
+	}
+	return report
+}
+
+func (caps capabilities) report() []CapabilityReport {
+	return []CapabilityReport{
+		{"Synchronized updates (mode 2026)", caps.synchronizedUpdate},
+		{"Unicode core (mode 2027)", caps.unicodeCore},
+		{"Explicit width (OSC 66)", caps.explicitWidth},
+		{"RGB color", caps.rgb},
+		{"Styled underlines", caps.styledUnderlines},
+		{"Hyperlinks (OSC 8)", caps.osc8},
+		{"Kitty keyboard", caps.kittyKeyboard},
+		{"Kitty graphics", caps.kittyGraphics},
+		{"Sixel graphics", caps.sixels},
+		{"Palette color query (OSC 4)", caps.osc4},
+		{"Foreground color query (OSC 10)", caps.osc10},
+		{"Background color query (OSC 11)", caps.osc11},
+		{"App ID (OSC 176)", caps.osc176},
+		{"Color theme updates (mode 2031)", caps.colorThemeUpdates},
+		{"Visibility reports (mode 2033)", caps.visibilityReports},
+		{"In-band resize (mode 2048)", caps.inBandResize},
+		{"Text area size in characters", caps.reportSizeChars},
+		{"Text area size in pixels", caps.reportSizePixels},
+		{"SGR pixel mouse (mode 1016)", caps.sgrPixels},
+	}
+}
+
+func graphicsProtocolName(p int) string {
+	switch p {
+	case fullBlock:
+		return "full block"
+	case halfBlock:
+		return "half block"
+	case sixelGraphics:
+		return "sixel"
+	case kitty:
+		return "kitty"
+	default:
+		return "none"
+	}
+}
+
+// matchCSI matches CSI replies with the final byte and, when marker isn't 0,
+// the private marker. Replies must start with one of params, if any are given
+func matchCSI(final rune, marker rune, params ...int) func(ansi.Sequence) bool {
+	return func(seq ansi.Sequence) bool {
+		csi, ok := seq.(ansi.CSI)
+		if !ok || csi.Final != final {
+			return false
+		}
+		intermediates := csi.Intermediates()
+		if marker != 0 && (len(intermediates) == 0 || intermediates[0] != marker) {
+			return false
+		}
+		if len(params) == 0 {
+			return true
+		}
+		if csi.NumParameters == 0 {
+			return false
+		}
+		for _, p := range params {
+			if csi.Param(0) == p {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matchDCS matches DCS replies with the final byte, the first intermediate
+// and data starting with prefix
+func matchDCS(final rune, intermediate rune, prefix string) func(ansi.Sequence) bool {
+	return func(seq ansi.Sequence) bool {
+		dcs, ok := seq.(ansi.DCS)
+		if !ok || dcs.Final != final {
+			return false
+		}
+		intermediates := dcs.Intermediates()
+		if len(intermediates) == 0 || intermediates[0] != intermediate {
+			return false
+		}
+		return strings.HasPrefix(string(dcs.Data), prefix)
+	}
+}
+
+// matchOSC matches OSC replies whose payload starts with prefix
+func matchOSC(prefix string) func(ansi.Sequence) bool {
+	return func(seq ansi.Sequence) bool {
+		osc, ok := seq.(ansi.OSC)
+		return ok && strings.HasPrefix(string(osc.Payload), prefix)
+	}
+}
+
+// matchAPC matches APC replies whose data starts with prefix
+func matchAPC(prefix string) func(ansi.Sequence) bool {
+	return func(seq ansi.Sequence) bool {
+		apc, ok := seq.(ansi.APC)
+		return ok && strings.HasPrefix(apc.Data, prefix)
+	}
+}
@@ -0,0 +1,87 @@
+package vaxis
+
+import (
+	"strings"
+	"testing"
+
+	"go.rockorager.dev/vaxis/ansi"
+)
+
+// recordReplies parses input as terminal replies and records them
+func recordReplies(d *startupDiagnostics, input string) {
+	parser := ansi.NewParser(strings.NewReader(input), ansi.ParserModeOutput)
+	for seq := range parser.Next() {
+		d.record(seq)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	vx := &Vaxis{termID: "foot(1.17.2)", graphicsProtocol: sixelGraphics}
+	vx.caps.sixels = true
+	d := newStartupDiagnostics()
+	vx.diag.Store(d)
+	d.addQuery("DECRQM synchronized update", decrqm(synchronizedUpdate), matchCSI('y', '?', synchronizedUpdate))
+	d.addQuery("DECRQM unicode core", decrqm(unicodeCore), matchCSI('y', '?', unicodeCore))
+	d.addQuery("Text area size", textAreaSize, matchCSI('t', 0, 4, 8))
+	d.addQuery("XTGETTCAP RGB", xtgettcap("RGB"), matchDCS('r', '+', hexEncode("RGB")))
+	d.addQuery("OSC 11 background color", osc11, matchOSC("11;"))
+	d.addQuery("Primary device attributes", primaryAttributes, matchCSI('c', '?'))
+
+	recordReplies(d, "\x1b[?2026;2$y"+
+		"\x1b[4;600;800t\x1b[8;24;80t"+
+		"typed"+
+		"\x1b]11;rgb:0000/0000/0000\x1b\\"+
+		"\x1b[?99u"+
+		"\x1b[?62;4c")
+	d.finish()
+	// Replies after startup aren't recorded
+	recordReplies(d, "\x1b[?2027;1$y")
+
+	report := vx.Diagnostics()
+	if report.TerminalID != "foot(1.17.2)" || report.Graphics != "sixel" {
+		t.Fatalf("report = %+v", report)
+	}
+	answered := map[string]int{}
+	for _, q := range report.Queries {
+		answered[q.Name] = len(q.Responses)
+	}
+	want := map[string]int{
+		"DECRQM synchronized update": 1,
+		"DECRQM unicode core":        0,
+		"Text area size":             2,
+		"XTGETTCAP RGB":              0,
+		"OSC 11 background color":    1,
+		"Primary device attributes":  1,
+	}
+	for name, n := range want {
+		if answered[name] != n {
+			t.Fatalf("query %q got %d responses, want %d", name, answered[name], n)
+		}
+	}
+	if got := report.Queries[0].Responses[0]; got != "\x1b[?2026;2$y" {
+		t.Fatalf("raw response = %q", got)
+	}
+
+	// Every reply is listed, including ones no query asked for, but not
+	// the typed keys
+	if len(report.Responses) != 6 {
+		t.Fatalf("got %d responses, want 6: %+v", len(report.Responses), report.Responses)
+	}
+	if got := report.Responses[4]; got.Query != "" || got.Sequence != "\x1b[?99u" {
+		t.Fatalf("unexpected reply = %+v", got)
+	}
+
+	for _, c := range report.Capabilities {
+		if c.Supported != (c.Name == "Sixel graphics") {
+			t.Fatalf("capability %q supported = %v", c.Name, c.Supported)
+		}
+	}
+}
+
+func TestDiagnosticsWithoutStartup(t *testing.T) {
+	vx := &Vaxis{}
+	report := vx.Diagnostics()
+	if len(report.Queries) != 0 || len(report.Responses) != 0 || report.Graphics != "none" {
+		t.Fatalf("report = %+v", report)
+	}
+}
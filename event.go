@@ -67,6 +67,16 @@ type Redraw struct{}
 // Applications should assume that a redraw must occur after a SyncFunc
 type SyncFunc func()
 
+// SuspendEvent is sent when job control suspends the application. The terminal
+// has already been restored and the process is stopped shortly after, so the
+// event is usually received once the application has been continued
+type SuspendEvent struct{}
+
+// ResumeEvent is sent when the application is continued after being stopped,
+// once raw mode and the screen have been restored. The next Render redraws the
+// entire screen
+type ResumeEvent struct{}
+
 // QuitEvent is sent when the application is closing. It is emitted when the
 // application calls vaxis.Close, and often times won't be seen by the
 // application.
@@ -121,7 +121,7 @@ type KittyImage struct {
 }
 
 func (vx *Vaxis) NewKittyGraphic(img image.Image) *KittyImage {
-	log.Trace("new kitty image")
+	log.Images.Trace("new kitty image")
 	k := &KittyImage{
 		vx:  vx,
 		img: img,
@@ -141,7 +141,7 @@ func (k *KittyImage) Draw(win Window) {
 		return
 	}
 	col, row := win.Origin()
-	log.Trace("placing kitty image at cell %d,%d", col, row)
+	log.Images.Trace("placing kitty image at cell %d,%d", col, row)
 	// the pid is a 32 bit number where the high 16bits are the width and
 	// the low 16 are the height
 	pid := uint(col)<<16 | uint(row)
@@ -216,7 +216,7 @@ func (k *KittyImage) Resize(w int, h int) {
 		wc := base64.NewEncoder(base64.StdEncoding, buf)
 		err := png.Encode(wc, img)
 		if err != nil {
-			log.Error("couldn't encode kitty image: %v", err)
+			log.Images.Error("couldn't encode kitty image: %v", err)
 			return
 		}
 		_ = wc.Close()
@@ -293,7 +293,7 @@ func (s *Sixel) Draw(win Window) {
 			_, _ = fmt.Fprintf(w, "\x1b[%d;%dH%*s", row+y+1, col+1, pw, "")
 		}
 	}
-	log.Trace("placing sixel image at cell %d,%d", col, row)
+	log.Images.Trace("placing sixel image at cell %d,%d", col, row)
 	placement := &placement{
 		col:      col,
 		row:      row,
@@ -348,7 +348,7 @@ func (s *Sixel) Resize(w int, h int) {
 		}
 		err := sixel.NewEncoder(s.buf).Encode(paletted)
 		if err != nil {
-			log.Error("couldn't encode sixel: %v", err)
+			log.Images.Error("couldn't encode sixel: %v", err)
 			return
 		}
 
@@ -378,7 +378,7 @@ func (s *Sixel) cellSizeChanged() bool {
 }
 
 func (vx *Vaxis) NewSixel(img image.Image) *Sixel {
-	log.Trace("new sixel image")
+	log.Images.Trace("new sixel image")
 	s := &Sixel{
 		vx:  vx,
 		img: img,
@@ -438,7 +438,7 @@ func resizeImage(img image.Image, w int, h int, cellPixW int, cellPixH int) imag
 	if hPix%cellPixH != 0 {
 		lines += 1
 	}
-	log.Debug("resizing image from (%d x %d) to (%d x %d)", columns, lines, w, h)
+	log.Images.Debug("resizing image from (%d x %d) to (%d x %d)", columns, lines, w, h)
 	if columns <= w && lines <= h {
 		return img
 	}
@@ -541,7 +541,7 @@ type FullBlockImage struct {
 }
 
 func (vx *Vaxis) NewFullBlockImage(img image.Image) *FullBlockImage {
-	log.Trace("new full block image")
+	log.Images.Trace("new full block image")
 	fb := &FullBlockImage{
 		vx:  vx,
 		img: img,
@@ -551,7 +551,7 @@ func (vx *Vaxis) NewFullBlockImage(img image.Image) *FullBlockImage {
 
 func (fb *FullBlockImage) Draw(win Window) {
 	col, row := win.Origin()
-	log.Trace("placing full block image at cell %d,%d", col, row)
+	log.Images.Trace("placing full block image at cell %d,%d", col, row)
 	for i, cell := range fb.cells {
 		y := i / fb.width
 		x := i - (y * fb.width)
@@ -654,7 +654,7 @@ type HalfBlockImage struct {
 }
 
 func (vx *Vaxis) NewHalfBlockImage(img image.Image) *HalfBlockImage {
-	log.Trace("new half block image")
+	log.Images.Trace("new half block image")
 	hb := &HalfBlockImage{
 		vx:  vx,
 		img: img,
@@ -664,7 +664,7 @@ func (vx *Vaxis) NewHalfBlockImage(img image.Image) *HalfBlockImage {
 
 func (hb *HalfBlockImage) Draw(win Window) {
 	col, row := win.Origin()
-	log.Trace("placing half block image at cell %d,%d", col, row)
+	log.Images.Trace("placing half block image at cell %d,%d", col, row)
 	for i, cell := range hb.cells {
 		y := i / hb.width
 		x := i - (y * hb.width)
@@ -0,0 +1,106 @@
+package vaxis
+
+import (
+	"os/signal"
+
+	"go.rockorager.dev/vaxis/log"
+)
+
+// newJobControl returns opts with defaults applied
+func newJobControl(opts JobControlOptions) *JobControlOptions {
+	if opts.SuspendKey == 0 {
+		opts.SuspendKey = 'z'
+		opts.SuspendModifiers = ModCtrl
+	}
+	return &opts
+}
+
+// postKey delivers a decoded key to the application. When job control is
+// enabled, the suspend key is consumed and suspends the application instead
+func (vx *Vaxis) postKey(key Key) {
+	if vx.isSuspendKey(key) {
+		select {
+		case vx.chJobSuspend <- struct{}{}:
+		default:
+			// A suspend is already pending
+		}
+		return
+	}
+	vx.PostEventBlocking(key)
+}
+
+func (vx *Vaxis) isSuspendKey(key Key) bool {
+	if vx.jobControl == nil {
+		return false
+	}
+	if key.EventType != EventPress {
+		return false
+	}
+	return key.Matches(vx.jobControl.SuspendKey, vx.jobControl.SuspendModifiers)
+}
+
+// runJobControl suspends and continues the application. Both are handled
+// from this goroutine: suspending stops the input parser, so it can't be
+// done from the goroutine reading input
+func (vx *Vaxis) runJobControl() {
+	for {
+		select {
+		case <-vx.chJobSuspend:
+			vx.suspendJob()
+		case <-vx.chSigCont:
+			vx.continueJob()
+		case <-vx.chQuit:
+			signal.Stop(vx.chSigCont)
+			return
+		}
+	}
+}
+
+// suspendJob restores the terminal and stops the process. When the process is
+// continued, SIGCONT is delivered and continueJob takes over
+func (vx *Vaxis) suspendJob() {
+	log.Info("[job control] suspending")
+	vx.PostEvent(SuspendEvent{})
+	vx.mu.Lock()
+	vx.jobSuspended = true
+	vx.mu.Unlock()
+	_ = vx.Suspend()
+	if err := raiseSuspend(); err != nil {
+		log.Error("[job control] couldn't stop the process: %v", err)
+		vx.continueJob()
+	}
+}
+
+// continueJob restores the terminal after the process was continued. If the
+// process wasn't suspended by job control, it was stopped externally (eg kill
+// -STOP) while the terminal was in raw mode and the shell may have changed the
+// terminal since, so the terminal state is reapplied
+func (vx *Vaxis) continueJob() {
+	log.Info("[job control] continuing")
+	vx.mu.Lock()
+	suspended := vx.jobSuspended
+	vx.jobSuspended = false
+	vx.mu.Unlock()
+	if suspended {
+		if err := vx.Resume(); err != nil {
+			log.Error("[job control] couldn't resume: %v", err)
+			return
+		}
+	} else {
+		// Reset before SetRaw so the saved state remains the one from
+		// before Vaxis started
+		_ = vx.tty.Reset()
+		if err := vx.tty.SetRaw(); err != nil {
+			log.Error("[job control] couldn't enter raw mode: %v", err)
+		}
+		if vx.primaryScreen == nil {
+			vx.enterAltScreen()
+		}
+		vx.enableModes()
+		go vx.detectResize(false)
+	}
+	vx.mu.Lock()
+	vx.refresh = true
+	vx.mu.Unlock()
+	vx.PostEvent(ResumeEvent{})
+}
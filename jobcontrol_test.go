@@ -0,0 +1,73 @@
+package vaxis
+
+import (
+	"testing"
+
+	"go.rockorager.dev/vaxis/ansi"
+)
+
+func TestJobControlSuspendKey(t *testing.T) {
+	vx := &Vaxis{
+		queue:        make(chan Event, 8),
+		chJobSuspend: make(chan struct{}, 1),
+		jobControl:   newJobControl(JobControlOptions{}),
+	}
+
+	// Ctrl+Z as sent by a legacy terminal
+	vx.handleSequence(ansi.C0(0x1A))
+	select {
+	case <-vx.chJobSuspend:
+	default:
+		t.Fatal("Ctrl+Z did not request a suspend")
+	}
+	if len(vx.queue) != 0 {
+		t.Fatalf("suspend key was delivered to the application: %v", <-vx.queue)
+	}
+
+	tests := []struct {
+		name string
+		key  Key
+	}{
+		{name: "other key", key: Key{Keycode: 'z'}},
+		{name: "release", key: Key{Keycode: 'z', Modifiers: ModCtrl, EventType: EventRelease}},
+		{name: "paste", key: Key{Keycode: 'z', Modifiers: ModCtrl, EventType: EventPaste}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vx.postKey(test.key)
+			if len(vx.chJobSuspend) != 0 {
+				t.Fatal("key requested a suspend")
+			}
+			if got := <-vx.queue; got != test.key {
+				t.Fatalf("got %v, want %v", got, test.key)
+			}
+		})
+	}
+}
+
+func TestJobControlCustomKey(t *testing.T) {
+	vx := &Vaxis{
+		queue:        make(chan Event, 8),
+		chJobSuspend: make(chan struct{}, 1),
+		jobControl:   newJobControl(JobControlOptions{SuspendKey: 's', SuspendModifiers: ModAlt}),
+	}
+	vx.postKey(Key{Keycode: 'z', Modifiers: ModCtrl})
+	if len(vx.chJobSuspend) != 0 || len(vx.queue) != 1 {
+		t.Fatal("Ctrl+Z suspended with a custom suspend key")
+	}
+	vx.postKey(Key{Keycode: 's', Modifiers: ModAlt})
+	if len(vx.chJobSuspend) != 1 {
+		t.Fatal("custom suspend key did not request a suspend")
+	}
+}
+
+func TestJobControlDisabled(t *testing.T) {
+	vx := &Vaxis{
+		queue:        make(chan Event, 8),
+		chJobSuspend: make(chan struct{}, 1),
+	}
+	vx.postKey(Key{Keycode: 'z', Modifiers: ModCtrl})
+	if len(vx.chJobSuspend) != 0 || len(vx.queue) != 1 {
+		t.Fatal("Ctrl+Z was consumed without job control")
+	}
+}
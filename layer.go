@@ -0,0 +1,266 @@
+package vaxis
+
+import "sort"
+
+// ColorTransparent is a sentinel [Color] for cells drawn on a [Layer]. A
+// layer cell with a transparent foreground or background inherits that
+// color from whatever is beneath it when layers are composited. Outside of a
+// layer it is rendered as the default color
+const ColorTransparent Color = 1 << 26
+
+// Layer is an independent plane of cells composited above the main screen
+// when rendering. Unlike a [Window] onto the main screen, a layer retains its
+// contents between frames: it can be moved, hidden or restacked without
+// redrawing it or the content beneath it.
+//
+// Cells which have never been drawn (the zero [Cell]) are transparent and show
+// the content beneath them. Use [ColorTransparent] for cells which should
+// keep their own text but inherit the colors beneath them.
+//
+// Layers are stacked by z-order, with higher values drawn on top. Layers with
+// the same z-order are stacked in the order they were created
+type Layer struct {
+	vx      *Vaxis
+	buf     *screen
+	col     int
+	row     int
+	z       int
+	seq     uint64
+	visible bool
+}
+
+// NewLayer creates a visible layer of the given size at offset 0,0 with the
+// given z-order. The layer is fully transparent until drawn on
+func (vx *Vaxis) NewLayer(cols int, rows int, z int) *Layer {
+	if cols < 0 {
+		cols = 0
+	}
+	if rows < 0 {
+		rows = 0
+	}
+	l := &Layer{
+		vx:      vx,
+		buf:     newScreen(),
+		z:       z,
+		visible: true,
+	}
+	l.buf.resize(cols, rows)
+	vx.mu.Lock()
+	defer vx.mu.Unlock()
+	vx.layerSeq += 1
+	l.seq = vx.layerSeq
+	vx.layers = append(vx.layers, l)
+	vx.sortLayers()
+	return l
+}
+
+// Window returns a Window for drawing onto the layer. Coordinates are
+// relative to the layer, not the screen
+func (l *Layer) Window() Window {
+	cols, rows := l.buf.size()
+	return Window{
+		Vx:     l.vx,
+		Width:  cols,
+		Height: rows,
+		layer:  l,
+	}
+}
+
+// Size returns the size of the layer
+func (l *Layer) Size() (cols int, rows int) {
+	return l.buf.size()
+}
+
+// Resize changes the size of the layer. The contents are preserved where
+// they fit
+func (l *Layer) Resize(cols int, rows int) {
+	if cols < 0 {
+		cols = 0
+	}
+	if rows < 0 {
+		rows = 0
+	}
+	l.vx.mu.Lock()
+	defer l.vx.mu.Unlock()
+	old := l.buf
+	l.buf = newScreen()
+	l.buf.resize(cols, rows)
+	for row := 0; row < min(rows, old.rows); row += 1 {
+		copy(l.buf.row(row), old.row(row)[:min(cols, old.cols)])
+	}
+}
+
+// Move places the top left corner of the layer at the given screen position.
+// Layers may be placed partially or fully offscreen
+func (l *Layer) Move(col int, row int) {
+	l.vx.mu.Lock()
+	defer l.vx.mu.Unlock()
+	l.col = col
+	l.row = row
+}
+
+// Offset returns the screen position of the top left corner of the layer
+func (l *Layer) Offset() (col int, row int) {
+	l.vx.mu.Lock()
+	defer l.vx.mu.Unlock()
+	return l.col, l.row
+}
+
+// SetZ changes the z-order of the layer
+func (l *Layer) SetZ(z int) {
+	l.vx.mu.Lock()
+	defer l.vx.mu.Unlock()
+	l.z = z
+	l.vx.sortLayers()
+}
+
+// Z returns the z-order of the layer
+func (l *Layer) Z() int {
+	l.vx.mu.Lock()
+	defer l.vx.mu.Unlock()
+	return l.z
+}
+
+// SetVisible shows or hides the layer. A hidden layer keeps its contents
+func (l *Layer) SetVisible(visible bool) {
+	l.vx.mu.Lock()
+	defer l.vx.mu.Unlock()
+	l.visible = visible
+}
+
+// Visible reports whether the layer is shown
+func (l *Layer) Visible() bool {
+	l.vx.mu.Lock()
+	defer l.vx.mu.Unlock()
+	return l.visible
+}
+
+// Clear makes every cell of the layer transparent
+func (l *Layer) Clear() {
+	l.vx.mu.Lock()
+	defer l.vx.mu.Unlock()
+	for i := range l.buf.buf {
+		l.buf.buf[i] = Cell{}
+	}
+}
+
+// Remove removes the layer from the Vaxis instance. A removed layer is never
+// composited again
+func (l *Layer) Remove() {
+	l.vx.mu.Lock()
+	defer l.vx.mu.Unlock()
+	for i, other := range l.vx.layers {
+		if other == l {
+			l.vx.layers = append(l.vx.layers[:i], l.vx.layers[i+1:]...)
+			return
+		}
+	}
+}
+
+// sortLayers orders layers bottom to top. vx.mu must be held
+func (vx *Vaxis) sortLayers() {
+	sort.SliceStable(vx.layers, func(i, j int) bool {
+		if vx.layers[i].z != vx.layers[j].z {
+			return vx.layers[i].z < vx.layers[j].z
+		}
+		return vx.layers[i].seq < vx.layers[j].seq
+	})
+}
+
+// composite returns the screen to render: screenNext with every visible layer
+// drawn above it. screenNext itself is left untouched so layers can move
+// without the application redrawing what was beneath them. vx.mu must be held
+func (vx *Vaxis) composite() *screen {
+	visible := false
+	for _, l := range vx.layers {
+		if l.visible {
+			visible = true
+			break
+		}
+	}
+	if !visible {
+		return vx.screenNext
+	}
+	if vx.screenComposite == nil {
+		vx.screenComposite = newScreen()
+	}
+	dst := vx.screenComposite
+	if dst.cols != vx.screenNext.cols || dst.rows != vx.screenNext.rows {
+		dst.resize(vx.screenNext.cols, vx.screenNext.rows)
+	}
+	copy(dst.buf, vx.screenNext.buf)
+	placed := make([]bool, dst.cols)
+	for _, l := range vx.layers {
+		if !l.visible {
+			continue
+		}
+		for row := 0; row < l.buf.rows; row += 1 {
+			dstRow := row + l.row
+			if dstRow < 0 || dstRow >= dst.rows {
+				continue
+			}
+			vx.compositeRow(dst.row(dstRow), l.buf.row(row), l.col, placed)
+		}
+	}
+	return dst
+}
+
+// compositeRow draws the layer cells over cells, starting at column offset.
+// Transparent cells and colors are resolved against the cells beneath. placed
+// is scratch space the length of cells
+func (vx *Vaxis) compositeRow(cells []Cell, layer []Cell, offset int, placed []bool) {
+	for i := range placed {
+		placed[i] = false
+	}
+	for col, cell := range layer {
+		col += offset
+		if col < 0 || col >= len(cells) {
+			continue
+		}
+		if cell == (Cell{}) {
+			continue
+		}
+		below := cells[col]
+		if cell.Foreground == ColorTransparent {
+			cell.Foreground = below.Foreground
+		}
+		if cell.Background == ColorTransparent {
+			cell.Background = below.Background
+		}
+		if cell.UnderlineColor == ColorTransparent {
+			cell.UnderlineColor = below.UnderlineColor
+		}
+		cells[col] = cell
+		placed[col] = true
+	}
+	// Walk the row the same way the renderer does. A wide character from
+	// beneath which is partially covered by the layer can no longer be
+	// drawn whole, so it is replaced with a space
+	for col := 0; col < len(cells); {
+		w := vx.compositeWidth(cells[col])
+		if !placed[col] {
+			for i := col + 1; i < col+w && i < len(cells); i += 1 {
+				if placed[i] {
+					cells[col].Character = Character{" ", 1}
+					w = 1
+					break
+				}
+			}
+		}
+		col += w
+	}
+}
+
+func (vx *Vaxis) compositeWidth(cell Cell) int {
+	if cell.Grapheme == "" {
+		return 1
+	}
+	w := cell.Width
+	if w == 0 {
+		w = vx.characterWidth(cell.Grapheme)
+	}
+	if w < 1 {
+		return 1
+	}
+	return w
+}
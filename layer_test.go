@@ -0,0 +1,178 @@
+package vaxis
+
+import (
+	"strings"
+	"testing"
+)
+
+func compositeLine(vx *Vaxis, row int) string {
+	var b strings.Builder
+	for _, cell := range vx.composite().row(row) {
+		if cell.Grapheme == "" {
+			b.WriteString(".")
+			continue
+		}
+		b.WriteString(cell.Grapheme)
+	}
+	return b.String()
+}
+
+func TestLayerCompositesAboveScreen(t *testing.T) {
+	base := newWindowTestWindow(6, 2)
+	vx := base.Vx
+	base.Print(Segment{Text: "abcdef"})
+
+	l := vx.NewLayer(2, 1, 0)
+	l.Window().Print(Segment{Text: "XY"})
+	l.Move(2, 0)
+
+	if got, want := compositeLine(vx, 0), "abXYef"; got != want {
+		t.Fatalf("row 0 = %q, want %q", got, want)
+	}
+	// The main screen is untouched, so moving the layer reveals it again
+	l.Move(3, 0)
+	if got, want := compositeLine(vx, 0), "abcXYf"; got != want {
+		t.Fatalf("row 0 after move = %q, want %q", got, want)
+	}
+	if got, want := vx.screenNext.cell(3, 0).Grapheme, "d"; got != want {
+		t.Fatalf("screenNext cell = %q, want %q", got, want)
+	}
+}
+
+func TestLayerVisibilityAndRemove(t *testing.T) {
+	base := newWindowTestWindow(3, 1)
+	vx := base.Vx
+	base.Print(Segment{Text: "abc"})
+	l := vx.NewLayer(1, 1, 0)
+	l.Window().Print(Segment{Text: "X"})
+
+	l.SetVisible(false)
+	if got, want := compositeLine(vx, 0), "abc"; got != want {
+		t.Fatalf("hidden row = %q, want %q", got, want)
+	}
+	l.SetVisible(true)
+	if got, want := compositeLine(vx, 0), "Xbc"; got != want {
+		t.Fatalf("visible row = %q, want %q", got, want)
+	}
+	l.Remove()
+	if got, want := compositeLine(vx, 0), "abc"; got != want {
+		t.Fatalf("removed row = %q, want %q", got, want)
+	}
+}
+
+func TestLayerZOrder(t *testing.T) {
+	base := newWindowTestWindow(3, 1)
+	vx := base.Vx
+	top := vx.NewLayer(3, 1, 10)
+	top.Window().Print(Segment{Text: "T"})
+	bottom := vx.NewLayer(3, 1, 1)
+	bottom.Window().Print(Segment{Text: "BBB"})
+
+	if got, want := compositeLine(vx, 0), "TBB"; got != want {
+		t.Fatalf("row = %q, want %q", got, want)
+	}
+	bottom.SetZ(20)
+	if got, want := compositeLine(vx, 0), "BBB"; got != want {
+		t.Fatalf("row after restack = %q, want %q", got, want)
+	}
+
+	// Equal z-orders stack in creation order
+	same := vx.NewLayer(1, 1, 20)
+	same.Window().Print(Segment{Text: "S"})
+	if got, want := compositeLine(vx, 0), "SBB"; got != want {
+		t.Fatalf("row with equal z = %q, want %q", got, want)
+	}
+}
+
+func TestLayerTransparency(t *testing.T) {
+	base := newWindowTestWindow(4, 1)
+	vx := base.Vx
+	bg := IndexColor(4)
+	base.Fill(Cell{Character: Character{"-", 1}, Style: Style{Background: bg}})
+
+	l := vx.NewLayer(4, 1, 0)
+	win := l.Window()
+	win.SetCell(0, 0, Cell{Character: Character{"a", 1}, Style: Style{Background: ColorTransparent}})
+	win.SetCell(2, 0, Cell{Character: Character{"b", 1}, Style: Style{Background: IndexColor(1)}})
+
+	screen := vx.composite()
+	if got, want := compositeLine(vx, 0), "a-b-"; got != want {
+		t.Fatalf("row = %q, want %q", got, want)
+	}
+	if got := screen.cell(0, 0).Background; got != bg {
+		t.Fatalf("transparent background = %v, want %v", got, bg)
+	}
+	if got := screen.cell(2, 0).Background; got != IndexColor(1) {
+		t.Fatalf("opaque background = %v, want %v", got, IndexColor(1))
+	}
+	if got := screen.cell(1, 0).Background; got != bg {
+		t.Fatalf("blank cell background = %v, want %v", got, bg)
+	}
+
+	l.Clear()
+	if got, want := compositeLine(vx, 0), "----"; got != want {
+		t.Fatalf("cleared row = %q, want %q", got, want)
+	}
+}
+
+func TestLayerCoversHalfOfWideCharacter(t *testing.T) {
+	base := newWindowTestWindow(4, 1)
+	vx := base.Vx
+	base.SetCell(0, 0, Cell{Character: Character{"🔥", 2}})
+	base.SetCell(2, 0, Cell{Character: Character{"c", 1}})
+
+	l := vx.NewLayer(1, 1, 0)
+	l.Window().SetCell(0, 0, Cell{Character: Character{"X", 1}})
+	l.Move(1, 0)
+
+	if got, want := compositeLine(vx, 0), " Xc."; got != want {
+		t.Fatalf("row = %q, want %q", got, want)
+	}
+}
+
+func TestLayerWindowClipsAndResizes(t *testing.T) {
+	base := newWindowTestWindow(4, 2)
+	vx := base.Vx
+	l := vx.NewLayer(2, 1, 0)
+	win := l.Window()
+	win.Print(Segment{Text: "ab"})
+	win.SetCell(5, 0, Cell{Character: Character{"z", 1}})
+	child := win.New(1, 0, 1, 1)
+	child.SetCell(0, 0, Cell{Character: Character{"c", 1}})
+
+	if got, want := compositeLine(vx, 0), "ac.."; got != want {
+		t.Fatalf("row = %q, want %q", got, want)
+	}
+
+	l.Resize(3, 2)
+	l.Window().SetCell(2, 1, Cell{Character: Character{"d", 1}})
+	l.Move(1, 0)
+	if got, want := compositeLine(vx, 0), ".ac."; got != want {
+		t.Fatalf("resized row 0 = %q, want %q", got, want)
+	}
+	if got, want := compositeLine(vx, 1), "...d"; got != want {
+		t.Fatalf("resized row 1 = %q, want %q", got, want)
+	}
+
+	// Partially offscreen layers are clipped
+	l.Move(-1, 1)
+	if got, want := compositeLine(vx, 1), "c..."; got != want {
+		t.Fatalf("offscreen row = %q, want %q", got, want)
+	}
+}
+
+func TestLayerWindowCursorAndOrigin(t *testing.T) {
+	base := newWindowTestWindow(10, 5)
+	vx := base.Vx
+	l := vx.NewLayer(4, 2, 0)
+	l.Move(3, 2)
+	child := l.Window().New(1, 0, 2, 2)
+
+	child.ShowCursor(1, 1, CursorDefault)
+	if vx.cursorNext.col != 5 || vx.cursorNext.row != 3 {
+		t.Fatalf("cursor = %d,%d, want 5,3", vx.cursorNext.col, vx.cursorNext.row)
+	}
+	if col, row := child.Origin(); col != 4 || row != 2 {
+		t.Fatalf("origin = %d,%d, want 4,2", col, row)
+	}
+}
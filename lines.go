@@ -0,0 +1,339 @@
+package vaxis
+
+import "sort"
+
+// LineWeight is the stroke used to draw a line with [Lines]
+type LineWeight uint8
+
+const (
+	LineNone LineWeight = iota
+	LineLight
+	LineHeavy
+	LineDouble
+	// LineRounded is a light line whose corners are drawn as arcs
+	LineRounded
+)
+
+// Lines records line strokes across cells and resolves them to box drawing
+// characters, choosing the junction (┼, ├, ╤, ...) where strokes meet. Draw
+// panes, splitters and boxes into one Lines and they join instead of
+// overlapping.
+//
+// Each cell has four arms, from its center to each edge. A line joins the
+// centers of the cells it covers, so its end cells only get the arm pointing
+// inwards. Ends which meet nothing are drawn as full cells. Where strokes
+// overlap, the later one wins. The zero value is ready to use
+type Lines struct {
+	cells map[linePoint]*lineCell
+}
+
+type linePoint struct {
+	col int
+	row int
+}
+
+// lineCell holds the weight of the up, right, down and left arms of a cell
+type lineCell struct {
+	arms  [4]LineWeight
+	style Style
+}
+
+const (
+	armUp = iota
+	armRight
+	armDown
+	armLeft
+)
+
+// Horizontal draws a line from col to col+length-1 along row
+func (l *Lines) Horizontal(col int, row int, length int, weight LineWeight, style Style) {
+	l.line(col, row, length, 1, 0, armRight, armLeft, weight, style)
+}
+
+// Vertical draws a line from row to row+length-1 down col
+func (l *Lines) Vertical(col int, row int, length int, weight LineWeight, style Style) {
+	l.line(col, row, length, 0, 1, armDown, armUp, weight, style)
+}
+
+// Box draws the outline of a rectangle. The outline is drawn on the outermost
+// cells of the rectangle
+func (l *Lines) Box(col int, row int, width int, height int, weight LineWeight, style Style) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	l.Horizontal(col, row, width, weight, style)
+	l.Horizontal(col, row+height-1, width, weight, style)
+	l.Vertical(col, row, height, weight, style)
+	l.Vertical(col+width-1, row, height, weight, style)
+}
+
+// Join adds the arms of the box drawing character glyph to the cell at col,row,
+// so strokes ending there join a line drawn by something else, such as the
+// border beneath. The cell takes style. Join reports whether glyph is a box
+// drawing character
+func (l *Lines) Join(col int, row int, glyph string, style Style) bool {
+	arms, ok := lineArms[glyph]
+	if !ok {
+		return false
+	}
+	c := l.cell(linePoint{col: col, row: row})
+	for i, w := range arms {
+		if w != LineNone {
+			c.arms[i] = w
+		}
+	}
+	c.style = style
+	return true
+}
+
+// Reset removes every stroke
+func (l *Lines) Reset() {
+	l.cells = nil
+}
+
+// Each calls fn with the resolved cell for every cell a stroke crosses, in row
+// then column order
+func (l *Lines) Each(fn func(col int, row int, cell Cell)) {
+	points := make([]linePoint, 0, len(l.cells))
+	for pt := range l.cells {
+		points = append(points, pt)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].row != points[j].row {
+			return points[i].row < points[j].row
+		}
+		return points[i].col < points[j].col
+	})
+	for _, pt := range points {
+		c := l.cells[pt]
+		fn(pt.col, pt.row, Cell{
+			Character: Character{Grapheme: string(c.glyph()), Width: 1},
+			Style:     c.style,
+		})
+	}
+}
+
+// Draw writes the resolved lines to win, relative to its origin
+func (l *Lines) Draw(win Window) {
+	l.Each(func(col int, row int, cell Cell) {
+		win.SetCell(col, row, cell)
+	})
+}
+
+func (l *Lines) line(col, row, length, dx, dy, forward, back int, weight LineWeight, style Style) {
+	if length <= 0 {
+		return
+	}
+	for i := 0; i < length; i += 1 {
+		c := l.cell(linePoint{col: col + i*dx, row: row + i*dy})
+		if i > 0 || length == 1 {
+			c.arms[back] = weight
+		}
+		if i < length-1 || length == 1 {
+			c.arms[forward] = weight
+		}
+		c.style = style
+	}
+}
+
+func (l *Lines) cell(pt linePoint) *lineCell {
+	if l.cells == nil {
+		l.cells = make(map[linePoint]*lineCell)
+	}
+	c, ok := l.cells[pt]
+	if !ok {
+		c = &lineCell{}
+		l.cells[pt] = c
+	}
+	return c
+}
+
+func (c *lineCell) glyph() rune {
+	arms := c.arms
+	rounded := true
+	count := 0
+	for i, w := range arms {
+		if w == LineNone {
+			continue
+		}
+		count += 1
+		if w != LineRounded {
+			rounded = false
+		}
+		if w == LineRounded {
+			arms[i] = LineLight
+		}
+	}
+	if rounded && count == 2 {
+		switch {
+		case arms[armDown] != LineNone && arms[armRight] != LineNone:
+			return '╭'
+		case arms[armDown] != LineNone && arms[armLeft] != LineNone:
+			return '╮'
+		case arms[armUp] != LineNone && arms[armLeft] != LineNone:
+			return '╯'
+		case arms[armUp] != LineNone && arms[armRight] != LineNone:
+			return '╰'
+		}
+	}
+	// A lone arm is an unjoined line end, which is drawn through the cell
+	if count == 1 {
+		for i, w := range arms {
+			if w != LineNone {
+				arms[(i+2)%4] = w
+			}
+		}
+	}
+	if r, ok := lineGlyphs[packArms(arms)]; ok {
+		return r
+	}
+	// Not every mix of weights has a glyph. Fall back to light for heavy
+	// arms, then for double arms
+	for _, from := range []LineWeight{LineHeavy, LineDouble} {
+		for i, w := range arms {
+			if w == from {
+				arms[i] = LineLight
+			}
+		}
+		if r, ok := lineGlyphs[packArms(arms)]; ok {
+			return r
+		}
+	}
+	return ' '
+}
+
+func packArms(arms [4]LineWeight) uint8 {
+	return uint8(arms[armUp]) | uint8(arms[armRight])<<2 | uint8(arms[armDown])<<4 | uint8(arms[armLeft])<<6
+}
+
+// lineArms maps box drawing characters back to the weights of their arms
+var lineArms = func() map[string][4]LineWeight {
+	arms := make(map[string][4]LineWeight, len(lineGlyphs)+4)
+	for packed, r := range lineGlyphs {
+		arms[string(r)] = [4]LineWeight{
+			LineWeight(packed & 3),
+			LineWeight(packed >> 2 & 3),
+			LineWeight(packed >> 4 & 3),
+			LineWeight(packed >> 6 & 3),
+		}
+	}
+	arms["╭"] = [4]LineWeight{armDown: LineRounded, armRight: LineRounded}
+	arms["╮"] = [4]LineWeight{armDown: LineRounded, armLeft: LineRounded}
+	arms["╯"] = [4]LineWeight{armUp: LineRounded, armLeft: LineRounded}
+	arms["╰"] = [4]LineWeight{armUp: LineRounded, armRight: LineRounded}
+	return arms
+}()
+
+// lineGlyphs maps the packed weights of the up, right, down and left arms of a
+// cell to its box drawing character
+var lineGlyphs = map[uint8]rune{
+	0x01: '╵', // light up
+	0x02: '╹', // heavy up
+	0x04: '╶', // light right
+	0x05: '└', // light up and right
+	0x06: '┖', // up heavy and right light
+	0x07: '╙', // up double and right single
+	0x08: '╺', // heavy right
+	0x09: '┕', // up light and right heavy
+	0x0a: '┗', // heavy up and right
+	0x0d: '╘', // up single and right double
+	0x0f: '╚', // double up and right
+	0x10: '╷', // light down
+	0x11: '│', // light vertical
+	0x12: '╿', // heavy up and light down
+	0x14: '┌', // light down and right
+	0x15: '├', // light vertical and right
+	0x16: '┞', // up heavy and right down light
+	0x18: '┍', // down light and right heavy
+	0x19: '┝', // vertical light and right heavy
+	0x1a: '┡', // down light and right up heavy
+	0x1c: '╒', // down single and right double
+	0x1d: '╞', // vertical single and right double
+	0x20: '╻', // heavy down
+	0x21: '╽', // light up and heavy down
+	0x22: '┃', // heavy vertical
+	0x24: '┎', // down heavy and right light
+	0x25: '┟', // down heavy and right up light
+	0x26: '┠', // vertical heavy and right light
+	0x28: '┏', // heavy down and right
+	0x29: '┢', // up light and right down heavy
+	0x2a: '┣', // heavy vertical and right
+	0x33: '║', // double vertical
+	0x34: '╓', // down double and right single
+	0x37: '╟', // vertical double and right single
+	0x3c: '╔', // double down and right
+	0x3f: '╠', // double vertical and right
+	0x40: '╴', // light left
+	0x41: '┘', // light up and left
+	0x42: '┚', // up heavy and left light
+	0x43: '╜', // up double and left single
+	0x44: '─', // light horizontal
+	0x45: '┴', // light up and horizontal
+	0x46: '┸', // up heavy and horizontal light
+	0x47: '╨', // up double and horizontal single
+	0x48: '╼', // light left and heavy right
+	0x49: '┶', // right heavy and left up light
+	0x4a: '┺', // left light and right up heavy
+	0x50: '┐', // light down and left
+	0x51: '┤', // light vertical and left
+	0x52: '┦', // up heavy and left down light
+	0x54: '┬', // light down and horizontal
+	0x55: '┼', // light vertical and horizontal
+	0x56: '╀', // up heavy and down horizontal light
+	0x58: '┮', // right heavy and left down light
+	0x59: '┾', // right heavy and left vertical light
+	0x5a: '╄', // right up heavy and left down light
+	0x60: '┒', // down heavy and left light
+	0x61: '┧', // down heavy and left up light
+	0x62: '┨', // vertical heavy and left light
+	0x64: '┰', // down heavy and horizontal light
+	0x65: '╁', // down heavy and up horizontal light
+	0x66: '╂', // vertical heavy and horizontal light
+	0x68: '┲', // left light and right down heavy
+	0x69: '╆', // right down heavy and left up light
+	0x6a: '╊', // left light and right vertical heavy
+	0x70: '╖', // down double and left single
+	0x73: '╢', // vertical double and left single
+	0x74: '╥', // down double and horizontal single
+	0x77: '╫', // vertical double and horizontal single
+	0x80: '╸', // heavy left
+	0x81: '┙', // up light and left heavy
+	0x82: '┛', // heavy up and left
+	0x84: '╾', // heavy left and light right
+	0x85: '┵', // left heavy and right up light
+	0x86: '┹', // right light and left up heavy
+	0x88: '━', // heavy horizontal
+	0x89: '┷', // up light and horizontal heavy
+	0x8a: '┻', // heavy up and horizontal
+	0x90: '┑', // down light and left heavy
+	0x91: '┥', // vertical light and left heavy
+	0x92: '┩', // down light and left up heavy
+	0x94: '┭', // left heavy and right down light
+	0x95: '┽', // left heavy and right vertical light
+	0x96: '╃', // left up heavy and right down light
+	0x98: '┯', // down light and horizontal heavy
+	0x99: '┿', // vertical light and horizontal heavy
+	0x9a: '╇', // down light and up horizontal heavy
+	0xa0: '┓', // heavy down and left
+	0xa1: '┪', // up light and left down heavy
+	0xa2: '┫', // heavy vertical and left
+	0xa4: '┱', // right light and left down heavy
+	0xa5: '╅', // left down heavy and right up light
+	0xa6: '╉', // right light and left vertical heavy
+	0xa8: '┳', // heavy down and horizontal
+	0xa9: '╈', // up light and down horizontal heavy
+	0xaa: '╋', // heavy vertical and horizontal
+	0xc1: '╛', // up single and left double
+	0xc3: '╝', // double up and left
+	0xcc: '═', // double horizontal
+	0xcd: '╧', // up single and horizontal double
+	0xcf: '╩', // double up and horizontal
+	0xd0: '╕', // down single and left double
+	0xd1: '╡', // vertical single and left double
+	0xdc: '╤', // down single and horizontal double
+	0xdd: '╪', // vertical single and horizontal double
+	0xf0: '╗', // double down and left
+	0xf3: '╣', // double vertical and left
+	0xfc: '╦', // double down and horizontal
+	0xff: '╬', // double vertical and horizontal
+}
@@ -0,0 +1,130 @@
+package vaxis_test
+
+import (
+	"strings"
+	"testing"
+
+	"go.rockorager.dev/vaxis"
+)
+
+// drawLines renders lines to a grid of width by height cells
+func drawLines(lines *vaxis.Lines, width int, height int) string {
+	grid := make([][]string, height)
+	for row := range grid {
+		grid[row] = strings.Split(strings.Repeat(" ", width), "")
+	}
+	lines.Each(func(col int, row int, cell vaxis.Cell) {
+		grid[row][col] = cell.Grapheme
+	})
+	rows := make([]string, height)
+	for i, row := range grid {
+		rows[i] = strings.Join(row, "")
+	}
+	return strings.Join(rows, "\n")
+}
+
+func TestLinesJoinSplitPanes(t *testing.T) {
+	var lines vaxis.Lines
+	lines.Box(0, 0, 7, 5, vaxis.LineLight, vaxis.Style{})
+	lines.Vertical(3, 0, 5, vaxis.LineLight, vaxis.Style{})
+	lines.Horizontal(3, 2, 4, vaxis.LineLight, vaxis.Style{})
+	want := strings.Join([]string{
+		"┌──┬──┐",
+		"│  │  │",
+		"│  ├──┤",
+		"│  │  │",
+		"└──┴──┘",
+	}, "\n")
+	if got := drawLines(&lines, 7, 5); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLinesMixedWeights(t *testing.T) {
+	var lines vaxis.Lines
+	lines.Box(0, 0, 5, 3, vaxis.LineDouble, vaxis.Style{})
+	lines.Vertical(2, 0, 3, vaxis.LineLight, vaxis.Style{})
+	want := strings.Join([]string{
+		"╔═╤═╗",
+		"║ │ ║",
+		"╚═╧═╝",
+	}, "\n")
+	if got := drawLines(&lines, 5, 3); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+
+	lines.Reset()
+	lines.Box(0, 0, 3, 3, vaxis.LineLight, vaxis.Style{})
+	lines.Horizontal(0, 1, 3, vaxis.LineHeavy, vaxis.Style{})
+	want = strings.Join([]string{
+		"┌─┐",
+		"┝━┥",
+		"└─┘",
+	}, "\n")
+	if got := drawLines(&lines, 3, 3); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Double and heavy have no shared junctions, so heavy falls back to light
+	lines.Reset()
+	lines.Horizontal(0, 0, 3, vaxis.LineDouble, vaxis.Style{})
+	lines.Vertical(1, 0, 2, vaxis.LineHeavy, vaxis.Style{})
+	if got := drawLines(&lines, 3, 2); got != "═╤═\n ┃ " {
+		t.Fatalf("got\n%s", got)
+	}
+}
+
+func TestLinesRoundedAndLoneEnds(t *testing.T) {
+	var lines vaxis.Lines
+	lines.Box(0, 0, 4, 3, vaxis.LineRounded, vaxis.Style{})
+	lines.Horizontal(5, 1, 3, vaxis.LineHeavy, vaxis.Style{})
+	lines.Vertical(9, 0, 1, vaxis.LineDouble, vaxis.Style{})
+	want := strings.Join([]string{
+		"╭──╮     ║",
+		"│  │ ━━━  ",
+		"╰──╯      ",
+	}, "\n")
+	if got := drawLines(&lines, 10, 3); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Rounded corners joined by another line become junctions
+	lines.Reset()
+	lines.Box(0, 0, 3, 2, vaxis.LineRounded, vaxis.Style{})
+	lines.Horizontal(2, 0, 2, vaxis.LineRounded, vaxis.Style{})
+	if got := drawLines(&lines, 4, 2); got != "╭─┬─\n╰─╯ " {
+		t.Fatalf("got\n%s", got)
+	}
+}
+
+func TestLinesLaterStrokeWins(t *testing.T) {
+	var lines vaxis.Lines
+	style := vaxis.Style{Foreground: vaxis.IndexColor(1)}
+	lines.Horizontal(0, 0, 3, vaxis.LineLight, vaxis.Style{})
+	lines.Horizontal(0, 0, 3, vaxis.LineHeavy, style)
+	lines.Each(func(col int, row int, cell vaxis.Cell) {
+		if cell.Grapheme != "━" || cell.Style != style {
+			t.Fatalf("cell %d = %q %v", col, cell.Grapheme, cell.Style)
+		}
+	})
+}
+
+func TestLinesJoinDrawnGlyphs(t *testing.T) {
+	var lines vaxis.Lines
+	lines.Vertical(1, 0, 3, vaxis.LineLight, vaxis.Style{})
+	lines.Horizontal(0, 1, 2, vaxis.LineLight, vaxis.Style{})
+	if !lines.Join(1, 0, "─", vaxis.Style{}) || !lines.Join(1, 2, "═", vaxis.Style{}) || !lines.Join(0, 1, "╮", vaxis.Style{}) {
+		t.Fatal("box drawing character not joined")
+	}
+	if lines.Join(2, 1, "x", vaxis.Style{}) {
+		t.Fatal("joined a letter")
+	}
+	want := strings.Join([]string{
+		" ┬",
+		"┬┤",
+		" ╧",
+	}, "\n")
+	if got := drawLines(&lines, 2, 3); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
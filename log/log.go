@@ -1,9 +1,17 @@
+// Package log is the leveled logger used internally by Vaxis. Output is
+// discarded by default. Messages can be written to an [io.Writer] with
+// SetOutput, to a [log/slog.Handler] with SetSlogHandler and to an in-memory
+// [RingBuffer] with SetRingBuffer.
 package log
 
 import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,24 +24,99 @@ const (
 
 	calldepth = 3
 	flags     = log.Lshortfile
+
+	// levelUnset marks a subsystem which uses the global level
+	levelUnset = -1
 )
 
+// Subsystem is an area of Vaxis which can be given its own level with
+// [SetSubsystemLevel]. Messages can be logged to a subsystem using its
+// methods, for example:
+//
+//	log.Render.Trace("rendered %d bytes", n)
+//
+// The package level functions log to [General]
+type Subsystem int
+
+const (
+	General Subsystem = iota
+	Parser            // Input parsing and terminal replies
+	Render            // Rendering to the terminal
+	Images            // Image encoding and placement
+	Term              // The terminal widget
+
+	numSubsystems
+)
+
+func (s Subsystem) String() string {
+	switch s {
+	case General:
+		return "general"
+	case Parser:
+		return "parser"
+	case Render:
+		return "render"
+	case Images:
+		return "images"
+	case Term:
+		return "term"
+	default:
+		return fmt.Sprintf("subsystem(%d)", int(s))
+	}
+}
+
 var (
-	level       = LevelError
+	level       atomic.Int32
+	levels      [numSubsystems]atomic.Int32
 	traceLogger = log.New(io.Discard, "TRACE ", flags)
 	debugLogger = log.New(io.Discard, "DEBUG ", flags)
 	infoLogger  = log.New(io.Discard, "INFO  ", flags)
 	warnLogger  = log.New(io.Discard, "WARN  ", flags)
 	errorLogger = log.New(io.Discard, "ERROR ", flags)
+
+	// mu guards the sinks other than the standard loggers
+	mu      sync.RWMutex
+	handler slog.Handler
+	ring    *RingBuffer
 )
 
+func init() {
+	level.Store(int32(LevelError))
+	for i := range levels {
+		levels[i].Store(levelUnset)
+	}
+}
+
 // LevelError = 0
 // LevelWarn = 1
 // LevelInfo  = 2
 // LevelDebug  = 3
 // LevelTrace = 4
 func SetLevel(l int) {
-	level = l
+	level.Store(int32(l))
+}
+
+// SetSubsystemLevel sets the level of a single subsystem, overriding the level
+// set with SetLevel. A negative level makes the subsystem use the global
+// level again
+func SetSubsystemLevel(s Subsystem, l int) {
+	if s < 0 || s >= numSubsystems {
+		return
+	}
+	if l < 0 {
+		l = levelUnset
+	}
+	levels[s].Store(int32(l))
+}
+
+// Enabled reports whether messages of level l are logged for the subsystem
+func (s Subsystem) Enabled(l int) bool {
+	if s >= 0 && s < numSubsystems {
+		if sl := levels[s].Load(); sl != levelUnset {
+			return int32(l) <= sl
+		}
+	}
+	return int32(l) <= level.Load()
 }
 
 func SetOutput(w io.Writer) {
@@ -44,53 +127,119 @@ func SetOutput(w io.Writer) {
 	errorLogger.SetOutput(w)
 }
 
-func now() string {
-	return time.Now().Format("15:04:05.000")
+// SetRingBuffer additionally sends every logged message to rb. Passing nil
+// removes the ring buffer
+func SetRingBuffer(rb *RingBuffer) {
+	mu.Lock()
+	defer mu.Unlock()
+	ring = rb
+}
+
+func timestamp(t time.Time) string {
+	return t.Format("15:04:05.000")
 }
 
 func fmtMessage(message string, args ...any) string {
 	if len(args) > 0 {
 		message = fmt.Sprintf(message, args...)
 	}
-	return fmt.Sprintf("%s %s", now(), message)
+	return message
 }
 
 func Trace(format string, args ...any) {
-	if level < LevelTrace {
-		return
-	}
-	message := fmtMessage(format, args...)
-	_ = traceLogger.Output(calldepth, message)
+	output(General, LevelTrace, format, args...)
 }
 
 func Debug(format string, args ...any) {
-	if level < LevelDebug {
-		return
-	}
-	message := fmtMessage(format, args...)
-	_ = debugLogger.Output(calldepth, message)
+	output(General, LevelDebug, format, args...)
 }
 
 func Info(format string, args ...any) {
-	if level < LevelInfo {
-		return
-	}
-	message := fmtMessage(format, args...)
-	_ = infoLogger.Output(calldepth, message)
+	output(General, LevelInfo, format, args...)
 }
 
 func Warn(format string, args ...any) {
-	if level < LevelWarn {
-		return
-	}
-	message := fmtMessage(format, args...)
-	_ = warnLogger.Output(calldepth, message)
+	output(General, LevelWarn, format, args...)
 }
 
 func Error(format string, args ...any) {
-	if level < LevelError {
+	output(General, LevelError, format, args...)
+}
+
+// Trace logs a message to the subsystem at LevelTrace
+func (s Subsystem) Trace(format string, args ...any) {
+	output(s, LevelTrace, format, args...)
+}
+
+// Debug logs a message to the subsystem at LevelDebug
+func (s Subsystem) Debug(format string, args ...any) {
+	output(s, LevelDebug, format, args...)
+}
+
+// Info logs a message to the subsystem at LevelInfo
+func (s Subsystem) Info(format string, args ...any) {
+	output(s, LevelInfo, format, args...)
+}
+
+// Warn logs a message to the subsystem at LevelWarn
+func (s Subsystem) Warn(format string, args ...any) {
+	output(s, LevelWarn, format, args...)
+}
+
+// Error logs a message to the subsystem at LevelError
+func (s Subsystem) Error(format string, args ...any) {
+	output(s, LevelError, format, args...)
+}
+
+// output sends a message to every sink. It must be called directly by the
+// exported logging functions so the caller can be found at calldepth
+func output(s Subsystem, l int, format string, args ...any) {
+	if !s.Enabled(l) {
 		return
 	}
+	t := time.Now()
 	message := fmtMessage(format, args...)
-	_ = errorLogger.Output(calldepth, message)
+
+	mu.RLock()
+	h := handler
+	rb := ring
+	mu.RUnlock()
+	var pc uintptr
+	if h != nil || rb != nil {
+		var pcs [1]uintptr
+		// Skip runtime.Callers, output and the exported function
+		runtime.Callers(calldepth, pcs[:])
+		pc = pcs[0]
+	}
+	if rb != nil {
+		rb.add(Entry{
+			Time:      t,
+			Level:     l,
+			Subsystem: s,
+			Message:   message,
+			PC:        pc,
+		})
+	}
+	if h != nil {
+		handleSlog(h, t, s, l, message, pc)
+	}
+
+	text := timestamp(t) + " " + message
+	if s != General {
+		text = timestamp(t) + " [" + s.String() + "] " + message
+	}
+	var logger *log.Logger
+	switch l {
+	case LevelTrace:
+		logger = traceLogger
+	case LevelDebug:
+		logger = debugLogger
+	case LevelInfo:
+		logger = infoLogger
+	case LevelWarn:
+		logger = warnLogger
+	default:
+		logger = errorLogger
+	}
+	_ = logger.Output(calldepth, text)
 }
@@ -0,0 +1,167 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// reset restores the global state after a test
+func reset(t *testing.T) {
+	t.Cleanup(func() {
+		SetLevel(LevelError)
+		for s := General; s < numSubsystems; s += 1 {
+			SetSubsystemLevel(s, -1)
+		}
+		SetSlogHandler(nil)
+		SetRingBuffer(nil)
+		SetOutput(&bytes.Buffer{})
+	})
+}
+
+func TestSubsystemLevels(t *testing.T) {
+	reset(t)
+	buf := &bytes.Buffer{}
+	SetOutput(buf)
+	SetLevel(LevelWarn)
+	SetSubsystemLevel(Parser, LevelTrace)
+	SetSubsystemLevel(Render, LevelError)
+
+	Info("general info")
+	Warn("general warn")
+	Parser.Trace("parser trace")
+	Render.Warn("render warn")
+	Render.Error("render error")
+	Images.Warn("images warn")
+
+	got := buf.String()
+	for _, want := range []string{"general warn", "[parser] parser trace", "[render] render error", "[images] images warn"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("output %q is missing %q", got, want)
+		}
+	}
+	for _, unwanted := range []string{"general info", "render warn"} {
+		if strings.Contains(got, unwanted) {
+			t.Fatalf("output %q contains %q", got, unwanted)
+		}
+	}
+	if !strings.Contains(got, "log_test.go") {
+		t.Fatalf("output %q does not reference the calling file", got)
+	}
+
+	SetSubsystemLevel(Render, -1)
+	if !Render.Enabled(LevelWarn) || Render.Enabled(LevelInfo) {
+		t.Fatal("unset subsystem level did not fall back to the global level")
+	}
+}
+
+func TestRingBuffer(t *testing.T) {
+	reset(t)
+	rb := NewRingBuffer(2)
+	SetRingBuffer(rb)
+	SetLevel(LevelInfo)
+
+	Info("one")
+	Term.Warn("two %d", 2)
+	select {
+	case <-rb.Notify():
+	default:
+		t.Fatal("ring buffer did not notify")
+	}
+	Debug("dropped by level")
+	Error("three")
+
+	entries := rb.Entries()
+	if len(entries) != 2 || rb.Len() != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].Message != "two 2" || entries[0].Subsystem != Term || entries[0].Level != LevelWarn {
+		t.Fatalf("oldest entry = %+v", entries[0])
+	}
+	if entries[1].Message != "three" {
+		t.Fatalf("newest entry = %+v", entries[1])
+	}
+	if got := entries[0].String(); !strings.HasSuffix(got, " WARN  [term] two 2") {
+		t.Fatalf("String() = %q", got)
+	}
+	if file, _ := entries[1].Source(); !strings.HasSuffix(file, "log_test.go") {
+		t.Fatalf("Source() file = %q", file)
+	}
+
+	rb.Clear()
+	if rb.Len() != 0 || len(rb.Entries()) != 0 {
+		t.Fatal("Clear did not discard entries")
+	}
+}
+
+type recordHandler struct {
+	mu      sync.Mutex
+	level   slog.Level
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(_ context.Context, l slog.Level) bool { return l >= h.level }
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler           { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler                { return h }
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func TestSlogHandler(t *testing.T) {
+	reset(t)
+	h := &recordHandler{level: slog.LevelDebug}
+	SetSlogHandler(h)
+	SetLevel(LevelTrace)
+
+	Parser.Debug("sequence %q", "x")
+	Trace("filtered by the handler")
+	Error("failed")
+
+	if len(h.records) != 2 {
+		t.Fatalf("got %d records, want 2", len(h.records))
+	}
+	r := h.records[0]
+	if r.Message != `sequence "x"` || r.Level != slog.LevelDebug {
+		t.Fatalf("record = %q at %v", r.Message, r.Level)
+	}
+	var subsystem string
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "subsystem" {
+			subsystem = a.Value.String()
+		}
+		return true
+	})
+	if subsystem != "parser" {
+		t.Fatalf("subsystem attribute = %q, want parser", subsystem)
+	}
+	if r.PC == 0 {
+		t.Fatal("record has no source")
+	}
+	if h.records[1].Level != slog.LevelError || h.records[1].NumAttrs() != 0 {
+		t.Fatalf("general record = %+v", h.records[1])
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	tests := []struct {
+		level int
+		want  slog.Level
+	}{
+		{LevelError, slog.LevelError},
+		{LevelWarn, slog.LevelWarn},
+		{LevelInfo, slog.LevelInfo},
+		{LevelDebug, slog.LevelDebug},
+		{LevelTrace, SlogLevelTrace},
+	}
+	for _, test := range tests {
+		if got := SlogLevel(test.level); got != test.want {
+			t.Fatalf("SlogLevel(%d) = %v, want %v", test.level, got, test.want)
+		}
+	}
+}
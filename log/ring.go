@@ -0,0 +1,126 @@
+package log
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Entry is a single logged message
+type Entry struct {
+	Time      time.Time
+	Level     int
+	Subsystem Subsystem
+	Message   string
+	// PC is the program counter of the logging call, or zero if unknown
+	PC uintptr
+}
+
+// Source returns the file and line of the logging call
+func (e Entry) Source() (file string, line int) {
+	if e.PC == 0 {
+		return "", 0
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{e.PC}).Next()
+	return frame.File, frame.Line
+}
+
+// String formats the entry the same way as the output set with SetOutput,
+// without the source location
+func (e Entry) String() string {
+	prefix := timestamp(e.Time) + " " + levelName(e.Level) + " "
+	if e.Subsystem != General {
+		prefix += "[" + e.Subsystem.String() + "] "
+	}
+	return prefix + e.Message
+}
+
+func levelName(l int) string {
+	switch l {
+	case LevelError:
+		return "ERROR"
+	case LevelWarn:
+		return "WARN "
+	case LevelInfo:
+		return "INFO "
+	case LevelDebug:
+		return "DEBUG"
+	default:
+		return "TRACE"
+	}
+}
+
+// RingBuffer keeps the most recent log entries in memory, so they can be
+// shown inside the application (for example in a debug pane) while the
+// terminal is owned by Vaxis. Install it with SetRingBuffer. A RingBuffer is
+// safe for concurrent use
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []Entry
+	start   int
+	len     int
+	notify  chan struct{}
+}
+
+// NewRingBuffer creates a RingBuffer holding at most size entries. Once full,
+// the oldest entries are discarded
+func NewRingBuffer(size int) *RingBuffer {
+	if size < 1 {
+		size = 1
+	}
+	return &RingBuffer{
+		entries: make([]Entry, size),
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+func (rb *RingBuffer) add(e Entry) {
+	rb.mu.Lock()
+	i := (rb.start + rb.len) % len(rb.entries)
+	rb.entries[i] = e
+	if rb.len < len(rb.entries) {
+		rb.len += 1
+	} else {
+		rb.start = (rb.start + 1) % len(rb.entries)
+	}
+	rb.mu.Unlock()
+	select {
+	case rb.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Entries returns a copy of the buffered entries, oldest first
+func (rb *RingBuffer) Entries() []Entry {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	out := make([]Entry, 0, rb.len)
+	for i := 0; i < rb.len; i += 1 {
+		out = append(out, rb.entries[(rb.start+i)%len(rb.entries)])
+	}
+	return out
+}
+
+// Len returns the number of buffered entries
+func (rb *RingBuffer) Len() int {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return rb.len
+}
+
+// Clear discards all buffered entries
+func (rb *RingBuffer) Clear() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	for i := range rb.entries {
+		rb.entries[i] = Entry{}
+	}
+	rb.start = 0
+	rb.len = 0
+}
+
+// Notify returns a channel which receives a value after entries are added.
+// Notifications are coalesced: a single value may stand for many entries
+func (rb *RingBuffer) Notify() <-chan struct{} {
+	return rb.notify
+}
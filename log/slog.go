@@ -0,0 +1,51 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogLevelTrace is the slog level Vaxis trace messages are logged at. Trace
+// is more verbose than slog.LevelDebug
+const SlogLevelTrace = slog.LevelDebug - 4
+
+// SetSlogHandler additionally sends every logged message to h, so Vaxis logs
+// become part of an application's structured logs. Records carry a
+// "subsystem" attribute unless they were logged to [General]. The levels set
+// with SetLevel and SetSubsystemLevel are applied before h is consulted.
+// Passing nil removes the handler
+func SetSlogHandler(h slog.Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handler = h
+}
+
+// SlogLevel converts a Vaxis level to a slog level
+func SlogLevel(l int) slog.Level {
+	switch {
+	case l <= LevelError:
+		return slog.LevelError
+	case l == LevelWarn:
+		return slog.LevelWarn
+	case l == LevelInfo:
+		return slog.LevelInfo
+	case l == LevelDebug:
+		return slog.LevelDebug
+	default:
+		return SlogLevelTrace
+	}
+}
+
+func handleSlog(h slog.Handler, t time.Time, s Subsystem, l int, message string, pc uintptr) {
+	ctx := context.Background()
+	level := SlogLevel(l)
+	if !h.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(t, level, message, pc)
+	if s != General {
+		r.AddAttrs(slog.String("subsystem", s.String()))
+	}
+	_ = h.Handle(ctx, r)
+}
@@ -0,0 +1,299 @@
+package vaxis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// markupAttributes are the attribute names accepted in markup tags, in the
+// order [FormatMarkup] writes them
+var markupAttributes = []struct {
+	name string
+	attr AttributeMask
+}{
+	{"bold", AttrBold},
+	{"dim", AttrDim},
+	{"italic", AttrItalic},
+	{"blink", AttrBlink},
+	{"reverse", AttrReverse},
+	{"invisible", AttrInvisible},
+	{"strikethrough", AttrStrikethrough},
+	{"overline", AttrOverline},
+}
+
+var markupUnderlines = []string{
+	UnderlineOff:    "off",
+	UnderlineSingle: "single",
+	UnderlineDouble: "double",
+	UnderlineCurly:  "curly",
+	UnderlineDotted: "dotted",
+	UnderlineDashed: "dashed",
+}
+
+// ParseMarkup parses styled text into segments. Styles are set with tags in
+// square brackets, which hold space separated properties and last until a
+// matching [/]:
+//
+//	[bold fg=#ff0000]error:[/] [link=https://vaxis.dev]details[/]
+//
+// Tags nest, with inner tags layered over outer ones. Tags still open at the
+// end of the text are closed. The properties are:
+//
+//	bold, dim, italic, blink, reverse, invisible, strikethrough, overline
+//	underline             a single underline
+//	underline=STYLE       off, single, double, curly, dotted or dashed
+//	fg=COLOR              the foreground color
+//	bg=COLOR              the background color
+//	ul=COLOR              the underline color
+//	COLOR                 shorthand for fg=COLOR
+//	link=URL              an OSC 8 hyperlink
+//	link-params=PARAMS    OSC 8 parameters, such as id=docs
+//
+// Colors are anything [ParseColor] accepts, with hyphens in place of spaces. A
+// backslash escapes the next character, both in text and in tags, so \[ is a
+// literal bracket. See [EscapeMarkup]
+func ParseMarkup(s string) ([]Segment, error) {
+	var (
+		segs  []Segment
+		stack = []Style{{}}
+		text  strings.Builder
+	)
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		style := stack[len(stack)-1]
+		if n := len(segs); n > 0 && segs[n-1].Style == style {
+			segs[n-1].Text += text.String()
+		} else {
+			segs = append(segs, Segment{Text: text.String(), Style: style})
+		}
+		text.Reset()
+	}
+	for i := 0; i < len(s); i += 1 {
+		switch s[i] {
+		case '\\':
+			if i+1 == len(s) {
+				return nil, fmt.Errorf("vaxis: markup ends with a backslash")
+			}
+			i += 1
+			text.WriteByte(s[i])
+		case '[':
+			tag, n, ok := scanMarkupTag(s[i+1:])
+			if !ok {
+				return nil, fmt.Errorf("vaxis: unclosed markup tag at offset %d", i)
+			}
+			start := i
+			i += n
+			flush()
+			if tag == "/" {
+				if len(stack) == 1 {
+					return nil, fmt.Errorf("vaxis: markup [/] at offset %d has no open tag", start)
+				}
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			style, err := parseMarkupTag(tag, stack[len(stack)-1])
+			if err != nil {
+				return nil, fmt.Errorf("vaxis: markup tag at offset %d: %w", start, err)
+			}
+			stack = append(stack, style)
+		default:
+			text.WriteByte(s[i])
+		}
+	}
+	flush()
+	return segs, nil
+}
+
+// FormatMarkup writes segments as markup which [ParseMarkup] parses back to the
+// same segments. Segments with an empty style are written as escaped text, and
+// the rest as a tag with the full style
+func FormatMarkup(segs []Segment) string {
+	var b strings.Builder
+	for _, seg := range segs {
+		if seg.Style == (Style{}) {
+			b.WriteString(EscapeMarkup(seg.Text))
+			continue
+		}
+		b.WriteByte('[')
+		b.WriteString(formatMarkupTag(seg.Style))
+		b.WriteByte(']')
+		b.WriteString(EscapeMarkup(seg.Text))
+		b.WriteString("[/]")
+	}
+	return b.String()
+}
+
+// EscapeMarkup escapes brackets and backslashes so s is parsed by
+// [ParseMarkup] as plain text
+func EscapeMarkup(s string) string {
+	if !strings.ContainsAny(s, `[]\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i += 1 {
+		switch s[i] {
+		case '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// scanMarkupTag returns the contents of the tag which starts s, still escaped,
+// and its length including the closing bracket
+func scanMarkupTag(s string) (string, int, bool) {
+	for i := 0; i < len(s); i += 1 {
+		switch s[i] {
+		case '\\':
+			i += 1
+		case ']':
+			return s[:i], i + 1, true
+		}
+	}
+	return "", 0, false
+}
+
+// splitMarkupTag splits a tag into properties on unescaped spaces, removing
+// the escapes
+func splitMarkupTag(tag string) []string {
+	var (
+		props []string
+		prop  strings.Builder
+	)
+	for i := 0; i < len(tag); i += 1 {
+		switch c := tag[i]; {
+		case c == '\\' && i+1 < len(tag):
+			i += 1
+			prop.WriteByte(tag[i])
+		case c == ' ':
+			if prop.Len() > 0 {
+				props = append(props, prop.String())
+				prop.Reset()
+			}
+		default:
+			prop.WriteByte(c)
+		}
+	}
+	if prop.Len() > 0 {
+		props = append(props, prop.String())
+	}
+	return props
+}
+
+func parseMarkupTag(tag string, style Style) (Style, error) {
+	props := splitMarkupTag(tag)
+	if len(props) == 0 {
+		return style, fmt.Errorf("empty tag")
+	}
+	for _, prop := range props {
+		key, value, hasValue := strings.Cut(prop, "=")
+		key = strings.ToLower(key)
+		switch key {
+		case "fg", "bg", "ul":
+			c, err := ParseColor(value)
+			if err != nil {
+				return style, err
+			}
+			switch key {
+			case "fg":
+				style.Foreground = c
+			case "bg":
+				style.Background = c
+			case "ul":
+				style.UnderlineColor = c
+			}
+		case "link":
+			style.Hyperlink = value
+		case "link-params":
+			style.HyperlinkParams = value
+		case "underline":
+			if !hasValue {
+				style.UnderlineStyle = UnderlineSingle
+				continue
+			}
+			found := false
+			for u, name := range markupUnderlines {
+				if strings.EqualFold(value, name) {
+					style.UnderlineStyle = UnderlineStyle(u)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return style, fmt.Errorf("unknown underline style %q", value)
+			}
+		default:
+			if hasValue {
+				return style, fmt.Errorf("unknown property %q", key)
+			}
+			if attr, ok := markupAttribute(key); ok {
+				style.Attribute |= attr
+				continue
+			}
+			c, err := ParseColor(prop)
+			if err != nil {
+				return style, fmt.Errorf("unknown property %q", prop)
+			}
+			style.Foreground = c
+		}
+	}
+	return style, nil
+}
+
+func markupAttribute(name string) (AttributeMask, bool) {
+	for _, a := range markupAttributes {
+		if a.name == name {
+			return a.attr, true
+		}
+	}
+	return 0, false
+}
+
+func formatMarkupTag(style Style) string {
+	var props []string
+	for _, a := range markupAttributes {
+		if style.Attribute&a.attr != 0 {
+			props = append(props, a.name)
+		}
+	}
+	switch {
+	case style.UnderlineStyle == UnderlineSingle:
+		props = append(props, "underline")
+	case int(style.UnderlineStyle) < len(markupUnderlines) && style.UnderlineStyle != UnderlineOff:
+		props = append(props, "underline="+markupUnderlines[style.UnderlineStyle])
+	}
+	if style.Foreground != 0 {
+		props = append(props, "fg="+style.Foreground.Spec())
+	}
+	if style.Background != 0 {
+		props = append(props, "bg="+style.Background.Spec())
+	}
+	if style.UnderlineColor != 0 {
+		props = append(props, "ul="+style.UnderlineColor.Spec())
+	}
+	if style.Hyperlink != "" {
+		props = append(props, "link="+escapeMarkupValue(style.Hyperlink))
+	}
+	if style.HyperlinkParams != "" {
+		props = append(props, "link-params="+escapeMarkupValue(style.HyperlinkParams))
+	}
+	return strings.Join(props, " ")
+}
+
+func escapeMarkupValue(s string) string {
+	if !strings.ContainsAny(s, ` []\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i += 1 {
+		switch s[i] {
+		case ' ', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
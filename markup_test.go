@@ -0,0 +1,105 @@
+package vaxis_test
+
+import (
+	"reflect"
+	"testing"
+
+	"go.rockorager.dev/vaxis"
+)
+
+func TestParseMarkup(t *testing.T) {
+	got, err := vaxis.ParseMarkup(`[bold fg=#ff0000]error:[/] [link=https://x]details[/]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []vaxis.Segment{
+		{Text: "error:", Style: vaxis.Style{Attribute: vaxis.AttrBold, Foreground: vaxis.HexColor(0xFF0000)}},
+		{Text: " "},
+		{Text: "details", Style: vaxis.Style{Hyperlink: "https://x"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseMarkupNesting(t *testing.T) {
+	got, err := vaxis.ParseMarkup(`[italic bg=blue]a[red underline=curly ul=color208]b[/]c[/]d[bold]e`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer := vaxis.Style{Attribute: vaxis.AttrItalic, Background: vaxis.IndexColor(4)}
+	inner := outer
+	inner.Foreground = vaxis.IndexColor(1)
+	inner.UnderlineStyle = vaxis.UnderlineCurly
+	inner.UnderlineColor = vaxis.IndexColor(208)
+	want := []vaxis.Segment{
+		{Text: "a", Style: outer},
+		{Text: "b", Style: inner},
+		{Text: "c", Style: outer},
+		{Text: "d"},
+		{Text: "e", Style: vaxis.Style{Attribute: vaxis.AttrBold}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseMarkupEscapes(t *testing.T) {
+	got, err := vaxis.ParseMarkup(`\[not a tag] \\ [link=https://x/a\]b\ c]x[/]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []vaxis.Segment{
+		{Text: `[not a tag] \ `},
+		{Text: "x", Style: vaxis.Style{Hyperlink: "https://x/a]b c"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseMarkupErrors(t *testing.T) {
+	for _, markup := range []string{
+		"[bold",
+		"[]",
+		"a[/]",
+		"[fg=nope]a",
+		"[underline=wavy]a",
+		"[sparkly]a",
+		"[size=2]a",
+		`a\`,
+	} {
+		if _, err := vaxis.ParseMarkup(markup); err == nil {
+			t.Fatalf("ParseMarkup(%q) succeeded, want an error", markup)
+		}
+	}
+}
+
+func TestFormatMarkupRoundTrip(t *testing.T) {
+	segs := []vaxis.Segment{
+		{Text: "plain [text] \\ "},
+		{Text: "styled", Style: vaxis.Style{
+			Attribute:      vaxis.AttrBold | vaxis.AttrStrikethrough,
+			Foreground:     vaxis.HexColor(0x123456),
+			Background:     vaxis.IndexColor(9),
+			UnderlineStyle: vaxis.UnderlineDotted,
+			UnderlineColor: vaxis.IndexColor(100),
+		}},
+		{Text: "link", Style: vaxis.Style{
+			UnderlineStyle:  vaxis.UnderlineSingle,
+			Hyperlink:       "https://example.com/a b]",
+			HyperlinkParams: "id=x",
+		}},
+	}
+	markup := vaxis.FormatMarkup(segs)
+	got, err := vaxis.ParseMarkup(markup)
+	if err != nil {
+		t.Fatalf("ParseMarkup(%q): %v", markup, err)
+	}
+	if !reflect.DeepEqual(got, segs) {
+		t.Fatalf("%q parsed to %+v", markup, got)
+	}
+	if want := "[bold]hi[/]"; vaxis.FormatMarkup([]vaxis.Segment{{Text: "hi", Style: vaxis.Style{Attribute: vaxis.AttrBold}}}) != want {
+		t.Fatalf("FormatMarkup didn't write %q", want)
+	}
+}
@@ -96,12 +96,12 @@ func parseMouseEvent(seq ansi.CSI, ws Resize, enableSGRPixels bool) (Mouse, bool
 	mouse := Mouse{}
 	intermediates := seq.Intermediates()
 	if len(intermediates) != 1 || intermediates[0] != '<' {
-		log.Error("[CSI] unknown sequence: %s", seq)
+		log.Parser.Error("[CSI] unknown sequence: %s", seq)
 		return mouse, false
 	}
 
 	if seq.NumParameters != 3 {
-		log.Error("[CSI] unknown sequence: %s", seq)
+		log.Parser.Error("[CSI] unknown sequence: %s", seq)
 		return mouse, false
 	}
 
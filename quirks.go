@@ -34,7 +34,7 @@ func (vx *Vaxis) applyQuirks() {
 			strings.Contains(id, "VTE"):
 			vx.caps.osc8 = true
 		default:
-			switch os.Getenv("TERM") {
+			switch vx.getenv("TERM") {
 			case "foot", "foot-extra", "xterm-kitty",
 				"alacritty", "contour", "wezterm",
 				"ghostty", "rio", "mintty":
@@ -43,10 +43,12 @@ func (vx *Vaxis) applyQuirks() {
 		}
 	}
 
-	if os.Getenv("ASCIINEMA_REC") != "" {
+	if vx.getenv("ASCIINEMA_REC") != "" {
 		// Asciinema doesn't support any advanced image protocols
 		vx.graphicsProtocol = halfBlock
 	}
+	// The SGR sequences are shared by every Vaxis in the process, so this
+	// one is read from the process's environment rather than the terminal's
 	if os.Getenv("VAXIS_FORCE_LEGACY_SGR") != "" {
 		sgrParamSeparator = ';'
 		fgIndexSet = strings.ReplaceAll(fgIndexSet, ":", ";")
@@ -54,21 +56,21 @@ func (vx *Vaxis) applyQuirks() {
 		bgIndexSet = strings.ReplaceAll(bgIndexSet, ":", ";")
 		bgRGBSet = strings.ReplaceAll(bgRGBSet, ":", ";")
 	}
-	if os.Getenv("VAXIS_FORCE_WCWIDTH") != "" {
+	if vx.getenv("VAXIS_FORCE_WCWIDTH") != "" {
 		vx.caps.unicodeCore = false
 		vx.caps.explicitWidth = false
 	}
-	if os.Getenv("VAXIS_FORCE_UNICODE") != "" {
+	if vx.getenv("VAXIS_FORCE_UNICODE") != "" {
 		vx.caps.unicodeCore = true
 	}
-	if os.Getenv("VAXIS_FORCE_NOZWJ") != "" {
+	if vx.getenv("VAXIS_FORCE_NOZWJ") != "" {
 		vx.caps.noZWJ = true
 		vx.caps.explicitWidth = false
 	}
-	if os.Getenv("VAXIS_DISABLE_NOZWJ") != "" {
+	if vx.getenv("VAXIS_DISABLE_NOZWJ") != "" {
 		vx.caps.noZWJ = false
 	}
-	if os.Getenv("VAXIS_FORCE_XTWINOPS") != "" {
+	if vx.getenv("VAXIS_FORCE_XTWINOPS") != "" {
 		vx.xtwinops = true
 	}
 }
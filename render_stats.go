@@ -0,0 +1,85 @@
+package vaxis
+
+import "time"
+
+// FrameStats describes a single frame drawn by [Vaxis.Render]
+type FrameStats struct {
+	// Frame is the number of the frame, starting at 1
+	Frame int
+	// Refresh reports whether the entire screen was redrawn
+	Refresh bool
+	// DiffTime is the time spent comparing the screen with the last frame
+	// and encoding the changes
+	DiffTime time.Duration
+	// FlushTime is the time spent writing the frame to the terminal
+	FlushTime time.Duration
+	// Bytes is the number of bytes written to the terminal
+	Bytes int
+	// CellsChanged is the number of cells which were redrawn
+	CellsChanged int
+	// GraphicsPlaced is the number of graphics placements drawn
+	GraphicsPlaced int
+	// GraphicsBytes is the number of bytes written to draw graphics,
+	// including image uploads
+	GraphicsBytes int
+	// QueueDepth is the number of events waiting to be read from
+	// [Vaxis.Events] when the frame started
+	QueueDepth int
+}
+
+// RenderStats are cumulative statistics of every frame drawn since Vaxis was
+// created
+type RenderStats struct {
+	Frames         int
+	RenderTime     time.Duration
+	DiffTime       time.Duration
+	FlushTime      time.Duration
+	Bytes          int64
+	CellsChanged   int64
+	GraphicsPlaced int64
+	GraphicsBytes  int64
+	// LastFrame is the most recent frame
+	LastFrame FrameStats
+}
+
+// RenderStats returns statistics of the frames drawn so far
+func (vx *Vaxis) RenderStats() RenderStats {
+	vx.mu.Lock()
+	defer vx.mu.Unlock()
+	stats := vx.renderStats
+	stats.Frames = vx.renders
+	stats.RenderTime = vx.elapsed
+	return stats
+}
+
+// OnRender sets a function which is called with the statistics of each frame
+// after it has been written to the terminal. The function is called from the
+// goroutine which called Render and must not call Render itself. Passing nil
+// removes the function
+func (vx *Vaxis) OnRender(fn func(FrameStats)) {
+	vx.mu.Lock()
+	defer vx.mu.Unlock()
+	vx.onRender = fn
+}
+
+// finishFrame records the statistics of the frame which was just flushed and
+// returns the OnRender function to call with them. vx.mu must be held
+func (vx *Vaxis) finishFrame(elapsed time.Duration, flush time.Duration, n int) (FrameStats, func(FrameStats)) {
+	vx.elapsed += elapsed
+	vx.renders += 1
+	frame := vx.frame
+	frame.Frame = vx.renders
+	frame.FlushTime = flush
+	frame.DiffTime = elapsed - flush
+	frame.Bytes = n
+
+	stats := &vx.renderStats
+	stats.DiffTime += frame.DiffTime
+	stats.FlushTime += frame.FlushTime
+	stats.Bytes += int64(frame.Bytes)
+	stats.CellsChanged += int64(frame.CellsChanged)
+	stats.GraphicsPlaced += int64(frame.GraphicsPlaced)
+	stats.GraphicsBytes += int64(frame.GraphicsBytes)
+	stats.LastFrame = frame
+	return frame, vx.onRender
+}
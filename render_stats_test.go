@@ -0,0 +1,114 @@
+package vaxis
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestRenderStats(t *testing.T) {
+	var out bytes.Buffer
+	vx := newWriterTestVaxis(&out)
+	vx.queue = make(chan Event, 4)
+	vx.queue <- Redraw{}
+
+	var frames []FrameStats
+	vx.OnRender(func(frame FrameStats) {
+		frames = append(frames, frame)
+	})
+
+	vx.screenNext.setCell(0, 0, Cell{Character: Character{Grapheme: "a", Width: 1}})
+	vx.screenNext.setCell(1, 0, Cell{Character: Character{Grapheme: "b", Width: 1}})
+	vx.Render()
+	first := out.Len()
+
+	// Nothing changed, so nothing is drawn
+	vx.Render()
+
+	vx.screenNext.setCell(1, 0, Cell{Character: Character{Grapheme: "c", Width: 1}})
+	vx.Render()
+
+	if len(frames) != 3 {
+		t.Fatalf("got %d frames, want 3", len(frames))
+	}
+	if got := frames[0]; got.Frame != 1 || got.CellsChanged != 2 || got.Bytes != first || got.QueueDepth != 1 {
+		t.Fatalf("first frame = %+v, want 2 cells and %d bytes", got, first)
+	}
+	if got := frames[1]; got.Frame != 2 || got.CellsChanged != 0 || got.Bytes != 0 {
+		t.Fatalf("unchanged frame = %+v", got)
+	}
+	if got := frames[2]; got.CellsChanged != 1 || got.Bytes != out.Len()-first {
+		t.Fatalf("third frame = %+v, want 1 cell and %d bytes", got, out.Len()-first)
+	}
+
+	stats := vx.RenderStats()
+	if stats.Frames != 3 || stats.CellsChanged != 3 || stats.Bytes != int64(out.Len()) {
+		t.Fatalf("stats = %+v, want 3 frames, 3 cells and %d bytes", stats, out.Len())
+	}
+	if stats.LastFrame != frames[2] {
+		t.Fatalf("last frame = %+v, want %+v", stats.LastFrame, frames[2])
+	}
+	if stats.DiffTime+stats.FlushTime != stats.RenderTime {
+		t.Fatalf("diff %s + flush %s != render %s", stats.DiffTime, stats.FlushTime, stats.RenderTime)
+	}
+
+	vx.OnRender(nil)
+	vx.Refresh()
+	if len(frames) != 3 {
+		t.Fatal("removed callback was called")
+	}
+	if got := vx.RenderStats().LastFrame; !got.Refresh || got.CellsChanged != 2 {
+		t.Fatalf("refresh frame = %+v", got)
+	}
+}
+
+func TestRenderStatsGraphics(t *testing.T) {
+	var out bytes.Buffer
+	vx := newWriterTestVaxis(&out)
+	vx.graphicsNext = []*placement{{
+		id:       1,
+		w:        1,
+		h:        1,
+		writeTo:  func(w io.Writer) { _, _ = io.WriteString(w, "image") },
+		deleteFn: func(io.Writer) {},
+	}}
+	vx.Render()
+	frame := vx.RenderStats().LastFrame
+	if frame.GraphicsPlaced != 1 || frame.GraphicsBytes != len("image") {
+		t.Fatalf("frame = %+v, want 1 placement of 5 bytes", frame)
+	}
+
+	// Placements which are already drawn aren't written again
+	vx.Render()
+	if frame := vx.RenderStats().LastFrame; frame.GraphicsPlaced != 0 {
+		t.Fatalf("frame = %+v, want no placements", frame)
+	}
+}
+
+// BenchmarkRenderStatsTyping reports the cost of a frame where a single cell
+// changes, as when typing. Compare the bytes/frame metric across runs to catch
+// output regressions
+func BenchmarkRenderStatsTyping(b *testing.B) {
+	vx := newWriterTestVaxis(&bytes.Buffer{})
+	vx.tw.terminal.w = io.Discard
+	vx.screenNext.resize(80, 24)
+	vx.screenLast.resize(80, 24)
+	for row := 0; row < 24; row += 1 {
+		for col := 0; col < 80; col += 1 {
+			vx.screenNext.setCell(col, row, Cell{Character: Character{Grapheme: "a", Width: 1}})
+		}
+	}
+	vx.Render()
+	start := vx.RenderStats()
+	chars := []string{"x", "y"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1 {
+		vx.screenNext.setCell(i%80, 12, Cell{Character: Character{Grapheme: chars[(i/80)%2], Width: 1}})
+		vx.Render()
+	}
+	b.StopTimer()
+	stats := vx.RenderStats()
+	b.ReportMetric(float64(stats.Bytes-start.Bytes)/float64(b.N), "bytes/frame")
+	b.ReportMetric(float64(stats.CellsChanged-start.CellsChanged)/float64(b.N), "cells/frame")
+}
@@ -27,22 +27,22 @@ const (
 	userCursorStyle = "\x1bP$q q\x1b\\"
 
 	// Misc
-	clear         = "\x1b[H\x1b[2J"
-	cup           = "\x1B[%d;%dH"
-	osc4          = "\x1b]4;%d;?\x1b\\"
-	osc8          = "\x1b]8;%s;%s\x1b\\"
-	osc10         = "\x1b]10;?\x07"
-	osc11         = "\x1b]11;?\x07"
-	osc52put      = "\x1b]52;c;%s\x1b\\"
-	osc52pop      = "\x1b]52;c;?\x1b\\"
-	osc9notify    = "\x1b]9;%s\x1b\\"
-	osc777notify  = "\x1b]777;notify;%s;%s\x1b\\"
-	setTitle      = "\x1b]2;%s\x1b\\"
-	setCWD        = "\x1b]7;%s\x1b\\"
-	getAppID      = "\x1b]176;?\x1b\\"
-	setAppID      = "\x1b]176;%s\x1b\\"
-	mouseShape    = "\x1b]22;%s\x1b\\"
-	explicitWidth = "\x1b]66;w=%d;%s\x1b\\"
+	clear        = "\x1b[H\x1b[2J"
+	eraseLine    = "\x1b[2K"
+	cup          = "\x1B[%d;%dH"
+	osc4         = "\x1b]4;%d;?\x1b\\"
+	osc8         = "\x1b]8;%s;%s\x1b\\"
+	osc10        = "\x1b]10;?\x07"
+	osc11        = "\x1b]11;?\x07"
+	osc52put     = "\x1b]52;c;%s\x1b\\"
+	osc52pop     = "\x1b]52;c;?\x1b\\"
+	osc9notify   = "\x1b]9;%s\x1b\\"
+	osc777notify = "\x1b]777;notify;%s;%s\x1b\\"
+	setTitle     = "\x1b]2;%s\x1b\\"
+	setCWD       = "\x1b]7;%s\x1b\\"
+	getAppID     = "\x1b]176;?\x1b\\"
+	setAppID     = "\x1b]176;%s\x1b\\"
+	mouseShape   = "\x1b]22;%s\x1b\\"
 
 	// SGR
 	sgrReset           = "\x1b[m"
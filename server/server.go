@@ -0,0 +1,236 @@
+// Package server serves Vaxis applications to remote terminals. Each
+// connection becomes a [Session] with its own [vaxis.Vaxis] instance, size,
+// resize handling and capability detection. Sessions can come from any
+// [net.Listener] (for example a Unix socket) or from an SSH channel.
+//
+// The server process never needs a tty and never installs signal handlers:
+// every Vaxis created by the server has [vaxis.Options.NoSignals] set.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.rockorager.dev/vaxis"
+	"go.rockorager.dev/vaxis/log"
+)
+
+// ErrServerClosed is returned by Serve after Close has been called
+var ErrServerClosed = errors.New("server: closed")
+
+// Handler runs an application for a single session. The Vaxis instance is
+// ready to use. When the client disconnects, a [vaxis.QuitEvent] is posted to
+// vx. Once Handler returns, the server restores the remote terminal and
+// closes the session
+type Handler func(vx *vaxis.Vaxis, sess *Session)
+
+// Server creates a Vaxis instance per session and runs the Handler with it
+type Server struct {
+	// Handler is called for each session
+	Handler Handler
+	// Options are used to create the Vaxis instance of each session.
+	// WithConsole, WithTTY, NoSignals and Getenv are overridden: capabilities
+	// are detected from the TERM and environment the client sent
+	Options vaxis.Options
+	// CloseTimeout bounds how long restoring the remote terminal may take
+	// once the Handler returns. A client which stops responding is
+	// disconnected after this timeout. Defaults to 3 seconds
+	CloseTimeout time.Duration
+	// OnError is called with the error of each session Serve accepted
+	// which failed, such as when the remote terminal couldn't be set up or
+	// the Handler panicked. When nil, errors are logged with the vaxis log
+	// package
+	OnError func(sess *Session, err error)
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	sessions  map[*Session]struct{}
+	closed    bool
+}
+
+// Serve accepts connections on l and serves each in its own goroutine. The
+// size of the remote terminal is initially [DefaultWindow]. Sessions which
+// fail are reported to OnError. Serve always
+// returns a non-nil error, [ErrServerClosed] after Close
+func (srv *Server) Serve(l net.Listener) error {
+	if !srv.trackListener(l, true) {
+		return ErrServerClosed
+	}
+	defer srv.trackListener(l, false)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if srv.isClosed() {
+				return ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		go func() {
+			sess := NewSession(conn, Window{})
+			if err := srv.ServeSession(sess); err != nil && !errors.Is(err, ErrServerClosed) {
+				srv.reportError(sess, err)
+			}
+		}()
+	}
+}
+
+// ListenAndServeUnix listens on the Unix socket at path and serves sessions
+// from it. The socket file is removed when the listener is closed
+func (srv *Server) ListenAndServeUnix(path string) error {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	return srv.Serve(l)
+}
+
+// ServeSession creates a Vaxis instance for sess and runs the Handler with it,
+// blocking until the Handler returns and the session is closed. This is the
+// entry point for sessions which don't come from a net.Listener, such as SSH
+// channels. A panic in the Handler is recovered, after restoring the remote
+// terminal, and returned as an error
+func (srv *Server) ServeSession(sess *Session) error {
+	defer sess.Close()
+	if !srv.trackSession(sess, true) {
+		return ErrServerClosed
+	}
+	defer srv.trackSession(sess, false)
+
+	opts := srv.Options
+	opts.WithConsole = sess
+	opts.WithTTY = ""
+	opts.NoSignals = true
+	opts.Getenv = sess.getenv
+	vx, err := vaxis.New(opts)
+	if err != nil {
+		return err
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-sess.Done():
+			vx.PostEvent(vaxis.QuitEvent{})
+		case <-finished:
+		}
+	}()
+
+	err = srv.runHandler(vx, sess)
+	close(finished)
+	srv.teardown(vx, sess)
+	return err
+}
+
+// runHandler calls the Handler, recovering a panic as an error
+func (srv *Server) runHandler(vx *vaxis.Vaxis, sess *Session) (err error) {
+	if srv.Handler == nil {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("server: handler panicked: %v\n%s", r, debug.Stack())
+		}
+	}()
+	srv.Handler(vx, sess)
+	return nil
+}
+
+func (srv *Server) reportError(sess *Session, err error) {
+	if srv.OnError != nil {
+		srv.OnError(sess, err)
+		return
+	}
+	log.Error("[server] session failed: %v", err)
+}
+
+// teardown restores the remote terminal. Restoring requires a reply from the
+// client to stop the input parser, so a client which doesn't reply is
+// disconnected after CloseTimeout
+func (srv *Server) teardown(vx *vaxis.Vaxis, sess *Session) {
+	timeout := srv.CloseTimeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	done := make(chan struct{})
+	go func() {
+		vx.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Warn("[server] session did not close in time, disconnecting")
+		_ = sess.Close()
+		<-done
+	}
+}
+
+// Close stops all listeners and disconnects every session
+func (srv *Server) Close() error {
+	srv.mu.Lock()
+	srv.closed = true
+	var err error
+	for l := range srv.listeners {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	sessions := make([]*Session, 0, len(srv.sessions))
+	for sess := range srv.sessions {
+		sessions = append(sessions, sess)
+	}
+	srv.mu.Unlock()
+	for _, sess := range sessions {
+		_ = sess.Close()
+	}
+	return err
+}
+
+func (srv *Server) isClosed() bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.closed
+}
+
+func (srv *Server) trackListener(l net.Listener, add bool) bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if !add {
+		delete(srv.listeners, l)
+		return true
+	}
+	if srv.closed {
+		return false
+	}
+	if srv.listeners == nil {
+		srv.listeners = make(map[net.Listener]struct{})
+	}
+	srv.listeners[l] = struct{}{}
+	return true
+}
+
+func (srv *Server) trackSession(sess *Session, add bool) bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if !add {
+		delete(srv.sessions, sess)
+		return true
+	}
+	if srv.closed {
+		return false
+	}
+	if srv.sessions == nil {
+		srv.sessions = make(map[*Session]struct{})
+	}
+	srv.sessions[sess] = struct{}{}
+	return true
+}
@@ -0,0 +1,309 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.rockorager.dev/vaxis"
+)
+
+// fakeClient plays the part of a remote terminal: it records everything the
+// server writes and answers primary device attribute queries
+type fakeClient struct {
+	conn net.Conn
+	mu   sync.Mutex
+	out  bytes.Buffer
+	done chan struct{}
+}
+
+func newFakeClient(conn net.Conn) *fakeClient {
+	c := &fakeClient{conn: conn, done: make(chan struct{})}
+	go c.run()
+	return c
+}
+
+func (c *fakeClient) run() {
+	defer close(c.done)
+	buf := make([]byte, 4096)
+	for {
+		n, err := c.conn.Read(buf)
+		if n > 0 {
+			c.mu.Lock()
+			c.out.Write(buf[:n])
+			c.mu.Unlock()
+			if bytes.Contains(buf[:n], []byte("\x1b[c")) {
+				go func() {
+					_, _ = io.WriteString(c.conn, "\x1b[?62c")
+				}()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (c *fakeClient) Output() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.out.String()
+}
+
+func TestServeSession(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	client := newFakeClient(clientConn)
+	sess := NewSession(serverConn, Window{Cols: 40, Rows: 10})
+
+	var initial, resized vaxis.Resize
+	srv := &Server{
+		Options: vaxis.Options{DisableMouse: true},
+		Handler: func(vx *vaxis.Vaxis, sess *Session) {
+			initial = vx.Size()
+			win := vx.Window()
+			win.Print(vaxis.Segment{Text: "hello session"})
+			vx.Render()
+
+			sess.Resize(Window{Cols: 60, Rows: 20})
+			timeout := time.After(2 * time.Second)
+			for {
+				select {
+				case ev := <-vx.Events():
+					if ev, ok := ev.(vaxis.Resize); ok && ev.Cols == 60 {
+						resized = ev
+						return
+					}
+				case <-timeout:
+					t.Error("timed out waiting for resize event")
+					return
+				}
+			}
+		},
+	}
+	if err := srv.ServeSession(sess); err != nil {
+		t.Fatal(err)
+	}
+	<-client.done
+
+	if initial.Cols != 40 || initial.Rows != 10 {
+		t.Fatalf("initial size = %+v, want 40x10", initial)
+	}
+	if resized.Cols != 60 || resized.Rows != 20 {
+		t.Fatalf("resized size = %+v, want 60x20", resized)
+	}
+	out := client.Output()
+	if !strings.Contains(out, "hello session") {
+		t.Fatalf("output did not contain rendered text: %q", out)
+	}
+	if !strings.Contains(out, "\x1b[?1049l") {
+		t.Fatalf("alternate screen was not exited on teardown: %q", out)
+	}
+}
+
+func TestServeSessionUsesClientEnvironment(t *testing.T) {
+	t.Setenv("TERM", "dumb")
+	t.Setenv("COLORTERM", "")
+	serverConn, clientConn := net.Pipe()
+	client := newFakeClient(clientConn)
+	sess := NewSession(serverConn, Window{Cols: 40, Rows: 10})
+	pty := append(sshString("xterm-kitty"), sshWindow(Window{Cols: 40, Rows: 10})...)
+	pty = append(pty, sshString("")...)
+	sess.HandleSSHRequest("pty-req", pty)
+	sess.HandleSSHRequest("env", append(sshString("COLORTERM"), sshString("truecolor")...))
+
+	var rgb, hyperlink bool
+	srv := &Server{
+		Options: vaxis.Options{DisableMouse: true},
+		Handler: func(vx *vaxis.Vaxis, sess *Session) {
+			rgb, hyperlink = vx.CanRGB(), vx.CanHyperlink()
+		},
+	}
+	if err := srv.ServeSession(sess); err != nil {
+		t.Fatal(err)
+	}
+	<-client.done
+	if !rgb {
+		t.Error("COLORTERM sent by the client didn't enable truecolor")
+	}
+	if !hyperlink {
+		t.Error("TERM sent by the client didn't enable hyperlinks")
+	}
+}
+
+func TestServeSessionClientDisconnect(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	newFakeClient(clientConn)
+	sess := NewSession(serverConn, Window{})
+
+	quit := make(chan struct{})
+	srv := &Server{
+		CloseTimeout: 100 * time.Millisecond,
+		Handler: func(vx *vaxis.Vaxis, sess *Session) {
+			_ = clientConn.Close()
+			for ev := range vx.Events() {
+				if _, ok := ev.(vaxis.QuitEvent); ok {
+					close(quit)
+					return
+				}
+			}
+		},
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ServeSession(sess) }()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeSession did not return after client disconnected")
+	}
+	select {
+	case <-quit:
+	default:
+		t.Fatal("handler did not receive a QuitEvent")
+	}
+}
+
+func TestServeReportsSessionErrors(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("tcp unavailable: %v", err)
+	}
+	errs := make(chan error, 1)
+	srv := &Server{
+		CloseTimeout: 100 * time.Millisecond,
+		Handler: func(vx *vaxis.Vaxis, sess *Session) {
+			panic("boom")
+		},
+		OnError: func(sess *Session, err error) { errs <- err },
+	}
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newFakeClient(conn)
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "boom") {
+			t.Fatalf("error = %v, want the handler's panic", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the handler's panic wasn't reported")
+	}
+	<-client.done
+	if !strings.Contains(client.Output(), "\x1b[?1049l") {
+		t.Fatalf("remote terminal wasn't restored after the panic: %q", client.Output())
+	}
+}
+
+func TestServeUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vaxis.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	handled := make(chan struct{})
+	srv := &Server{
+		Handler: func(vx *vaxis.Vaxis, sess *Session) {
+			if got := sess.Window(); got != DefaultWindow {
+				t.Errorf("window = %+v, want %+v", got, DefaultWindow)
+			}
+			close(handled)
+		},
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(l) }()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newFakeClient(conn)
+	select {
+	case <-handled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler was not called")
+	}
+	<-client.done
+
+	if err := srv.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; !errors.Is(err, ErrServerClosed) {
+		t.Fatalf("Serve returned %v, want ErrServerClosed", err)
+	}
+}
+
+func sshString(s string) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(s)))
+	return append(b, s...)
+}
+
+func sshWindow(w Window) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(w.Cols))
+	b = binary.BigEndian.AppendUint32(b, uint32(w.Rows))
+	b = binary.BigEndian.AppendUint32(b, uint32(w.XPixel))
+	return binary.BigEndian.AppendUint32(b, uint32(w.YPixel))
+}
+
+func TestHandleSSHRequest(t *testing.T) {
+	sess := NewSession(nopConn{}, Window{})
+
+	pty := append(sshString("xterm-kitty"), sshWindow(Window{Cols: 120, Rows: 40, XPixel: 1200, YPixel: 800})...)
+	pty = append(pty, sshString("")...)
+	if !sess.HandleSSHRequest("pty-req", pty) {
+		t.Fatal("pty-req was rejected")
+	}
+	if got := sess.Term(); got != "xterm-kitty" {
+		t.Fatalf("term = %q, want xterm-kitty", got)
+	}
+	if got, want := sess.Window(), (Window{Cols: 120, Rows: 40, XPixel: 1200, YPixel: 800}); got != want {
+		t.Fatalf("window = %+v, want %+v", got, want)
+	}
+	select {
+	case <-sess.ResizeNotify():
+	default:
+		t.Fatal("pty-req did not notify a resize")
+	}
+
+	if !sess.HandleSSHRequest("window-change", sshWindow(Window{Cols: 100, Rows: 30})) {
+		t.Fatal("window-change was rejected")
+	}
+	if cols, rows, _, _, _ := sess.Size(); cols != 100 || rows != 30 {
+		t.Fatalf("size = %dx%d, want 100x30", cols, rows)
+	}
+
+	if !sess.HandleSSHRequest("env", append(sshString("LANG"), sshString("C.UTF-8")...)) {
+		t.Fatal("env was rejected")
+	}
+	if got := sess.Env("LANG"); got != "C.UTF-8" {
+		t.Fatalf("env LANG = %q, want C.UTF-8", got)
+	}
+
+	if sess.HandleSSHRequest("window-change", []byte{0, 1}) {
+		t.Fatal("short window-change was accepted")
+	}
+	if sess.HandleSSHRequest("exec", sshString("ls")) {
+		t.Fatal("exec was accepted")
+	}
+	if !sess.HandleSSHRequest("shell", nil) {
+		t.Fatal("shell was rejected")
+	}
+}
+
+type nopConn struct{}
+
+func (nopConn) Read([]byte) (int, error)    { return 0, io.EOF }
+func (nopConn) Write(p []byte) (int, error) { return len(p), nil }
+func (nopConn) Close() error                { return nil }
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"io"
+	"sync"
+)
+
+// Window is the size of a remote terminal
+type Window struct {
+	Cols   int
+	Rows   int
+	XPixel int
+	YPixel int
+}
+
+// DefaultWindow is the size assumed for a session whose client never reports
+// one. Terminals supporting in-band resize reports or XTWINOPS will correct
+// it once Vaxis has detected their capabilities
+var DefaultWindow = Window{Cols: 80, Rows: 24}
+
+// Session is a single remote terminal connected over a byte stream. It
+// implements [vaxis.Console] and [vaxis.ResizeNotifier], so a [vaxis.Vaxis]
+// can draw to it without a tty. A Session never installs signal handlers or
+// touches the terminal of the server process
+type Session struct {
+	rw io.ReadWriteCloser
+
+	mu     sync.Mutex
+	window Window
+	term   string
+	env    map[string]string
+
+	resize    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	doneOnce  sync.Once
+}
+
+// NewSession returns a Session reading input from and writing output to rw.
+// A zero window is replaced by [DefaultWindow]
+func NewSession(rw io.ReadWriteCloser, window Window) *Session {
+	if window.Cols <= 0 || window.Rows <= 0 {
+		window = DefaultWindow
+	}
+	return &Session{
+		rw:     rw,
+		window: window,
+		env:    make(map[string]string),
+		resize: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+// Read reads terminal input from the client. Once the client has
+// disconnected, the channel returned by Done is closed
+func (s *Session) Read(p []byte) (int, error) {
+	n, err := s.rw.Read(p)
+	if err != nil {
+		s.markDone()
+	}
+	return n, err
+}
+
+// Write writes terminal output to the client
+func (s *Session) Write(p []byte) (int, error) {
+	n, err := s.rw.Write(p)
+	if err != nil {
+		s.markDone()
+	}
+	return n, err
+}
+
+// Fd returns an invalid file descriptor. Sessions are not backed by a tty, so
+// the size is always taken from the Window
+func (s *Session) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// SetRaw is a no-op. The client is responsible for putting its own terminal
+// in raw mode
+func (s *Session) SetRaw() error {
+	return nil
+}
+
+// Reset is a no-op. The client is responsible for restoring its own terminal
+func (s *Session) Reset() error {
+	return nil
+}
+
+// Size returns the last reported size of the remote terminal
+func (s *Session) Size() (cols int, rows int, xPixels int, yPixels int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.window.Cols, s.window.Rows, s.window.XPixel, s.window.YPixel, nil
+}
+
+// Close closes the underlying stream. It is safe to call Close more than
+// once
+func (s *Session) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.rw.Close()
+		s.markDone()
+	})
+	return err
+}
+
+// ResizeNotify implements [vaxis.ResizeNotifier]
+func (s *Session) ResizeNotify() <-chan struct{} {
+	return s.resize
+}
+
+// Resize records a new size for the remote terminal. The [vaxis.Vaxis] drawing
+// to the session will deliver a [vaxis.Resize] event
+func (s *Session) Resize(window Window) {
+	if window.Cols <= 0 || window.Rows <= 0 {
+		return
+	}
+	s.mu.Lock()
+	changed := s.window != window
+	s.window = window
+	s.mu.Unlock()
+	if !changed {
+		return
+	}
+	select {
+	case s.resize <- struct{}{}:
+	default:
+	}
+}
+
+// Window returns the last reported size of the remote terminal
+func (s *Session) Window() Window {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.window
+}
+
+// Term returns the TERM value reported by the client, if any
+func (s *Session) Term() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.term
+}
+
+// Env returns the value of an environment variable sent by the client
+func (s *Session) Env(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.env[key]
+}
+
+// getenv looks up the client's environment. TERM comes from the pty-req,
+// when the client sent one
+func (s *Session) getenv(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key == "TERM" && s.term != "" {
+		return s.term
+	}
+	return s.env[key]
+}
+
+// Done returns a channel which is closed when the client disconnects or the
+// session is closed
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Session) markDone() {
+	s.doneOnce.Do(func() {
+		close(s.done)
+	})
+}
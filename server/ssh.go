@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errShortPayload = errors.New("server: short request payload")
+
+// HandleSSHRequest applies an SSH channel request (RFC 4254, section 6) to the
+// session and reports whether it was accepted. It is meant to be called from
+// the request loop of an x/crypto/ssh session channel, with the result passed
+// to Request.Reply:
+//
+//	for req := range reqs {
+//		ok := sess.HandleSSHRequest(req.Type, req.Payload)
+//		if req.Type == "shell" && ok {
+//			go srv.ServeSession(sess)
+//		}
+//		if req.WantReply {
+//			_ = req.Reply(ok, nil)
+//		}
+//	}
+//
+// "pty-req" records the terminal type and size, "window-change" resizes the
+// session, "env" records an environment variable and "shell" is accepted.
+// All other requests are rejected
+func (s *Session) HandleSSHRequest(typ string, payload []byte) bool {
+	switch typ {
+	case "pty-req":
+		term, window, err := ParsePtyRequest(payload)
+		if err != nil {
+			return false
+		}
+		s.mu.Lock()
+		s.term = term
+		s.mu.Unlock()
+		s.Resize(window)
+		return true
+	case "window-change":
+		window, err := ParseWindowChange(payload)
+		if err != nil {
+			return false
+		}
+		s.Resize(window)
+		return true
+	case "env":
+		key, rest, err := readString(payload)
+		if err != nil {
+			return false
+		}
+		value, _, err := readString(rest)
+		if err != nil {
+			return false
+		}
+		s.mu.Lock()
+		s.env[key] = value
+		s.mu.Unlock()
+		return true
+	case "shell":
+		return true
+	default:
+		return false
+	}
+}
+
+// ParsePtyRequest parses the payload of an SSH "pty-req" request, returning the
+// requested TERM value and window size. The encoded terminal modes are
+// ignored
+func ParsePtyRequest(payload []byte) (term string, window Window, err error) {
+	term, rest, err := readString(payload)
+	if err != nil {
+		return "", Window{}, err
+	}
+	window, err = ParseWindowChange(rest)
+	return term, window, err
+}
+
+// ParseWindowChange parses the payload of an SSH "window-change" request
+func ParseWindowChange(payload []byte) (Window, error) {
+	if len(payload) < 16 {
+		return Window{}, errShortPayload
+	}
+	return Window{
+		Cols:   int(binary.BigEndian.Uint32(payload[0:])),
+		Rows:   int(binary.BigEndian.Uint32(payload[4:])),
+		XPixel: int(binary.BigEndian.Uint32(payload[8:])),
+		YPixel: int(binary.BigEndian.Uint32(payload[12:])),
+	}, nil
+}
+
+// readString reads an SSH wire format string: a uint32 length followed by that
+// many bytes
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 4 {
+		return "", nil, errShortPayload
+	}
+	n := binary.BigEndian.Uint32(b)
+	b = b[4:]
+	if uint64(len(b)) < uint64(n) {
+		return "", nil, errShortPayload
+	}
+	return string(b[:n]), b[n:], nil
+}
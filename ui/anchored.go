@@ -0,0 +1,267 @@
+package ui
+
+// AnchorSide is the side of its anchor an Anchored child is placed on.
+type AnchorSide int
+
+const (
+	// AnchorBelow places the child under the anchor with left edges aligned.
+	AnchorBelow AnchorSide = iota
+	// AnchorAbove places the child over the anchor with left edges aligned.
+	AnchorAbove
+	// AnchorRight places the child after the anchor with top edges aligned.
+	AnchorRight
+	// AnchorLeft places the child before the anchor with top edges aligned.
+	AnchorLeft
+)
+
+// Anchored places its child against a rectangle, such as the widget a menu or
+// tooltip belongs to, and fills the space it is given.
+//
+// The child is placed on Side of Anchor. When it doesn't fit there and the
+// opposite side has more room, it flips to the opposite side. A child running
+// past the far edge is aligned to the other edge of the anchor instead, and is
+// finally shifted to stay within bounds. Points outside the child pass
+// through to the widgets below, so Anchored is usually an Overlay entry whose
+// Anchor comes from OverlayController.Rect.
+type Anchored struct {
+	// Anchor is the rectangle the child is placed against, in the coordinates
+	// of the Anchored widget.
+	Anchor Rect
+	// Side is the preferred side of Anchor.
+	Side AnchorSide
+	// Child is laid out loosely within the available space.
+	Child Widget
+
+	// follow replaces Anchor with the rectangle it returns at each layout.
+	follow func() (Rect, bool)
+	// dismiss, when set, is called for presses outside the child and Anchor,
+	// which then no longer pass through.
+	dismiss func()
+}
+
+func (w Anchored) WidgetChild() Widget {
+	return w.Child
+}
+
+func (w Anchored) CreateRenderObject(BuildContext) RenderObject {
+	return &renderAnchored{Anchor: w.Anchor, Side: w.Side, follow: w.follow, dismiss: w.dismiss}
+}
+
+func (w Anchored) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	r := ro.(*renderAnchored)
+	r.follow = w.follow
+	r.dismiss = w.dismiss
+	if r.Anchor != w.Anchor || r.Side != w.Side || w.follow != nil {
+		r.Anchor = w.Anchor
+		r.Side = w.Side
+		r.MarkNeedsLayout()
+	}
+}
+
+type renderAnchored struct {
+	SingleChildRenderObject
+	Anchor  Rect
+	Side    AnchorSide
+	follow  func() (Rect, bool)
+	dismiss func()
+	offset  Offset
+}
+
+func (r *renderAnchored) Layout(ctx LayoutContext, c Constraints) {
+	if r.follow != nil {
+		if anchor, ok := r.follow(); ok {
+			r.Anchor = anchor
+		}
+	}
+	child := r.Child()
+	size := alignOuterSize(c, Size{})
+	if child != nil {
+		child.Layout(ctx, alignChildConstraints(c))
+		cs := child.Base().Size()
+		size = alignOuterSize(c, cs)
+		r.offset = anchoredOffset(size, r.Anchor, cs, r.Side)
+	}
+	r.SetSize(size)
+}
+
+func (r *renderAnchored) DryLayout(ctx LayoutContext, c Constraints) Size {
+	if child := r.Child(); child != nil {
+		return alignOuterSize(c, DryLayout(ctx, child, alignChildConstraints(c)))
+	}
+	return alignOuterSize(c, Size{})
+}
+
+func (r *renderAnchored) Paint(p *Painter, off Offset) {
+	if child := r.Child(); child != nil {
+		child.Paint(p, off.Add(r.offset))
+	}
+}
+
+func (r *renderAnchored) ChildOffset(RenderObject) Offset {
+	return r.offset
+}
+
+func (r *renderAnchored) HitTest(*HitTestResult, Point) bool {
+	return false
+}
+
+func (r *renderAnchored) HitTestSelf(pt Point) bool {
+	if r.dismiss == nil {
+		return false
+	}
+	return !pointInSize(Point{X: pt.X - r.Anchor.X, Y: pt.Y - r.Anchor.Y}, Size{Width: r.Anchor.Width, Height: r.Anchor.Height})
+}
+
+func (r *renderAnchored) HandleEvent(ctx EventContext, ev Event) EventResult {
+	mouse, ok := ev.(Mouse)
+	if !ok || r.dismiss == nil || ctx.Phase() != TargetPhase {
+		return EventIgnored
+	}
+	if mouse.EventType == EventPress {
+		r.dismiss()
+	}
+	return EventHandled
+}
+
+// anchoredOffset places a child of size child against anchor within bounds.
+func anchoredOffset(bounds Size, anchor Rect, child Size, side AnchorSide) Offset {
+	var off Offset
+	switch side {
+	case AnchorAbove, AnchorBelow:
+		above := anchor.Y
+		below := bounds.Height - anchor.Y - anchor.Height
+		if side == AnchorBelow && child.Height > below && above > below {
+			side = AnchorAbove
+		} else if side == AnchorAbove && child.Height > above && below > above {
+			side = AnchorBelow
+		}
+		off.Y = anchor.Y + anchor.Height
+		if side == AnchorAbove {
+			off.Y = anchor.Y - child.Height
+		}
+		off.X = anchor.X
+		if off.X+child.Width > bounds.Width {
+			off.X = anchor.X + anchor.Width - child.Width
+		}
+	default:
+		before := anchor.X
+		after := bounds.Width - anchor.X - anchor.Width
+		if side == AnchorRight && child.Width > after && before > after {
+			side = AnchorLeft
+		} else if side == AnchorLeft && child.Width > before && after > before {
+			side = AnchorRight
+		}
+		off.X = anchor.X + anchor.Width
+		if side == AnchorLeft {
+			off.X = anchor.X - child.Width
+		}
+		off.Y = anchor.Y
+		if off.Y+child.Height > bounds.Height {
+			off.Y = anchor.Y + anchor.Height - child.Height
+		}
+	}
+	off.X = clampInt(off.X, 0, max(0, bounds.Width-child.Width))
+	off.Y = clampInt(off.Y, 0, max(0, bounds.Height-child.Height))
+	return off
+}
+
+// anchoredPopup shows a widget in the nearest Overlay, anchored to the widget
+// owning it. The entry is inserted once and rebuilt in place as the owner
+// rebuilds, so showing it from Build doesn't rebuild the Overlay each time.
+// The anchor is found again each time the entry is laid out, after the
+// owner's own layout.
+type anchoredPopup struct {
+	entry   *OverlayEntryHandle
+	popup   *anchoredPopupState
+	owner   *StateBase
+	overlay *OverlayController
+	side    AnchorSide
+	child   Widget
+	// onDismiss, when set, is called for presses outside the popup and the
+	// owner, which are kept from the widgets below.
+	onDismiss func()
+}
+
+// show places child on side of the widget at owner's context.
+func (a *anchoredPopup) show(owner *StateBase, side AnchorSide, child Widget) {
+	overlay := OverlayOf(owner.Context())
+	if !overlay.Attached() {
+		a.hide()
+		return
+	}
+	if a.overlay != overlay {
+		a.hide()
+	}
+	a.owner, a.overlay, a.side, a.child = owner, overlay, side, child
+	if a.entry.Mounted() {
+		if a.popup != nil {
+			a.popup.element.MarkNeedsBuild()
+		}
+		return
+	}
+	a.entry = overlay.Insert(OverlayEntry{Child: anchoredPopupWidget{popup: a}})
+}
+
+func (a *anchoredPopup) hide() {
+	a.entry.Remove()
+	a.entry = nil
+}
+
+// focused reports whether focus is inside the shown widget.
+func (a *anchoredPopup) focused() bool {
+	return a.popup != nil && a.popup.element.owner != nil && a.popup.element.owner.app.focusedWithin(a.popup.element)
+}
+
+// anchor returns the owner's bounds in the overlay.
+func (a *anchoredPopup) anchor() (Rect, bool) {
+	if a.owner == nil || a.owner.element == nil || a.owner.element.owner == nil {
+		return Rect{}, false
+	}
+	return a.overlay.Rect(a.owner.Context())
+}
+
+type anchoredPopupWidget struct {
+	popup *anchoredPopup
+}
+
+func (w anchoredPopupWidget) CreateState() State {
+	return &anchoredPopupState{}
+}
+
+type anchoredPopupState struct {
+	StateBase
+}
+
+func (s *anchoredPopupState) Dispose() {
+	if popup := s.Widget().(anchoredPopupWidget).popup; popup.popup == s {
+		popup.popup = nil
+	}
+}
+
+// focusWithinChanged rebuilds the owner, which may stay shown while focus is
+// inside the popup.
+func (s *anchoredPopupState) focusWithinChanged() {
+	if owner := s.Widget().(anchoredPopupWidget).popup.owner; owner != nil && owner.element != nil {
+		owner.element.MarkNeedsBuild()
+	}
+}
+
+func (s *anchoredPopupState) Build(BuildContext) Widget {
+	popup := s.Widget().(anchoredPopupWidget).popup
+	popup.popup = s
+	return Anchored{Side: popup.side, Child: popup.child, follow: popup.anchor, dismiss: popup.onDismiss}
+}
+
+// popupDismissBindings binds DismissIntent to dismiss while a popup is shown.
+// Otherwise it binds nothing, so Escape reaches the surfaces further up.
+func popupDismissBindings(shown bool, dismiss func()) map[IntentType]ActionFunc {
+	if !shown {
+		return nil
+	}
+	return map[IntentType]ActionFunc{
+		DismissIntentType: func(EventContext, Intent) EventResult {
+			dismiss()
+			return EventHandled
+		},
+	}
+}
@@ -146,6 +146,18 @@ func (c *AnimationController) tick(now time.Time) bool {
 	return wasDirty || c.value != old
 }
 
+// complete jumps a running animation to its end value.
+func (c *AnimationController) complete() {
+	if c.disposed || c.status != AnimationForward {
+		return
+	}
+	c.value = 1
+	c.status = AnimationCompleted
+	c.dirty = true
+	c.unregister()
+	c.requestBuild()
+}
+
 func (c *AnimationController) dispose() {
 	if c.disposed {
 		return
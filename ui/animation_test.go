@@ -123,3 +123,39 @@ func frameText(p *ui.Painter) string {
 	}
 	return out
 }
+
+func TestAnimationSkippedWhileBandwidthSaturated(t *testing.T) {
+	now := time.Unix(10, 0)
+	backend := newFakeBackend(ui.Size{Width: 4, Height: 1})
+	var controller *ui.AnimationController
+	runner := ui.NewRunner(ui.NewApp(autoAnimationWidget{
+		Start:      now,
+		Duration:   time.Second,
+		Controller: &controller,
+	}), backend, ui.NewFrameScheduler(time.Second/60))
+	runner.Start(now)
+	if err := runner.HandleFrame(now); err != nil {
+		t.Fatal(err)
+	}
+
+	runner.HandleEvent(ui.BandwidthUpdate{Saturated: true}, now)
+	if err := runner.HandleFrame(now.Add(100 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if got := frameText(backend.frames[len(backend.frames)-1]); got != "1.00" {
+		t.Fatalf("saturated frame = %q, want 1.00", got)
+	}
+	if controller.Status() != ui.AnimationCompleted {
+		t.Fatalf("status = %v, want completed", controller.Status())
+	}
+
+	// Animations run again once the link recovers
+	runner.HandleEvent(ui.BandwidthUpdate{Saturated: false}, now)
+	controller.ForwardAt(now.Add(time.Second))
+	if err := runner.HandleFrame(now.Add(1500 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if got := frameText(backend.frames[len(backend.frames)-1]); got != "0.50" {
+		t.Fatalf("recovered frame = %q, want 0.50", got)
+	}
+}
@@ -170,6 +170,13 @@ func (a *App) tickAnimations(now time.Time) {
 	}
 }
 
+// completeAnimations jumps every running animation to its end
+func (a *App) completeAnimations() {
+	for controller := range a.animations {
+		controller.complete()
+	}
+}
+
 func (a *App) tickFrameCallbacks(now time.Time) bool {
 	if a.build.root == nil {
 		return false
@@ -533,6 +540,7 @@ func (a *App) unregisterFocusTarget(target focusTarget) {
 		old := a.focused
 		a.focused = focusTarget{}
 		a.notifyFocusChanged(old)
+		a.notifyFocusWithin(old.element)
 		if a.build.building {
 			a.pendingFocusFallback = true
 			if removed < 0 {
@@ -660,6 +668,9 @@ func (a *App) focusablesInTreeOrder(root element) []focusTarget {
 	var out []focusTarget
 	var walk func(element)
 	walk = func(e element) {
+		if scope, ok := e.Base().widget.(FocusScope); ok && scope.SkipTraversal && e != root {
+			return
+		}
 		if _, ok := registered[focusTarget{element: e, index: elementFocusIndex}]; ok {
 			out = append(out, focusTarget{element: e, index: elementFocusIndex})
 		}
@@ -677,6 +688,15 @@ func (a *App) focusablesInTreeOrder(root element) []focusTarget {
 	return out
 }
 
+func (a *App) hasFocusTarget(target focusTarget) bool {
+	for _, existing := range a.focusables {
+		if existing == target {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *App) focusedWithin(scope element) bool {
 	return a.focused.element != nil && elementContains(scope, a.focused.element)
 }
@@ -712,6 +732,27 @@ func (a *App) setFocused(next focusTarget) {
 	a.focused = next
 	a.notifyFocusChanged(old)
 	a.notifyFocusChanged(next)
+	a.notifyFocusWithin(old.element, next.element)
+}
+
+// focusWithinObserver is a State told when focus moves into, out of, or
+// within its subtree.
+type focusWithinObserver interface {
+	focusWithinChanged()
+}
+
+// notifyFocusWithin tells the observing ancestors of each element that focus
+// moved.
+func (a *App) notifyFocusWithin(elements ...element) {
+	for _, e := range elements {
+		for ; e != nil; e = e.Base().parent {
+			if stateful, ok := e.(*statefulElement); ok {
+				if observer, ok := stateful.state.(focusWithinObserver); ok {
+					observer.focusWithinChanged()
+				}
+			}
+		}
+	}
 }
 
 func (a *App) notifyFocusChanged(target focusTarget) {
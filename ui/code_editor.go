@@ -0,0 +1,775 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultCodeEditorTabWidth = 4
+	// codeEditorBracketScanLimit is how many characters bracket matching
+	// looks through for the matching bracket.
+	codeEditorBracketScanLimit = 20000
+)
+
+// DiagnosticSeverity orders diagnostics from the most severe.
+type DiagnosticSeverity int
+
+const (
+	DiagnosticError DiagnosticSeverity = iota
+	DiagnosticWarning
+	DiagnosticInfo
+)
+
+// Diagnostic is a message about a line of a CodeEditor, such as an error from
+// a compiler or linter.
+type Diagnostic struct {
+	// Line is the line the message is about, counting from zero.
+	Line     int
+	Severity DiagnosticSeverity
+	Message  string
+}
+
+// CodeEditor is a multiline editor for source code and other long text.
+//
+// The text is kept in a CodeEditorController, whose buffer stores it in a
+// rope, so edits and line lookups stay fast in long documents, and only the
+// lines in view are laid out and painted. A gutter shows line numbers and
+// marks the lines with Diagnostics; the most severe diagnostic of a line is
+// shown after its text. Lines don't wrap unless SoftWrap is set: the view
+// scrolls sideways to follow the cursor, and with the horizontal mouse wheel.
+// Tabs are kept in the text and drawn to the next multiple of TabWidth.
+//
+// Editing keys are the same as TextArea's. In addition, Tab and Shift+Tab
+// indent and outdent the selected lines instead of moving focus, Enter keeps
+// the indentation of the line and indents after an opening bracket, Page Up
+// and Page Down move the cursor by a page, Ctrl+Home and Ctrl+End move it to
+// the start and end of the text, and Ctrl+G asks for a line to go to, as
+// "line" or "line:column" counting from one. The bracket at or before the
+// cursor and its match are highlighted.
+//
+// CodeEditor fills the height it is given, which must be bounded.
+type CodeEditor struct {
+	// Controller holds the text. When nil, the editor keeps its own, which
+	// starts empty.
+	Controller *CodeEditorController
+	// Highlighter styles the text, such as with SyntaxHighlighter. Keep the
+	// same highlighter between builds: a different one highlights every line
+	// again.
+	Highlighter TextHighlighter
+	// Diagnostics mark lines in the gutter.
+	Diagnostics []Diagnostic
+	// TabWidth is the distance between tab stops. Zero means 4.
+	TabWidth int
+	// IndentWithTabs makes Tab and auto-indent insert tabs instead of
+	// spaces.
+	IndentWithTabs bool
+	// SoftWrap wraps long lines to the width of the view.
+	SoftWrap bool
+	// HideGutter hides the line numbers and diagnostic markers.
+	HideGutter bool
+	// AutoFocus requests focus when the editor is mounted.
+	AutoFocus bool
+	// HistoryLimit is the number of edits Ctrl+Z can undo when greater than
+	// zero. Zero keeps 100 and a negative limit turns undo off.
+	HistoryLimit int
+	// OnChanged is called after an edit. Read the text from the controller.
+	OnChanged func(EventContext)
+}
+
+func (w CodeEditor) CreateState() State {
+	return &codeEditorState{}
+}
+
+// CodeEditorController holds the text, cursor and undo history of the
+// CodeEditor it is attached to, so the text doesn't have to pass through each
+// build. The zero value is an empty document. Line breaks are stored as \n:
+// SetText turns \r\n into \n.
+type CodeEditorController struct {
+	buffer    TextBuffer
+	listeners map[any]codeEditorListener
+}
+
+// NewCodeEditorController returns a controller holding text.
+func NewCodeEditorController(text string) *CodeEditorController {
+	c := &CodeEditorController{}
+	c.SetText(text)
+	return c
+}
+
+// codeEditorListener is how an attached editor hears about changes: lines for
+// edits made by any editor, and changed when the controller moved the cursor
+// and wants it revealed.
+type codeEditorListener struct {
+	lines   func(line, removed, inserted int)
+	changed func(reveal ScrollAlign)
+}
+
+func (c *CodeEditorController) attach(owner any, listener codeEditorListener) {
+	if c.listeners == nil {
+		c.listeners = make(map[any]codeEditorListener)
+	}
+	c.listeners[owner] = listener
+}
+
+func (c *CodeEditorController) detach(owner any) {
+	delete(c.listeners, owner)
+}
+
+// buf returns the buffer, set up to keep tabs and report the lines edits
+// replace.
+func (c *CodeEditorController) buf() *TextBuffer {
+	if c.buffer.onLinesChanged == nil {
+		c.buffer.keepTabs = true
+		c.buffer.onLinesChanged = c.linesChanged
+	}
+	return &c.buffer
+}
+
+func (c *CodeEditorController) linesChanged(line, removed, inserted int) {
+	for _, listener := range c.listeners {
+		listener.lines(line, removed, inserted)
+	}
+}
+
+func (c *CodeEditorController) notify(reveal ScrollAlign) {
+	for _, listener := range c.listeners {
+		listener.changed(reveal)
+	}
+}
+
+// Attached reports whether the controller is attached to a mounted
+// CodeEditor.
+func (c *CodeEditorController) Attached() bool {
+	return c != nil && len(c.listeners) > 0
+}
+
+// Text returns the whole text.
+func (c *CodeEditorController) Text() string {
+	if c == nil {
+		return ""
+	}
+	return c.buf().Text()
+}
+
+// SetText replaces the text and clears the undo history. The cursor keeps its
+// offset where the text is long enough.
+func (c *CodeEditorController) SetText(text string) {
+	if c == nil {
+		return
+	}
+	c.buf().SetText(strings.ReplaceAll(text, "\r\n", "\n"))
+	c.notify(ScrollAlignNearest)
+}
+
+// LineCount returns the number of lines, which is at least one.
+func (c *CodeEditorController) LineCount() int {
+	if c == nil {
+		return 1
+	}
+	return c.buf().lineCount()
+}
+
+// Line returns a line without its line break, counting from zero, or "" when
+// there is no such line.
+func (c *CodeEditorController) Line(line int) string {
+	if c == nil || line < 0 || line >= c.LineCount() {
+		return ""
+	}
+	return c.buf().lineText(line)
+}
+
+// Cursor returns the line and column of the cursor.
+func (c *CodeEditorController) Cursor() TextCursor {
+	if c == nil {
+		return TextCursor{}
+	}
+	return c.buf().Cursor()
+}
+
+// SetCursor moves the cursor, clearing the selection, and scrolls it into
+// view.
+func (c *CodeEditorController) SetCursor(cursor TextCursor) {
+	if c == nil {
+		return
+	}
+	c.buf().SetCursor(cursor)
+	c.notify(ScrollAlignNearest)
+}
+
+// Selection returns the selection, whose extent is the cursor.
+func (c *CodeEditorController) Selection() TextSelection {
+	if c == nil {
+		return TextSelection{}
+	}
+	return c.buf().Selection()
+}
+
+// SetSelection selects text and scrolls the cursor into view. It returns
+// false when a position is not on a character boundary.
+func (c *CodeEditorController) SetSelection(selection TextSelection) bool {
+	if c == nil || !c.buf().SetSelection(selection) {
+		return false
+	}
+	c.notify(ScrollAlignNearest)
+	return true
+}
+
+// SelectedText returns the selected text.
+func (c *CodeEditorController) SelectedText() string {
+	if c == nil {
+		return ""
+	}
+	return c.buf().SelectedText()
+}
+
+// GoToLine moves the cursor to the start of line, counting from zero, and
+// scrolls it to the middle of the view. It returns false when there is no
+// such line.
+func (c *CodeEditorController) GoToLine(line int) bool {
+	return c.goTo(TextCursor{Line: line})
+}
+
+func (c *CodeEditorController) goTo(cursor TextCursor) bool {
+	if c == nil || cursor.Line < 0 || cursor.Line >= c.LineCount() {
+		return false
+	}
+	c.buf().SetCursor(cursor)
+	c.notify(ScrollAlignCenter)
+	return true
+}
+
+// codeEditorControllerLink attaches a CodeEditor state to its controller, or
+// to a controller of its own when the widget has none.
+type codeEditorControllerLink struct {
+	own        CodeEditorController
+	controller *CodeEditorController
+}
+
+func (l *codeEditorControllerLink) attach(c *CodeEditorController, owner any, listener codeEditorListener) {
+	if c == nil {
+		c = &l.own
+	}
+	c.attach(owner, listener)
+	l.controller = c
+}
+
+func (l *codeEditorControllerLink) detach(owner any) {
+	if l.controller != nil {
+		l.controller.detach(owner)
+	}
+	l.controller = nil
+}
+
+type codeEditorState struct {
+	StateBase
+	link        codeEditorControllerLink
+	editor      textEditorState
+	highlight   textHighlightCache
+	rows        codeEditorRows
+	diagnostics map[int]Diagnostic
+	tabWidth    int
+	// wrap is the width lines wrap at, or zero when they don't.
+	wrap      int
+	scrollCol int
+	// reveal scrolls the cursor into view at the next layout.
+	reveal   pendingSliverReveal
+	view     codeEditorView
+	revision int
+	goTo     codeEditorGoTo
+}
+
+// codeEditorView is the last layout of the lines in view, for the mouse and
+// paging.
+type codeEditorView struct {
+	lines        []codeEditorLine
+	scrollOffset int
+	height       int
+	gutter       int
+	textWidth    int
+	// width is the widest line in view, plus a column for the cursor.
+	width int
+}
+
+// codeEditorGoTo is the state of the go-to-line bar.
+type codeEditorGoTo struct {
+	open    bool
+	text    string
+	invalid bool
+}
+
+func (s *codeEditorState) InitState() {
+	s.attach(s.Widget().(CodeEditor).Controller)
+}
+
+func (s *codeEditorState) DidUpdateWidget(old Widget) {
+	if next := s.Widget().(CodeEditor).Controller; next != old.(CodeEditor).Controller {
+		s.link.detach(s)
+		s.attach(next)
+	}
+}
+
+func (s *codeEditorState) Dispose() {
+	s.link.detach(s)
+}
+
+func (s *codeEditorState) attach(c *CodeEditorController) {
+	s.link.attach(c, s, codeEditorListener{lines: s.linesChanged, changed: s.controllerChanged})
+	s.editor.UseBuffer(s.link.controller.buf())
+	// The caches describe the old text
+	s.highlight = textHighlightCache{generation: s.highlight.generation}
+	s.rows = codeEditorRows{}
+	s.reveal = pendingSliverReveal{Align: ScrollAlignNearest, Active: true}
+}
+
+func (s *codeEditorState) buffer() *TextBuffer {
+	return s.link.controller.buf()
+}
+
+func (s *codeEditorState) linesChanged(line, removed, inserted int) {
+	s.highlight.splice(line, removed, inserted)
+	s.rows.splice(line, removed, inserted)
+	s.MarkNeedsBuild()
+}
+
+func (s *codeEditorState) controllerChanged(reveal ScrollAlign) {
+	s.reveal = pendingSliverReveal{Align: reveal, Active: true}
+	s.MarkNeedsBuild()
+}
+
+func (s *codeEditorState) Build(ctx BuildContext) Widget {
+	w := s.Widget().(CodeEditor)
+	s.editor.SetHistoryLimit(w.HistoryLimit)
+	s.editor.SetFocusChange(s.MarkNeedsBuild)
+	s.highlight.reset(w.Highlighter, s.buffer().lineCount())
+	s.diagnostics = codeEditorDiagnostics(w.Diagnostics)
+	s.tabWidth = codeEditorTabWidth(w)
+	s.revision++
+	appTheme := MustDepend[Theme](ctx)
+	theme := codeEditorTheme(appTheme)
+	var child Widget = s.editor.Focus(FocusScope{
+		SkipTraversal: true,
+		Child: CustomScrollView{Slivers: []Widget{codeEditorLines{
+			State:     s,
+			Revision:  s.revision,
+			Theme:     theme,
+			Syntax:    syntaxTheme(appTheme),
+			Highlight: s.highlight.generation,
+			SoftWrap:  w.SoftWrap,
+			Gutter:    !w.HideGutter,
+			Focused:   s.editor.HasFocus(),
+		}}},
+	})
+	if w.AutoFocus {
+		child = autoFocus{Child: child}
+	}
+	child = Actions{
+		Bindings: map[IntentType]ActionFunc{
+			NextFocusIntentType: func(ctx EventContext, intent Intent) EventResult {
+				return s.indent(ctx, false)
+			},
+			PreviousFocusIntentType: func(ctx EventContext, intent Intent) EventResult {
+				return s.indent(ctx, true)
+			},
+		},
+		Child: s.editor.DefaultActions(s.handleOptions(), child),
+	}
+	children := []Widget{Expanded(child)}
+	if s.goTo.open {
+		children = append(children, s.goToBar(theme))
+	}
+	return Column(children...)
+}
+
+func (s *codeEditorState) HandleEvent(ctx EventContext, ev Event) EventResult {
+	if ctx.Phase() != TargetPhase && ctx.Phase() != BubblePhase {
+		return EventIgnored
+	}
+	switch ev := ev.(type) {
+	case Key:
+		// Keys the go-to-line bar leaves unhandled aren't for the text
+		if !s.editor.HasFocus() {
+			return EventIgnored
+		}
+		if s.handleKey(ev) {
+			return EventHandled
+		}
+	case Mouse:
+		if ev.EventType == EventPress && (ev.Button == MouseWheelLeft || ev.Button == MouseWheelRight) {
+			return s.scrollSideways(ev.Button == MouseWheelRight)
+		}
+		if ev.EventType == EventPress && ev.Row >= s.view.height {
+			return EventIgnored
+		}
+	}
+	return s.editor.HandleEvent(ctx, ev, s.handleOptions())
+}
+
+func (s *codeEditorState) MouseShape(EventContext, Mouse) MouseShape {
+	return MouseShapeTextInput
+}
+
+func (s *codeEditorState) handleOptions() textEditorHandleOptions {
+	return textEditorHandleOptions{
+		insertMode:       textEditorMultiline,
+		markNeedsBuild:   s.cursorMoved,
+		changed:          s.changed,
+		positionForMouse: s.positionForMouse,
+		moveUp:           func() bool { return s.moveRows(-1, false) },
+		moveDown:         func() bool { return s.moveRows(1, false) },
+		extendUp:         func() bool { return s.moveRows(-1, true) },
+		extendDown:       func() bool { return s.moveRows(1, true) },
+		insertLineBreak:  s.insertLineBreak,
+	}
+}
+
+// cursorMoved scrolls the cursor into view.
+func (s *codeEditorState) cursorMoved() {
+	s.reveal = pendingSliverReveal{Align: ScrollAlignNearest, Active: true}
+	s.MarkNeedsBuild()
+}
+
+func (s *codeEditorState) changed(ctx EventContext) {
+	s.cursorMoved()
+	if w := s.Widget().(CodeEditor); w.OnChanged != nil {
+		w.OnChanged(ctx)
+	}
+}
+
+// handleKey handles the keys CodeEditor adds to TextArea's, and reports
+// whether key was one.
+func (s *codeEditorState) handleKey(key Key) bool {
+	if keyIsRelease(key) {
+		return false
+	}
+	b := s.buffer()
+	page := max(1, s.view.height-1)
+	switch {
+	case key.MatchString("Ctrl+g"):
+		s.SetState(func() { s.goTo = codeEditorGoTo{open: true} })
+	case key.MatchString("Ctrl+Home"), key.MatchString("Ctrl+Shift+Home"):
+		b.setCursorOffset(0, key.MatchString("Ctrl+Shift+Home"))
+		b.clearPreferredColumn()
+		s.cursorMoved()
+	case key.MatchString("Ctrl+End"), key.MatchString("Ctrl+Shift+End"):
+		b.setCursorOffset(b.Len(), key.MatchString("Ctrl+Shift+End"))
+		b.clearPreferredColumn()
+		s.cursorMoved()
+	case key.Keycode == KeyPgUp:
+		if s.moveRows(-page, key.MatchString("Shift+Page_Up")) {
+			s.cursorMoved()
+		}
+	case key.Keycode == KeyPgDown:
+		if s.moveRows(page, key.MatchString("Shift+Page_Down")) {
+			s.cursorMoved()
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *codeEditorState) scrollSideways(right bool) EventResult {
+	if s.wrap > 0 {
+		return EventIgnored
+	}
+	next := s.scrollCol - 1
+	if right {
+		next = min(s.scrollCol+1, max(0, s.view.width-s.view.textWidth))
+	}
+	next = max(0, next)
+	if next != s.scrollCol {
+		s.SetState(func() { s.scrollCol = next })
+	}
+	return EventHandled
+}
+
+// layoutLine lays out line as it is painted.
+func (s *codeEditorState) layoutLine(line int) codeEditorLine {
+	text := s.buffer().text
+	start, end := text.lineStart(line), text.lineEnd(line)
+	return layoutCodeEditorLine(line, start, text.slice(start, end), s.tabWidth, s.wrap)
+}
+
+func (s *codeEditorState) positionForMouse(mouse Mouse) (TextPosition, bool) {
+	lines := s.view.lines
+	if len(lines) == 0 {
+		return TextPosition{}, true
+	}
+	row := mouse.Row + s.view.scrollOffset
+	col := mouse.Col - s.view.gutter + s.scrollCol
+	l := lines[len(lines)-1]
+	for _, candidate := range lines {
+		if row < candidate.top+candidate.rowCount() {
+			l = candidate
+			break
+		}
+	}
+	// The text may have changed since the view was laid out
+	row -= l.top
+	if l.line >= s.buffer().lineCount() {
+		return s.buffer().positionForOffset(s.buffer().Len()), true
+	}
+	l = s.layoutLine(l.line)
+	offset := l.start + l.indexAt(clampInt(row, 0, l.rowCount()-1), col)
+	return s.buffer().positionForOffset(offset), true
+}
+
+// moveRows moves the cursor by delta rows as they are shown, keeping its
+// column.
+func (s *codeEditorState) moveRows(delta int, extend bool) bool {
+	b := s.buffer()
+	offset := b.CursorOffset()
+	line := b.text.lineOf(offset)
+	l := s.layoutLine(line)
+	i := offset - l.start
+	row := l.rows[i] + delta
+	col := b.verticalColumn(l.cols[i])
+	for row < 0 && line > 0 {
+		line--
+		l = s.layoutLine(line)
+		row += l.rowCount()
+	}
+	for row >= l.rowCount() && line < b.lineCount()-1 {
+		row -= l.rowCount()
+		line++
+		l = s.layoutLine(line)
+	}
+	next := l.start + l.indexAt(clampInt(row, 0, l.rowCount()-1), col)
+	if next == offset {
+		return false
+	}
+	b.setCursorOffset(next, extend)
+	return true
+}
+
+// indentUnit returns what Tab inserts at display column col.
+func (s *codeEditorState) indentUnit(col int) string {
+	if s.Widget().(CodeEditor).IndentWithTabs {
+		return "\t"
+	}
+	return strings.Repeat(" ", s.tabWidth-col%s.tabWidth)
+}
+
+// indent indents the selected lines, or outdents them, as one undo step.
+// Without a selection across lines, Tab inserts an indent at the cursor.
+func (s *codeEditorState) indent(ctx EventContext, outdent bool) EventResult {
+	b := s.buffer()
+	start, end := b.selectionOffsets()
+	first, last := b.text.lineOf(start), b.text.lineOf(end)
+	if !outdent && first == last {
+		l := layoutCodeEditorLine(first, b.text.lineStart(first), b.text.slice(b.text.lineStart(first), start), s.tabWidth, 0)
+		insert := b.characters(s.indentUnit(l.cols[len(l.chars)]))
+		b.replace(start, end, insert, start+len(insert), textEditOther)
+		s.changed(ctx)
+		return EventHandled
+	}
+	if last > first && end == b.text.lineStart(last) {
+		// A selection ending at the start of a line leaves that line alone
+		last--
+	}
+	from, to := b.text.lineStart(first), b.text.lineEnd(last)
+	type lineEdit struct{ at, removed, inserted int }
+	var edits []lineEdit
+	var out []Character
+	unit := b.characters(s.indentUnit(0))
+	chars := b.text.slice(from, to)
+	for lineStart := 0; lineStart <= len(chars); {
+		n := lineStart
+		for n < len(chars) && chars[n].Grapheme != "\n" {
+			n++
+		}
+		line, at := chars[lineStart:n], from+lineStart
+		if outdent {
+			removed := codeEditorOutdent(line, s.tabWidth)
+			if removed > 0 {
+				edits = append(edits, lineEdit{at: at, removed: removed})
+			}
+			out = append(out, line[removed:]...)
+		} else {
+			edits = append(edits, lineEdit{at: at, inserted: len(unit)})
+			out = append(append(out, unit...), line...)
+		}
+		if n < len(chars) {
+			out = append(out, chars[n])
+		}
+		lineStart = n + 1
+	}
+	if len(edits) == 0 {
+		return EventHandled
+	}
+	moved := func(offset int) int {
+		shift := 0
+		for _, e := range edits {
+			// A selection starting at the start of a line keeps the indent
+			if offset < e.at || offset == e.at && offset == start && end > start {
+				break
+			}
+			if offset < e.at+e.removed {
+				return e.at + shift
+			}
+			shift += e.inserted - e.removed
+		}
+		return offset + shift
+	}
+	b.replaceSelecting(from, to, out, moved(clampInt(b.anchor, 0, b.Len())), moved(b.CursorOffset()), textEditOther)
+	s.changed(ctx)
+	return EventHandled
+}
+
+// codeEditorOutdent returns how many characters of indentation to remove from
+// the start of line: a tab, or up to tabWidth spaces.
+func codeEditorOutdent(line []Character, tabWidth int) int {
+	if len(line) > 0 && line[0].Grapheme == "\t" {
+		return 1
+	}
+	n := 0
+	for n < len(line) && n < tabWidth && line[n].Grapheme == " " {
+		n++
+	}
+	return n
+}
+
+// insertLineBreak starts a new line with the indentation of the current one,
+// indenting further after an opening bracket. A closing bracket right after
+// the cursor moves to a line of its own.
+func (s *codeEditorState) insertLineBreak() bool {
+	b := s.buffer()
+	start, end := b.selectionOffsets()
+	lineStart := b.text.lineStart(b.text.lineOf(start))
+	before := b.text.slice(lineStart, start)
+	indent := 0
+	for indent < len(before) && (before[indent].Grapheme == " " || before[indent].Grapheme == "\t") {
+		indent++
+	}
+	insert := append([]Character{{Grapheme: "\n", Width: 0}}, before[:indent]...)
+	cursor := len(insert)
+	last := len(before) - 1
+	for last >= indent && strings.TrimSpace(before[last].Grapheme) == "" {
+		last--
+	}
+	if last >= indent {
+		if closer, ok := codeEditorClosers[before[last].Grapheme]; ok {
+			col := layoutCodeEditorLine(0, 0, before[:indent], s.tabWidth, 0).cols[indent]
+			insert = append(insert, b.characters(s.indentUnit(col))...)
+			cursor = len(insert)
+			if end < b.Len() && b.text.at(end).Grapheme == closer {
+				insert = append(append(insert, Character{Grapheme: "\n"}), before[:indent]...)
+			}
+		}
+	}
+	b.replace(start, end, insert, start+cursor, textEditOther)
+	return true
+}
+
+func (s *codeEditorState) goToBar(theme CodeEditorTheme) Widget {
+	label := Text{Value: " Go to line: ", Style: theme.Panel}
+	if s.goTo.invalid {
+		label.Style = mergeStyle(theme.Panel, theme.Error)
+	}
+	return Actions{
+		Bindings: popupDismissBindings(true, s.closeGoTo),
+		Child: DecoratedBox(Decoration{Style: theme.Panel}, Row(
+			label,
+			Expanded(TextField{
+				Value:       s.goTo.text,
+				Placeholder: "line[:column]",
+				AutoFocus:   true,
+				OnChanged: func(ctx EventContext, text string) {
+					s.SetState(func() { s.goTo.text, s.goTo.invalid = text, false })
+				},
+				OnSubmitted: s.submitGoTo,
+			}),
+		)),
+	}
+}
+
+func (s *codeEditorState) submitGoTo(ctx EventContext, text string) {
+	cursor, ok := parseCodeEditorGoTo(text)
+	if !ok || !s.link.controller.goTo(cursor) {
+		s.SetState(func() { s.goTo.invalid = true })
+		return
+	}
+	s.closeGoTo()
+}
+
+func (s *codeEditorState) closeGoTo() {
+	s.SetState(func() { s.goTo = codeEditorGoTo{} })
+	s.editor.node.RequestFocus()
+}
+
+// parseCodeEditorGoTo parses "line" or "line:column", counting from one.
+func parseCodeEditorGoTo(text string) (TextCursor, bool) {
+	lineText, colText, hasCol := strings.Cut(strings.TrimSpace(text), ":")
+	line, err := strconv.Atoi(strings.TrimSpace(lineText))
+	if err != nil || line < 1 {
+		return TextCursor{}, false
+	}
+	col := 1
+	if hasCol {
+		col, err = strconv.Atoi(strings.TrimSpace(colText))
+		if err != nil || col < 1 {
+			return TextCursor{}, false
+		}
+	}
+	return TextCursor{Line: line - 1, Column: col - 1}, true
+}
+
+// codeEditorDiagnostics returns the most severe diagnostic of each line.
+func codeEditorDiagnostics(diagnostics []Diagnostic) map[int]Diagnostic {
+	if len(diagnostics) == 0 {
+		return nil
+	}
+	byLine := make(map[int]Diagnostic, len(diagnostics))
+	for _, d := range diagnostics {
+		if prev, ok := byLine[d.Line]; !ok || d.Severity < prev.Severity {
+			byLine[d.Line] = d
+		}
+	}
+	return byLine
+}
+
+func codeEditorTabWidth(w CodeEditor) int {
+	if w.TabWidth > 0 {
+		return w.TabWidth
+	}
+	return defaultCodeEditorTabWidth
+}
+
+var (
+	codeEditorClosers = map[string]string{"(": ")", "[": "]", "{": "}"}
+	codeEditorOpeners = map[string]string{")": "(", "]": "[", "}": "{"}
+)
+
+// codeEditorMatchBracket returns the offsets of the bracket at or just before
+// cursor and of the bracket matching it.
+func codeEditorMatchBracket(text textRope, cursor int) (int, int, bool) {
+	for _, at := range [2]int{cursor, cursor - 1} {
+		if at < 0 || at >= text.Len() {
+			continue
+		}
+		bracket := text.at(at).Grapheme
+		step := 1
+		match, ok := codeEditorClosers[bracket]
+		if !ok {
+			step = -1
+			if match, ok = codeEditorOpeners[bracket]; !ok {
+				continue
+			}
+		}
+		depth := 0
+		for i, n := at, 0; i >= 0 && i < text.Len() && n <= codeEditorBracketScanLimit; i, n = i+step, n+1 {
+			switch text.at(i).Grapheme {
+			case bracket:
+				depth++
+			case match:
+				depth--
+				if depth == 0 {
+					return at, i, true
+				}
+			}
+		}
+		return 0, 0, false
+	}
+	return 0, 0, false
+}
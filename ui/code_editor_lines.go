@@ -0,0 +1,418 @@
+package ui
+
+import "strconv"
+
+// codeEditorLine is one line of a CodeEditor laid out in rows and columns.
+type codeEditorLine struct {
+	line  int
+	start int
+	chars []Character
+	// rows and cols place each character, and the end of the line after
+	// them.
+	rows []int
+	cols []int
+	// top is the row of the sliver the line starts on.
+	top int
+}
+
+// layoutCodeEditorLine lays out the chars of line, which starts at offset
+// start, expanding tabs to tab stops and wrapping at wrap columns when wrap is
+// greater than zero.
+func layoutCodeEditorLine(line, start int, chars []Character, tabWidth, wrap int) codeEditorLine {
+	l := codeEditorLine{
+		line:  line,
+		start: start,
+		chars: chars,
+		rows:  make([]int, len(chars)+1),
+		cols:  make([]int, len(chars)+1),
+	}
+	row, col := 0, 0
+	for i, ch := range chars {
+		width := codeEditorCharWidth(ch, col, tabWidth)
+		if wrap > 0 && col > 0 && col+width > wrap {
+			row, col = row+1, 0
+			width = codeEditorCharWidth(ch, col, tabWidth)
+		}
+		l.rows[i], l.cols[i] = row, col
+		col += width
+	}
+	if wrap > 0 && col >= wrap {
+		row, col = row+1, 0
+	}
+	l.rows[len(chars)], l.cols[len(chars)] = row, col
+	return l
+}
+
+func codeEditorCharWidth(ch Character, col, tabWidth int) int {
+	if ch.Grapheme == "\t" {
+		return tabWidth - col%tabWidth
+	}
+	return max(0, ch.Width)
+}
+
+func (l codeEditorLine) rowCount() int {
+	return l.rows[len(l.rows)-1] + 1
+}
+
+// width returns the columns the line takes without wrapping.
+func (l codeEditorLine) width() int {
+	return l.cols[len(l.cols)-1]
+}
+
+// indexAt returns the index of the character at col of row, or of the nearest
+// position on the row.
+func (l codeEditorLine) indexAt(row, col int) int {
+	index := -1
+	for i := range l.rows {
+		if l.rows[i] > row {
+			break
+		}
+		if l.rows[i] == row && (index < 0 || l.cols[i] <= col) {
+			index = i
+		}
+	}
+	return max(0, index)
+}
+
+// codeEditorRows counts the rows each line of a CodeEditor wraps to. Lines
+// not measured yet count as one.
+type codeEditorRows struct {
+	lines    int
+	wrap     int
+	tabWidth int
+	counts   []int
+}
+
+// sync starts over when the text or its layout no longer matches the counts.
+func (r *codeEditorRows) sync(lines, wrap, tabWidth int) {
+	if r.lines == lines && r.wrap == wrap && r.tabWidth == tabWidth {
+		return
+	}
+	*r = codeEditorRows{lines: lines, wrap: wrap, tabWidth: tabWidth}
+	if wrap > 0 {
+		r.counts = make([]int, lines)
+	}
+}
+
+// splice replaces the counts of removed lines from line with inserted
+// unmeasured ones.
+func (r *codeEditorRows) splice(line, removed, inserted int) {
+	r.lines += inserted - removed
+	if r.counts == nil {
+		return
+	}
+	line = clampInt(line, 0, len(r.counts))
+	removed = clampInt(removed, 0, len(r.counts)-line)
+	counts := make([]int, 0, len(r.counts)-removed+inserted)
+	counts = append(counts, r.counts[:line]...)
+	counts = append(counts, make([]int, inserted)...)
+	r.counts = append(counts, r.counts[line+removed:]...)
+}
+
+func (r *codeEditorRows) measure(line, rows int) {
+	if line >= 0 && line < len(r.counts) {
+		r.counts[line] = rows
+	}
+}
+
+func (r codeEditorRows) extent(line int) int {
+	if line < len(r.counts) && r.counts[line] > 0 {
+		return r.counts[line]
+	}
+	return 1
+}
+
+func (r codeEditorRows) total() int {
+	return r.offset(r.lines)
+}
+
+// offset returns the row line starts on.
+func (r codeEditorRows) offset(line int) int {
+	if r.counts == nil {
+		return line
+	}
+	row := 0
+	for i := 0; i < line; i++ {
+		row += r.extent(i)
+	}
+	return row
+}
+
+// lineAt returns the line showing row, and the row within it.
+func (r codeEditorRows) lineAt(row int) (int, int) {
+	if r.counts == nil {
+		line := clampInt(row, 0, max(0, r.lines-1))
+		return line, row - line
+	}
+	top := 0
+	for i := 0; i < r.lines; i++ {
+		if row < top+r.extent(i) {
+			return i, row - top
+		}
+		top += r.extent(i)
+	}
+	return max(0, r.lines-1), 0
+}
+
+// codeEditorLines is the sliver of a CodeEditor's lines.
+type codeEditorLines struct {
+	State     *codeEditorState
+	Revision  int
+	Theme     CodeEditorTheme
+	Syntax    SyntaxTheme
+	Highlight int
+	SoftWrap  bool
+	Gutter    bool
+	Focused   bool
+}
+
+func (w codeEditorLines) CreateRenderObject(BuildContext) RenderObject {
+	return &renderCodeEditorLines{view: w}
+}
+
+func (w codeEditorLines) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	r := ro.(*renderCodeEditorLines)
+	if r.view != w {
+		r.view = w
+		r.MarkNeedsLayout()
+	}
+}
+
+// renderCodeEditorLines lays out and paints only the lines in view, keeping
+// the layout in the state for the mouse and for moving by rows.
+type renderCodeEditorLines struct {
+	LeafRenderObject
+	view     codeEditorLines
+	brackets [2]int
+	matched  bool
+}
+
+func (r *renderCodeEditorLines) Layout(ctx LayoutContext, c Constraints) {
+	r.LayoutSliver(ctx, SliverConstraints{
+		ViewportWidth:        c.MaxWidth,
+		ViewportHeight:       c.MaxHeight,
+		RemainingPaintExtent: c.MaxHeight,
+	})
+}
+
+func (r *renderCodeEditorLines) LayoutSliver(ctx LayoutContext, c SliverConstraints) SliverGeometry {
+	s := r.view.State
+	b := s.buffer()
+	lineCount := b.lineCount()
+	gutter := 0
+	if r.view.Gutter {
+		gutter = codeEditorGutterWidth(lineCount)
+	}
+	textWidth := max(1, c.ViewportWidth-gutter)
+	s.wrap = 0
+	if r.view.SoftWrap {
+		s.wrap = textWidth
+		s.scrollCol = 0
+	}
+	s.rows.sync(lineCount, s.wrap, s.tabWidth)
+	height := max(0, min(c.ViewportHeight, c.RemainingPaintExtent))
+
+	first, skip := s.rows.lineAt(c.ScrollOffset)
+	top := c.ScrollOffset - skip
+	var lines []codeEditorLine
+	width := 0
+	for line := first; line < lineCount && (top < c.ScrollOffset+height || line == first); line++ {
+		l := s.layoutLine(line)
+		l.top = top
+		s.rows.measure(line, l.rowCount())
+		top += l.rowCount()
+		width = max(width, l.width()+1)
+		lines = append(lines, l)
+	}
+	if len(lines) > 0 {
+		s.highlight.highlight(lines[len(lines)-1].line, b.lineText)
+	}
+	total := s.rows.total()
+	correction := 0
+	if maxScroll := max(0, total-height); c.ScrollOffset > maxScroll {
+		correction = maxScroll - c.ScrollOffset
+	} else if len(lines) > 0 && skip >= lines[0].rowCount() {
+		correction = lines[0].rowCount() - 1 - skip
+	}
+	if s.reveal.Active && correction == 0 {
+		correction = r.revealCursor(lines, c.ScrollOffset, height, textWidth)
+	}
+	r.matched = false
+	if r.view.Focused {
+		r.brackets[0], r.brackets[1], r.matched = codeEditorMatchBracket(b.text, b.CursorOffset())
+	}
+	s.view = codeEditorView{
+		lines:        lines,
+		scrollOffset: c.ScrollOffset,
+		height:       height,
+		gutter:       gutter,
+		textWidth:    textWidth,
+		width:        width,
+	}
+	r.SetSize(Size{Width: c.ViewportWidth, Height: total})
+	return SliverGeometry{
+		ScrollExtent:           total,
+		PaintExtent:            visibleSliverExtent(c, total),
+		ScrollOffsetCorrection: correction,
+	}
+}
+
+// revealCursor scrolls the cursor's column into view and returns the scroll
+// offset correction that brings its row into view. The reveal is done once no
+// correction is needed.
+func (r *renderCodeEditorLines) revealCursor(lines []codeEditorLine, scrollOffset, height, textWidth int) int {
+	s := r.view.State
+	b := s.buffer()
+	offset := b.CursorOffset()
+	line := b.text.lineOf(offset)
+	l, found := codeEditorLine{}, false
+	for _, candidate := range lines {
+		if candidate.line == line {
+			l, found = candidate, true
+		}
+	}
+	if !found {
+		l = s.layoutLine(line)
+		s.rows.measure(line, l.rowCount())
+		l.top = s.rows.offset(line)
+	}
+	row, col := l.top+l.rows[offset-l.start], l.cols[offset-l.start]
+	if s.wrap == 0 {
+		if s.reveal.Align == ScrollAlignCenter {
+			// Jumps show as much of the start of the line as they can
+			s.scrollCol = max(0, col-textWidth+1)
+		} else if col < s.scrollCol {
+			s.scrollCol = col
+		} else if col >= s.scrollCol+textWidth {
+			s.scrollCol = col - textWidth + 1
+		}
+	}
+	target := scrollOffset
+	switch s.reveal.Align {
+	case ScrollAlignCenter:
+		target = row - height/2
+	default:
+		if row < scrollOffset {
+			target = row
+		} else if row >= scrollOffset+height {
+			target = row - height + 1
+		}
+	}
+	target = clampInt(target, 0, max(0, s.rows.total()-height))
+	if target == scrollOffset {
+		s.reveal = pendingSliverReveal{}
+	} else {
+		// Once scrolled, the next layout only checks the row is in view
+		s.reveal.Align = ScrollAlignNearest
+	}
+	return target - scrollOffset
+}
+
+func (r *renderCodeEditorLines) Paint(p *Painter, off Offset) {
+	r.PaintSliver(p, off)
+}
+
+func (r *renderCodeEditorLines) PaintSliver(p *Painter, off Offset) {
+	s := r.view.State
+	view := s.view
+	theme := r.view.Theme
+	b := s.buffer()
+	top := off.Y + view.scrollOffset
+	width := view.gutter + view.textWidth
+	p.Fill(Rect{X: off.X, Y: top, Width: width, Height: view.height}, Cell{Character: Character{Grapheme: " ", Width: 1}, Style: theme.Text})
+	if view.gutter > 0 {
+		p.Fill(Rect{X: off.X, Y: top, Width: view.gutter, Height: view.height}, Cell{Character: Character{Grapheme: " ", Width: 1}, Style: theme.Gutter})
+	}
+	cursor := b.CursorOffset()
+	cursorLine := b.text.lineOf(cursor)
+	selStart, selEnd := b.selectionOffsets()
+	if !r.view.Focused {
+		selStart, selEnd = 0, 0
+	}
+	for _, l := range view.lines {
+		y := off.Y + l.top
+		diagnostic, marked := s.diagnostics[l.line]
+		if view.gutter > 0 {
+			r.paintGutter(p, Offset{X: off.X, Y: y}, l.line, l.line == cursorLine, diagnostic, marked)
+		}
+		p.PushClip(Rect{X: off.X + view.gutter, Y: top, Width: view.textWidth, Height: view.height})
+		x := off.X + view.gutter - s.scrollCol
+		ranges := s.highlight.ranges(l.line)
+		byteCol := 0
+		for i, ch := range l.chars {
+			offset := l.start + i
+			style := theme.Text
+			if ranges != nil {
+				style = mergeStyle(style, highlightStyleAt(ranges, byteCol, r.view.Syntax))
+			}
+			if r.matched && (offset == r.brackets[0] || offset == r.brackets[1]) {
+				style = mergeStyle(style, theme.MatchingBracket)
+			}
+			if offset >= selStart && offset < selEnd {
+				style = mergeStyle(style, theme.Selection)
+			}
+			byteCol += len(ch.Grapheme)
+			pt := Point{X: x + l.cols[i], Y: y + l.rows[i]}
+			if ch.Grapheme == "\t" {
+				blank := Cell{Character: Character{Grapheme: " ", Width: 1}, Style: style}
+				for n := codeEditorCharWidth(ch, l.cols[i], s.tabWidth); n > 0; n-- {
+					p.DrawCell(pt, blank)
+					pt.X++
+				}
+			} else if ch.Width > 0 {
+				p.DrawCell(pt, Cell{Character: ch, Style: style})
+			}
+		}
+		end := len(l.chars)
+		endPt := Point{X: x + l.cols[end], Y: y + l.rows[end]}
+		if l.start+end >= selStart && l.start+end < selEnd {
+			// The selection takes in the line break
+			p.DrawCell(endPt, Cell{Character: Character{Grapheme: " ", Width: 1}, Style: mergeStyle(theme.Text, theme.Selection)})
+		}
+		if marked && diagnostic.Message != "" {
+			p.DrawText(Offset{X: endPt.X + 2, Y: endPt.Y}, diagnostic.Message, mergeStyle(theme.Text, r.severityStyle(diagnostic.Severity)))
+		}
+		p.PopClip()
+		if r.view.Focused && l.line == cursorLine {
+			i := cursor - l.start
+			cx, cy := x+l.cols[i], y+l.rows[i]
+			if cx >= off.X+view.gutter && cx < off.X+width && cy >= top && cy < top+view.height {
+				p.ShowCursor(cx, cy, CursorBeam)
+			}
+		}
+	}
+}
+
+// paintGutter paints the diagnostic marker and number of line.
+func (r *renderCodeEditorLines) paintGutter(p *Painter, off Offset, line int, current bool, diagnostic Diagnostic, marked bool) {
+	theme := r.view.Theme
+	if marked {
+		p.DrawText(off, "●", mergeStyle(theme.Gutter, r.severityStyle(diagnostic.Severity)))
+	}
+	style := theme.Gutter
+	if current {
+		style = mergeStyle(style, theme.CurrentLine)
+	}
+	number := strconv.Itoa(line + 1)
+	p.DrawText(Offset{X: off.X + r.view.State.view.gutter - 1 - len(number), Y: off.Y}, number, style)
+}
+
+func (r *renderCodeEditorLines) severityStyle(severity DiagnosticSeverity) Style {
+	switch severity {
+	case DiagnosticError:
+		return r.view.Theme.Error
+	case DiagnosticWarning:
+		return r.view.Theme.Warning
+	}
+	return r.view.Theme.Info
+}
+
+func (r *renderCodeEditorLines) HitTest(*HitTestResult, Point) bool {
+	return false
+}
+
+// codeEditorGutterWidth returns the width of the gutter: a column for
+// diagnostic markers, the line numbers, and a space.
+func codeEditorGutterWidth(lines int) int {
+	return 1 + max(2, len(strconv.Itoa(lines))) + 1
+}
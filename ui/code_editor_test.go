@@ -0,0 +1,292 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.rockorager.dev/vaxis"
+)
+
+func TestCodeEditorPaintsGutterAndDiagnostics(t *testing.T) {
+	c := NewCodeEditorController("func main() {\n\tx := 1\n}")
+	app := NewApp(CodeEditor{
+		Controller:  c,
+		AutoFocus:   true,
+		Diagnostics: []Diagnostic{{Line: 1, Severity: DiagnosticWarning, Message: "unused"}, {Line: 1, Message: "undefined"}},
+	})
+	size := Size{Width: 30, Height: 4}
+	app.Pump(size)
+	c.SetCursor(TextCursor{Line: 1, Column: 1})
+	app.Pump(size)
+	p := NewPainter(size)
+	app.Paint(p)
+	lines := strings.Split(debugRenderedText(p), "\n")
+	want := []string{"  1 func main() {", "● 2     x := 1  undefined", "  3 }", ""}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("line %d = %q, want %q\n%s", i, lines[i], want[i], strings.Join(lines, "\n"))
+		}
+	}
+	theme := codeEditorTheme(DefaultTheme())
+	if got := p.Cell(0, 1).Style.Foreground; got != theme.Error.Foreground {
+		t.Fatalf("marker color = %v, want the most severe diagnostic's %v", got, theme.Error.Foreground)
+	}
+	if got := p.Cell(2, 1).Style.Attribute; got != theme.CurrentLine.Attribute {
+		t.Fatalf("current line number attribute = %v, want %v", got, theme.CurrentLine.Attribute)
+	}
+	// The tab is drawn to the next stop and the cursor sits after it
+	if cursor, ok := p.Cursor(); !ok || cursor.Col != 8 || cursor.Row != 1 {
+		t.Fatalf("cursor = %+v, %v; want column 8 of row 1", cursor, ok)
+	}
+
+	app.UpdateRoot(CodeEditor{Controller: c, HideGutter: true})
+	if lines := debugRenderedLines(app, size); lines[0] != "func main() {" {
+		t.Fatalf("HideGutter still paints the gutter:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestCodeEditorAutoIndentsAndUndoes(t *testing.T) {
+	c := NewCodeEditorController("\tif x {}")
+	changes := 0
+	app := NewApp(CodeEditor{Controller: c, AutoFocus: true, OnChanged: func(EventContext) { changes++ }})
+	size := Size{Width: 30, Height: 5}
+	app.Pump(size)
+
+	c.SetCursor(TextCursor{Line: 0, Column: 7})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	if got, want := c.Text(), "\tif x {\n\t    \n\t}"; got != want {
+		t.Fatalf("Enter between brackets = %q, want %q", got, want)
+	}
+	if got := c.Cursor(); got != (TextCursor{Line: 1, Column: 5}) {
+		t.Fatalf("cursor = %+v, want the end of the indented line", got)
+	}
+	app.Send(vaxis.Key{Text: "y", Keycode: 'y'})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	if got, want := c.Text(), "\tif x {\n\t    y\n\t    \n\t}"; got != want {
+		t.Fatalf("Enter keeps indentation = %q, want %q", got, want)
+	}
+	if changes != 3 {
+		t.Fatalf("OnChanged called %d times, want 3", changes)
+	}
+
+	app.Send(vaxis.Key{Text: "z", Keycode: 'z', Modifiers: vaxis.ModCtrl})
+	if got, want := c.Text(), "\tif x {\n\t    y\n\t}"; got != want {
+		t.Fatalf("undo = %q, want %q", got, want)
+	}
+	if lines := debugRenderedLines(app, size); lines[1] != "  2         y" || lines[2] != "  3     }" {
+		t.Fatalf("undone text painted as:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestCodeEditorIndentsSelectedLines(t *testing.T) {
+	c := NewCodeEditorController("a\n\tb\nc")
+	app := NewApp(CodeEditor{Controller: c, AutoFocus: true, TabWidth: 2})
+	app.Pump(Size{Width: 20, Height: 4})
+
+	c.SetCursor(TextCursor{Line: 0, Column: 1})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyTab})
+	if got, want := c.Text(), "a \n\tb\nc"; got != want {
+		t.Fatalf("Tab without a selection = %q, want %q", got, want)
+	}
+	app.Send(vaxis.Key{Text: "z", Keycode: 'z', Modifiers: vaxis.ModCtrl})
+
+	c.SetSelection(TextSelection{Base: TextPosition{ByteOffset: 0}, Extent: TextPosition{ByteOffset: 5}})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyTab})
+	if got, want := c.Text(), "  a\n  \tb\nc"; got != want {
+		t.Fatalf("Tab over a selection = %q, want %q", got, want)
+	}
+	if got := c.SelectedText(); got != "  a\n  \tb\n" {
+		t.Fatalf("selection after Tab = %q, want both whole lines", got)
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyTab, Modifiers: vaxis.ModShift})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyTab, Modifiers: vaxis.ModShift})
+	if got, want := c.Text(), "a\nb\nc"; got != want {
+		t.Fatalf("Shift+Tab twice = %q, want %q", got, want)
+	}
+	if !findState[*codeEditorState](app.build.Root()).editor.HasFocus() {
+		t.Fatal("Tab moved focus out of the editor")
+	}
+}
+
+func TestCodeEditorGoesToLine(t *testing.T) {
+	var sb strings.Builder
+	for i := 1; i <= 1000; i++ {
+		sb.WriteString("line " + strconv.Itoa(i) + "\n")
+	}
+	c := NewCodeEditorController(sb.String())
+	app := NewApp(CodeEditor{Controller: c, AutoFocus: true})
+	size := Size{Width: 20, Height: 6}
+	app.Pump(size)
+
+	c.GoToLine(499)
+	lines := debugRenderedLines(app, size)
+	if lines[3] != "  500 line 500" {
+		t.Fatalf("GoToLine didn't center line 500:\n%s", strings.Join(lines, "\n"))
+	}
+
+	app.Send(vaxis.Key{Text: "g", Keycode: 'g', Modifiers: vaxis.ModCtrl})
+	app.Pump(size)
+	for _, r := range "20:3" {
+		app.Send(vaxis.Key{Text: string(r), Keycode: r})
+	}
+	lines = debugRenderedLines(app, size)
+	if !strings.HasPrefix(lines[5], " Go to line:  20:3") {
+		t.Fatalf("Ctrl+G didn't open the go-to-line bar:\n%s", strings.Join(lines, "\n"))
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	lines = debugRenderedLines(app, size)
+	if got := c.Cursor(); got != (TextCursor{Line: 19, Column: 2}) {
+		t.Fatalf("cursor = %+v, want line 19 column 2", got)
+	}
+	if lines[3] != "   20 line 20" || strings.Contains(strings.Join(lines, "\n"), "Go to line") {
+		t.Fatalf("go-to-line bar didn't jump and close:\n%s", strings.Join(lines, "\n"))
+	}
+	app.Send(vaxis.Key{Text: "x", Keycode: 'x'})
+	if got := c.Line(19); got != "lixne 20" {
+		t.Fatalf("typing after the jump edits %q, want focus back in the editor", got)
+	}
+
+	app.Send(vaxis.Key{Text: "g", Keycode: 'g', Modifiers: vaxis.ModCtrl})
+	app.Pump(size)
+	app.Send(vaxis.Key{Text: "x", Keycode: 'x'})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	if lines := debugRenderedLines(app, size); !strings.Contains(strings.Join(lines, "\n"), "Go to line") {
+		t.Fatalf("an invalid line closed the bar:\n%s", strings.Join(lines, "\n"))
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEsc})
+	if lines := debugRenderedLines(app, size); strings.Contains(strings.Join(lines, "\n"), "Go to line") {
+		t.Fatalf("Escape didn't close the bar:\n%s", strings.Join(lines, "\n"))
+	}
+
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnd, Modifiers: vaxis.ModCtrl})
+	lines = debugRenderedLines(app, size)
+	if got := c.Cursor(); got != (TextCursor{Line: 1000}) || lines[5] != " 1001" {
+		t.Fatalf("Ctrl+End cursor = %+v:\n%s", got, strings.Join(lines, "\n"))
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyPgUp})
+	if got := c.Cursor(); got != (TextCursor{Line: 995}) {
+		t.Fatalf("Page Up cursor = %+v, want a page up", got)
+	}
+}
+
+func TestCodeEditorWrapsAndScrollsSideways(t *testing.T) {
+	c := NewCodeEditorController("abcdefghijklmnopqrstuvwxyz\n\tx")
+	app := NewApp(CodeEditor{Controller: c, AutoFocus: true, TabWidth: 8})
+	size := Size{Width: 14, Height: 4}
+	app.Pump(size)
+
+	c.SetCursor(TextCursor{Line: 0, Column: 20})
+	lines := debugRenderedLines(app, size)
+	if lines[0] != "  1 lmnopqrstu" || lines[1] != "  2" {
+		t.Fatalf("the view didn't scroll sideways to the cursor:\n%s", strings.Join(lines, "\n"))
+	}
+	for i := 0; i < 11; i++ {
+		app.Send(vaxis.Mouse{Col: 5, Row: 0, Button: vaxis.MouseWheelLeft, EventType: vaxis.EventPress})
+	}
+	if lines := debugRenderedLines(app, size); lines[0] != "  1 abcdefghij" || lines[1] != "  2         x" {
+		t.Fatalf("the wheel didn't scroll back:\n%s", strings.Join(lines, "\n"))
+	}
+
+	app.UpdateRoot(CodeEditor{Controller: c, SoftWrap: true, TabWidth: 8})
+	lines = debugRenderedLines(app, size)
+	want := []string{"  1 abcdefghij", "    klmnopqrst", "    uvwxyz", "  2         x"}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("soft wrap row %d = %q, want %q\n%s", i, lines[i], want[i], strings.Join(lines, "\n"))
+		}
+	}
+	c.SetCursor(TextCursor{Line: 0, Column: 3})
+	app.Pump(size)
+	app.Send(vaxis.Key{Keycode: vaxis.KeyDown})
+	if got := c.Cursor(); got != (TextCursor{Line: 0, Column: 13}) {
+		t.Fatalf("Down moved to %+v, want the next wrapped row", got)
+	}
+}
+
+func TestCodeEditorClickPlacesCursor(t *testing.T) {
+	c := NewCodeEditorController("\tab\nc")
+	app := NewApp(CodeEditor{Controller: c})
+	size := Size{Width: 20, Height: 4}
+	app.Pump(size)
+
+	click := func(col, row int) {
+		app.Send(vaxis.Mouse{Col: col, Row: row, Button: vaxis.MouseLeftButton, EventType: vaxis.EventPress})
+		app.Send(vaxis.Mouse{Col: col, Row: row, Button: vaxis.MouseLeftButton, EventType: vaxis.EventRelease})
+		app.Pump(size)
+	}
+	click(9, 0)
+	if got := c.Cursor(); got != (TextCursor{Line: 0, Column: 2}) {
+		t.Fatalf("click after the tab = %+v, want column 2", got)
+	}
+	click(15, 1)
+	if got := c.Cursor(); got != (TextCursor{Line: 1, Column: 1}) {
+		t.Fatalf("click past the end of a line = %+v, want its end", got)
+	}
+	click(15, 3)
+	if got := c.Cursor(); got != (TextCursor{Line: 1, Column: 1}) {
+		t.Fatalf("click below the text = %+v, want the end of the last line", got)
+	}
+}
+
+func TestCodeEditorHighlightsMatchingBracket(t *testing.T) {
+	c := NewCodeEditorController("f(a[1], b)\n")
+	app := NewApp(CodeEditor{Controller: c, AutoFocus: true, HideGutter: true})
+	size := Size{Width: 20, Height: 3}
+	app.Pump(size)
+	c.SetCursor(TextCursor{Line: 0, Column: 10})
+	app.Pump(size)
+	p := NewPainter(size)
+	app.Paint(p)
+	theme := codeEditorTheme(DefaultTheme())
+	for x := 0; x < 10; x++ {
+		matched := p.Cell(x, 0).Style.Attribute&theme.MatchingBracket.Attribute != 0
+		if matched != (x == 1 || x == 9) {
+			t.Fatalf("cell %d highlighted = %v", x, matched)
+		}
+	}
+}
+
+func TestCodeEditorMatchBracket(t *testing.T) {
+	tests := []struct {
+		text      string
+		cursor    int
+		at, match int
+		ok        bool
+	}{
+		{"(a)", 0, 0, 2, true},
+		{"(a)", 3, 2, 0, true},
+		{"{[()]}", 2, 2, 3, true},
+		{"{[()]}", 6, 5, 0, true},
+		{"(]", 0, 0, 0, false},
+		{"a(b", 1, 0, 0, false},
+		{"ab", 1, 0, 0, false},
+	}
+	for _, tt := range tests {
+		at, match, ok := codeEditorMatchBracket(newTextRope(TextBuffer{}.characters(tt.text)), tt.cursor)
+		if ok != tt.ok || ok && (at != tt.at || match != tt.match) {
+			t.Errorf("%q at %d = %d, %d, %v; want %d, %d, %v", tt.text, tt.cursor, at, match, ok, tt.at, tt.match, tt.ok)
+		}
+	}
+}
+
+func TestParseCodeEditorGoTo(t *testing.T) {
+	tests := []struct {
+		text string
+		want TextCursor
+		ok   bool
+	}{
+		{"12", TextCursor{Line: 11}, true},
+		{" 3:4 ", TextCursor{Line: 2, Column: 3}, true},
+		{"0", TextCursor{}, false},
+		{"2:0", TextCursor{}, false},
+		{"x", TextCursor{}, false},
+		{"", TextCursor{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseCodeEditorGoTo(tt.text)
+		if ok != tt.ok || ok && got != tt.want {
+			t.Errorf("%q = %+v, %v; want %+v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package ui
+
+const (
+	defaultComboBoxEmptyText = "No matches"
+
+	comboBoxHighlightIntentType IntentType = "ui.combo-box.highlight"
+	comboBoxRemoveIntentType    IntentType = "ui.combo-box.remove"
+)
+
+// ComboBox is a text field suggesting matching items in a popup list as the
+// user types.
+//
+// Typing filters Items with Filter, or asks LoadItems for the items matching
+// the text, and shows them below the field in the nearest Overlay. Down opens
+// the list, Up and Down move the highlight, Enter chooses the highlighted
+// item, and Escape, a click outside or moving focus away closes the list.
+// Choosing an item puts its title in the field and calls OnChanged.
+//
+// With MultiSelect, the chosen items are shown as chips before the text,
+// which is cleared after each choice. Choosing an item again removes it, as
+// does clicking its chip's ×, or Backspace in an empty field for the last
+// one. The caller owns updating Value or Values in response to OnChanged or
+// OnValuesChanged.
+type ComboBox[T comparable] struct {
+	// Items are the suggestions filtered by the text.
+	Items []T
+	// Item converts an item into searchable row data; Title, Aliases and
+	// Disabled are used. When nil, items are formatted with fmt.Sprint.
+	Item FuzzySelectItemFunc[T]
+	// Filter filters and ranks Items for the text. When nil,
+	// DefaultFuzzySelectFilter is used.
+	Filter FuzzySelectFilter[T]
+	// LoadItems loads the items matching query asynchronously and is used
+	// instead of Items and Filter when set. It is called as the text changes
+	// and must call done once, from any goroutine. Results of earlier calls
+	// arriving late are dropped.
+	LoadItems func(query string, done func(items []T, err error))
+	// Value is the chosen item, whose title the field shows until it is edited.
+	Value T
+	// Values are the chosen items with MultiSelect.
+	Values []T
+	// MultiSelect allows more than one item to be chosen.
+	MultiSelect bool
+	// Placeholder is shown in the field when it is empty and not focused.
+	Placeholder string
+	// EmptyText is shown in the list when nothing matches the text.
+	EmptyText string
+	// Width overrides the default text field width when greater than zero.
+	Width int
+	// MaxVisibleRows limits the visible rows of the list before it scrolls.
+	MaxVisibleRows int
+	// OnChanged is called with the chosen item.
+	OnChanged ValueChangedCallback[T]
+	// OnValuesChanged is called with the chosen items with MultiSelect.
+	OnValuesChanged func(EventContext, []T)
+	// OnTextChanged is called with the text after each edit.
+	OnTextChanged TextChangedCallback
+}
+
+func (w ComboBox[T]) CreateState() State {
+	return &comboBoxState[T]{}
+}
+
+type comboBoxState[T comparable] struct {
+	selectListState
+	text   string
+	edited bool
+	cursor *int
+	value  T
+	synced bool
+	loaded []T
+}
+
+func (s *comboBoxState[T]) Dispose() {
+	s.disposeList()
+}
+
+func (s *comboBoxState[T]) focusWithinChanged() {
+	if s.open && !s.element.owner.app.focusedWithin(s.element) {
+		s.open = false
+		s.popup.hide()
+		s.element.MarkNeedsBuild()
+	}
+}
+
+func (s *comboBoxState[T]) Build(ctx BuildContext) Widget {
+	w := s.Widget().(ComboBox[T])
+	if !w.MultiSelect && (!s.synced || w.Value != s.value) {
+		s.setText(comboBoxValueText(w))
+	}
+	s.value, s.synced = w.Value, true
+	theme := dropdownTheme(MustDepend[Theme](ctx))
+	width := w.Width
+	if width <= 0 {
+		width = textFieldTheme(MustDepend[Theme](ctx)).MinWidth
+	}
+	if s.open {
+		s.setRows(s.rowsFor(w, s.items(w)), selectMaxVisibleRows(w.MaxVisibleRows))
+		empty := w.EmptyText
+		if empty == "" {
+			empty = defaultComboBoxEmptyText
+		}
+		s.showList(theme, width, empty, s.choose)
+	} else {
+		s.popup.hide()
+	}
+
+	cursor := s.cursor
+	s.cursor = nil
+	var field Widget = TextField{
+		Value:        s.text,
+		Placeholder:  w.Placeholder,
+		MinWidth:     width,
+		CursorOffset: cursor,
+		OnChanged:    s.edit,
+		OnSubmitted: func(ctx EventContext, _ string) {
+			if s.open {
+				s.choose(ctx, s.highlight)
+			}
+		},
+	}
+	bindings := ShortcutMap{
+		"Down":   comboBoxHighlightIntent{Delta: 1},
+		"Ctrl+n": comboBoxHighlightIntent{Delta: 1},
+		"Up":     comboBoxHighlightIntent{Delta: -1},
+		"Ctrl+p": comboBoxHighlightIntent{Delta: -1},
+	}
+	if w.MultiSelect {
+		// The chips stay in the tree when there are none, so the text field
+		// keeps its state and focus
+		labels := make([]string, len(w.Values))
+		for i, value := range w.Values {
+			labels[i] = fuzzySelectItemForFunc(value, w.Item).Title
+		}
+		field = Row(
+			selectChips{labels: labels, style: theme.Field, chip: theme.Chip, mouse: theme.Mouse, onRemove: s.remove},
+			SizedBox{Width: min(1, len(labels))},
+			field,
+		)
+		if s.text == "" && len(labels) > 0 {
+			bindings["Backspace"] = comboBoxRemoveIntent{}
+		}
+	}
+	actions := map[IntentType]ActionFunc{
+		comboBoxHighlightIntentType: func(ctx EventContext, intent Intent) EventResult {
+			s.moveHighlight(intent.(comboBoxHighlightIntent).Delta)
+			return EventHandled
+		},
+		comboBoxRemoveIntentType: func(ctx EventContext, intent Intent) EventResult {
+			s.remove(ctx, len(s.Widget().(ComboBox[T]).Values)-1)
+			return EventHandled
+		},
+	}
+	for intent, action := range popupDismissBindings(s.open, s.closeList) {
+		actions[intent] = action
+	}
+	return Actions{Bindings: actions, Child: Shortcuts{Bindings: bindings, Child: field}}
+}
+
+// items returns the suggestions for the text. Until the text is edited, all
+// items are suggested.
+func (s *comboBoxState[T]) items(w ComboBox[T]) []T {
+	if w.LoadItems != nil {
+		return s.loaded
+	}
+	filter := w.Filter
+	if filter == nil {
+		filter = DefaultFuzzySelectFilter[T]
+	}
+	return filter(s.query(), w.Items, w.Item)
+}
+
+func (s *comboBoxState[T]) query() string {
+	if !s.edited {
+		return ""
+	}
+	return s.text
+}
+
+func (s *comboBoxState[T]) rowsFor(w ComboBox[T], items []T) []selectRow {
+	rows := make([]selectRow, len(items))
+	for i, item := range items {
+		row := fuzzySelectItemForFunc(item, w.Item)
+		checked := w.MultiSelect && selectContains(w.Values, item)
+		rows[i] = selectRow{label: row.Title, checked: checked, disabled: row.Disabled}
+	}
+	return rows
+}
+
+// setText replaces the text with text that wasn't typed, moving the cursor to
+// its end.
+func (s *comboBoxState[T]) setText(text string) {
+	cursor := len(vaxisCharacters(text))
+	s.text, s.edited, s.cursor = text, false, &cursor
+}
+
+func (s *comboBoxState[T]) edit(ctx EventContext, text string) {
+	w := s.Widget().(ComboBox[T])
+	s.text, s.edited = text, true
+	s.top = 0
+	s.refresh(w)
+	s.setRows(s.rowsFor(w, s.items(w)), selectMaxVisibleRows(w.MaxVisibleRows))
+	s.openList(0)
+	if w.OnTextChanged != nil {
+		w.OnTextChanged(ctx, text)
+	}
+}
+
+// refresh loads the items for the current query with LoadItems.
+func (s *comboBoxState[T]) refresh(w ComboBox[T]) {
+	if w.LoadItems == nil {
+		return
+	}
+	gen := s.beginLoad()
+	rt := s.Context().Runtime()
+	w.LoadItems(s.query(), func(items []T, err error) {
+		rt.Dispatch(func() {
+			if !s.finishLoad(gen, err) {
+				return
+			}
+			s.SetState(func() {
+				w := s.Widget().(ComboBox[T])
+				s.loaded = items
+				s.setRows(s.rowsFor(w, items), selectMaxVisibleRows(w.MaxVisibleRows))
+				s.setHighlight(0, 1)
+			})
+		})
+	})
+}
+
+// moveHighlight opens the list, or moves its highlight by delta rows.
+func (s *comboBoxState[T]) moveHighlight(delta int) {
+	w := s.Widget().(ComboBox[T])
+	if !s.open {
+		s.refresh(w)
+		s.setRows(s.rowsFor(w, s.items(w)), selectMaxVisibleRows(w.MaxVisibleRows))
+		s.openList(0)
+		return
+	}
+	if len(s.rows) == 0 {
+		return
+	}
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+	s.setHighlight(s.highlight+delta, step)
+	s.MarkNeedsBuild()
+}
+
+func (s *comboBoxState[T]) choose(ctx EventContext, index int) {
+	w := s.Widget().(ComboBox[T])
+	items := s.items(w)
+	if index < 0 || index >= len(items) || fuzzySelectItemForFunc(items[index], w.Item).Disabled {
+		return
+	}
+	item := items[index]
+	if w.MultiSelect {
+		s.setText("")
+		s.refresh(w)
+		s.MarkNeedsBuild()
+		if w.OnValuesChanged != nil {
+			w.OnValuesChanged(ctx, selectToggled(w.Values, item))
+		}
+		return
+	}
+	s.setText(fuzzySelectItemForFunc(item, w.Item).Title)
+	s.closeList()
+	if w.OnChanged != nil {
+		w.OnChanged(ctx, item)
+	}
+}
+
+func (s *comboBoxState[T]) remove(ctx EventContext, index int) {
+	w := s.Widget().(ComboBox[T])
+	if index < 0 || index >= len(w.Values) {
+		return
+	}
+	if w.OnValuesChanged != nil {
+		w.OnValuesChanged(ctx, selectToggled(w.Values, w.Values[index]))
+	}
+}
+
+// comboBoxValueText returns the title of Value, or nothing while Value is the
+// zero value.
+func comboBoxValueText[T comparable](w ComboBox[T]) string {
+	var zero T
+	if w.Value == zero {
+		return ""
+	}
+	return fuzzySelectItemForFunc(w.Value, w.Item).Title
+}
+
+type comboBoxHighlightIntent struct{ Delta int }
+
+func (comboBoxHighlightIntent) IntentType() IntentType {
+	return comboBoxHighlightIntentType
+}
+
+type comboBoxRemoveIntent struct{}
+
+func (comboBoxRemoveIntent) IntentType() IntentType {
+	return comboBoxRemoveIntentType
+}
@@ -93,3 +93,12 @@ func debugRenderedText(p *Painter) string {
 	}
 	return b.String()
 }
+
+// debugRenderedLines pumps a frame of app at size and returns its rendered
+// text, one string per row.
+func debugRenderedLines(app *App, size Size) []string {
+	app.Pump(size)
+	p := NewPainter(size)
+	app.Paint(p)
+	return strings.Split(debugRenderedText(p), "\n")
+}
@@ -0,0 +1,368 @@
+package ui
+
+import "go.rockorager.dev/vaxis"
+
+const (
+	defaultDropdownEmptyText = "No items"
+	dropdownArrow            = "▾"
+)
+
+// Dropdown is a controlled picker showing the chosen item in a compact field
+// and the items in a popup list.
+//
+// Clicking the field, or pressing Enter, Space or Down while it has focus,
+// opens the list in the nearest Overlay, below the field or above it when
+// there isn't room. Up and Down, Home and End, and Page Up and Page Down move
+// the highlight, Enter or Space chooses the highlighted item, and Escape, a
+// click outside or moving focus away closes the list. Typing the first
+// letters of a label highlights the next matching item, or chooses it while
+// the list is closed.
+//
+// With MultiSelect, choosing an item toggles it in Values and leaves the list
+// open, typing opens the list on the match, and the field shows the chosen
+// items as chips. Clicking a chip's ×
+// removes it, as does Backspace for the last one. The caller owns updating
+// Value or Values in response to OnChanged or OnValuesChanged.
+type Dropdown[T comparable] struct {
+	// Items are the choices, in order.
+	Items []T
+	// Item converts an item into its row; only Title and Disabled are used.
+	// When nil, items are formatted with fmt.Sprint.
+	Item FuzzySelectItemFunc[T]
+	// LoadItems loads the items asynchronously each time the list opens and is
+	// used instead of Items when set. It must call done once, from any
+	// goroutine.
+	LoadItems func(done func(items []T, err error))
+	// Value is the chosen item. The field shows Placeholder while Value is the
+	// zero value and no item equals it.
+	Value T
+	// Values are the chosen items with MultiSelect.
+	Values []T
+	// MultiSelect allows more than one item to be chosen.
+	MultiSelect bool
+	// Placeholder is shown in the field when nothing is chosen.
+	Placeholder string
+	// EmptyText is shown in the list when there are no items.
+	EmptyText string
+	// Width is the field width when greater than zero. Otherwise the field
+	// fits the widest item.
+	Width int
+	// MaxVisibleRows limits the visible rows of the list before it scrolls.
+	MaxVisibleRows int
+	// Disabled prevents focus, hover, and opening the list when true.
+	Disabled bool
+	// OnChanged is called with the chosen item.
+	OnChanged ValueChangedCallback[T]
+	// OnValuesChanged is called with the chosen items with MultiSelect.
+	OnValuesChanged func(EventContext, []T)
+}
+
+func (w Dropdown[T]) CreateState() State {
+	return &dropdownState[T]{}
+}
+
+type dropdownState[T comparable] struct {
+	selectListState
+	node    FocusNode
+	hovered bool
+	loaded  []T
+}
+
+func (s *dropdownState[T]) Dispose() {
+	s.disposeList()
+}
+
+func (s *dropdownState[T]) Build(ctx BuildContext) Widget {
+	w := s.Widget().(Dropdown[T])
+	s.node.onChange = s.focusChanged
+	appTheme := MustDepend[Theme](ctx)
+	theme := dropdownTheme(appTheme)
+	items := s.items(w)
+	width := dropdownWidth(w, items)
+	if s.open && !w.Disabled {
+		s.setRows(s.rowsFor(w, items), selectMaxVisibleRows(w.MaxVisibleRows))
+		empty := w.EmptyText
+		if empty == "" {
+			empty = defaultDropdownEmptyText
+		}
+		s.showList(theme, width, empty, s.choose)
+	} else {
+		s.open = false
+		s.popup.hide()
+	}
+
+	style := theme.Field
+	switch {
+	case w.Disabled:
+		style.Foreground = appTheme.DisabledForeground
+	case s.hovered:
+		style = theme.FieldHovered
+	case s.node.HasFocus():
+		style = theme.FieldFocused
+	}
+	var content Widget
+	switch {
+	case w.MultiSelect && len(w.Values) > 0:
+		labels := make([]string, len(w.Values))
+		for i, value := range w.Values {
+			labels[i] = fuzzySelectItemForFunc(value, w.Item).Title
+		}
+		chips := selectChips{labels: labels, style: style, chip: theme.Chip, mouse: theme.Mouse, onRemove: s.remove}
+		if w.Disabled {
+			chips.onRemove = nil
+		}
+		content = chips
+	case !w.MultiSelect && dropdownHasValue(w, items):
+		content = Text{Value: fuzzySelectItemForFunc(w.Value, w.Item).Title, Style: style, MaxLines: 1, Overflow: TextOverflowEllipsis}
+	default:
+		content = Text{Value: w.Placeholder, Style: mergeStyle(style, theme.Placeholder), MaxLines: 1, Overflow: TextOverflowEllipsis}
+	}
+	field := SizedBox{Width: width, Height: 1, Child: DecoratedBox(
+		Decoration{Style: style},
+		Padding(Symmetric(1, 0), Row(Expanded(content), Text{Value: " " + dropdownArrow, Style: style})),
+	)}
+	if w.Disabled {
+		return field
+	}
+	return Actions{
+		Bindings: popupDismissBindings(s.open, s.closeList),
+		Child:    Focus(&s.node, field),
+	}
+}
+
+func (s *dropdownState[T]) focusChanged() {
+	if !s.node.HasFocus() {
+		s.closeList()
+	}
+	s.MarkNeedsBuild()
+}
+
+// items returns the items shown by the list.
+func (s *dropdownState[T]) items(w Dropdown[T]) []T {
+	if w.LoadItems != nil {
+		return s.loaded
+	}
+	return w.Items
+}
+
+func (s *dropdownState[T]) rowsFor(w Dropdown[T], items []T) []selectRow {
+	rows := make([]selectRow, len(items))
+	for i, item := range items {
+		row := fuzzySelectItemForFunc(item, w.Item)
+		checked := item == w.Value
+		if w.MultiSelect {
+			checked = selectContains(w.Values, item)
+		}
+		rows[i] = selectRow{label: row.Title, checked: checked, disabled: row.Disabled}
+	}
+	return rows
+}
+
+// valueRow returns the row of Value, or of the first of Values.
+func (s *dropdownState[T]) valueRow(w Dropdown[T], items []T) int {
+	value := w.Value
+	if w.MultiSelect {
+		if len(w.Values) == 0 {
+			return 0
+		}
+		value = w.Values[0]
+	}
+	for i, item := range items {
+		if item == value {
+			return i
+		}
+	}
+	return 0
+}
+
+// openDropdown opens the list with highlight, or with Value highlighted when
+// highlight is negative, and starts loading the items.
+func (s *dropdownState[T]) openDropdown(w Dropdown[T], highlight int) {
+	if w.LoadItems != nil {
+		s.load(w)
+	}
+	items := s.items(w)
+	s.setRows(s.rowsFor(w, items), selectMaxVisibleRows(w.MaxVisibleRows))
+	if highlight < 0 {
+		highlight = s.valueRow(w, items)
+	}
+	s.openList(highlight)
+}
+
+func (s *dropdownState[T]) load(w Dropdown[T]) {
+	gen := s.beginLoad()
+	rt := s.Context().Runtime()
+	w.LoadItems(func(items []T, err error) {
+		rt.Dispatch(func() {
+			if !s.finishLoad(gen, err) {
+				return
+			}
+			s.SetState(func() {
+				w := s.Widget().(Dropdown[T])
+				s.loaded = items
+				s.setRows(s.rowsFor(w, items), selectMaxVisibleRows(w.MaxVisibleRows))
+				s.setHighlight(s.valueRow(w, items), 1)
+			})
+		})
+	})
+}
+
+func (s *dropdownState[T]) choose(ctx EventContext, index int) {
+	w := s.Widget().(Dropdown[T])
+	items := s.items(w)
+	if index < 0 || index >= len(items) || fuzzySelectItemForFunc(items[index], w.Item).Disabled {
+		return
+	}
+	item := items[index]
+	if w.MultiSelect {
+		s.highlight = index
+		s.MarkNeedsBuild()
+		if w.OnValuesChanged != nil {
+			w.OnValuesChanged(ctx, selectToggled(w.Values, item))
+		}
+		return
+	}
+	s.closeList()
+	if w.OnChanged != nil {
+		w.OnChanged(ctx, item)
+	}
+}
+
+func (s *dropdownState[T]) remove(ctx EventContext, index int) {
+	w := s.Widget().(Dropdown[T])
+	if index < 0 || index >= len(w.Values) {
+		return
+	}
+	if w.OnValuesChanged != nil {
+		w.OnValuesChanged(ctx, selectToggled(w.Values, w.Values[index]))
+	}
+}
+
+func (s *dropdownState[T]) MouseShape(EventContext, Mouse) MouseShape {
+	if s.Widget().(Dropdown[T]).Disabled {
+		return MouseShapeDefault
+	}
+	return dropdownTheme(MustDepend[Theme](s.Context())).Mouse
+}
+
+func (s *dropdownState[T]) HandleEvent(ctx EventContext, ev Event) EventResult {
+	w := s.Widget().(Dropdown[T])
+	if w.Disabled || (ctx.Phase() != TargetPhase && ctx.Phase() != BubblePhase) {
+		return EventIgnored
+	}
+	switch ev := ev.(type) {
+	case Key:
+		if keyIsRelease(ev) {
+			return EventIgnored
+		}
+		return s.handleKey(ctx, w, ev)
+	case hoverExit:
+		if s.hovered {
+			s.SetState(func() { s.hovered = false })
+		}
+	case Mouse:
+		switch {
+		case ev.EventType == EventMotion && !s.hovered:
+			s.SetState(func() { s.hovered = true })
+		case ev.EventType == EventPress && ev.Button == MouseLeftButton:
+			s.node.RequestFocus()
+			if s.open {
+				s.closeList()
+			} else {
+				s.openDropdown(w, -1)
+			}
+			return EventHandled
+		}
+	}
+	return EventIgnored
+}
+
+func (s *dropdownState[T]) handleKey(ctx EventContext, w Dropdown[T], key Key) EventResult {
+	switch {
+	case !s.open && (key.MatchString("Enter") || key.MatchString("Space") || key.Keycode == KeyDown):
+		s.openDropdown(w, -1)
+		return EventHandled
+	case !s.open && w.MultiSelect && key.MatchString("Backspace") && len(w.Values) > 0:
+		s.remove(ctx, len(w.Values)-1)
+		return EventHandled
+	case s.open && s.handleListKey(key):
+		return EventHandled
+	case s.open && key.MatchString("Enter"):
+		s.choose(ctx, s.highlight)
+		return EventHandled
+	case key.Text != "" && key.Modifiers&(vaxis.ModCtrl|vaxis.ModAlt|vaxis.ModSuper) == 0:
+		return s.typeAheadKey(ctx, w, key.Text)
+	}
+	return EventIgnored
+}
+
+// typeAheadKey highlights the next item matching the letters typed so far.
+// While the list is closed, it chooses that item instead, or opens the list
+// on it with MultiSelect. Space chooses the highlighted item unless it
+// continues a label being typed.
+func (s *dropdownState[T]) typeAheadKey(ctx EventContext, w Dropdown[T], text string) EventResult {
+	if !s.open {
+		items := s.items(w)
+		s.setRows(s.rowsFor(w, items), selectMaxVisibleRows(w.MaxVisibleRows))
+		s.highlight = s.valueRow(w, items)
+	}
+	index, ok := s.typeAhead(text)
+	switch {
+	case !ok:
+		s.choose(ctx, s.highlight)
+	case s.open || index < 0:
+	case w.MultiSelect:
+		s.openDropdown(w, index)
+	default:
+		s.choose(ctx, index)
+	}
+	return EventHandled
+}
+
+// dropdownWidth returns the field width: Width, or enough for the widest
+// item, the placeholder and the arrow, and at least a text field's width.
+func dropdownWidth[T comparable](w Dropdown[T], items []T) int {
+	if w.Width > 0 {
+		return w.Width
+	}
+	width := textWidth(w.Placeholder)
+	for _, item := range items {
+		width = max(width, textWidth(fuzzySelectItemForFunc(item, w.Item).Title))
+	}
+	var zero T
+	if !w.MultiSelect && w.Value != zero {
+		width = max(width, textWidth(fuzzySelectItemForFunc(w.Value, w.Item).Title))
+	}
+	return max(defaultTextFieldMinWidth, width+4)
+}
+
+// dropdownHasValue reports whether Value is a chosen item rather than an unset
+// zero value.
+func dropdownHasValue[T comparable](w Dropdown[T], items []T) bool {
+	var zero T
+	return w.Value != zero || selectContains(items, zero)
+}
+
+func selectContains[T comparable](items []T, value T) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// selectToggled returns a copy of values with value removed, or added when it
+// isn't there.
+func selectToggled[T comparable](values []T, value T) []T {
+	out := make([]T, 0, len(values)+1)
+	for _, v := range values {
+		if v != value {
+			out = append(out, v)
+		}
+	}
+	if len(out) == len(values) {
+		out = append(out, value)
+	}
+	return out
+}
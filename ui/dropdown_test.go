@@ -0,0 +1,271 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.rockorager.dev/vaxis"
+)
+
+// selectHarness holds the value of a Dropdown or ComboBox built by build,
+// below an Overlay.
+type selectHarness struct {
+	build func(s *selectHarnessState) Widget
+}
+
+func (w selectHarness) CreateState() State {
+	return &selectHarnessState{}
+}
+
+type selectHarnessState struct {
+	StateBase
+	value  string
+	values []string
+}
+
+func (s *selectHarnessState) Build(BuildContext) Widget {
+	return Overlay{Child: Align{Alignment: TopLeft, Child: s.Widget().(selectHarness).build(s)}}
+}
+
+func (s *selectHarnessState) setValue(_ EventContext, value string) {
+	s.SetState(func() { s.value = value })
+}
+
+func (s *selectHarnessState) setValues(_ EventContext, values []string) {
+	s.SetState(func() { s.values = values })
+}
+
+var selectTestFruits = []string{"Apple", "Banana", "Blueberry", "Cherry", "Grape"}
+
+func selectTestItem(item string) FuzzySelectItem {
+	return FuzzySelectItem{Title: item, Disabled: item == "Banana"}
+}
+
+func TestDropdownChoosesWithKeyboardAndTypeAhead(t *testing.T) {
+	var harness *selectHarnessState
+	app := NewApp(selectHarness{build: func(s *selectHarnessState) Widget {
+		harness = s
+		return Column(
+			Dropdown[string]{Items: selectTestFruits, Item: selectTestItem, Value: s.value, Placeholder: "Fruit", OnChanged: s.setValue},
+			Button{Label: "next"},
+		)
+	}})
+	size := Size{Width: 30, Height: 10}
+	if got := debugRenderedLines(app, size)[0]; !strings.HasPrefix(got, " Fruit     ▾") {
+		t.Fatalf("field = %q", got)
+	}
+
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	lines := debugRenderedLines(app, size)
+	want := []string{
+		"┌─────────────┐",
+		"│   Apple     │",
+		"│   Banana    │",
+		"│   Blueberry │",
+		"│   Cherry    │",
+		"│   Grape     │",
+		"└─────────────┘",
+	}
+	for i, line := range want {
+		if got := strings.TrimRight(lines[1+i], " "); got != line {
+			t.Fatalf("list line %d = %q, want %q\n%s", i, got, line, strings.Join(lines, "\n"))
+		}
+	}
+
+	// Down skips the disabled Banana
+	app.Send(vaxis.Key{Keycode: vaxis.KeyDown})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	lines = debugRenderedLines(app, size)
+	if harness.value != "Blueberry" || !strings.HasPrefix(lines[0], " Blueberry") || strings.Contains(lines[2], "Banana") {
+		t.Fatalf("value %q after Enter:\n%s", harness.value, strings.Join(lines, "\n"))
+	}
+
+	// Typing chooses the next match while the list is closed
+	app.Send(vaxis.Key{Text: "c", Keycode: 'c'})
+	debugRenderedLines(app, size)
+	if harness.value != "Cherry" {
+		t.Fatalf("value %q after typing", harness.value)
+	}
+
+	// The reopened list checks the value, and Escape closes it
+	app.Send(vaxis.Key{Keycode: vaxis.KeyDown})
+	if got := debugRenderedLines(app, size)[5]; !strings.HasPrefix(got, "│ ✓ Cherry") {
+		t.Fatalf("value row = %q", got)
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEsc})
+	if got := debugRenderedLines(app, size)[5]; strings.Contains(got, "Cherry") {
+		t.Fatalf("list still open after Escape: %q", got)
+	}
+}
+
+func TestDropdownMouseAndClickOutside(t *testing.T) {
+	var harness *selectHarnessState
+	app := NewApp(selectHarness{build: func(s *selectHarnessState) Widget {
+		harness = s
+		return Dropdown[string]{Items: selectTestFruits, Item: selectTestItem, Value: s.value, MaxVisibleRows: 3, OnChanged: s.setValue}
+	}})
+	size := Size{Width: 30, Height: 10}
+	debugRenderedLines(app, size)
+
+	app.Send(Mouse{Col: 2, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	lines := debugRenderedLines(app, size)
+	if !strings.HasSuffix(strings.TrimRight(lines[4], " "), "▼") || strings.Contains(strings.Join(lines, "\n"), "Cherry") {
+		t.Fatalf("list not limited to three rows:\n%s", strings.Join(lines, "\n"))
+	}
+	app.Send(Mouse{Col: 20, Row: 8, Button: MouseLeftButton, EventType: EventPress})
+	if lines := debugRenderedLines(app, size); strings.Contains(strings.Join(lines, "\n"), "Apple") {
+		t.Fatalf("list still open after clicking outside:\n%s", strings.Join(lines, "\n"))
+	}
+
+	app.Send(Mouse{Col: 2, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	debugRenderedLines(app, size)
+	app.Send(Mouse{Col: 4, Row: 2, Button: MouseWheelDown, EventType: EventPress})
+	lines = debugRenderedLines(app, size)
+	if !strings.Contains(lines[4], "Cherry") {
+		t.Fatalf("wheel didn't scroll the list:\n%s", strings.Join(lines, "\n"))
+	}
+	// Pressing the disabled Banana does nothing
+	app.Send(Mouse{Col: 4, Row: 2, Button: MouseLeftButton, EventType: EventPress})
+	app.Send(Mouse{Col: 4, Row: 4, Button: MouseLeftButton, EventType: EventPress})
+	lines = debugRenderedLines(app, size)
+	if harness.value != "Cherry" || strings.Contains(lines[4], "Cherry") {
+		t.Fatalf("value %q after clicking:\n%s", harness.value, strings.Join(lines, "\n"))
+	}
+}
+
+func TestDropdownMultiSelectChips(t *testing.T) {
+	var harness *selectHarnessState
+	app := NewApp(selectHarness{build: func(s *selectHarnessState) Widget {
+		harness = s
+		return Dropdown[string]{Items: selectTestFruits, Values: s.values, MultiSelect: true, Width: 28, OnValuesChanged: s.setValues}
+	}})
+	size := Size{Width: 30, Height: 10}
+	debugRenderedLines(app, size)
+
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	app.Send(vaxis.Key{Text: " ", Keycode: vaxis.KeySpace})
+	debugRenderedLines(app, size)
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnd})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	lines := debugRenderedLines(app, size)
+	if strings.Join(harness.values, ",") != "Apple,Grape" || !strings.HasPrefix(lines[2], "│ ✓ Apple") {
+		t.Fatalf("values %q, list:\n%s", harness.values, strings.Join(lines, "\n"))
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEsc})
+	if got := debugRenderedLines(app, size)[0]; !strings.HasPrefix(got, "  Apple ×   Grape × ") {
+		t.Fatalf("chips = %q", got)
+	}
+
+	// The first chip's × removes it, and Backspace removes the last one
+	app.Send(Mouse{Col: 8, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	debugRenderedLines(app, size)
+	if strings.Join(harness.values, ",") != "Grape" {
+		t.Fatalf("values %q after removing a chip", harness.values)
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyBackspace})
+	if got := debugRenderedLines(app, size)[0]; len(harness.values) != 0 || strings.Contains(got, "Grape") {
+		t.Fatalf("values %q, field %q after Backspace", harness.values, got)
+	}
+}
+
+func TestDropdownLoadsItemsWhenOpened(t *testing.T) {
+	var done func([]string, error)
+	loads := 0
+	app := NewApp(selectHarness{build: func(s *selectHarnessState) Widget {
+		return Dropdown[string]{Value: s.value, OnChanged: s.setValue, Width: 16, LoadItems: func(fn func([]string, error)) {
+			loads++
+			done = fn
+		}}
+	}})
+	size := Size{Width: 30, Height: 10}
+	debugRenderedLines(app, size)
+
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	if got := debugRenderedLines(app, size)[2]; loads != 1 || !strings.Contains(got, "Loading…") {
+		t.Fatalf("loads %d, row %q", loads, got)
+	}
+	done(nil, errors.New("offline"))
+	if got := debugRenderedLines(app, size)[2]; !strings.Contains(got, "offline") {
+		t.Fatalf("error row = %q", got)
+	}
+
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEsc})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	debugRenderedLines(app, size)
+	done([]string{"north", "south"}, nil)
+	if got := debugRenderedLines(app, size)[3]; loads != 2 || !strings.Contains(got, "south") {
+		t.Fatalf("loads %d, row %q", loads, got)
+	}
+}
+
+func TestComboBoxFiltersSuggestions(t *testing.T) {
+	var harness *selectHarnessState
+	var typed []string
+	app := NewApp(selectHarness{build: func(s *selectHarnessState) Widget {
+		harness = s
+		return ComboBox[string]{Items: selectTestFruits, Item: selectTestItem, Value: s.value, Width: 16, OnChanged: s.setValue,
+			OnTextChanged: func(_ EventContext, text string) { typed = append(typed, text) }}
+	}})
+	size := Size{Width: 30, Height: 10}
+	debugRenderedLines(app, size)
+
+	app.Send(vaxis.Key{Text: "b", Keycode: 'b'})
+	app.Send(vaxis.Key{Text: "e", Keycode: 'e'})
+	lines := debugRenderedLines(app, size)
+	if !strings.Contains(lines[2], "Blueberry") || strings.Contains(strings.Join(lines[2:], "\n"), "Apple") {
+		t.Fatalf("suggestions for %q:\n%s", typed, strings.Join(lines, "\n"))
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	lines = debugRenderedLines(app, size)
+	if harness.value != "Blueberry" || !strings.HasPrefix(lines[0], " Blueberry") || strings.Contains(lines[2], "Blueberry") {
+		t.Fatalf("value %q after Enter:\n%s", harness.value, strings.Join(lines, "\n"))
+	}
+
+	// Down shows every item again until the text is edited
+	app.Send(vaxis.Key{Keycode: vaxis.KeyDown})
+	if got := strings.Join(debugRenderedLines(app, size), "\n"); !strings.Contains(got, "Apple") || !strings.Contains(got, "Grape") {
+		t.Fatalf("suggestions after Down:\n%s", got)
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEsc})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyBackspace})
+	app.Send(vaxis.Key{Text: "x", Keycode: 'x'})
+	if got := debugRenderedLines(app, size)[2]; !strings.Contains(got, "No matches") {
+		t.Fatalf("row = %q, typed %q", got, typed)
+	}
+}
+
+func TestComboBoxLoadsMultipleChoices(t *testing.T) {
+	var harness *selectHarnessState
+	pending := map[string]func([]string, error){}
+	app := NewApp(selectHarness{build: func(s *selectHarnessState) Widget {
+		harness = s
+		return ComboBox[string]{Values: s.values, MultiSelect: true, Width: 12, OnValuesChanged: s.setValues,
+			LoadItems: func(query string, done func([]string, error)) { pending[query] = done }}
+	}})
+	size := Size{Width: 40, Height: 10}
+	debugRenderedLines(app, size)
+
+	app.Send(vaxis.Key{Text: "r", Keycode: 'r'})
+	app.Send(vaxis.Key{Text: "e", Keycode: 'e'})
+	// The results for "re" arrive before the outdated ones for "r"
+	pending["re"]([]string{"red", "green"}, nil)
+	pending["r"]([]string{"rust"}, nil)
+	lines := debugRenderedLines(app, size)
+	if !strings.Contains(lines[2], "red") || strings.Contains(strings.Join(lines, "\n"), "rust") {
+		t.Fatalf("suggestions:\n%s", strings.Join(lines, "\n"))
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyDown})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	debugRenderedLines(app, size)
+	pending[""]([]string{"blue", "green"}, nil)
+	lines = debugRenderedLines(app, size)
+	if strings.Join(harness.values, ",") != "green" || !strings.HasPrefix(lines[0], " green ×") || !strings.Contains(lines[3], "✓ green") {
+		t.Fatalf("values %q:\n%s", harness.values, strings.Join(lines, "\n"))
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyBackspace})
+	debugRenderedLines(app, size)
+	if len(harness.values) != 0 {
+		t.Fatalf("values %q after Backspace", harness.values)
+	}
+}
@@ -22,6 +22,9 @@ type FocusScope struct {
 	// ReclaimFocus moves focus back into the scope on rebuild when focus is
 	// outside the scope after the initial autofocus.
 	ReclaimFocus bool
+	// SkipTraversal leaves focusable descendants out of Tab and Shift+Tab
+	// traversal, such as those of a page that is mounted but not shown.
+	SkipTraversal bool
 	// Child is the scoped subtree.
 	Child Widget
 }
@@ -51,6 +51,10 @@ const (
 	SelectAllTextIntentType IntentType = "vaxis.text.select-all"
 	// CopySelectionTextIntentType copies the current text selection.
 	CopySelectionTextIntentType IntentType = "vaxis.text.copy-selection"
+	// UndoIntentType reverts the last text edit.
+	UndoIntentType IntentType = "vaxis.text.undo"
+	// RedoIntentType reapplies the last undone text edit.
+	RedoIntentType IntentType = "vaxis.text.redo"
 )
 
 // MoveCaretIntent moves the caret or extends the selection.
@@ -107,3 +111,21 @@ type CopySelectionTextIntent struct {
 func (CopySelectionTextIntent) IntentType() IntentType {
 	return CopySelectionTextIntentType
 }
+
+// UndoIntent reverts the last text edit, restoring the cursor and selection
+// from before it. Text widgets bind it to Ctrl+Z, which never reaches them
+// when vaxis.Options.JobControl keeps its default suspend key; set
+// JobControlOptions.SuspendKey to another key to undo with Ctrl+Z.
+type UndoIntent struct{}
+
+func (UndoIntent) IntentType() IntentType {
+	return UndoIntentType
+}
+
+// RedoIntent reapplies the last undone text edit. Text widgets bind it to
+// Ctrl+Shift+Z and Ctrl+Y.
+type RedoIntent struct{}
+
+func (RedoIntent) IntentType() IntentType {
+	return RedoIntentType
+}
@@ -0,0 +1,48 @@
+package ui
+
+import "go.rockorager.dev/vaxis"
+
+// ParseTextSpans parses styled text into spans. See [vaxis.ParseMarkup] for
+// the syntax.
+func ParseTextSpans(markup string) ([]TextSpan, error) {
+	segs, err := vaxis.ParseMarkup(markup)
+	if err != nil {
+		return nil, err
+	}
+	spans := make([]TextSpan, 0, len(segs))
+	for _, seg := range segs {
+		spans = append(spans, TextSpan{Text: seg.Text, Style: seg.Style})
+	}
+	return spans, nil
+}
+
+// FormatTextSpans writes the text and style of spans as markup which
+// [ParseTextSpans] parses back to the same spans. Callbacks are not written.
+func FormatTextSpans(spans []TextSpan) string {
+	segs := make([]vaxis.Segment, 0, len(spans))
+	for _, span := range spans {
+		segs = append(segs, vaxis.Segment{Text: span.Text, Style: span.Style})
+	}
+	return vaxis.FormatMarkup(segs)
+}
+
+// Markup returns a RichText displaying styled text, or an error if markup is
+// invalid. See [vaxis.ParseMarkup] for the syntax.
+func Markup(markup string) (RichText, error) {
+	spans, err := ParseTextSpans(markup)
+	if err != nil {
+		return RichText{}, err
+	}
+	return RichText{Spans: spans}, nil
+}
+
+// MustMarkup is like [Markup] but panics if markup is invalid, so it suits
+// markup written in source. Use [Markup] for markup loaded at runtime, such
+// as translations.
+func MustMarkup(markup string) RichText {
+	text, err := Markup(markup)
+	if err != nil {
+		panic("ui: " + err.Error())
+	}
+	return text
+}
@@ -0,0 +1,58 @@
+package ui_test
+
+import (
+	"testing"
+
+	"go.rockorager.dev/vaxis"
+	"go.rockorager.dev/vaxis/ui"
+	"go.rockorager.dev/vaxis/ui/uitest"
+)
+
+func TestMarkupPaintsStyledSpans(t *testing.T) {
+	app := uitest.New(ui.MustMarkup("[bold red]err[/] [link=https://vaxis.dev]docs[/]"))
+	app.Pump(20, 1)
+	if got := app.Cell(0, 0).Attribute; got&vaxis.AttrBold == 0 {
+		t.Fatalf("attribute = %v, want bold", got)
+	}
+	if got := app.Cell(0, 0).Foreground; got != vaxis.IndexColor(1) {
+		t.Fatalf("foreground = %v, want red", got)
+	}
+	if got := app.Cell(4, 0).Hyperlink; got != "https://vaxis.dev" {
+		t.Fatalf("hyperlink = %q, want https://vaxis.dev", got)
+	}
+	if got := app.Cell(3, 0).Attribute; got&vaxis.AttrBold != 0 {
+		t.Fatal("bold leaked past [/]")
+	}
+}
+
+func TestMarkupRejectsInvalidMarkup(t *testing.T) {
+	if _, err := ui.Markup("[bold"); err == nil {
+		t.Fatal("Markup accepted an unclosed tag")
+	}
+	if text, err := ui.Markup("[bold]ok"); err != nil || len(text.Spans) != 1 {
+		t.Fatalf("Markup = %+v, %v", text, err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustMarkup didn't panic on an unclosed tag")
+		}
+	}()
+	ui.MustMarkup("[bold")
+}
+
+func TestFormatTextSpansRoundTrip(t *testing.T) {
+	spans := []ui.TextSpan{
+		{Text: "a [b]"},
+		{Text: "c", Style: ui.Style{Attribute: vaxis.AttrItalic, Foreground: vaxis.HexColor(0xABCDEF)}},
+	}
+	got, err := ui.ParseTextSpans(ui.FormatTextSpans(spans))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Text != spans[0].Text || got[1].Text != spans[1].Text || got[1].Style != spans[1].Style {
+		t.Fatalf("round trip = %+v", got)
+	}
+	if _, err := ui.ParseTextSpans("[bold"); err == nil {
+		t.Fatal("invalid markup parsed")
+	}
+}
@@ -0,0 +1,995 @@
+package ui
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.rockorager.dev/vaxis"
+)
+
+const (
+	menuCheckMark   = "✓"
+	menuSubmenuMark = "▸"
+)
+
+// MenuItem is one entry of a MenuBar menu or a ContextMenu.
+type MenuItem struct {
+	// Label is the item text. An "&" marks the rune after it as the item's
+	// mnemonic, which is underlined and chooses the item when typed; "&&" is a
+	// literal "&".
+	Label string
+	// Intent is invoked through the Actions above the MenuBar or ContextMenu
+	// when the item is chosen.
+	Intent Intent
+	// OnSelected is called when the item is chosen, after Intent is invoked.
+	OnSelected VoidCallback
+	// Shortcut is the key hint shown at the end of the item. When empty, the
+	// hint is the binding of Intent in the nearest Shortcuts above the menu
+	// that binds it.
+	Shortcut string
+	// Disabled dims the item and keeps it from being chosen.
+	Disabled bool
+	// Checked shows a check mark before the label.
+	Checked bool
+	// Children is a submenu opened by the item instead of choosing it.
+	Children []MenuItem
+	// Separator draws a line between groups of items. The other fields are
+	// ignored.
+	Separator bool
+}
+
+// menuLabel returns the text of an "&" marked label and the byte offset of
+// its mnemonic rune, or -1 when it has none.
+func menuLabel(label string) (string, int) {
+	var b strings.Builder
+	mnemonic := -1
+	for i := 0; i < len(label); i++ {
+		if label[i] == '&' && i+1 < len(label) {
+			i++
+			if label[i] != '&' && mnemonic < 0 {
+				mnemonic = b.Len()
+			}
+		}
+		b.WriteByte(label[i])
+	}
+	return b.String(), mnemonic
+}
+
+// menuMnemonic returns the lowercased mnemonic rune of label, or 0.
+func menuMnemonic(label string) rune {
+	text, at := menuLabel(label)
+	if at < 0 {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(text[at:])
+	return unicode.ToLower(r)
+}
+
+// menuSelectable reports whether item can be highlighted and chosen.
+func menuSelectable(item MenuItem) bool {
+	return !item.Separator && !item.Disabled
+}
+
+// menuNextSelectable returns the selectable item after from in direction
+// delta, wrapping around, or -1 when there is none.
+func menuNextSelectable(items []MenuItem, from, delta int) int {
+	n := len(items)
+	if from < 0 && delta < 0 {
+		from = n
+	}
+	for i := 1; i <= n; i++ {
+		index := ((from+delta*i)%n + n) % n
+		if menuSelectable(items[index]) {
+			return index
+		}
+	}
+	return -1
+}
+
+// menuShortcutHint returns the binding of intent in the nearest Shortcuts
+// above ctx that binds it, falling back to the app-level shortcuts. It chooses
+// the first in sorted order when there are several.
+func menuShortcutHint(ctx BuildContext, intent Intent) string {
+	if intent == nil || ctx.element == nil {
+		return ""
+	}
+	for e := ctx.element; e != nil; e = e.Base().parent {
+		shortcuts, ok := e.(*shortcutsElement)
+		if !ok {
+			continue
+		}
+		if binding := menuBinding(shortcuts.widget.(Shortcuts).Bindings, intent); binding != "" {
+			return binding
+		}
+	}
+	if owner := ctx.element.Base().owner; owner != nil && owner.app != nil {
+		return menuBinding(owner.app.shortcuts, intent)
+	}
+	return ""
+}
+
+// menuBinding returns the first binding of intent in sorted order, or "".
+func menuBinding(shortcuts ShortcutMap, intent Intent) string {
+	var bindings []string
+	for binding, bound := range shortcuts {
+		if reflect.DeepEqual(bound, intent) {
+			bindings = append(bindings, binding)
+		}
+	}
+	if len(bindings) == 0 {
+		return ""
+	}
+	sort.Strings(bindings)
+	return bindings[0]
+}
+
+// menuSession is the chain of menus open from a MenuBar or ContextMenu. The
+// menus are one Overlay entry, a menuLayer, which holds focus while it is
+// shown.
+type menuSession struct {
+	owner   BuildContext
+	overlay *OverlayController
+	entry   *OverlayEntryHandle
+	layer   *menuLayerState
+	levels  []*menuLevel
+	node    FocusNode
+	// focus is the widget focused when the menus opened.
+	focus focusTarget
+	// passthrough is the area, in overlay coordinates, left to the widgets
+	// below the menus, such as the MenuBar they drop from.
+	passthrough Rect
+	// step opens the neighbouring menu of a MenuBar when Left or Right can't
+	// move within the open menus.
+	step func(delta int) bool
+	// onClose is called after the menus close.
+	onClose func()
+}
+
+// menuLevel is one open menu. The first level is the menu opened by the
+// owner; each further level is a submenu of the item active in the one
+// before.
+type menuLevel struct {
+	items  []MenuItem
+	hints  []string
+	anchor Rect
+	side   AnchorSide
+	active int
+	panel  *renderMenuPanel
+}
+
+func (s *menuSession) isOpen() bool {
+	return s.entry.Mounted()
+}
+
+// open shows items as the only open menu, inserting the menus into overlay
+// unless they are already shown.
+func (s *menuSession) open(overlay *OverlayController, items []MenuItem, anchor Rect, side AnchorSide, active int) bool {
+	if !overlay.Attached() {
+		return false
+	}
+	level := s.newLevel(items, anchor, side, active)
+	if s.entry.Mounted() && s.overlay == overlay {
+		s.levels = []*menuLevel{level}
+		s.changed()
+		return true
+	}
+	s.dispose()
+	s.levels = []*menuLevel{level}
+	s.overlay = overlay
+	s.focus = s.owner.element.Base().owner.app.focused
+	s.entry = overlay.Insert(OverlayEntry{Child: menuLayer{session: s}})
+	return true
+}
+
+func (s *menuSession) newLevel(items []MenuItem, anchor Rect, side AnchorSide, active int) *menuLevel {
+	hints := make([]string, len(items))
+	for i, item := range items {
+		switch {
+		case len(item.Children) > 0:
+			hints[i] = menuSubmenuMark
+		case item.Shortcut != "":
+			hints[i] = item.Shortcut
+		default:
+			hints[i] = menuShortcutHint(s.owner, item.Intent)
+		}
+	}
+	return &menuLevel{items: items, hints: hints, anchor: anchor, side: side, active: active}
+}
+
+// close hides the menus and moves focus back to where it was when they
+// opened.
+func (s *menuSession) close() {
+	if !s.entry.Mounted() {
+		return
+	}
+	s.dispose()
+	app := s.owner.element.Base().owner.app
+	if saved := s.focus; saved.element != nil && app.hasFocusTarget(saved) {
+		app.setFocused(saved)
+	}
+	s.focus = focusTarget{}
+	if s.onClose != nil {
+		s.onClose()
+	}
+}
+
+// dispose removes the menus without restoring focus or calling onClose, as
+// when their owner is disposed.
+func (s *menuSession) dispose() {
+	s.entry.Remove()
+	s.entry = nil
+	s.levels = nil
+}
+
+func (s *menuSession) changed() {
+	if s.layer != nil {
+		s.layer.MarkNeedsBuild()
+	}
+}
+
+// back closes the innermost submenu, or every menu when none is open.
+func (s *menuSession) back() {
+	if len(s.levels) > 1 {
+		s.levels = s.levels[:len(s.levels)-1]
+		s.changed()
+		return
+	}
+	s.close()
+}
+
+// openSubmenu opens the submenu of item index of level beside its row. A
+// submenu opened from the keyboard highlights its first item.
+func (s *menuSession) openSubmenu(level, index int, keyboard bool) {
+	parent := s.levels[level]
+	s.levels = s.levels[:level+1]
+	parent.active = index
+	s.changed()
+	item := parent.items[index]
+	if !menuSelectable(item) || len(item.Children) == 0 || parent.panel == nil {
+		return
+	}
+	rect, ok := s.overlay.state.rect(parent.panel)
+	if !ok {
+		return
+	}
+	active := -1
+	if keyboard {
+		active = menuNextSelectable(item.Children, -1, 1)
+	}
+	// The anchor spans the row and the border rows around it, so the first
+	// item of the submenu lines up with the row
+	anchor := Rect{X: rect.X, Y: rect.Y + index, Width: rect.Width, Height: 3}
+	s.levels = append(s.levels, s.newLevel(item.Children, anchor, AnchorRight, active))
+}
+
+// choose opens the submenu of an item with children and otherwise closes the
+// menus and runs the item.
+func (s *menuSession) choose(level, index int) {
+	item := s.levels[level].items[index]
+	if !menuSelectable(item) {
+		return
+	}
+	if len(item.Children) > 0 {
+		s.openSubmenu(level, index, true)
+		return
+	}
+	s.close()
+	ctx := s.owner.EventContext()
+	if item.Intent != nil {
+		ctx.Invoke(item.Intent)
+	}
+	if item.OnSelected != nil {
+		item.OnSelected(ctx)
+	}
+}
+
+// hover highlights the item under the mouse, opening its submenu and closing
+// the submenus of other items.
+func (s *menuSession) hover(level, index int) {
+	current := s.levels[level]
+	if current.active == index && len(s.levels) > level+1 {
+		s.levels = s.levels[:level+2]
+		s.changed()
+		return
+	}
+	if !menuSelectable(current.items[index]) {
+		return
+	}
+	if current.active == index && len(s.levels) == level+1 {
+		return
+	}
+	s.openSubmenu(level, index, false)
+}
+
+func (s *menuSession) handleKey(key Key) EventResult {
+	level := len(s.levels) - 1
+	current := s.levels[level]
+	switch {
+	case key.Keycode == KeyUp:
+		s.highlight(menuNextSelectable(current.items, current.active, -1))
+	case key.Keycode == KeyDown:
+		s.highlight(menuNextSelectable(current.items, current.active, 1))
+	case key.Keycode == KeyHome:
+		s.highlight(menuNextSelectable(current.items, -1, 1))
+	case key.Keycode == KeyEnd:
+		s.highlight(menuNextSelectable(current.items, -1, -1))
+	case key.Keycode == KeyRight:
+		if current.active >= 0 && len(current.items[current.active].Children) > 0 {
+			s.openSubmenu(level, current.active, true)
+		} else if s.step != nil {
+			s.step(1)
+		}
+	case key.Keycode == KeyLeft:
+		if level > 0 {
+			s.back()
+		} else if s.step != nil {
+			s.step(-1)
+		}
+	case key.MatchString("Enter") || key.MatchString("Space"):
+		if current.active >= 0 {
+			s.choose(level, current.active)
+		}
+	case key.Text != "" && key.Modifiers&(vaxis.ModCtrl|vaxis.ModAlt|vaxis.ModSuper) == 0:
+		r, _ := utf8.DecodeRuneInString(key.Text)
+		s.mnemonic(unicode.ToLower(r))
+	default:
+		return EventIgnored
+	}
+	return EventHandled
+}
+
+func (s *menuSession) highlight(index int) {
+	if index < 0 {
+		return
+	}
+	s.levels[len(s.levels)-1].active = index
+	s.changed()
+}
+
+// mnemonic chooses the only item of the innermost menu with mnemonic r, or
+// highlights the next of several.
+func (s *menuSession) mnemonic(r rune) {
+	level := len(s.levels) - 1
+	current := s.levels[level]
+	var matches []int
+	for i, item := range current.items {
+		if menuSelectable(item) && menuMnemonic(item.Label) == r {
+			matches = append(matches, i)
+		}
+	}
+	switch {
+	case len(matches) == 1:
+		s.choose(level, matches[0])
+	case len(matches) > 1:
+		next := matches[0]
+		for _, i := range matches {
+			if i > current.active {
+				next = i
+				break
+			}
+		}
+		s.highlight(next)
+	}
+}
+
+// menuLayer is the Overlay entry showing the open menus of a session.
+type menuLayer struct {
+	session *menuSession
+}
+
+func (w menuLayer) CreateState() State {
+	return &menuLayerState{}
+}
+
+type menuLayerState struct {
+	StateBase
+}
+
+func (s *menuLayerState) Dispose() {
+	if session := s.Widget().(menuLayer).session; session.layer == s {
+		session.layer = nil
+	}
+}
+
+func (s *menuLayerState) Build(ctx BuildContext) Widget {
+	session := s.Widget().(menuLayer).session
+	session.layer = s
+	theme := menuTheme(MustDepend[Theme](ctx))
+	var children []Widget
+	for i, level := range session.levels {
+		children = append(children, Anchored{
+			Anchor: level.anchor,
+			Side:   level.side,
+			Child:  menuPanel{session: session, level: i, items: level.items, hints: level.hints, active: level.active, theme: theme},
+		})
+	}
+	return Actions{
+		Bindings: map[IntentType]ActionFunc{
+			DismissIntentType: func(EventContext, Intent) EventResult {
+				session.back()
+				return EventHandled
+			},
+		},
+		Child: FocusScope{Trap: true, AutoFocus: true, Child: Focus(&session.node, menuLayerArea{session: session, children: children})},
+	}
+}
+
+func (s *menuLayerState) HandleEvent(ctx EventContext, ev Event) EventResult {
+	if ctx.Phase() != TargetPhase && ctx.Phase() != BubblePhase {
+		return EventIgnored
+	}
+	session := s.Widget().(menuLayer).session
+	key, ok := ev.(Key)
+	if !ok || keyIsRelease(key) || len(session.levels) == 0 {
+		return EventIgnored
+	}
+	return session.handleKey(key)
+}
+
+// menuLayerArea covers the overlay with the open menus, painting them in
+// order. Pressing outside the menus closes them, except in the session's
+// passthrough area, which is left to the widgets below.
+type menuLayerArea struct {
+	session  *menuSession
+	children []Widget
+}
+
+func (w menuLayerArea) WidgetChildren() []Widget {
+	return w.children
+}
+
+func (w menuLayerArea) CreateRenderObject(BuildContext) RenderObject {
+	return &renderMenuLayerArea{session: w.session}
+}
+
+func (w menuLayerArea) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	ro.(*renderMenuLayerArea).session = w.session
+}
+
+type renderMenuLayerArea struct {
+	MultiChildRenderObject
+	session *menuSession
+}
+
+func (r *renderMenuLayerArea) Layout(ctx LayoutContext, c Constraints) {
+	for _, child := range r.Children() {
+		child.Layout(ctx, alignChildConstraints(c))
+	}
+	r.SetSize(alignOuterSize(c, Size{}))
+}
+
+func (r *renderMenuLayerArea) DryLayout(_ LayoutContext, c Constraints) Size {
+	return alignOuterSize(c, Size{})
+}
+
+func (r *renderMenuLayerArea) Paint(p *Painter, off Offset) {
+	for _, child := range r.Children() {
+		child.Paint(p, off)
+	}
+}
+
+func (r *renderMenuLayerArea) HitTest(*HitTestResult, Point) bool {
+	return false
+}
+
+func (r *renderMenuLayerArea) HitTestChildrenReverse() bool {
+	return true
+}
+
+func (r *renderMenuLayerArea) HitTestSelf(pt Point) bool {
+	area := r.session.passthrough
+	return pt.X < area.X || pt.Y < area.Y || pt.X >= area.X+area.Width || pt.Y >= area.Y+area.Height
+}
+
+func (r *renderMenuLayerArea) HandleEvent(ctx EventContext, ev Event) EventResult {
+	mouse, ok := ev.(Mouse)
+	if !ok || ctx.Phase() != TargetPhase || mouse.EventType != EventPress {
+		return EventIgnored
+	}
+	r.session.close()
+	return EventHandled
+}
+
+// menuPanel paints one open menu: a bordered column of items with check
+// marks, labels and hints.
+type menuPanel struct {
+	session *menuSession
+	level   int
+	items   []MenuItem
+	hints   []string
+	active  int
+	theme   MenuTheme
+}
+
+func (w menuPanel) CreateRenderObject(BuildContext) RenderObject {
+	r := &renderMenuPanel{session: w.session, level: w.level, items: w.items, hints: w.hints, active: w.active, theme: w.theme}
+	w.session.levels[w.level].panel = r
+	return r
+}
+
+func (w menuPanel) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	r := ro.(*renderMenuPanel)
+	r.session = w.session
+	r.level = w.level
+	r.items = w.items
+	r.hints = w.hints
+	r.active = w.active
+	r.theme = w.theme
+	if w.level < len(w.session.levels) {
+		w.session.levels[w.level].panel = r
+	}
+	r.MarkNeedsLayout()
+}
+
+type renderMenuPanel struct {
+	LeafRenderObject
+	session *menuSession
+	level   int
+	items   []MenuItem
+	hints   []string
+	active  int
+	theme   MenuTheme
+}
+
+// naturalSize fits a border around rows of a space, the check column, the
+// widest label, a two-cell gap, the widest hint and a space.
+func (r *renderMenuPanel) naturalSize() Size {
+	labelWidth, hintWidth := 0, 0
+	for i, item := range r.items {
+		if item.Separator {
+			continue
+		}
+		text, _ := menuLabel(item.Label)
+		labelWidth = max(labelWidth, textWidth(text))
+		hintWidth = max(hintWidth, textWidth(r.hints[i]))
+	}
+	width := 2 + 1 + 2 + labelWidth + 1
+	if hintWidth > 0 {
+		width += 2 + hintWidth
+	}
+	return Size{Width: width, Height: len(r.items) + 2}
+}
+
+func (r *renderMenuPanel) Layout(_ LayoutContext, c Constraints) {
+	r.SetSize(c.Constrain(r.naturalSize()))
+}
+
+func (r *renderMenuPanel) DryLayout(_ LayoutContext, c Constraints) Size {
+	return c.Constrain(r.naturalSize())
+}
+
+func (r *renderMenuPanel) Paint(p *Painter, off Offset) {
+	size := r.Size()
+	if size.Width < 2 || size.Height < 2 {
+		return
+	}
+	t := r.theme
+	p.Fill(Rect{X: off.X, Y: off.Y, Width: size.Width, Height: size.Height}, Cell{Character: Character{Grapheme: " ", Width: 1}, Style: t.Panel})
+	var lines Lines
+	lines.Box(0, 0, size.Width, size.Height, vaxis.LineLight, t.Border)
+	for i, item := range r.items {
+		if item.Separator && i+1 < size.Height-1 {
+			lines.Horizontal(0, i+1, size.Width, vaxis.LineLight, t.Border)
+		}
+	}
+	p.DrawLines(off, &lines)
+
+	inner := size.Width - 2
+	for i, item := range r.items {
+		y := off.Y + 1 + i
+		if item.Separator || i+1 >= size.Height-1 {
+			continue
+		}
+		style := t.Panel
+		switch {
+		case item.Disabled:
+			style = t.Disabled
+		case i == r.active:
+			style = t.Active
+		}
+		p.Fill(Rect{X: off.X + 1, Y: y, Width: inner, Height: 1}, Cell{Character: Character{Grapheme: " ", Width: 1}, Style: style})
+		x := off.X + 2
+		if item.Checked {
+			p.DrawText(Offset{X: x, Y: y}, menuCheckMark, style)
+		}
+		x += 2
+		text, at := menuLabel(item.Label)
+		mnemonic := mergeStyle(style, t.Mnemonic)
+		if item.Disabled {
+			mnemonic = style
+		}
+		x = paintMenuLabel(p, Offset{X: x, Y: y}, text, at, style, mnemonic)
+		if hint := r.hints[i]; hint != "" {
+			hintStyle := mergeStyle(style, t.Hint)
+			if item.Disabled {
+				hintStyle = style
+			}
+			p.DrawText(Offset{X: off.X + size.Width - 2 - textWidth(hint), Y: y}, hint, hintStyle)
+		}
+	}
+}
+
+// paintMenuLabel draws text with the rune at byte offset at in the mnemonic
+// style and returns the column after it.
+func paintMenuLabel(p *Painter, off Offset, text string, at int, style, mnemonic Style) int {
+	if at < 0 {
+		p.DrawText(off, text, style)
+		return off.X + textWidth(text)
+	}
+	_, n := utf8.DecodeRuneInString(text[at:])
+	p.DrawText(off, text[:at], style)
+	off.X += textWidth(text[:at])
+	p.DrawText(off, text[at:at+n], mnemonic)
+	off.X += textWidth(text[at : at+n])
+	p.DrawText(off, text[at+n:], style)
+	return off.X + textWidth(text[at+n:])
+}
+
+// itemAt returns the item under pt, or -1 on the border.
+func (r *renderMenuPanel) itemAt(pt Point) int {
+	size := r.Size()
+	index := pt.Y - 1
+	if pt.X < 1 || pt.X >= size.Width-1 || index < 0 || index >= len(r.items) || index >= size.Height-2 {
+		return -1
+	}
+	return index
+}
+
+func (r *renderMenuPanel) MouseShape(_ EventContext, mouse Mouse) MouseShape {
+	if index := r.itemAt(Point{X: mouse.Col, Y: mouse.Row}); index >= 0 && menuSelectable(r.items[index]) {
+		return r.theme.Mouse
+	}
+	return MouseShapeDefault
+}
+
+func (r *renderMenuPanel) HandleEvent(ctx EventContext, ev Event) EventResult {
+	mouse, ok := ev.(Mouse)
+	if !ok || ctx.Phase() != TargetPhase || r.level >= len(r.session.levels) {
+		return EventIgnored
+	}
+	index := r.itemAt(Point{X: mouse.Col, Y: mouse.Row})
+	switch {
+	case index < 0:
+	case mouse.EventType == EventMotion:
+		r.session.hover(r.level, index)
+	case mouse.EventType == EventPress && (mouse.Button == MouseLeftButton || mouse.Button == MouseRightButton):
+		r.session.choose(r.level, index)
+	}
+	return EventHandled
+}
+
+// MenuBar is a row of menu titles, each dropping down a menu.
+//
+// Clicking a title opens its menu, and moving the mouse across the bar while
+// a menu is open opens the menu under it. When the bar has focus, Left and
+// Right move between titles, Enter, Space or Down open the highlighted menu,
+// and typing a title's mnemonic, with or without Alt, opens that menu.
+//
+// In an open menu, Up and Down move between items, Right opens a submenu or
+// the next menu of the bar, Left closes a submenu or opens the previous menu,
+// Enter or Space chooses the highlighted item, and typing a mnemonic chooses
+// its item. Escape or a click outside the menus closes them and returns focus
+// to where it was. Choosing an item invokes its Intent from the MenuBar, so
+// it is handled by the Actions above the bar.
+//
+// The menus are shown in the nearest Overlay above the MenuBar; without one
+// they can't open.
+type MenuBar struct {
+	// Menus are the titles of the bar. Each menu's Label is the title and its
+	// Children the items of its menu. Disabled menus can't be opened.
+	Menus []MenuItem
+}
+
+func (w MenuBar) CreateState() State {
+	return &menuBarState{open: -1}
+}
+
+type menuBarState struct {
+	StateBase
+	node      FocusNode
+	session   menuSession
+	open      int
+	highlight int
+}
+
+func (s *menuBarState) InitState() {
+	s.session.owner = s.Context()
+	s.session.step = func(delta int) bool {
+		menus := s.Widget().(MenuBar).Menus
+		return s.openMenu(menuNextSelectable(menus, s.open, delta), true)
+	}
+	s.session.onClose = func() {
+		s.SetState(func() { s.open = -1 })
+	}
+}
+
+func (s *menuBarState) Dispose() {
+	s.session.dispose()
+}
+
+func (s *menuBarState) Build(ctx BuildContext) Widget {
+	w := s.Widget().(MenuBar)
+	s.node.onChange = s.MarkNeedsBuild
+	if s.open >= len(w.Menus) {
+		s.session.dispose()
+		s.open = -1
+	}
+	s.highlight = clampInt(s.highlight, 0, max(0, len(w.Menus)-1))
+	active := s.open
+	if active < 0 && s.node.HasFocus() {
+		active = s.highlight
+	}
+	return Focus(&s.node, menuBarRenderWidget{Menus: w.Menus, Active: active, Theme: menuTheme(MustDepend[Theme](ctx))})
+}
+
+// openMenu opens the menu of the title at index below it. A menu opened from
+// the keyboard highlights its first item.
+func (s *menuBarState) openMenu(index int, keyboard bool) bool {
+	menus := s.Widget().(MenuBar).Menus
+	if index < 0 || index >= len(menus) || menus[index].Disabled {
+		return false
+	}
+	ctx := s.Context()
+	overlay := OverlayOf(ctx)
+	bar, ok := overlay.Rect(ctx)
+	if !ok {
+		return false
+	}
+	start, width := menuBarTitleSpan(menus, index)
+	active := -1
+	if keyboard {
+		active = menuNextSelectable(menus[index].Children, -1, 1)
+	}
+	s.session.passthrough = bar
+	anchor := Rect{X: bar.X + start, Y: bar.Y, Width: width, Height: 1}
+	if !s.session.open(overlay, menus[index].Children, anchor, AnchorBelow, active) {
+		return false
+	}
+	s.SetState(func() { s.open, s.highlight = index, index })
+	return true
+}
+
+func (s *menuBarState) MouseShape(_ EventContext, mouse Mouse) MouseShape {
+	menus := s.Widget().(MenuBar).Menus
+	if index := menuBarTitleAt(menus, mouse.Col); index >= 0 && !menus[index].Disabled {
+		return menuTheme(MustDepend[Theme](s.Context())).Mouse
+	}
+	return MouseShapeDefault
+}
+
+func (s *menuBarState) HandleEvent(ctx EventContext, ev Event) EventResult {
+	if ctx.Phase() != TargetPhase && ctx.Phase() != BubblePhase {
+		return EventIgnored
+	}
+	menus := s.Widget().(MenuBar).Menus
+	switch ev := ev.(type) {
+	case Key:
+		if keyIsRelease(ev) || len(menus) == 0 {
+			return EventIgnored
+		}
+		return s.handleKey(menus, ev)
+	case Mouse:
+		index := menuBarTitleAt(menus, ev.Col)
+		switch {
+		case ev.EventType == EventPress && ev.Button == MouseLeftButton:
+			if index >= 0 && index == s.open {
+				s.session.close()
+			} else {
+				s.openMenu(index, false)
+			}
+			return EventHandled
+		case ev.EventType == EventMotion && s.session.isOpen() && index >= 0 && index != s.open:
+			s.openMenu(index, false)
+			return EventHandled
+		}
+	}
+	return EventIgnored
+}
+
+func (s *menuBarState) handleKey(menus []MenuItem, key Key) EventResult {
+	switch {
+	case key.Keycode == KeyLeft:
+		s.moveHighlight(menuNextSelectable(menus, s.highlight, -1))
+	case key.Keycode == KeyRight:
+		s.moveHighlight(menuNextSelectable(menus, s.highlight, 1))
+	case key.MatchString("Enter") || key.MatchString("Space") || key.Keycode == KeyDown:
+		s.openMenu(s.highlight, true)
+	case key.Text != "" && key.Modifiers&(vaxis.ModCtrl|vaxis.ModSuper) == 0:
+		r, _ := utf8.DecodeRuneInString(key.Text)
+		r = unicode.ToLower(r)
+		for i, menu := range menus {
+			if menuSelectable(menu) && menuMnemonic(menu.Label) == r {
+				return s.openResult(s.openMenu(i, true))
+			}
+		}
+		return EventIgnored
+	default:
+		return EventIgnored
+	}
+	return EventHandled
+}
+
+func (s *menuBarState) openResult(opened bool) EventResult {
+	if opened {
+		return EventHandled
+	}
+	return EventIgnored
+}
+
+func (s *menuBarState) moveHighlight(index int) {
+	if index >= 0 {
+		s.SetState(func() { s.highlight = index })
+	}
+}
+
+// menuBarTitleSpan returns the start column and width of the title at index.
+// Each title is its label with a space on either side.
+func menuBarTitleSpan(menus []MenuItem, index int) (int, int) {
+	start := 0
+	for i, menu := range menus {
+		text, _ := menuLabel(menu.Label)
+		width := textWidth(text) + 2
+		if i == index {
+			return start, width
+		}
+		start += width
+	}
+	return start, 0
+}
+
+// menuBarTitleAt returns the title under col, or -1.
+func menuBarTitleAt(menus []MenuItem, col int) int {
+	start := 0
+	for i, menu := range menus {
+		text, _ := menuLabel(menu.Label)
+		width := textWidth(text) + 2
+		if col >= start && col < start+width {
+			return i
+		}
+		start += width
+	}
+	return -1
+}
+
+type menuBarRenderWidget struct {
+	Menus  []MenuItem
+	Active int
+	Theme  MenuTheme
+}
+
+func (w menuBarRenderWidget) CreateRenderObject(BuildContext) RenderObject {
+	return &renderMenuBar{menus: w.Menus, active: w.Active, theme: w.Theme}
+}
+
+func (w menuBarRenderWidget) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	r := ro.(*renderMenuBar)
+	r.menus = w.Menus
+	r.active = w.Active
+	r.theme = w.Theme
+	r.MarkNeedsLayout()
+}
+
+type renderMenuBar struct {
+	LeafRenderObject
+	menus  []MenuItem
+	active int
+	theme  MenuTheme
+}
+
+func (r *renderMenuBar) naturalSize(c Constraints) Size {
+	start, width := menuBarTitleSpan(r.menus, len(r.menus)-1)
+	size := Size{Width: start + width, Height: 1}
+	if c.HasBoundedWidth() {
+		size.Width = c.MaxWidth
+	}
+	return c.Constrain(size)
+}
+
+func (r *renderMenuBar) Layout(_ LayoutContext, c Constraints) {
+	r.SetSize(r.naturalSize(c))
+}
+
+func (r *renderMenuBar) DryLayout(_ LayoutContext, c Constraints) Size {
+	return r.naturalSize(c)
+}
+
+func (r *renderMenuBar) Paint(p *Painter, off Offset) {
+	size := r.Size()
+	t := r.theme
+	p.Fill(Rect{X: off.X, Y: off.Y, Width: size.Width, Height: size.Height}, Cell{Character: Character{Grapheme: " ", Width: 1}, Style: t.Bar})
+	x := off.X
+	for i, menu := range r.menus {
+		style := t.Bar
+		switch {
+		case menu.Disabled:
+			style = mergeStyle(t.Bar, Style{Foreground: t.Disabled.Foreground})
+		case i == r.active:
+			style = t.BarActive
+		}
+		text, at := menuLabel(menu.Label)
+		mnemonic := mergeStyle(style, t.Mnemonic)
+		if menu.Disabled {
+			mnemonic = style
+		}
+		p.DrawText(Offset{X: x, Y: off.Y}, " ", style)
+		x = paintMenuLabel(p, Offset{X: x + 1, Y: off.Y}, text, at, style, mnemonic)
+		p.DrawText(Offset{X: x, Y: off.Y}, " ", style)
+		x++
+	}
+}
+
+// ContextMenu opens a menu of Items at the mouse when Child is right-clicked,
+// or at Child's top-left corner on Shift+F10 while focus is inside Child.
+//
+// The menu is placed below and right of the mouse, flipping when it is near
+// the edges of the screen, and behaves like a MenuBar menu: choosing an item
+// invokes its Intent from the ContextMenu, so it is handled by the Actions
+// above it. The menu is shown in the nearest Overlay above the ContextMenu;
+// without one it can't open.
+type ContextMenu struct {
+	// Items are the entries of the menu.
+	Items []MenuItem
+	// Child is the area that opens the menu.
+	Child Widget
+}
+
+func (w ContextMenu) CreateState() State {
+	return &contextMenuState{}
+}
+
+type contextMenuState struct {
+	StateBase
+	session menuSession
+}
+
+func (s *contextMenuState) InitState() {
+	s.session.owner = s.Context()
+}
+
+func (s *contextMenuState) Dispose() {
+	s.session.dispose()
+}
+
+func (s *contextMenuState) Build(BuildContext) Widget {
+	return s.Widget().(ContextMenu).Child
+}
+
+func (s *contextMenuState) HandleEvent(ctx EventContext, ev Event) EventResult {
+	if ctx.Phase() != TargetPhase && ctx.Phase() != BubblePhase {
+		return EventIgnored
+	}
+	switch ev := ev.(type) {
+	case Mouse:
+		if ev.EventType == EventPress && ev.Button == MouseRightButton && s.openAt(Point{X: ev.Col, Y: ev.Row}, false) {
+			return EventHandled
+		}
+	case Key:
+		if !keyIsRelease(ev) && ev.MatchString("Shift+F10") && s.openAt(Point{}, true) {
+			return EventHandled
+		}
+	}
+	return EventIgnored
+}
+
+// openAt opens the menu with its corner at pt, local to the child.
+func (s *contextMenuState) openAt(pt Point, keyboard bool) bool {
+	items := s.Widget().(ContextMenu).Items
+	if len(items) == 0 {
+		return false
+	}
+	ctx := s.Context()
+	overlay := OverlayOf(ctx)
+	rect, ok := overlay.Rect(ctx)
+	if !ok {
+		return false
+	}
+	active := -1
+	if keyboard {
+		active = menuNextSelectable(items, -1, 1)
+	}
+	anchor := Rect{X: rect.X + pt.X, Y: rect.Y + pt.Y, Height: 1}
+	return s.session.open(overlay, items, anchor, AnchorRight, active)
+}
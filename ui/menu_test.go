@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go.rockorager.dev/vaxis"
+)
+
+type menuTestIntent struct{ name string }
+
+func (menuTestIntent) IntentType() IntentType {
+	return "ui.test.menu"
+}
+
+// menuHarness is a MenuBar and a ContextMenu below an Overlay, with Actions
+// recording the intents their items invoke.
+func menuHarness(invoked *[]string, menus []MenuItem, context []MenuItem) Widget {
+	return Overlay{Child: Actions{
+		Bindings: map[IntentType]ActionFunc{
+			"ui.test.menu": func(ctx EventContext, intent Intent) EventResult {
+				*invoked = append(*invoked, intent.(menuTestIntent).name)
+				return EventHandled
+			},
+		},
+		Child: Shortcuts{
+			Bindings: ShortcutMap{"Ctrl+s": menuTestIntent{"save"}},
+			Child: Column(
+				MenuBar{Menus: menus},
+				Expanded(ContextMenu{Items: context, Child: SizedBox{Width: 40, Height: 10}}),
+			),
+		},
+	}}
+}
+
+var menuTestMenus = []MenuItem{
+	{Label: "&File", Children: []MenuItem{
+		{Label: "&New", Intent: menuTestIntent{"new"}, Shortcut: "Ctrl+n"},
+		{Label: "&Save", Intent: menuTestIntent{"save"}},
+		{Separator: true},
+		{Label: "&Recent", Children: []MenuItem{
+			{Label: "&a.txt", Intent: menuTestIntent{"a"}},
+			{Label: "&b.txt", Intent: menuTestIntent{"b"}},
+		}},
+		{Label: "&Quit", Intent: menuTestIntent{"quit"}, Disabled: true},
+	}},
+	{Label: "&View", Children: []MenuItem{
+		{Label: "&Wrap", Intent: menuTestIntent{"wrap"}, Checked: true},
+	}},
+}
+
+func TestMenuBarDropsDownMenuWithHints(t *testing.T) {
+	var invoked []string
+	app := NewApp(menuHarness(&invoked, menuTestMenus, nil))
+	size := Size{Width: 40, Height: 12}
+	app.Pump(size)
+	if got := debugRenderedLines(app, size)[0]; !strings.HasPrefix(got, " File  View") {
+		t.Fatalf("bar = %q", got)
+	}
+
+	app.Send(Mouse{Col: 2, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	app.Pump(size)
+	lines := debugRenderedLines(app, size)
+	want := []string{
+		"┌──────────────────┐",
+		"│   New     Ctrl+n │",
+		"│   Save    Ctrl+s │",
+		"├──────────────────┤",
+		"│   Recent       ▸ │",
+		"│   Quit           │",
+		"└──────────────────┘",
+	}
+	for i, line := range want {
+		if got := strings.TrimRight(lines[1+i], " "); got != strings.TrimRight(line, " ") {
+			t.Fatalf("menu line %d = %q, want %q\n%s", i, got, line, strings.Join(lines, "\n"))
+		}
+	}
+
+	// Moving across the bar opens the other menu, with its check mark
+	app.Send(Mouse{Col: 8, Row: 0, EventType: EventMotion})
+	app.Pump(size)
+	if got := debugRenderedLines(app, size)[2]; !strings.HasPrefix(got, "      │ ✓ Wrap") {
+		t.Fatalf("view menu row = %q", got)
+	}
+
+	// Clicking an item invokes its intent and closes the menus
+	app.Send(Mouse{Col: 9, Row: 2, Button: MouseLeftButton, EventType: EventPress})
+	app.Pump(size)
+	if strings.Join(invoked, ",") != "wrap" {
+		t.Fatalf("invoked %q", invoked)
+	}
+	if got := debugRenderedLines(app, size)[2]; strings.TrimSpace(got) != "" {
+		t.Fatalf("menu still painted: %q", got)
+	}
+}
+
+func TestMenuKeyboardNavigationAndMnemonics(t *testing.T) {
+	var invoked []string
+	app := NewApp(menuHarness(&invoked, menuTestMenus, nil))
+	size := Size{Width: 40, Height: 12}
+	app.Pump(size)
+	bar := focusedDebugLabel(app)
+
+	// The bar is the first focusable; its mnemonic opens File
+	app.Send(vaxis.Key{Text: "f", Keycode: 'f'})
+	app.Pump(size)
+	// r opens Recent, Down moves to b.txt, Left closes the submenu again
+	app.Send(vaxis.Key{Text: "r", Keycode: 'r'})
+	app.Pump(size)
+	if got := debugRenderedLines(app, size)[5]; !strings.Contains(got, "a.txt") {
+		t.Fatalf("submenu not beside Recent: %q", got)
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyLeft})
+	app.Pump(size)
+	if got := debugRenderedLines(app, size)[5]; strings.Contains(got, "a.txt") {
+		t.Fatalf("Left didn't close the submenu: %q", got)
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyRight})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyDown})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	app.Pump(size)
+	if strings.Join(invoked, ",") != "b" {
+		t.Fatalf("invoked %q", invoked)
+	}
+	if got := focusedDebugLabel(app); got != bar {
+		t.Fatalf("focus %q after closing, want it back on %q", got, bar)
+	}
+
+	// Disabled items are skipped and can't be chosen by mnemonic; Right on
+	// an item without a submenu moves to the next menu
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyDown})
+	app.Pump(size)
+	app.Send(vaxis.Key{Text: "q", Keycode: 'q'})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyRight})
+	app.Pump(size)
+	if got := debugRenderedLines(app, size)[2]; !strings.Contains(got, "Wrap") {
+		t.Fatalf("Right didn't open the View menu: %q", got)
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEsc})
+	app.Pump(size)
+	if len(invoked) != 1 || strings.Contains(debugRenderedLines(app, size)[2], "Wrap") {
+		t.Fatalf("after Escape, invoked %q and lines %q", invoked, debugRenderedLines(app, size)[:3])
+	}
+}
+
+func TestContextMenuOpensAtMouseAndFlips(t *testing.T) {
+	var invoked []string
+	items := []MenuItem{
+		{Label: "&Copy", Intent: menuTestIntent{"copy"}},
+		{Label: "&Paste", Intent: menuTestIntent{"paste"}},
+	}
+	app := NewApp(menuHarness(&invoked, nil, items))
+	size := Size{Width: 40, Height: 12}
+	app.Pump(size)
+
+	app.Send(Mouse{Col: 3, Row: 2, Button: MouseRightButton, EventType: EventPress})
+	app.Pump(size)
+	if got := debugRenderedLines(app, size)[2]; !strings.HasPrefix(got, "   ┌────") {
+		t.Fatalf("menu top = %q, want it at the mouse", got)
+	}
+
+	// A click outside closes the menu without choosing anything
+	app.Send(Mouse{Col: 30, Row: 9, Button: MouseLeftButton, EventType: EventPress})
+	app.Pump(size)
+	if got := debugRenderedLines(app, size)[2]; strings.TrimSpace(got) != "" || len(invoked) != 0 {
+		t.Fatalf("after clicking outside, row %q invoked %q", got, invoked)
+	}
+
+	// Near the bottom-right corner the menu opens above and left of the mouse
+	app.Send(Mouse{Col: 38, Row: 10, Button: MouseRightButton, EventType: EventPress})
+	app.Pump(size)
+	lines := debugRenderedLines(app, size)
+	if got := lines[10]; !strings.HasSuffix(strings.TrimRight(got, " "), "┘") || strings.Index(got, "└") != 38-11 {
+		t.Fatalf("flipped menu bottom = %q\n%s", got, strings.Join(lines, "\n"))
+	}
+	app.Send(vaxis.Key{Text: "p", Keycode: 'p'})
+	app.Pump(size)
+	if strings.Join(invoked, ",") != "paste" {
+		t.Fatalf("invoked %q", invoked)
+	}
+}
+
+func TestAnchoredOffsetFlipsAtEdges(t *testing.T) {
+	bounds := Size{Width: 20, Height: 10}
+	child := Size{Width: 6, Height: 4}
+	for _, tc := range []struct {
+		anchor Rect
+		side   AnchorSide
+		want   Offset
+	}{
+		{Rect{X: 2, Y: 1, Width: 4, Height: 1}, AnchorBelow, Offset{X: 2, Y: 2}},
+		{Rect{X: 2, Y: 8, Width: 4, Height: 1}, AnchorBelow, Offset{X: 2, Y: 4}},
+		{Rect{X: 16, Y: 1, Width: 4, Height: 1}, AnchorBelow, Offset{X: 14, Y: 2}},
+		{Rect{X: 2, Y: 1, Width: 4, Height: 1}, AnchorAbove, Offset{X: 2, Y: 2}},
+		{Rect{X: 2, Y: 1, Width: 4, Height: 1}, AnchorRight, Offset{X: 6, Y: 1}},
+		{Rect{X: 12, Y: 8, Width: 4, Height: 1}, AnchorRight, Offset{X: 6, Y: 5}},
+		{Rect{X: 0, Y: 0, Width: 20, Height: 10}, AnchorBelow, Offset{X: 0, Y: 6}},
+	} {
+		if got := anchoredOffset(bounds, tc.anchor, child, tc.side); got != tc.want {
+			t.Errorf("anchoredOffset(%v, %v) = %v, want %v", tc.anchor, tc.side, got, tc.want)
+		}
+	}
+}
+
+func TestMenuHintFallsBackToAppShortcuts(t *testing.T) {
+	var invoked []string
+	menus := []MenuItem{{Label: "&File", Children: []MenuItem{
+		{Label: "&Quit", Intent: menuTestIntent{"quit"}},
+	}}}
+	app := NewApp(menuHarness(&invoked, menus, nil), WithShortcuts(ShortcutMap{"Ctrl+q": menuTestIntent{"quit"}}))
+	size := Size{Width: 40, Height: 12}
+	app.Pump(size)
+	app.Send(Mouse{Col: 2, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	app.Pump(size)
+	if got := debugRenderedLines(app, size)[2]; !strings.Contains(got, "Quit  Ctrl+q") {
+		t.Fatalf("quit row = %q", got)
+	}
+}
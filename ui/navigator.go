@@ -0,0 +1,561 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const defaultRouteTransitionDuration = 150 * time.Millisecond
+
+// RouteTransition selects how a route animates when it is pushed or popped.
+type RouteTransition int
+
+const (
+	// RouteTransitionDefault uses the Navigator's transition.
+	RouteTransitionDefault RouteTransition = iota
+	// RouteTransitionNone switches routes immediately.
+	RouteTransitionNone
+	// RouteTransitionSlide slides routes in from the right edge and back out.
+	RouteTransitionSlide
+	// RouteTransitionFade fades routes in from the theme background and back
+	// out. Only RGB colors fade.
+	RouteTransitionFade
+)
+
+// Route is one page of a Navigator.
+type Route struct {
+	// Name identifies the route for PopUntil and Routes.
+	Name string
+	// Builder builds the page. The context is below the Navigator, so
+	// NavigatorOf finds it.
+	Builder func(BuildContext) Widget
+	// Transition overrides the Navigator transition for this route.
+	Transition RouteTransition
+}
+
+// Navigator shows the top route of a stack of routes.
+//
+// Routes below the top stay mounted while covered, so they keep their state,
+// such as scroll offsets and text input. The focused widget is saved when a
+// route is covered and restored when it is revealed again, and Tab traversal
+// stays inside the top route while others are covered. Escape and other
+// DismissIntent sources pop the top route unless DisableDismiss is set. The
+// first route is never popped.
+type Navigator struct {
+	// Controller pushes and pops routes. When nil, use NavigatorOf from a
+	// descendant.
+	Controller *NavigatorController
+	// InitialRoute is the first route. It is read when the Navigator mounts.
+	InitialRoute Route
+	// Transition animates routes which use RouteTransitionDefault. The zero
+	// value switches routes immediately.
+	Transition RouteTransition
+	// TransitionDuration is the length of route transitions. The zero value
+	// uses 150ms. It is read when the Navigator mounts.
+	TransitionDuration time.Duration
+	// DisableDismiss stops Escape and DismissIntent from popping routes.
+	DisableDismiss bool
+}
+
+func (w Navigator) CreateState() State {
+	return &navigatorState{}
+}
+
+// NavigatorController pushes and pops the routes of a mounted Navigator.
+//
+// Methods return false when the controller is not attached to a mounted
+// Navigator or when the request does not change the route stack. Call them
+// from event handlers, not during build.
+type NavigatorController struct {
+	state *navigatorState
+}
+
+// NavigatorOf returns the controller of the nearest ancestor Navigator, or nil
+// when there is none. The methods of a nil controller return false.
+func NavigatorOf(ctx BuildContext) *NavigatorController {
+	for e := ctx.element; e != nil; e = e.Base().parent {
+		if stateful, ok := e.(*statefulElement); ok {
+			if s, ok := stateful.state.(*navigatorState); ok {
+				return s.controller
+			}
+		}
+	}
+	return nil
+}
+
+// Attached reports whether the controller is attached to a mounted Navigator.
+func (c *NavigatorController) Attached() bool {
+	return c != nil && c.state != nil
+}
+
+// Push shows route above the current routes.
+func (c *NavigatorController) Push(route Route) bool {
+	if !c.Attached() {
+		return false
+	}
+	c.state.push(route, nil)
+	return true
+}
+
+// PushForResult shows route above the current routes and calls onResult when
+// it is removed. ok is false when the route was removed without a result of
+// type T, such as when it was dismissed with Escape.
+func PushForResult[T any](c *NavigatorController, route Route, onResult func(ctx EventContext, result T, ok bool)) bool {
+	if !c.Attached() {
+		return false
+	}
+	c.state.push(route, func(ctx EventContext, result any, hasResult bool) {
+		v, ok := result.(T)
+		if onResult != nil {
+			onResult(ctx, v, hasResult && ok)
+		}
+	})
+	return true
+}
+
+// Pop removes the top route without a result.
+func (c *NavigatorController) Pop() bool {
+	if !c.Attached() {
+		return false
+	}
+	return c.state.pop(nil, false)
+}
+
+// PopWith removes the top route and passes result to its PushForResult
+// callback.
+func (c *NavigatorController) PopWith(result any) bool {
+	if !c.Attached() {
+		return false
+	}
+	return c.state.pop(result, true)
+}
+
+// Replace swaps the top route for route. The replaced route is removed without
+// a result.
+func (c *NavigatorController) Replace(route Route) bool {
+	if !c.Attached() {
+		return false
+	}
+	c.state.replace(route)
+	return true
+}
+
+// PopUntil removes routes without a result until the top route is the highest
+// one named name. It returns false when no route is named name.
+func (c *NavigatorController) PopUntil(name string) bool {
+	if !c.Attached() {
+		return false
+	}
+	return c.state.popUntil(name)
+}
+
+// CanPop reports whether there is a route to pop.
+func (c *NavigatorController) CanPop() bool {
+	return c.Attached() && len(c.state.entries) > 1
+}
+
+// Routes returns the route stack, from the first route to the top.
+func (c *NavigatorController) Routes() []Route {
+	if !c.Attached() {
+		return nil
+	}
+	routes := make([]Route, len(c.state.entries))
+	for i, entry := range c.state.entries {
+		routes[i] = entry.route
+	}
+	return routes
+}
+
+// navigatorEntry is a mounted route.
+type navigatorEntry struct {
+	key      KeyValue
+	route    Route
+	onResult func(EventContext, any, bool)
+	// focus is the focused widget saved when the route was covered.
+	focus focusTarget
+}
+
+// navigatorTransition describes a route animating in or out.
+type navigatorTransition struct {
+	kind   RouteTransition
+	moving *navigatorEntry
+	in     bool
+	// removed is a route kept mounted until the transition ends.
+	removed *navigatorEntry
+}
+
+type navigatorState struct {
+	StateBase
+	controller *NavigatorController
+	own        NavigatorController
+	entries    []*navigatorEntry
+	nextKey    int
+	animation  *AnimationController
+	transition *navigatorTransition
+}
+
+func (s *navigatorState) InitState() {
+	w := s.Widget().(Navigator)
+	s.attach(w.Controller)
+	s.entries = []*navigatorEntry{s.newEntry(w.InitialRoute, nil)}
+	duration := w.TransitionDuration
+	if duration == 0 {
+		duration = defaultRouteTransitionDuration
+	}
+	s.animation = s.NewAnimation(AnimationOptions{Duration: duration, Curve: EaseInOut})
+}
+
+func (s *navigatorState) DidUpdateWidget(old Widget) {
+	if prev := old.(Navigator).Controller; prev != s.Widget().(Navigator).Controller {
+		s.detach()
+		s.attach(s.Widget().(Navigator).Controller)
+	}
+}
+
+func (s *navigatorState) Dispose() {
+	s.detach()
+}
+
+func (s *navigatorState) attach(c *NavigatorController) {
+	if c == nil {
+		c = &s.own
+	}
+	c.state = s
+	s.controller = c
+}
+
+func (s *navigatorState) detach() {
+	if s.controller != nil && s.controller.state == s {
+		s.controller.state = nil
+	}
+	s.controller = nil
+}
+
+func (s *navigatorState) Build(ctx BuildContext) Widget {
+	w := s.Widget().(Navigator)
+	theme := MustDepend[Theme](ctx)
+	t := s.transition
+	if t != nil && s.animation.Status() != AnimationForward {
+		s.transition = nil
+		t = nil
+	}
+
+	top := len(s.entries) - 1
+	covered := len(s.entries) > 1
+	stack := navigatorStack{Active: top, Below: top, Moving: -1, Background: theme.Background}
+	var routes []*navigatorEntry
+	switch {
+	case t == nil:
+		routes = s.entries
+	case t.removed == nil:
+		// A pushed route moves in over the route below it
+		routes = s.entries
+		stack.Below = top - 1
+		stack.Moving = top
+	case t.in:
+		// A replacing route moves in over the route it replaced
+		routes = append(append([]*navigatorEntry{}, s.entries[:top]...), t.removed, s.entries[top])
+		stack.Active = top + 1
+		stack.Below = top
+		stack.Moving = top + 1
+	default:
+		// A popped route moves out, revealing the top route
+		routes = append(append([]*navigatorEntry{}, s.entries...), t.removed)
+		stack.Moving = top + 1
+	}
+	if t != nil {
+		stack.Kind = t.kind
+		stack.Progress = s.animation.Value()
+		if !t.in {
+			stack.Progress = 1 - stack.Progress
+		}
+	}
+	stack.Children = make([]Widget, len(routes))
+	for i, entry := range routes {
+		active := i == stack.Active
+		stack.Children[i] = navigatorRoute{entry: entry, active: active, trap: active && covered}
+	}
+
+	var bindings map[IntentType]ActionFunc
+	if !w.DisableDismiss {
+		bindings = map[IntentType]ActionFunc{
+			DismissIntentType: func(EventContext, Intent) EventResult {
+				if s.pop(nil, false) {
+					return EventHandled
+				}
+				return EventIgnored
+			},
+		}
+	}
+	return DefaultActions{Bindings: bindings, Child: stack}
+}
+
+func (s *navigatorState) newEntry(route Route, onResult func(EventContext, any, bool)) *navigatorEntry {
+	s.nextKey++
+	return &navigatorEntry{key: KeyValue(fmt.Sprintf("vaxis.route.%d", s.nextKey)), route: route, onResult: onResult}
+}
+
+func (s *navigatorState) push(route Route, onResult func(EventContext, any, bool)) {
+	s.finishTransition()
+	app := s.element.owner.app
+	s.entries[len(s.entries)-1].focus = app.focused
+	entry := s.newEntry(route, onResult)
+	s.entries = append(s.entries, entry)
+	// The new route's focus scope takes focus once it is built
+	app.setFocused(focusTarget{})
+	s.startTransition(&navigatorTransition{moving: entry, in: true})
+	s.MarkNeedsBuild()
+}
+
+func (s *navigatorState) pop(result any, hasResult bool) bool {
+	if len(s.entries) < 2 {
+		return false
+	}
+	s.finishTransition()
+	top := s.entries[len(s.entries)-1]
+	s.entries = s.entries[:len(s.entries)-1]
+	s.restoreFocus()
+	s.startTransition(&navigatorTransition{moving: top, removed: top})
+	s.MarkNeedsBuild()
+	s.complete(top, result, hasResult)
+	return true
+}
+
+func (s *navigatorState) replace(route Route) {
+	s.finishTransition()
+	top := len(s.entries) - 1
+	old := s.entries[top]
+	entry := s.newEntry(route, nil)
+	s.entries[top] = entry
+	s.element.owner.app.setFocused(focusTarget{})
+	s.startTransition(&navigatorTransition{moving: entry, in: true, removed: old})
+	s.MarkNeedsBuild()
+	s.complete(old, nil, false)
+}
+
+func (s *navigatorState) popUntil(name string) bool {
+	target := -1
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if s.entries[i].route.Name == name {
+			target = i
+			break
+		}
+	}
+	if target < 0 || target == len(s.entries)-1 {
+		return false
+	}
+	s.finishTransition()
+	removed := append([]*navigatorEntry(nil), s.entries[target+1:]...)
+	s.entries = s.entries[:target+1]
+	s.restoreFocus()
+	top := removed[len(removed)-1]
+	s.startTransition(&navigatorTransition{moving: top, removed: top})
+	s.MarkNeedsBuild()
+	for i := len(removed) - 1; i >= 0; i-- {
+		s.complete(removed[i], nil, false)
+	}
+	return true
+}
+
+// complete reports the result of a removed route to its pusher
+func (s *navigatorState) complete(entry *navigatorEntry, result any, hasResult bool) {
+	if entry.onResult == nil {
+		return
+	}
+	entry.onResult(s.Context().EventContext(), result, hasResult)
+}
+
+// restoreFocus moves focus back to the widget which was focused when the top
+// route was covered, or to its first focusable widget when that is gone
+func (s *navigatorState) restoreFocus() {
+	app := s.element.owner.app
+	top := s.entries[len(s.entries)-1]
+	saved := top.focus
+	top.focus = focusTarget{}
+	if saved.element != nil && app.hasFocusTarget(saved) {
+		app.setFocused(saved)
+		return
+	}
+	app.setFocused(focusTarget{})
+	if route := s.routeElement(top); route != nil {
+		app.focusFirstWithin(route)
+	}
+}
+
+func (s *navigatorState) routeElement(entry *navigatorEntry) element {
+	var found element
+	walkElements(s.element, func(e element) {
+		if r, ok := e.Base().widget.(navigatorRoute); ok && found == nil && r.entry == entry {
+			found = e
+		}
+	})
+	return found
+}
+
+func (s *navigatorState) startTransition(t *navigatorTransition) {
+	w := s.Widget().(Navigator)
+	t.kind = t.moving.route.Transition
+	if t.kind == RouteTransitionDefault {
+		t.kind = w.Transition
+	}
+	if t.kind == RouteTransitionDefault || t.kind == RouteTransitionNone {
+		return
+	}
+	s.transition = t
+	s.animation.Forward()
+}
+
+// finishTransition jumps a running transition to its end, so a new one can
+// start from a settled route stack
+func (s *navigatorState) finishTransition() {
+	if s.transition == nil {
+		return
+	}
+	s.transition = nil
+	if s.animation.Running() {
+		s.animation.Stop()
+	}
+}
+
+// navigatorRoute builds one route inside a focus scope. The key keeps the
+// route mounted as routes above and below it change.
+type navigatorRoute struct {
+	entry  *navigatorEntry
+	active bool
+	trap   bool
+}
+
+func (w navigatorRoute) WidgetKey() KeyValue {
+	return w.entry.key
+}
+
+func (w navigatorRoute) Build(ctx BuildContext) Widget {
+	var child Widget
+	if w.entry.route.Builder != nil {
+		child = w.entry.route.Builder(ctx)
+	}
+	return FocusScope{Trap: w.trap, AutoFocus: w.trap, Child: child}
+}
+
+// navigatorStack lays out every route and paints the active one, along with a
+// route moving in or out during a transition.
+type navigatorStack struct {
+	// Active is the index of the route which receives pointer events.
+	Active int
+	// Below is the index of the route painted below a moving route.
+	Below int
+	// Moving is the index of the route in transition, or -1.
+	Moving int
+	// Kind is the transition of the moving route.
+	Kind RouteTransition
+	// Progress is how far the moving route is shown, from 0 to 1.
+	Progress float64
+	// Background is the color routes fade from.
+	Background Color
+	// Children are the routes, from first to top.
+	Children []Widget
+}
+
+func (w navigatorStack) WidgetChildren() []Widget {
+	return w.Children
+}
+
+func (w navigatorStack) CreateRenderObject(BuildContext) RenderObject {
+	r := &renderNavigatorStack{}
+	r.update(w)
+	return r
+}
+
+func (w navigatorStack) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	r := ro.(*renderNavigatorStack)
+	if r.Active != w.Active || r.Below != w.Below || r.Moving != w.Moving || r.Kind != w.Kind ||
+		r.Progress != w.Progress || r.Background != w.Background {
+		r.update(w)
+		r.MarkNeedsPaint()
+	}
+}
+
+type renderNavigatorStack struct {
+	MultiChildRenderObject
+	Active     int
+	Below      int
+	Moving     int
+	Kind       RouteTransition
+	Progress   float64
+	Background Color
+}
+
+func (r *renderNavigatorStack) update(w navigatorStack) {
+	r.Active, r.Below, r.Moving = w.Active, w.Below, w.Moving
+	r.Kind, r.Progress, r.Background = w.Kind, w.Progress, w.Background
+}
+
+func (r *renderNavigatorStack) Layout(ctx LayoutContext, c Constraints) {
+	r.SetSize(r.layout(ctx, c, false))
+}
+
+func (r *renderNavigatorStack) DryLayout(ctx LayoutContext, c Constraints) Size {
+	return r.layout(ctx, c, true)
+}
+
+// layout fills bounded constraints. Otherwise the stack takes the size of its
+// largest route
+func (r *renderNavigatorStack) layout(ctx LayoutContext, c Constraints, dry bool) Size {
+	childConstraints := stackLooseConstraints(c)
+	if c.HasBoundedWidth() && c.HasBoundedHeight() {
+		childConstraints = Tight(Size{Width: c.MaxWidth, Height: c.MaxHeight})
+	}
+	size := Size{}
+	for _, child := range r.Children() {
+		var childSize Size
+		if dry {
+			childSize = DryLayout(ctx, child, childConstraints)
+		} else {
+			child.Layout(ctx, childConstraints)
+			childSize = child.Base().Size()
+		}
+		size.Width = max(size.Width, childSize.Width)
+		size.Height = max(size.Height, childSize.Height)
+	}
+	return c.Constrain(size)
+}
+
+func (r *renderNavigatorStack) Paint(p *Painter, off Offset) {
+	children := r.Children()
+	if r.Below >= 0 && r.Below < len(children) {
+		children[r.Below].Paint(p, off)
+	}
+	if r.Moving < 0 || r.Moving >= len(children) {
+		return
+	}
+	size := r.Size()
+	rect := Rect{X: off.X, Y: off.Y, Width: size.Width, Height: size.Height}
+	blank := Cell{Character: Character{Grapheme: " ", Width: 1}, Style: Style{Background: r.Background}}
+	p.PushClip(rect)
+	defer p.PopClip()
+	switch r.Kind {
+	case RouteTransitionSlide:
+		dx := int(math.Round((1 - r.Progress) * float64(size.Width)))
+		if dx >= size.Width {
+			return
+		}
+		p.Fill(Rect{X: off.X + dx, Y: off.Y, Width: size.Width - dx, Height: size.Height}, blank)
+		children[r.Moving].Paint(p, off.Add(Offset{X: dx}))
+	case RouteTransitionFade:
+		p.Fill(rect, blank)
+		children[r.Moving].Paint(p, off)
+		p.Scrim(rect, r.Background, uint8(math.Round((1-r.Progress)*255)))
+	default:
+		children[r.Moving].Paint(p, off)
+	}
+}
+
+func (r *renderNavigatorStack) HitTest(*HitTestResult, Point) bool {
+	return false
+}
+
+func (r *renderNavigatorStack) HitTestChild(child RenderObject) bool {
+	children := r.Children()
+	return r.Active >= 0 && r.Active < len(children) && child == children[r.Active]
+}
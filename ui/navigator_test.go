@@ -0,0 +1,242 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.rockorager.dev/vaxis"
+)
+
+func navigatorButtons(labels ...string) Route {
+	return Route{Name: labels[0], Builder: func(BuildContext) Widget {
+		buttons := make([]Widget, len(labels))
+		for i, label := range labels {
+			buttons[i] = Button{Label: label}
+		}
+		return Row(buttons...)
+	}}
+}
+
+func TestNavigatorRestoresFocusAfterPop(t *testing.T) {
+	nav := &NavigatorController{}
+	app := NewApp(Navigator{Controller: nav, InitialRoute: navigatorButtons("A", "B")})
+	size := Size{Width: 40, Height: 4}
+	app.Pump(size)
+	app.Send(vaxis.Key{Keycode: vaxis.KeyTab})
+	app.Pump(size)
+	if got := focusedDebugLabel(app); !strings.Contains(got, "B") {
+		t.Fatalf("focused label = %q, want B", got)
+	}
+
+	if !nav.Push(navigatorButtons("C", "D")) {
+		t.Fatal("Push failed")
+	}
+	app.Pump(size)
+	if got := focusedDebugLabel(app); !strings.Contains(got, "C") {
+		t.Fatalf("focused label after push = %q, want C", got)
+	}
+	// Traversal stays inside the top route
+	for _, want := range []string{"D", "C"} {
+		app.Send(vaxis.Key{Keycode: vaxis.KeyTab})
+		app.Pump(size)
+		if got := focusedDebugLabel(app); !strings.Contains(got, want) {
+			t.Fatalf("focused label after Tab = %q, want %s", got, want)
+		}
+	}
+
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEsc})
+	app.Pump(size)
+	if got := len(nav.Routes()); got != 1 {
+		t.Fatalf("routes after Escape = %d, want 1", got)
+	}
+	if got := focusedDebugLabel(app); !strings.Contains(got, "B") {
+		t.Fatalf("focused label after pop = %q, want B", got)
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEsc})
+	app.Pump(size)
+	if got := len(nav.Routes()); got != 1 {
+		t.Fatalf("Escape popped the first route")
+	}
+}
+
+type navigatorCounter struct {
+	state **navigatorCounterState
+}
+
+func (w navigatorCounter) CreateState() State {
+	return &navigatorCounterState{}
+}
+
+type navigatorCounterState struct {
+	StateBase
+	count int
+}
+
+func (s *navigatorCounterState) InitState() {
+	*s.Widget().(navigatorCounter).state = s
+}
+
+func (s *navigatorCounterState) Build(BuildContext) Widget {
+	return Text{Value: "count"}
+}
+
+func TestNavigatorKeepsCoveredRoutesMounted(t *testing.T) {
+	var counter *navigatorCounterState
+	nav := &NavigatorController{}
+	app := NewApp(Navigator{Controller: nav, InitialRoute: Route{Name: "home", Builder: func(BuildContext) Widget {
+		return navigatorCounter{state: &counter}
+	}}})
+	size := Size{Width: 20, Height: 2}
+	app.Pump(size)
+	first := counter
+	first.count = 3
+
+	nav.Push(Route{Name: "a", Builder: func(BuildContext) Widget { return Text{Value: "a"} }})
+	app.Pump(size)
+	nav.Push(Route{Name: "b", Builder: func(BuildContext) Widget { return Text{Value: "b"} }})
+	app.Pump(size)
+	p := NewPainter(size)
+	app.Paint(p)
+	if got := p.Cell(0, 0).Grapheme; got != "b" {
+		t.Fatalf("painted %q, want the top route", got)
+	}
+
+	if !nav.PopUntil("home") {
+		t.Fatal("PopUntil failed")
+	}
+	app.Pump(size)
+	if counter != first || counter.count != 3 {
+		t.Fatal("covered route lost its state")
+	}
+	if nav.PopUntil("home") || nav.PopUntil("missing") {
+		t.Fatal("PopUntil changed nothing but reported success")
+	}
+
+	nav.Replace(Route{Name: "other", Builder: func(BuildContext) Widget { return Text{Value: "other"} }})
+	app.Pump(size)
+	if routes := nav.Routes(); len(routes) != 1 || routes[0].Name != "other" {
+		t.Fatalf("routes after Replace = %+v", routes)
+	}
+	if nav.CanPop() {
+		t.Fatal("CanPop with a single route")
+	}
+}
+
+func TestNavigatorPushForResult(t *testing.T) {
+	nav := &NavigatorController{}
+	app := NewApp(Navigator{Controller: nav, InitialRoute: navigatorButtons("home")})
+	size := Size{Width: 20, Height: 2}
+	app.Pump(size)
+
+	var got string
+	var gotOK bool
+	calls := 0
+	push := func() {
+		PushForResult(nav, navigatorButtons("pick"), func(ctx EventContext, result string, ok bool) {
+			got, gotOK = result, ok
+			calls++
+		})
+		app.Pump(size)
+	}
+
+	push()
+	nav.PopWith("picked")
+	app.Pump(size)
+	if calls != 1 || got != "picked" || !gotOK {
+		t.Fatalf("result = %q %v after %d calls", got, gotOK, calls)
+	}
+
+	push()
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEsc})
+	app.Pump(size)
+	if calls != 2 || gotOK {
+		t.Fatalf("dismissed route reported ok = %v after %d calls", gotOK, calls)
+	}
+
+	push()
+	nav.PopWith(42)
+	app.Pump(size)
+	if calls != 3 || gotOK {
+		t.Fatalf("result of the wrong type reported ok = %v", gotOK)
+	}
+
+	var unattached *NavigatorController
+	if unattached.Push(navigatorButtons("x")) || PushForResult[string](&NavigatorController{}, navigatorButtons("x"), nil) {
+		t.Fatal("unattached controller pushed a route")
+	}
+}
+
+func TestNavigatorOfFindsAncestor(t *testing.T) {
+	var found *NavigatorController
+	app := NewApp(Navigator{InitialRoute: Route{Builder: func(ctx BuildContext) Widget {
+		found = NavigatorOf(ctx)
+		return Text{Value: "home"}
+	}}})
+	app.Pump(Size{Width: 10, Height: 1})
+	if !found.Attached() {
+		t.Fatal("NavigatorOf didn't find the Navigator")
+	}
+	found.Push(Route{Builder: func(BuildContext) Widget { return Text{Value: "next"} }})
+	app.Pump(Size{Width: 10, Height: 1})
+	if !found.CanPop() {
+		t.Fatal("push through NavigatorOf didn't add a route")
+	}
+}
+
+func TestNavigatorSlideTransition(t *testing.T) {
+	nav := &NavigatorController{}
+	app := NewApp(Navigator{
+		Controller:         nav,
+		InitialRoute:       Route{Builder: func(BuildContext) Widget { return Text{Value: "home"} }},
+		Transition:         RouteTransitionSlide,
+		TransitionDuration: time.Second,
+	})
+	size := Size{Width: 20, Height: 1}
+	app.Pump(size)
+	start := time.Now()
+	nav.Push(Route{Builder: func(BuildContext) Widget { return Text{Value: "next"} }})
+	app.Pump(size)
+
+	app.tickAnimations(start.Add(500 * time.Millisecond))
+	app.Pump(size)
+	p := NewPainter(size)
+	app.Paint(p)
+	if got := p.Cell(0, 0).Grapheme; got != "h" {
+		t.Fatalf("covered route isn't visible mid-transition: %q", got)
+	}
+	col := -1
+	for x := 0; x < size.Width; x++ {
+		if p.Cell(x, 0).Grapheme == "n" {
+			col = x
+			break
+		}
+	}
+	if col < 5 || col > 15 {
+		t.Fatalf("sliding route at column %d, want about halfway", col)
+	}
+
+	app.tickAnimations(start.Add(2 * time.Second))
+	app.Pump(size)
+	p = NewPainter(size)
+	app.Paint(p)
+	if got := p.Cell(0, 0).Grapheme; got != "n" {
+		t.Fatalf("pushed route at %q after the transition", got)
+	}
+
+	// A popped route stays painted until it has moved out
+	nav.Pop()
+	app.Pump(size)
+	p = NewPainter(size)
+	app.Paint(p)
+	if got := p.Cell(0, 0).Grapheme; got != "n" {
+		t.Fatalf("popped route disappeared before its transition: %q", got)
+	}
+	app.tickAnimations(time.Now().Add(2 * time.Second))
+	app.Pump(size)
+	p = NewPainter(size)
+	app.Paint(p)
+	if got := p.Cell(0, 0).Grapheme; got != "h" {
+		t.Fatalf("painted %q after popping, want the first route", got)
+	}
+}
@@ -1,11 +1,16 @@
 package ui
 
+import "fmt"
+
 // Overlay paints entries above a stable child subtree.
 //
 // Overlay is useful for app-level surfaces such as dialogs, command palettes,
 // menus, and other popups. It keeps the child as the first child of an
 // always-present Stack so showing or hiding entries does not change the root
 // shape of the application body.
+//
+// Widgets below an Overlay can also add entries of their own with OverlayOf.
+// Those are painted above Entries, in the order they were inserted.
 type Overlay struct {
 	// Child is the base subtree painted below all entries.
 	Child Widget
@@ -14,25 +19,17 @@ type Overlay struct {
 }
 
 func (w Overlay) Build(BuildContext) Widget {
-	children := []Widget{w.Child}
-	for _, entry := range w.Entries {
-		if entry.Modal {
-			barrier := entry.Barrier
-			if barrier == nil {
-				barrier = ModalBarrier{}
-			}
-			children = append(children, barrier)
-		}
-		child := entry.Child
-		if child == nil {
-			continue
-		}
-		if entry.Alignment != (Alignment{}) {
-			child = Align{Alignment: entry.Alignment, Child: child}
-		}
-		children = append(children, child)
-	}
-	return Stack{Alignment: CenterAlign, Children: children}
+	return overlayHost{overlay: w}
+}
+
+// overlayHost holds the state of an Overlay, which keeps the entries inserted
+// through its OverlayController.
+type overlayHost struct {
+	overlay Overlay
+}
+
+func (w overlayHost) CreateState() State {
+	return &overlayState{}
 }
 
 // OverlayEntry describes one overlay surface.
@@ -46,3 +43,188 @@ type OverlayEntry struct {
 	// Alignment wraps Child in Align when non-zero.
 	Alignment Alignment
 }
+
+// OverlayController inserts entries into a mounted Overlay.
+type OverlayController struct {
+	state *overlayState
+}
+
+// OverlayEntryHandle updates or removes an entry added with
+// OverlayController.Insert.
+type OverlayEntryHandle struct {
+	overlay *overlayState
+	key     KeyValue
+	entry   OverlayEntry
+}
+
+type overlayState struct {
+	StateBase
+	controller OverlayController
+	inserted   []*OverlayEntryHandle
+	nextKey    int
+}
+
+// OverlayOf returns the controller of the nearest ancestor Overlay, or nil
+// when there is none. The methods of a nil controller do nothing.
+func OverlayOf(ctx BuildContext) *OverlayController {
+	for e := ctx.element; e != nil; e = e.Base().parent {
+		if stateful, ok := e.(*statefulElement); ok {
+			if s, ok := stateful.state.(*overlayState); ok {
+				return &s.controller
+			}
+		}
+	}
+	return nil
+}
+
+// Attached reports whether the controller belongs to a mounted Overlay.
+func (c *OverlayController) Attached() bool {
+	return c != nil && c.state != nil
+}
+
+// Insert adds entry above the Overlay's entries and those inserted before it.
+// It returns nil when the controller isn't attached.
+func (c *OverlayController) Insert(entry OverlayEntry) *OverlayEntryHandle {
+	if !c.Attached() {
+		return nil
+	}
+	s := c.state
+	s.nextKey++
+	h := &OverlayEntryHandle{overlay: s, key: KeyValue(fmt.Sprintf("vaxis.overlay.%d", s.nextKey)), entry: entry}
+	s.change(func() { s.inserted = append(s.inserted, h) })
+	return h
+}
+
+// Rect returns the bounds of the widget at ctx in the Overlay's coordinates,
+// which are the coordinates an Anchored entry places its child in. It reports
+// false when ctx isn't laid out below the Overlay.
+func (c *OverlayController) Rect(ctx BuildContext) (Rect, bool) {
+	if !c.Attached() {
+		return Rect{}, false
+	}
+	return c.state.rect(ctx.FindRenderObject())
+}
+
+// rect returns the bounds of ro in the overlay's coordinates.
+func (s *overlayState) rect(ro RenderObject) (Rect, bool) {
+	root := s.Context().FindRenderObject()
+	if ro == nil || root == nil {
+		return Rect{}, false
+	}
+	size := ro.Base().Size()
+	rect := Rect{Width: size.Width, Height: size.Height}
+	for child, parent := ro, ro.Base().parent; parent != nil; child, parent = parent, parent.Base().parent {
+		if op, ok := parent.(ChildOffsetProvider); ok {
+			off := op.ChildOffset(child)
+			rect.X += off.X
+			rect.Y += off.Y
+		}
+		if parent == root {
+			return rect, true
+		}
+	}
+	return Rect{}, false
+}
+
+// Mounted reports whether the entry is still shown by its Overlay.
+func (h *OverlayEntryHandle) Mounted() bool {
+	return h != nil && h.overlay != nil
+}
+
+// Update replaces the entry's contents, keeping its place above the others.
+func (h *OverlayEntryHandle) Update(entry OverlayEntry) {
+	if !h.Mounted() {
+		return
+	}
+	h.overlay.change(func() { h.entry = entry })
+}
+
+// Remove takes the entry out of its Overlay. Removing an entry twice does
+// nothing.
+func (h *OverlayEntryHandle) Remove() {
+	if !h.Mounted() {
+		return
+	}
+	s := h.overlay
+	h.overlay = nil
+	s.change(func() {
+		for i, inserted := range s.inserted {
+			if inserted == h {
+				s.inserted = append(s.inserted[:i:i], s.inserted[i+1:]...)
+				break
+			}
+		}
+	})
+}
+
+// change applies fn and rebuilds the overlay. Unlike SetState it may run
+// while the tree is building, as when the widget owning an entry is disposed
+// and removes it.
+func (s *overlayState) change(fn func()) {
+	fn()
+	s.element.MarkNeedsBuild()
+}
+
+func (s *overlayState) InitState() {
+	s.controller.state = s
+}
+
+func (s *overlayState) Dispose() {
+	s.controller.state = nil
+	for _, h := range s.inserted {
+		h.overlay = nil
+	}
+	s.inserted = nil
+}
+
+func (s *overlayState) Build(BuildContext) Widget {
+	w := s.Widget().(overlayHost).overlay
+	children := []Widget{w.Child}
+	for _, entry := range w.Entries {
+		children = appendOverlayEntry(children, entry, "")
+	}
+	for _, h := range s.inserted {
+		children = appendOverlayEntry(children, h.entry, h.key)
+	}
+	return Stack{Alignment: CenterAlign, Children: children}
+}
+
+// appendOverlayEntry appends the barrier and child of entry. A non-empty key
+// keeps an inserted entry mounted as the entries below it come and go.
+func appendOverlayEntry(children []Widget, entry OverlayEntry, key KeyValue) []Widget {
+	if entry.Modal {
+		barrier := entry.Barrier
+		if barrier == nil {
+			barrier = ModalBarrier{}
+		}
+		if key != "" {
+			barrier = overlayKeyed{key: key + ".barrier", child: barrier}
+		}
+		children = append(children, barrier)
+	}
+	child := entry.Child
+	if child == nil {
+		return children
+	}
+	if entry.Alignment != (Alignment{}) {
+		child = Align{Alignment: entry.Alignment, Child: child}
+	}
+	if key != "" {
+		child = overlayKeyed{key: key, child: child}
+	}
+	return append(children, child)
+}
+
+// overlayKeyed gives an inserted overlay entry a stable key.
+type overlayKeyed struct {
+	key   KeyValue
+	child Widget
+}
+
+func (w overlayKeyed) WidgetKey() KeyValue {
+	return w.key
+}
+
+func (w overlayKeyed) Build(BuildContext) Widget {
+	return w.child
+}
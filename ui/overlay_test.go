@@ -33,3 +33,47 @@ func TestOverlayPaintsModalBarrierAndEntryAboveChild(t *testing.T) {
 		t.Fatalf("overlay entry background = %#v, want %#v", got, theme.Surface)
 	}
 }
+
+func TestOverlayOfInsertsEntriesAboveChild(t *testing.T) {
+	var ctx BuildContext
+	app := NewApp(Overlay{Child: Column(
+		SizedBox{Height: 2},
+		overlayContextProbe{ctx: &ctx},
+	)})
+	size := Size{Width: 10, Height: 5}
+	app.Pump(size)
+	overlay := OverlayOf(ctx)
+	rect, ok := overlay.Rect(ctx)
+	if !ok || rect != (Rect{X: 3, Y: 2, Width: 4, Height: 1}) {
+		t.Fatalf("Rect = %v, %v", rect, ok)
+	}
+	entry := overlay.Insert(OverlayEntry{Child: Anchored{Anchor: rect, Child: Text{Value: "tip"}}})
+	app.Pump(size)
+	p := NewPainter(size)
+	app.Paint(p)
+	if got := p.Cell(3, 3).Grapheme + p.Cell(4, 3).Grapheme + p.Cell(5, 3).Grapheme; got != "tip" {
+		t.Fatalf("anchored entry = %q, want it below base", got)
+	}
+
+	entry.Remove()
+	entry.Remove()
+	app.Pump(size)
+	p = NewPainter(size)
+	app.Paint(p)
+	if got := p.Cell(3, 3).Grapheme; got != " " && got != "" {
+		t.Fatalf("removed entry still painted %q", got)
+	}
+	if entry.Mounted() {
+		t.Fatal("removed entry reports mounted")
+	}
+}
+
+// overlayContextProbe records its build context.
+type overlayContextProbe struct {
+	ctx *BuildContext
+}
+
+func (w overlayContextProbe) Build(ctx BuildContext) Widget {
+	*w.ctx = ctx
+	return SizedBox{Width: 4, Height: 1, Child: Text{Value: "base"}}
+}
@@ -99,6 +99,18 @@ func (p *Painter) DrawText(off Offset, s string, style Style) {
 	}
 }
 
+// DrawLines writes the resolved cells of lines at off. Line cells keep the
+// background already painted beneath them.
+func (p *Painter) DrawLines(off Offset, lines *Lines) {
+	lines.Each(func(col, row int, cell Cell) {
+		pt := Point{X: off.X + col, Y: off.Y + row}
+		if p.inBounds(pt) {
+			cell.Style = mergeStyle(p.Cell(pt.X, pt.Y).Style, cell.Style)
+		}
+		p.DrawCell(pt, cell)
+	})
+}
+
 // Fill writes cell into every visible cell of r.
 func (p *Painter) Fill(r Rect, cell Cell) {
 	for y := r.Y; y < r.Y+r.Height; y++ {
@@ -187,21 +199,14 @@ func mergeStyle(base, over Style) Style {
 }
 
 func scrimColor(src, dst Color, opacity uint8) Color {
-	if src == 0 || dst == 0 || opacity == 0 {
+	if opacity == 0 {
 		return src
 	}
-	s := src.Params()
-	d := dst.Params()
-	if len(s) != 3 || len(d) != 3 {
+	if _, _, _, ok := src.RGB(); !ok {
 		return src
 	}
-	return RGB(
-		blendChannel(s[0], d[0], opacity),
-		blendChannel(s[1], d[1], opacity),
-		blendChannel(s[2], d[2], opacity),
-	)
-}
-
-func blendChannel(src, dst, opacity uint8) uint8 {
-	return uint8((int(src)*(255-int(opacity)) + int(dst)*int(opacity)) / 255)
+	if _, _, _, ok := dst.RGB(); !ok {
+		return src
+	}
+	return dst.Blend(src, opacity)
 }
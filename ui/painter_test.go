@@ -0,0 +1,30 @@
+package ui_test
+
+import (
+	"testing"
+
+	"go.rockorager.dev/vaxis/ui"
+)
+
+func TestPainterDrawLinesKeepsBackground(t *testing.T) {
+	p := ui.NewPainter(ui.Size{Width: 5, Height: 3})
+	bg := ui.RGB(10, 20, 30)
+	p.Fill(ui.Rect{Width: 5, Height: 3}, ui.Cell{Character: ui.Character{Grapheme: " ", Width: 1}, Style: ui.Style{Background: bg}})
+	var lines ui.Lines
+	lines.Box(0, 0, 4, 3, ui.LineLight, ui.Style{Foreground: ui.RGB(200, 200, 200)})
+	lines.Vertical(2, 0, 3, ui.LineLight, ui.Style{})
+	p.DrawLines(ui.Offset{X: 1}, &lines)
+
+	if got := p.Cell(3, 0).Grapheme; got != "┬" {
+		t.Fatalf("junction = %q, want ┬", got)
+	}
+	if got := p.Cell(1, 2).Grapheme; got != "└" {
+		t.Fatalf("corner = %q, want └", got)
+	}
+	if got := p.Cell(1, 1).Background; got != bg {
+		t.Fatalf("background = %v, want %v", got, bg)
+	}
+	if got := p.Cell(0, 0).Grapheme; got != " " {
+		t.Fatalf("cell before offset = %q", got)
+	}
+}
@@ -0,0 +1,165 @@
+package ui
+
+import "time"
+
+const defaultPopoverCloseDelay = 300 * time.Millisecond
+
+// Popover shows Content in a bordered panel next to Child after the mouse has
+// rested on Child for Delay, and while focus is inside Child or Content.
+//
+// Unlike a Tooltip, Content may hold any widgets, including interactive ones.
+// The popover stays open while the mouse is over Child or the panel, and
+// closes shortly after it leaves both, which leaves time to move the mouse
+// from Child onto the panel. Escape closes it until the mouse comes back or
+// focus moves. It is shown in the nearest Overlay above the
+// Popover, on Side of Child, flipping to the other side when it doesn't fit.
+type Popover struct {
+	// Content is shown inside the panel.
+	Content Widget
+	// Child is the widget that opens the popover.
+	Child Widget
+	// Side is the side of Child the panel is shown on. The zero value shows it
+	// below.
+	Side AnchorSide
+	// Delay is how long the mouse rests on Child before the panel opens. The
+	// zero value uses a half-second delay.
+	Delay time.Duration
+}
+
+func (w Popover) CreateState() State {
+	return &popoverState{}
+}
+
+type popoverState struct {
+	StateBase
+	popup          anchoredPopup
+	opening        *AnimationController
+	closing        *AnimationController
+	open           bool
+	hoveredChild   bool
+	hoveredContent bool
+	dismissed      bool
+}
+
+func (s *popoverState) InitState() {
+	s.opening = s.NewAnimation(AnimationOptions{Duration: tooltipDelay(s.Widget().(Popover).Delay, defaultTooltipDelay)})
+	s.closing = s.NewAnimation(AnimationOptions{Duration: defaultPopoverCloseDelay})
+}
+
+func (s *popoverState) DidUpdateWidget(Widget) {
+	s.opening.duration = tooltipDelay(s.Widget().(Popover).Delay, defaultTooltipDelay)
+}
+
+func (s *popoverState) Dispose() {
+	s.popup.hide()
+}
+
+func (s *popoverState) focusWithinChanged() {
+	s.dismissed = false
+	s.element.MarkNeedsBuild()
+}
+
+func (s *popoverState) Build(ctx BuildContext) Widget {
+	w := s.Widget().(Popover)
+	hovered := s.hoveredChild || s.hoveredContent
+	if hovered && s.opening.Status() == AnimationCompleted {
+		s.open = true
+	}
+	if !hovered && s.closing.Status() == AnimationCompleted {
+		s.open = false
+	}
+	focused := s.element.owner.app.focusedWithin(s.element) || s.popup.focused()
+	shown := w.Content != nil && !s.dismissed && (s.open || focused)
+	if shown {
+		theme := tooltipTheme(MustDepend[Theme](ctx))
+		s.popup.show(&s.StateBase, w.Side, popoverRegion{
+			state: s,
+			child: Actions{
+				Bindings: popupDismissBindings(true, s.dismiss),
+				Child: DecoratedBox(
+					Decoration{Style: theme.Popover, Border: BorderAll(theme.PopoverBorder)},
+					Padding(Symmetric(2, 1), w.Content),
+				),
+			},
+		})
+	} else {
+		s.popup.hide()
+	}
+	return Actions{Bindings: popupDismissBindings(shown, s.dismiss), Child: w.Child}
+}
+
+func (s *popoverState) dismiss() {
+	s.dismissed, s.open = true, false
+	s.opening.Reset()
+	s.closing.Reset()
+	s.MarkNeedsBuild()
+}
+
+func (s *popoverState) HandleEvent(ctx EventContext, ev Event) EventResult {
+	switch ev.(type) {
+	case hoverExit:
+		s.setHovered(false, s.hoveredContent)
+	case Mouse:
+		// Watch the mouse on the way down, before Child can handle it
+		if (ctx.Phase() == CapturePhase || ctx.Phase() == TargetPhase) && !s.hoveredChild {
+			s.setHovered(true, s.hoveredContent)
+		}
+	}
+	return EventIgnored
+}
+
+// setHovered records where the mouse is, starting the delay to open the
+// popover when the mouse arrives and the delay to close it when the mouse
+// leaves both Child and the panel.
+func (s *popoverState) setHovered(child, content bool) {
+	if child && !s.hoveredChild {
+		s.dismissed = false
+	}
+	s.hoveredChild, s.hoveredContent = child, content
+	if child || content {
+		s.closing.Reset()
+		if !s.open && s.opening.Status() == AnimationIdle {
+			s.opening.Forward()
+		}
+	} else {
+		s.opening.Reset()
+		if s.open && s.closing.Status() == AnimationIdle {
+			s.closing.Forward()
+		}
+	}
+	s.MarkNeedsBuild()
+}
+
+// popoverRegion tells a Popover whether the mouse is over its panel.
+type popoverRegion struct {
+	state *popoverState
+	child Widget
+}
+
+func (w popoverRegion) CreateState() State {
+	return &popoverRegionState{}
+}
+
+type popoverRegionState struct {
+	StateBase
+}
+
+func (s *popoverRegionState) Build(BuildContext) Widget {
+	return s.Widget().(popoverRegion).child
+}
+
+func (s *popoverRegionState) HandleEvent(ctx EventContext, ev Event) EventResult {
+	popover := s.Widget().(popoverRegion).state
+	if popover.element == nil || popover.element.owner == nil {
+		return EventIgnored
+	}
+	switch ev.(type) {
+	case hoverExit:
+		popover.setHovered(popover.hoveredChild, false)
+	case Mouse:
+		if (ctx.Phase() == CapturePhase || ctx.Phase() == TargetPhase) && !popover.hoveredContent {
+			popover.setHovered(popover.hoveredChild, true)
+		}
+	}
+	return EventIgnored
+}
@@ -11,6 +11,7 @@ type Runner struct {
 	lastFrame *Painter
 	profile   *profileStore
 	options   options
+	saturated bool
 }
 
 // NewRunner creates a runner for app and backend.
@@ -101,6 +102,11 @@ func (r *Runner) HandleEvent(ev Event, now time.Time) {
 	if update, ok := ev.(VisibilityUpdate); ok && update.Visible {
 		r.app.RequestFrame()
 	}
+	if update, ok := ev.(BandwidthUpdate); ok {
+		// Animations are skipped while the output link is saturated
+		r.saturated = update.Saturated
+		r.app.RequestFrame()
+	}
 	if r.app.FrameRequested() && !r.scheduler.Scheduled() {
 		r.scheduler.Request(now)
 	}
@@ -109,6 +115,9 @@ func (r *Runner) HandleEvent(ev Event, now time.Time) {
 // HandleFrame rebuilds, lays out, paints, and renders one frame if needed.
 func (r *Runner) HandleFrame(now time.Time) error {
 	frameStart := time.Now()
+	if r.saturated {
+		r.app.completeAnimations()
+	}
 	r.app.tickAnimations(now)
 	activeFrameTicks := r.app.tickFrameCallbacks(now)
 	if !r.app.FrameRequested() {
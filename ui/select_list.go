@@ -0,0 +1,517 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"go.rockorager.dev/vaxis"
+)
+
+const (
+	defaultSelectMaxVisibleRows = 8
+	selectChipRemove            = "×"
+
+	typeAheadTimeout = time.Second
+)
+
+// typeAhead matches letters typed in quick succession against item labels.
+type typeAhead struct {
+	typed string
+	at    time.Time
+}
+
+// find adds text to the letters typed so far and returns the first of n
+// items, searching from current and wrapping, whose label starts with them.
+// Typing the same letter again moves on to the next match after current.
+// Label reports false for items that can't match. Find returns -1 when
+// nothing matches, and false when text doesn't start a search, as a leading
+// space doesn't.
+func (t *typeAhead) find(text string, current, n int, label func(int) (string, bool)) (int, bool) {
+	now := time.Now()
+	if now.Sub(t.at) > typeAheadTimeout {
+		t.typed = ""
+	}
+	t.at = now
+	if t.typed == "" && text == " " {
+		return -1, false
+	}
+	t.typed += strings.ToLower(text)
+	prefix, start := t.typed, max(0, current)
+	if len(t.typed) == 1 || strings.Count(t.typed, t.typed[:1]) == len(t.typed) {
+		prefix, start = t.typed[:1], current+1
+	}
+	for i := 0; i < n; i++ {
+		index := (start + i) % n
+		if text, ok := label(index); ok && strings.HasPrefix(strings.ToLower(text), prefix) {
+			return index, true
+		}
+	}
+	return -1, true
+}
+
+// selectRow is one row of a select list.
+type selectRow struct {
+	label    string
+	checked  bool
+	disabled bool
+}
+
+// selectListState is the popup list shared by Dropdown and ComboBox: whether
+// it is open, the highlighted row, the first row scrolled into view, and the
+// progress of items loading asynchronously.
+type selectListState struct {
+	StateBase
+	popup     anchoredPopup
+	rows      []selectRow
+	open      bool
+	highlight int
+	top       int
+	maxRows   int
+	typed     typeAhead
+	loading   bool
+	loadErr   error
+	loads     int
+	disposed  bool
+}
+
+// openList opens the list with highlight, or the first enabled row when
+// highlight is disabled or out of range.
+func (s *selectListState) openList(highlight int) {
+	s.popup.onDismiss = s.closeList
+	s.open = true
+	s.setHighlight(highlight, 1)
+	s.MarkNeedsBuild()
+}
+
+func (s *selectListState) closeList() {
+	if !s.open {
+		return
+	}
+	s.open = false
+	s.popup.hide()
+	s.MarkNeedsBuild()
+}
+
+func (s *selectListState) disposeList() {
+	s.disposed = true
+	s.popup.hide()
+}
+
+// setRows replaces the rows, keeping the highlight on an enabled row.
+func (s *selectListState) setRows(rows []selectRow, maxRows int) {
+	s.rows, s.maxRows = rows, maxRows
+	s.top = clampInt(s.top, 0, max(0, len(rows)-maxRows))
+	if s.highlight >= len(rows) || s.highlight < 0 || rows[s.highlight].disabled {
+		s.setHighlight(s.highlight, 1)
+	}
+}
+
+// setHighlight highlights the first enabled row from index in direction step,
+// or in the other direction when there is none.
+func (s *selectListState) setHighlight(index, step int) {
+	index = clampInt(index, 0, len(s.rows)-1)
+	next := selectNextRow(s.rows, index, step)
+	if next < 0 {
+		next = selectNextRow(s.rows, index, -step)
+	}
+	s.highlight = next
+	s.reveal()
+}
+
+func (s *selectListState) reveal() {
+	if s.highlight < 0 || s.maxRows <= 0 {
+		return
+	}
+	if s.highlight < s.top {
+		s.top = s.highlight
+	} else if s.highlight >= s.top+s.maxRows {
+		s.top = s.highlight - s.maxRows + 1
+	}
+}
+
+func (s *selectListState) scroll(delta int) {
+	top := clampInt(s.top+delta, 0, max(0, len(s.rows)-s.maxRows))
+	if top != s.top {
+		s.top = top
+		s.MarkNeedsBuild()
+	}
+}
+
+func (s *selectListState) hoverRow(index int) {
+	if index != s.highlight && index >= 0 && index < len(s.rows) && !s.rows[index].disabled {
+		s.highlight = index
+		s.MarkNeedsBuild()
+	}
+}
+
+// handleListKey moves the highlight of the open list for navigation keys.
+func (s *selectListState) handleListKey(key Key) bool {
+	if len(s.rows) == 0 {
+		return false
+	}
+	page := max(1, s.maxRows)
+	switch {
+	case key.Keycode == KeyUp:
+		if s.highlight < 0 {
+			s.setHighlight(len(s.rows)-1, -1)
+		} else {
+			s.setHighlight(s.highlight-1, -1)
+		}
+	case key.Keycode == KeyDown:
+		s.setHighlight(s.highlight+1, 1)
+	case key.Keycode == KeyPgUp:
+		s.setHighlight(s.highlight-page, 1)
+	case key.Keycode == KeyPgDown:
+		s.setHighlight(s.highlight+page, -1)
+	case key.Keycode == KeyHome:
+		s.setHighlight(0, 1)
+	case key.Keycode == KeyEnd:
+		s.setHighlight(len(s.rows)-1, -1)
+	default:
+		return false
+	}
+	s.MarkNeedsBuild()
+	return true
+}
+
+// typeAhead highlights the next row whose label starts with the letters typed
+// so far, and returns it like typeAhead.find.
+func (s *selectListState) typeAhead(text string) (int, bool) {
+	index, ok := s.typed.find(text, s.highlight, len(s.rows), func(i int) (string, bool) {
+		return s.rows[i].label, !s.rows[i].disabled
+	})
+	if index >= 0 {
+		s.highlight = index
+		s.reveal()
+		s.MarkNeedsBuild()
+	}
+	return index, ok
+}
+
+// beginLoad marks items as loading and returns the generation telling the
+// load apart from later ones.
+func (s *selectListState) beginLoad() int {
+	s.loads++
+	s.loading, s.loadErr = true, nil
+	return s.loads
+}
+
+// finishLoad records the outcome of load gen and reports whether it is still
+// the latest one.
+func (s *selectListState) finishLoad(gen int, err error) bool {
+	if s.disposed || gen != s.loads {
+		return false
+	}
+	s.loading, s.loadErr = false, err
+	return true
+}
+
+// showList shows the rows, or message when there are none, in a panel at
+// least width cells wide below the owner.
+func (s *selectListState) showList(theme DropdownTheme, width int, empty string, choose func(EventContext, int)) {
+	message, messageStyle := "", theme.Message
+	switch {
+	case s.loading:
+		message = "Loading…"
+	case s.loadErr != nil:
+		message, messageStyle = s.loadErr.Error(), theme.Error
+	case len(s.rows) == 0:
+		message = empty
+	}
+	s.popup.show(&s.StateBase, AnchorBelow, selectPanel{
+		list:         s,
+		rows:         s.rows,
+		highlight:    s.highlight,
+		top:          s.top,
+		maxRows:      s.maxRows,
+		width:        width,
+		message:      message,
+		messageStyle: messageStyle,
+		theme:        theme,
+		choose:       choose,
+	})
+}
+
+func selectNextRow(rows []selectRow, index, step int) int {
+	for ; index >= 0 && index < len(rows); index += step {
+		if !rows[index].disabled {
+			return index
+		}
+	}
+	return -1
+}
+
+func selectMaxVisibleRows(rows int) int {
+	if rows > 0 {
+		return rows
+	}
+	return defaultSelectMaxVisibleRows
+}
+
+// selectFitLabel shortens text to width cells, ending it with an ellipsis
+// when it doesn't fit.
+func selectFitLabel(text string, width int) string {
+	if textWidth(text) <= width {
+		return text
+	}
+	if width <= 0 {
+		return ""
+	}
+	out, used := "", 1
+	for _, ch := range vaxisCharacters(text) {
+		if used+ch.Width > width {
+			break
+		}
+		out += ch.Grapheme
+		used += ch.Width
+	}
+	return out + "…"
+}
+
+// selectPanel paints the rows of a select list in a bordered panel, with a
+// check column and arrows on the border when rows are scrolled out of view.
+type selectPanel struct {
+	list         *selectListState
+	rows         []selectRow
+	highlight    int
+	top          int
+	maxRows      int
+	width        int
+	message      string
+	messageStyle Style
+	theme        DropdownTheme
+	choose       func(EventContext, int)
+}
+
+func (w selectPanel) CreateRenderObject(BuildContext) RenderObject {
+	r := &renderSelectPanel{}
+	w.update(r)
+	return r
+}
+
+func (w selectPanel) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	r := ro.(*renderSelectPanel)
+	w.update(r)
+	r.MarkNeedsLayout()
+}
+
+func (w selectPanel) update(r *renderSelectPanel) {
+	r.list, r.rows, r.highlight, r.top, r.maxRows = w.list, w.rows, w.highlight, w.top, w.maxRows
+	r.width, r.message, r.messageStyle, r.theme, r.choose = w.width, w.message, w.messageStyle, w.theme, w.choose
+}
+
+type renderSelectPanel struct {
+	LeafRenderObject
+	list         *selectListState
+	rows         []selectRow
+	highlight    int
+	top          int
+	maxRows      int
+	width        int
+	message      string
+	messageStyle Style
+	theme        DropdownTheme
+	choose       func(EventContext, int)
+}
+
+// naturalSize fits a border around rows of a space, the check column, the
+// widest label and a space, or around the message.
+func (r *renderSelectPanel) naturalSize() Size {
+	if r.message != "" || len(r.rows) == 0 {
+		return Size{Width: max(r.width, textWidth(r.message)+4), Height: 3}
+	}
+	labelWidth := 0
+	for _, row := range r.rows {
+		labelWidth = max(labelWidth, textWidth(row.label))
+	}
+	return Size{Width: max(r.width, labelWidth+6), Height: min(len(r.rows), max(1, r.maxRows)) + 2}
+}
+
+func (r *renderSelectPanel) Layout(_ LayoutContext, c Constraints) {
+	r.SetSize(c.Constrain(r.naturalSize()))
+}
+
+func (r *renderSelectPanel) DryLayout(_ LayoutContext, c Constraints) Size {
+	return c.Constrain(r.naturalSize())
+}
+
+func (r *renderSelectPanel) Paint(p *Painter, off Offset) {
+	size := r.Size()
+	if size.Width < 2 || size.Height < 3 {
+		return
+	}
+	t := r.theme
+	p.Fill(Rect{X: off.X, Y: off.Y, Width: size.Width, Height: size.Height}, Cell{Character: Character{Grapheme: " ", Width: 1}, Style: t.Panel})
+	var lines Lines
+	lines.Box(0, 0, size.Width, size.Height, vaxis.LineLight, t.Border)
+	p.DrawLines(off, &lines)
+	if r.message != "" || len(r.rows) == 0 {
+		p.DrawText(Offset{X: off.X + 2, Y: off.Y + 1}, selectFitLabel(r.message, size.Width-4), r.messageStyle)
+		return
+	}
+
+	visible := size.Height - 2
+	for i := 0; i < visible && r.top+i < len(r.rows); i++ {
+		index := r.top + i
+		row := r.rows[index]
+		y := off.Y + 1 + i
+		style := t.Panel
+		switch {
+		case row.disabled:
+			style = t.Disabled
+		case index == r.highlight:
+			style = t.Highlight
+		}
+		p.Fill(Rect{X: off.X + 1, Y: y, Width: size.Width - 2, Height: 1}, Cell{Character: Character{Grapheme: " ", Width: 1}, Style: style})
+		if row.checked {
+			p.DrawText(Offset{X: off.X + 2, Y: y}, menuCheckMark, style)
+		}
+		p.DrawText(Offset{X: off.X + 4, Y: y}, selectFitLabel(row.label, size.Width-6), style)
+	}
+	if r.top > 0 {
+		p.DrawText(Offset{X: off.X + size.Width - 1, Y: off.Y + 1}, "▲", t.Border)
+	}
+	if r.top+visible < len(r.rows) {
+		p.DrawText(Offset{X: off.X + size.Width - 1, Y: off.Y + visible}, "▼", t.Border)
+	}
+}
+
+// rowAt returns the row under pt, or -1.
+func (r *renderSelectPanel) rowAt(pt Point) int {
+	size := r.Size()
+	index := r.top + pt.Y - 1
+	if r.message != "" || pt.X < 1 || pt.X >= size.Width-1 || pt.Y < 1 || pt.Y >= size.Height-1 || index >= len(r.rows) {
+		return -1
+	}
+	return index
+}
+
+func (r *renderSelectPanel) MouseShape(_ EventContext, mouse Mouse) MouseShape {
+	if index := r.rowAt(Point{X: mouse.Col, Y: mouse.Row}); index >= 0 && !r.rows[index].disabled {
+		return r.theme.Mouse
+	}
+	return MouseShapeDefault
+}
+
+func (r *renderSelectPanel) HandleEvent(ctx EventContext, ev Event) EventResult {
+	mouse, ok := ev.(Mouse)
+	if !ok || ctx.Phase() != TargetPhase {
+		return EventIgnored
+	}
+	index := r.rowAt(Point{X: mouse.Col, Y: mouse.Row})
+	switch {
+	case mouse.EventType == EventPress && mouse.Button == MouseWheelUp:
+		r.list.scroll(-1)
+	case mouse.EventType == EventPress && mouse.Button == MouseWheelDown:
+		r.list.scroll(1)
+	case index < 0 || r.rows[index].disabled:
+	case mouse.EventType == EventMotion:
+		r.list.hoverRow(index)
+	case mouse.EventType == EventPress && mouse.Button == MouseLeftButton:
+		r.choose(ctx, index)
+	}
+	return EventHandled
+}
+
+// selectChips paints chosen items as chips, each with a button removing it.
+type selectChips struct {
+	labels   []string
+	style    Style
+	chip     Style
+	mouse    MouseShape
+	onRemove func(EventContext, int)
+}
+
+func (w selectChips) CreateRenderObject(BuildContext) RenderObject {
+	return &renderSelectChips{labels: w.labels, style: w.style, chip: w.chip, mouse: w.mouse, onRemove: w.onRemove}
+}
+
+func (w selectChips) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	r := ro.(*renderSelectChips)
+	r.labels, r.style, r.chip, r.mouse, r.onRemove = w.labels, w.style, w.chip, w.mouse, w.onRemove
+	r.MarkNeedsLayout()
+}
+
+type renderSelectChips struct {
+	LeafRenderObject
+	labels   []string
+	style    Style
+	chip     Style
+	mouse    MouseShape
+	onRemove func(EventContext, int)
+}
+
+// removeColumns returns the column of each chip's remove button. Chips are
+// " label × " with a space between them.
+func (r *renderSelectChips) removeColumns() []int {
+	cols := make([]int, len(r.labels))
+	x := 0
+	for i, label := range r.labels {
+		x += 1 + textWidth(label) + 1
+		cols[i] = x
+		x += 3
+	}
+	return cols
+}
+
+func (r *renderSelectChips) naturalWidth() int {
+	width := 0
+	for _, label := range r.labels {
+		width += textWidth(label) + 5
+	}
+	return max(0, width-1)
+}
+
+func (r *renderSelectChips) Layout(_ LayoutContext, c Constraints) {
+	r.SetSize(c.Constrain(Size{Width: r.naturalWidth(), Height: 1}))
+}
+
+func (r *renderSelectChips) DryLayout(_ LayoutContext, c Constraints) Size {
+	return c.Constrain(Size{Width: r.naturalWidth(), Height: 1})
+}
+
+func (r *renderSelectChips) Paint(p *Painter, off Offset) {
+	size := r.Size()
+	p.PushClip(Rect{X: off.X, Y: off.Y, Width: size.Width, Height: size.Height})
+	defer p.PopClip()
+	x := off.X
+	for i, label := range r.labels {
+		if i > 0 {
+			p.DrawText(Offset{X: x, Y: off.Y}, " ", r.style)
+			x++
+		}
+		chip := " " + label + " " + selectChipRemove + " "
+		p.DrawText(Offset{X: x, Y: off.Y}, chip, r.chip)
+		x += textWidth(chip)
+	}
+}
+
+// chipAt returns the chip whose remove button is at col, or -1.
+func (r *renderSelectChips) chipAt(col int) int {
+	for i, x := range r.removeColumns() {
+		if col == x && x < r.Size().Width {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *renderSelectChips) MouseShape(_ EventContext, mouse Mouse) MouseShape {
+	if r.chipAt(mouse.Col) >= 0 {
+		return r.mouse
+	}
+	return MouseShapeDefault
+}
+
+func (r *renderSelectChips) HandleEvent(ctx EventContext, ev Event) EventResult {
+	mouse, ok := ev.(Mouse)
+	if !ok || ctx.Phase() != TargetPhase || mouse.EventType != EventPress || mouse.Button != MouseLeftButton {
+		return EventIgnored
+	}
+	index := r.chipAt(mouse.Col)
+	if index < 0 || r.onRemove == nil {
+		return EventIgnored
+	}
+	r.onRemove(ctx, index)
+	return EventHandled
+}
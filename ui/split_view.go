@@ -0,0 +1,775 @@
+package ui
+
+import (
+	"math"
+	"time"
+
+	"go.rockorager.dev/vaxis"
+)
+
+const (
+	// splitViewPageStep is how far Shift and an arrow key move a divider.
+	splitViewPageStep = 10
+	// splitViewFarStep moves a divider further than any pane can go.
+	splitViewFarStep = 1 << 20
+)
+
+// SplitPane is one pane of a SplitView.
+type SplitPane struct {
+	// Child fills the pane.
+	Child Widget
+	// Ratio is the pane's initial share of the view relative to the other
+	// panes. Zero means 1.
+	Ratio float64
+	// MinSize is the smallest size of the pane along the axis, in cells. Zero
+	// means 1.
+	MinSize int
+	// MaxSize is the largest size of the pane along the axis when greater than
+	// zero.
+	MaxSize int
+	// Collapsible lets the user collapse the pane from a divider beside it.
+	Collapsible bool
+}
+
+// SplitView lays out panes along an axis with a resizable divider between
+// each pair.
+//
+// Panes share the space left by the dividers by their ratios, within their
+// MinSize and MaxSize. The limits give way only when the panes can't fill the
+// view otherwise. Dragging a divider, or pressing Left and Right (Up and Down
+// for a Vertical view) while it has focus, resizes the panes either side of
+// it; Shift moves it further, and Home and End as far as the panes allow.
+// Double-clicking a divider, or pressing Enter, collapses the Collapsible pane
+// beside it, preferring the one before it, or expands a collapsed one.
+//
+// Dividers are drawn with Lines and join box drawing already painted at their
+// ends, such as the border of a DecoratedBox around the view or the divider of
+// an enclosing SplitView.
+type SplitView struct {
+	// Axis is the direction the panes are placed: Horizontal puts them side
+	// by side.
+	Axis Axis
+	// Panes is the ordered list of panes.
+	Panes []SplitPane
+	// Controller holds the pane ratios and collapsed panes. When nil, the view
+	// keeps its own.
+	Controller *SplitController
+	// OnChanged is called with the ratios after the user moves a divider or
+	// collapses or expands a pane.
+	OnChanged func(EventContext, []float64)
+}
+
+func (w SplitView) CreateState() State {
+	return &splitViewState{}
+}
+
+// SplitController holds the pane ratios of the SplitView it is attached to, so
+// they can be saved and restored.
+//
+// Ratios are relative: each visible pane gets its ratio's share of the space
+// left by the dividers. A collapsed pane keeps its ratio for when it is
+// expanded. The number of panes is known once the controller is attached to a
+// mounted SplitView or given ratios; when it no longer matches the view, the
+// ratios are reset from the panes.
+type SplitController struct {
+	ratios    []float64
+	collapsed []bool
+	listeners map[any]func()
+}
+
+func (c *SplitController) attach(owner any, onChange func()) {
+	if c.listeners == nil {
+		c.listeners = make(map[any]func())
+	}
+	c.listeners[owner] = onChange
+}
+
+func (c *SplitController) detach(owner any) {
+	delete(c.listeners, owner)
+}
+
+// sync fits the ratios to the panes of a building SplitView.
+func (c *SplitController) sync(panes []SplitPane) {
+	if len(c.ratios) == len(panes) {
+		return
+	}
+	c.ratios = make([]float64, len(panes))
+	c.collapsed = make([]bool, len(panes))
+	for i, pane := range panes {
+		c.ratios[i] = splitRatio(pane.Ratio)
+	}
+}
+
+func (c *SplitController) notify() {
+	for _, onChange := range c.listeners {
+		onChange()
+	}
+}
+
+// Attached reports whether the controller is attached to a mounted SplitView.
+func (c *SplitController) Attached() bool {
+	return c != nil && len(c.listeners) > 0
+}
+
+// Ratios returns a copy of the pane ratios.
+func (c *SplitController) Ratios() []float64 {
+	if c == nil {
+		return nil
+	}
+	return append([]float64(nil), c.ratios...)
+}
+
+// SetRatios replaces the pane ratios, such as with ones saved from Ratios. It
+// returns false when a ratio isn't a positive number, or when their number
+// doesn't match the attached view.
+func (c *SplitController) SetRatios(ratios []float64) bool {
+	if c == nil || (c.Attached() && len(ratios) != len(c.ratios)) {
+		return false
+	}
+	for _, ratio := range ratios {
+		if !(ratio > 0) || math.IsInf(ratio, 1) {
+			return false
+		}
+	}
+	if len(ratios) != len(c.collapsed) {
+		c.collapsed = make([]bool, len(ratios))
+	}
+	c.ratios = append([]float64(nil), ratios...)
+	c.notify()
+	return true
+}
+
+// Collapsed reports whether the pane at index is collapsed.
+func (c *SplitController) Collapsed(index int) bool {
+	return c != nil && index >= 0 && index < len(c.collapsed) && c.collapsed[index]
+}
+
+// SetCollapsed collapses or expands the pane at index. It returns false when
+// the pane doesn't exist.
+func (c *SplitController) SetCollapsed(index int, collapsed bool) bool {
+	if c == nil || index < 0 || index >= len(c.collapsed) {
+		return false
+	}
+	if c.collapsed[index] != collapsed {
+		c.collapsed[index] = collapsed
+		c.notify()
+	}
+	return true
+}
+
+// splitControllerLink attaches a SplitView state to its controller, or to a
+// controller of its own when the widget has none.
+type splitControllerLink struct {
+	own        SplitController
+	controller *SplitController
+}
+
+func (l *splitControllerLink) attach(c *SplitController, owner any, onChange func()) {
+	if c == nil {
+		c = &l.own
+	}
+	c.attach(owner, onChange)
+	l.controller = c
+}
+
+func (l *splitControllerLink) detach(owner any) {
+	if l.controller != nil {
+		l.controller.detach(owner)
+	}
+	l.controller = nil
+}
+
+type splitViewState struct {
+	StateBase
+	link splitControllerLink
+}
+
+func (s *splitViewState) InitState() {
+	s.link.attach(s.Widget().(SplitView).Controller, s, s.MarkNeedsBuild)
+}
+
+func (s *splitViewState) DidUpdateWidget(old Widget) {
+	if next := s.Widget().(SplitView).Controller; next != old.(SplitView).Controller {
+		s.link.detach(s)
+		s.link.attach(next, s, s.MarkNeedsBuild)
+	}
+}
+
+func (s *splitViewState) Dispose() {
+	s.link.detach(s)
+}
+
+func (s *splitViewState) Build(ctx BuildContext) Widget {
+	w := s.Widget().(SplitView)
+	c := s.link.controller
+	c.sync(w.Panes)
+	theme := splitViewTheme(MustDepend[Theme](ctx))
+	children := make([]Widget, 0, max(0, 2*len(w.Panes)-1))
+	for i, pane := range w.Panes {
+		if i > 0 {
+			children = append(children, splitDivider{
+				axis:   w.Axis,
+				index:  i - 1,
+				theme:  theme,
+				move:   s.moveDivider,
+				toggle: s.toggleCollapsed,
+			})
+		}
+		var child Widget = SizedBox{}
+		if pane.Child != nil {
+			child = pane.Child
+		}
+		children = append(children, FocusScope{SkipTraversal: c.Collapsed(i), Child: child})
+	}
+	return splitViewLayout{
+		Axis:      w.Axis,
+		Panes:     w.Panes,
+		Ratios:    c.Ratios(),
+		Collapsed: append([]bool(nil), c.collapsed...),
+		Children:  children,
+	}
+}
+
+func (s *splitViewState) renderObject() *renderSplitView {
+	if r, ok := s.Context().FindRenderObject().(*renderSplitView); ok {
+		return r
+	}
+	return nil
+}
+
+// moveDivider moves the divider at index by delta cells, resizing the panes
+// either side of it.
+func (s *splitViewState) moveDivider(ctx EventContext, index, delta int) EventResult {
+	w := s.Widget().(SplitView)
+	r := s.renderObject()
+	if r == nil || len(r.sizes) != len(w.Panes) || index < 0 || index+1 >= len(w.Panes) {
+		return EventIgnored
+	}
+	c := s.link.controller
+	sizes := append([]int(nil), r.sizes...)
+	collapsed := append([]bool(nil), c.collapsed...)
+	if !splitMoveDivider(sizes, collapsed, w.Panes, index, delta) {
+		return EventHandled
+	}
+	avail := 0
+	for i, size := range sizes {
+		if !collapsed[i] {
+			avail += size
+		}
+	}
+	if avail <= 0 {
+		return EventHandled
+	}
+	// Visible panes take the shares they were given. Collapsed panes keep
+	// theirs for when they are expanded
+	ratios := c.Ratios()
+	for i, size := range sizes {
+		if !collapsed[i] {
+			ratios[i] = float64(size) / float64(avail)
+		}
+	}
+	c.collapsed = collapsed
+	c.ratios = ratios
+	c.notify()
+	s.changed(ctx)
+	return EventHandled
+}
+
+// toggleCollapsed expands a collapsed pane beside the divider at index, or
+// collapses a Collapsible one, preferring the pane before it.
+func (s *splitViewState) toggleCollapsed(ctx EventContext, index int) EventResult {
+	w := s.Widget().(SplitView)
+	c := s.link.controller
+	if index < 0 || index+1 >= len(w.Panes) {
+		return EventIgnored
+	}
+	visible := 0
+	for i := range w.Panes {
+		if !c.Collapsed(i) {
+			visible++
+		}
+	}
+	switch {
+	case c.Collapsed(index):
+		c.SetCollapsed(index, false)
+	case c.Collapsed(index + 1):
+		c.SetCollapsed(index+1, false)
+	case visible <= 1:
+		return EventIgnored
+	case w.Panes[index].Collapsible:
+		c.SetCollapsed(index, true)
+	case w.Panes[index+1].Collapsible:
+		c.SetCollapsed(index+1, true)
+	default:
+		return EventIgnored
+	}
+	s.changed(ctx)
+	return EventHandled
+}
+
+func (s *splitViewState) changed(ctx EventContext) {
+	if onChanged := s.Widget().(SplitView).OnChanged; onChanged != nil {
+		onChanged(ctx, s.link.controller.Ratios())
+	}
+}
+
+// splitMoveDivider moves the divider at index by delta cells within the limits
+// of the panes either side of it, expanding a collapsed pane it is dragged
+// into. It reports whether anything changed.
+func splitMoveDivider(sizes []int, collapsed []bool, panes []SplitPane, index, delta int) bool {
+	a, b := index, index+1
+	if delta == 0 || (collapsed[a] && delta < 0) || (collapsed[b] && delta > 0) {
+		return false
+	}
+	if collapsed[a] {
+		sizes[a] = 0
+	}
+	if collapsed[b] {
+		sizes[b] = 0
+	}
+	total := sizes[a] + sizes[b]
+	lo := max(splitMinSize(panes[a]), total-splitMaxSize(panes[b], total))
+	hi := min(splitMaxSize(panes[a], total), total-splitMinSize(panes[b]))
+	if lo > hi {
+		return false
+	}
+	next := clamp(sizes[a]+delta, lo, hi)
+	if next == sizes[a] && !collapsed[a] && !collapsed[b] {
+		return false
+	}
+	sizes[a], sizes[b] = next, total-next
+	collapsed[a], collapsed[b] = false, false
+	return true
+}
+
+// splitSizes divides avail cells between the panes by their ratios within
+// their limits. Collapsed panes get nothing.
+func splitSizes(avail int, panes []SplitPane, ratios []float64, collapsed []bool) []int {
+	n := len(panes)
+	shares := make([]float64, n)
+	frozen := make([]bool, n)
+	visible := -1
+	for i := range panes {
+		frozen[i] = collapsed[i]
+		if !collapsed[i] {
+			visible = i
+		}
+	}
+	// Panes whose share breaks a limit are fixed at it and the rest is shared
+	// again, until every share fits
+	for {
+		free, weight := float64(avail), 0.0
+		for i := range panes {
+			if frozen[i] {
+				free -= shares[i]
+			} else {
+				weight += ratios[i]
+			}
+		}
+		if weight <= 0 {
+			break
+		}
+		violation := 0.0
+		clamped := make([]float64, n)
+		for i, pane := range panes {
+			if frozen[i] {
+				continue
+			}
+			shares[i] = max(0, free) * ratios[i] / weight
+			clamped[i] = math.Min(math.Max(shares[i], float64(splitMinSize(pane))), float64(splitMaxSize(pane, avail)))
+			violation += clamped[i] - shares[i]
+		}
+		if violation == 0 {
+			break
+		}
+		for i := range panes {
+			if !frozen[i] && (violation > 0 && clamped[i] > shares[i] || violation < 0 && clamped[i] < shares[i]) {
+				shares[i] = clamped[i]
+				frozen[i] = true
+			}
+		}
+	}
+
+	// Round down, then hand the cells left over to the largest remainders
+	sizes := make([]int, n)
+	left := avail
+	for i, share := range shares {
+		sizes[i] = int(share)
+		left -= sizes[i]
+	}
+	for left > 0 && visible >= 0 {
+		best := -1
+		for i, share := range shares {
+			if collapsed[i] || share-float64(sizes[i]) <= 0 {
+				continue
+			}
+			if best < 0 || share-float64(sizes[i]) > shares[best]-float64(sizes[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			// Every pane is at its largest: the last one grows past it
+			sizes[visible] += left
+			break
+		}
+		sizes[best]++
+		shares[best] = float64(sizes[best])
+		left--
+	}
+	// When the smallest sizes don't fit, the last panes shrink past them
+	for i := n - 1; i >= 0 && left < 0; i-- {
+		take := min(sizes[i], -left)
+		sizes[i] -= take
+		left += take
+	}
+	return sizes
+}
+
+func splitRatio(ratio float64) float64 {
+	if !(ratio > 0) || math.IsInf(ratio, 1) {
+		return 1
+	}
+	return ratio
+}
+
+func splitMinSize(pane SplitPane) int {
+	return max(1, pane.MinSize)
+}
+
+// splitMaxSize returns the pane's MaxSize, or limit when it has none.
+func splitMaxSize(pane SplitPane, limit int) int {
+	if pane.MaxSize > 0 {
+		return max(pane.MaxSize, splitMinSize(pane))
+	}
+	return max(limit, splitMinSize(pane))
+}
+
+// splitDivider is the focusable handle between two panes of a SplitView.
+type splitDivider struct {
+	axis   Axis
+	index  int
+	theme  SplitViewTheme
+	move   func(ctx EventContext, index, delta int) EventResult
+	toggle func(ctx EventContext, index int) EventResult
+}
+
+func (w splitDivider) CreateState() State {
+	return &splitDividerState{}
+}
+
+type splitDividerState struct {
+	StateBase
+	node      FocusNode
+	hovered   bool
+	dragging  bool
+	lastPress time.Time
+}
+
+func (s *splitDividerState) Build(BuildContext) Widget {
+	w := s.Widget().(splitDivider)
+	s.node.onChange = s.MarkNeedsBuild
+	style := w.theme.Divider
+	switch {
+	case s.dragging || s.node.HasFocus():
+		style = w.theme.Focused
+	case s.hovered:
+		style = w.theme.Hovered
+	}
+	return Focus(&s.node, splitDividerLine{Axis: w.axis, Weight: w.theme.Weight, Style: style})
+}
+
+func (s *splitDividerState) MouseShape(EventContext, Mouse) MouseShape {
+	if s.Widget().(splitDivider).axis == Horizontal {
+		return MouseShapeResizeColumn
+	}
+	return MouseShapeResizeRow
+}
+
+func (s *splitDividerState) HandleEvent(ctx EventContext, ev Event) EventResult {
+	if ctx.Phase() != TargetPhase && ctx.Phase() != BubblePhase {
+		return EventIgnored
+	}
+	w := s.Widget().(splitDivider)
+	switch ev := ev.(type) {
+	case Key:
+		if keyIsRelease(ev) {
+			return EventIgnored
+		}
+		return s.handleKey(ctx, w, ev)
+	case hoverExit:
+		if s.hovered {
+			s.SetState(func() { s.hovered = false })
+		}
+	case Mouse:
+		return s.handleMouse(ctx, w, ev)
+	}
+	return EventIgnored
+}
+
+func (s *splitDividerState) handleKey(ctx EventContext, w splitDivider, key Key) EventResult {
+	back, forward := KeyLeft, KeyRight
+	if w.axis == Vertical {
+		back, forward = KeyUp, KeyDown
+	}
+	step := 1
+	if key.Modifiers&vaxis.ModShift != 0 {
+		step = splitViewPageStep
+	}
+	switch {
+	case key.Keycode == back:
+		return w.move(ctx, w.index, -step)
+	case key.Keycode == forward:
+		return w.move(ctx, w.index, step)
+	case key.Keycode == KeyHome:
+		return w.move(ctx, w.index, -splitViewFarStep)
+	case key.Keycode == KeyEnd:
+		return w.move(ctx, w.index, splitViewFarStep)
+	case key.MatchString("Enter"):
+		return w.toggle(ctx, w.index)
+	}
+	return EventIgnored
+}
+
+func (s *splitDividerState) handleMouse(ctx EventContext, w splitDivider, mouse Mouse) EventResult {
+	offset := mouse.Col
+	if w.axis == Vertical {
+		offset = mouse.Row
+	}
+	switch mouse.EventType {
+	case EventMotion:
+		if !s.dragging {
+			if !s.hovered {
+				s.SetState(func() { s.hovered = true })
+			}
+			return EventIgnored
+		}
+		if mouse.Button == MouseNoButton {
+			s.stopDragging(ctx)
+			return EventHandled
+		}
+		if offset != 0 {
+			// A drag doesn't start a double-click
+			s.lastPress = time.Time{}
+			w.move(ctx, w.index, offset)
+		}
+		return EventHandled
+	case EventRelease:
+		if s.dragging {
+			s.stopDragging(ctx)
+			return EventHandled
+		}
+	case EventPress:
+		if mouse.Button != MouseLeftButton {
+			return EventIgnored
+		}
+		s.node.RequestFocus()
+		now := time.Now()
+		if now.Sub(s.lastPress) <= textEditorMultiClickInterval {
+			s.lastPress = time.Time{}
+			w.toggle(ctx, w.index)
+			return EventHandled
+		}
+		s.lastPress = now
+		s.SetState(func() { s.dragging = true })
+		if ctx.app != nil {
+			ctx.app.captureMouse(s.element)
+		}
+		return EventHandled
+	}
+	return EventIgnored
+}
+
+func (s *splitDividerState) stopDragging(ctx EventContext) {
+	s.SetState(func() { s.dragging = false })
+	if ctx.app != nil {
+		ctx.app.releaseMouseCapture(s.element)
+	}
+}
+
+// splitDividerLine paints a divider line across the view, joining box drawing
+// already painted beyond its ends.
+type splitDividerLine struct {
+	Axis   Axis
+	Weight LineWeight
+	Style  Style
+}
+
+func (w splitDividerLine) CreateRenderObject(BuildContext) RenderObject {
+	return &renderSplitDividerLine{Axis: w.Axis, Weight: w.Weight, Style: w.Style}
+}
+
+func (w splitDividerLine) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	r := ro.(*renderSplitDividerLine)
+	if r.Axis != w.Axis || r.Weight != w.Weight || r.Style != w.Style {
+		r.Axis = w.Axis
+		r.Weight = w.Weight
+		r.Style = w.Style
+		r.MarkNeedsPaint()
+	}
+}
+
+type renderSplitDividerLine struct {
+	LeafRenderObject
+	Axis   Axis
+	Weight LineWeight
+	Style  Style
+}
+
+func (r *renderSplitDividerLine) Layout(_ LayoutContext, c Constraints) {
+	r.SetSize(c.Constrain(Size{}))
+}
+
+func (r *renderSplitDividerLine) DryLayout(_ LayoutContext, c Constraints) Size {
+	return c.Constrain(Size{})
+}
+
+func (r *renderSplitDividerLine) Paint(p *Painter, off Offset) {
+	size := r.Size()
+	if size.Width <= 0 || size.Height <= 0 {
+		return
+	}
+	var lines Lines
+	if r.Axis == Horizontal {
+		// Side by side panes are divided by a vertical line
+		lines.Vertical(0, 0, size.Height, r.Weight, r.Style)
+		r.joinEnd(&lines, p, off, 0, 0, 0, -1)
+		r.joinEnd(&lines, p, off, 0, size.Height-1, 0, 1)
+	} else {
+		lines.Horizontal(0, 0, size.Width, r.Weight, r.Style)
+		r.joinEnd(&lines, p, off, 0, 0, -1, 0)
+		r.joinEnd(&lines, p, off, size.Width-1, 0, 1, 0)
+	}
+	p.DrawLines(off, &lines)
+}
+
+// joinEnd extends the line from its end at col,row one cell in direction
+// dx,dy when that cell already shows box drawing, so the two join.
+func (r *renderSplitDividerLine) joinEnd(lines *Lines, p *Painter, off Offset, col, row, dx, dy int) {
+	beyond := p.Cell(off.X+col+dx, off.Y+row+dy)
+	if !lines.Join(col+dx, row+dy, beyond.Grapheme, beyond.Style) {
+		return
+	}
+	if dx != 0 {
+		lines.Horizontal(min(col, col+dx), row, 2, r.Weight, r.Style)
+	} else {
+		lines.Vertical(col, min(row, row+dy), 2, r.Weight, r.Style)
+	}
+	// The joined cell keeps the style it was painted with
+	lines.Join(col+dx, row+dy, beyond.Grapheme, beyond.Style)
+}
+
+// splitViewLayout places the panes and dividers built by a SplitView. Children
+// alternate between panes and dividers.
+type splitViewLayout struct {
+	Axis      Axis
+	Panes     []SplitPane
+	Ratios    []float64
+	Collapsed []bool
+	Children  []Widget
+}
+
+func (w splitViewLayout) WidgetChildren() []Widget {
+	return w.Children
+}
+
+func (w splitViewLayout) CreateRenderObject(BuildContext) RenderObject {
+	return &renderSplitView{Axis: w.Axis, Panes: w.Panes, Ratios: w.Ratios, Collapsed: w.Collapsed}
+}
+
+func (w splitViewLayout) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	r := ro.(*renderSplitView)
+	r.Axis = w.Axis
+	r.Panes = w.Panes
+	r.Ratios = w.Ratios
+	r.Collapsed = w.Collapsed
+	r.MarkNeedsLayout()
+}
+
+// SplitViewParentData stores layout data for children of SplitView.
+type SplitViewParentData struct {
+	Offset Offset
+}
+
+// RenderOffset returns the child's paint offset.
+func (d SplitViewParentData) RenderOffset() Offset {
+	return d.Offset
+}
+
+type renderSplitView struct {
+	MultiChildRenderObject
+	Axis      Axis
+	Panes     []SplitPane
+	Ratios    []float64
+	Collapsed []bool
+	// sizes are the pane sizes along the axis from the last layout.
+	sizes []int
+}
+
+func (r *renderSplitView) Layout(ctx LayoutContext, c Constraints) {
+	size := r.size(c)
+	main, cross := size.Width, size.Height
+	if r.Axis == Vertical {
+		main, cross = size.Height, size.Width
+	}
+	children := r.Children()
+	if len(r.Panes) == 0 || len(children) != 2*len(r.Panes)-1 || len(r.Ratios) != len(r.Panes) {
+		r.sizes = nil
+		for _, child := range children {
+			child.Layout(ctx, Tight(Size{}))
+		}
+		r.SetSize(size)
+		return
+	}
+	r.sizes = splitSizes(max(0, main-len(r.Panes)+1), r.Panes, r.Ratios, r.Collapsed)
+	pos := 0
+	for i, child := range children {
+		length := 1
+		if i%2 == 0 {
+			length = r.sizes[i/2]
+		}
+		pd, _ := child.Base().ParentData().(SplitViewParentData)
+		childSize := Size{Width: length, Height: cross}
+		pd.Offset = Offset{X: pos}
+		if r.Axis == Vertical {
+			childSize = Size{Width: cross, Height: length}
+			pd.Offset = Offset{Y: pos}
+		}
+		child.Layout(ctx, Tight(childSize))
+		child.Base().SetParentData(pd)
+		pos += length
+	}
+	r.SetSize(size)
+}
+
+func (r *renderSplitView) DryLayout(_ LayoutContext, c Constraints) Size {
+	return r.size(c)
+}
+
+// size fills the constraints, taking the smallest size along unbounded
+// directions.
+func (r *renderSplitView) size(c Constraints) Size {
+	size := Size{Width: c.MinWidth, Height: c.MinHeight}
+	if c.HasBoundedWidth() {
+		size.Width = c.MaxWidth
+	}
+	if c.HasBoundedHeight() {
+		size.Height = c.MaxHeight
+	}
+	return c.Constrain(size)
+}
+
+// Paint paints the dividers before the panes, so dividers of a SplitView
+// nested in a pane join them.
+func (r *renderSplitView) Paint(p *Painter, off Offset) {
+	children := r.Children()
+	for _, first := range []int{1, 0} {
+		for i := first; i < len(children); i += 2 {
+			pd, _ := children[i].Base().ParentData().(SplitViewParentData)
+			children[i].Paint(p, off.Add(pd.Offset))
+		}
+	}
+}
+
+func (r *renderSplitView) HitTest(*HitTestResult, Point) bool {
+	return false
+}
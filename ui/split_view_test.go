@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.rockorager.dev/vaxis"
+)
+
+func TestSplitSizesKeepsLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		avail     int
+		panes     []SplitPane
+		ratios    []float64
+		collapsed []bool
+		want      []int
+	}{
+		{"ratios", 10, []SplitPane{{}, {}}, []float64{1, 1}, []bool{false, false}, []int{5, 5}},
+		{"remainders", 10, []SplitPane{{}, {}, {}}, []float64{1, 1, 1}, []bool{false, false, false}, []int{4, 3, 3}},
+		{"min", 10, []SplitPane{{MinSize: 6}, {}}, []float64{1, 3}, []bool{false, false}, []int{6, 4}},
+		{"max", 20, []SplitPane{{MaxSize: 4}, {}, {}}, []float64{2, 1, 1}, []bool{false, false, false}, []int{4, 8, 8}},
+		{"collapsed", 9, []SplitPane{{}, {}, {}}, []float64{1, 1, 2}, []bool{true, false, false}, []int{0, 3, 6}},
+		{"max gives way", 10, []SplitPane{{MaxSize: 2}, {MaxSize: 3}}, []float64{1, 1}, []bool{false, false}, []int{2, 8}},
+		{"min gives way", 5, []SplitPane{{MinSize: 4}, {MinSize: 4}}, []float64{1, 1}, []bool{false, false}, []int{4, 1}},
+	}
+	for _, tt := range tests {
+		if got := splitSizes(tt.avail, tt.panes, tt.ratios, tt.collapsed); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: sizes = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitViewDividersJoinBorders(t *testing.T) {
+	app := NewApp(DecoratedBox(
+		Decoration{Border: BorderAll(Style{})},
+		Padding(All(1), SplitView{Panes: []SplitPane{
+			{Child: Text{Value: "left"}},
+			{Child: SplitView{Axis: Vertical, Panes: []SplitPane{
+				{Child: Text{Value: "top"}},
+				{Child: Text{Value: "bottom"}},
+			}}},
+		}}),
+	))
+	want := []string{
+		"┌──────┬──────┐",
+		"│left  │top   │",
+		"│      │      │",
+		"│      ├──────┤",
+		"│      │bottom│",
+		"│      │      │",
+		"└──────┴──────┘",
+	}
+	if got := debugRenderedLines(app, Size{Width: 15, Height: 7}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSplitViewDragsDivider(t *testing.T) {
+	var changed []float64
+	controller := &SplitController{}
+	app := NewApp(SplitView{
+		Controller: controller,
+		Panes:      []SplitPane{{Child: Text{Value: "a"}, MinSize: 3}, {Child: Text{Value: "b"}, MaxSize: 8}},
+		OnChanged:  func(_ EventContext, ratios []float64) { changed = ratios },
+	})
+	size := Size{Width: 11, Height: 2}
+	if got := debugRenderedLines(app, size)[0]; got != "a    │b" {
+		t.Fatalf("row = %q", got)
+	}
+
+	app.Send(Mouse{Col: 5, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	app.Send(Mouse{Col: 7, Row: 1, Button: MouseLeftButton, EventType: EventMotion})
+	app.Send(Mouse{Col: 7, Row: 1, Button: MouseLeftButton, EventType: EventRelease})
+	if got := debugRenderedLines(app, size)[0]; got != "a      │b" {
+		t.Fatalf("row after drag = %q", got)
+	}
+	if want := []float64{0.7, 0.3}; !reflect.DeepEqual(changed, want) || !reflect.DeepEqual(controller.Ratios(), want) {
+		t.Fatalf("ratios = %v, controller %v, want %v", changed, controller.Ratios(), want)
+	}
+
+	// The panes' limits stop the divider
+	app.Send(Mouse{Col: 7, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	app.Send(Mouse{Col: 0, Row: 0, Button: MouseLeftButton, EventType: EventMotion})
+	app.Send(Mouse{Col: 0, Row: 0, Button: MouseLeftButton, EventType: EventRelease})
+	if got := debugRenderedLines(app, size)[0]; got != "a  │b" {
+		t.Fatalf("row after dragging past the first pane's MinSize = %q", got)
+	}
+	// Saved ratios can be restored
+	if !controller.SetRatios([]float64{1, 1}) {
+		t.Fatal("SetRatios failed")
+	}
+	if got := debugRenderedLines(app, size)[0]; got != "a    │b" {
+		t.Fatalf("row after restoring ratios = %q", got)
+	}
+	if controller.SetRatios([]float64{1}) || controller.SetRatios([]float64{1, -1}) {
+		t.Fatal("SetRatios accepted ratios that don't fit the view")
+	}
+}
+
+func TestSplitViewKeyboardAndCollapse(t *testing.T) {
+	controller := &SplitController{}
+	if !controller.SetRatios([]float64{1, 3}) {
+		t.Fatal("SetRatios before mount failed")
+	}
+	app := NewApp(SplitView{
+		Axis:       Vertical,
+		Controller: controller,
+		Panes:      []SplitPane{{Child: Text{Value: "a"}, Collapsible: true}, {Child: Text{Value: "b"}}},
+	})
+	size := Size{Width: 3, Height: 9}
+	lines := debugRenderedLines(app, size)
+	if lines[2] != "───" || lines[3] != "b" {
+		t.Fatalf("restored ratios not used:\n%s", strings.Join(lines, "\n"))
+	}
+
+	app.Send(vaxis.Key{Keycode: vaxis.KeyTab})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyDown})
+	if lines := debugRenderedLines(app, size); lines[3] != "───" {
+		t.Fatalf("Down didn't move the divider:\n%s", strings.Join(lines, "\n"))
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnd})
+	if lines := debugRenderedLines(app, size); lines[7] != "───" {
+		t.Fatalf("End didn't move the divider to the last pane's MinSize:\n%s", strings.Join(lines, "\n"))
+	}
+
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnter})
+	lines = debugRenderedLines(app, size)
+	if lines[0] != "───" || lines[1] != "b" || !controller.Collapsed(0) {
+		t.Fatalf("Enter didn't collapse the first pane:\n%s", strings.Join(lines, "\n"))
+	}
+
+	// Double-clicking the divider expands the pane again
+	app.Send(Mouse{Col: 1, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	app.Send(Mouse{Col: 1, Row: 0, Button: MouseLeftButton, EventType: EventRelease})
+	app.Send(Mouse{Col: 1, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	app.Send(Mouse{Col: 1, Row: 0, Button: MouseLeftButton, EventType: EventRelease})
+	if lines := debugRenderedLines(app, size); lines[0] != "a" || lines[7] != "───" || controller.Collapsed(0) {
+		t.Fatalf("double-click didn't expand the first pane:\n%s", strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,696 @@
+package ui
+
+const (
+	tabCloseMark     = "×"
+	tabOverflowLeft  = "‹"
+	tabOverflowRight = "›"
+)
+
+// TabReorderCallback is called when a tab is dragged or moved from one
+// position to another.
+type TabReorderCallback func(ctx EventContext, from, to int)
+
+// TabItem describes one tab of a TabBar and TabView.
+type TabItem struct {
+	// Key identifies the tab across reorders and closes. When empty, Label is
+	// used.
+	Key KeyValue
+	// Label is the text shown in the tab.
+	Label string
+	// Closable shows a close mark on the tab when the TabBar has OnClose.
+	Closable bool
+	// Builder builds the page TabView shows for the tab. It is first called
+	// when the tab is selected.
+	Builder func(BuildContext) Widget
+}
+
+func (t TabItem) key() KeyValue {
+	if t.Key != "" {
+		return t.Key
+	}
+	return KeyValue(t.Label)
+}
+
+func tabItemKeys(tabs []TabItem) []KeyValue {
+	keys := make([]KeyValue, len(tabs))
+	for i, tab := range tabs {
+		keys[i] = tab.key()
+	}
+	return keys
+}
+
+// TabController selects the tab shown by the TabBar and TabView it is
+// attached to. Share one controller between a TabBar and a TabView to link
+// them.
+//
+// The selection follows the selected tab's key, so closing or reordering other
+// tabs keeps it selected. When the selected tab itself is removed, the tab
+// that takes its place is selected. Methods return false when the controller
+// is not attached to a mounted widget or the tab doesn't exist.
+type TabController struct {
+	index     int
+	key       KeyValue
+	keys      []KeyValue
+	listeners map[any]func()
+}
+
+func (c *TabController) attach(owner any, onChange func()) {
+	if c.listeners == nil {
+		c.listeners = make(map[any]func())
+	}
+	c.listeners[owner] = onChange
+}
+
+func (c *TabController) detach(owner any) {
+	delete(c.listeners, owner)
+}
+
+// sync updates the tab list from a building TabBar or TabView.
+func (c *TabController) sync(keys []KeyValue) {
+	c.keys = keys
+	if len(keys) == 0 {
+		c.index, c.key = 0, ""
+		return
+	}
+	for i, key := range keys {
+		if key == c.key {
+			c.index = i
+			return
+		}
+	}
+	c.index = clamp(c.index, 0, len(keys)-1)
+	c.key = keys[c.index]
+}
+
+// Attached reports whether the controller is attached to a mounted TabBar or
+// TabView.
+func (c *TabController) Attached() bool {
+	return c != nil && len(c.listeners) > 0
+}
+
+// Index returns the position of the selected tab.
+func (c *TabController) Index() int {
+	if c == nil {
+		return 0
+	}
+	return c.index
+}
+
+// Key returns the key of the selected tab, or "" when there are no tabs.
+func (c *TabController) Key() KeyValue {
+	if c == nil {
+		return ""
+	}
+	return c.key
+}
+
+// Len returns the number of tabs.
+func (c *TabController) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.keys)
+}
+
+// Select selects the tab at index.
+func (c *TabController) Select(index int) bool {
+	if !c.Attached() || index < 0 || index >= len(c.keys) {
+		return false
+	}
+	if c.index == index && c.key == c.keys[index] {
+		return true
+	}
+	c.index, c.key = index, c.keys[index]
+	for _, onChange := range c.listeners {
+		onChange()
+	}
+	return true
+}
+
+// SelectKey selects the tab with key.
+func (c *TabController) SelectKey(key KeyValue) bool {
+	if c == nil {
+		return false
+	}
+	for i, k := range c.keys {
+		if k == key {
+			return c.Select(i)
+		}
+	}
+	return false
+}
+
+// Next selects the tab after the selected one, wrapping to the first.
+func (c *TabController) Next() bool {
+	if c.Len() == 0 {
+		return false
+	}
+	return c.Select((c.index + 1) % len(c.keys))
+}
+
+// Previous selects the tab before the selected one, wrapping to the last.
+func (c *TabController) Previous() bool {
+	if c.Len() == 0 {
+		return false
+	}
+	return c.Select((c.index - 1 + len(c.keys)) % len(c.keys))
+}
+
+// tabControllerLink attaches a TabBar or TabView state to its controller, or
+// to a controller of its own when the widget has none.
+type tabControllerLink struct {
+	own        TabController
+	controller *TabController
+}
+
+func (l *tabControllerLink) attach(c *TabController, owner any, onChange func()) {
+	if c == nil {
+		c = &l.own
+	}
+	c.attach(owner, onChange)
+	l.controller = c
+}
+
+func (l *tabControllerLink) detach(owner any) {
+	if l.controller != nil {
+		l.controller.detach(owner)
+	}
+	l.controller = nil
+}
+
+// TabBar is a row of tabs selecting the page of a TabView.
+//
+// Left and Right, or h and l, select the previous or next tab, and Home and End
+// the first or last. Ctrl+W and Delete close the selected tab, and Shift+Left
+// and Shift+Right move it. Clicking a tab selects it, clicking its close mark
+// or middle-clicking it closes it, and dragging it reorders the tabs. Tabs that
+// don't fit are scrolled to keep the selected tab visible, with arrows marking
+// the hidden tabs; the arrows and the mouse wheel scroll the row.
+//
+// The caller owns the tab list and updates it in response to OnClose and
+// OnReorder.
+type TabBar struct {
+	// Tabs is the ordered list of tabs.
+	Tabs []TabItem
+	// Controller selects the tab. Share it with a TabView to link the two. When
+	// nil, the bar keeps its own selection.
+	Controller *TabController
+	// OnChanged is called with the index of a tab selected from the bar.
+	OnChanged ValueChangedCallback[int]
+	// OnClose is called with the index of a closable tab the user closes.
+	// Tabs have no close mark when it is nil.
+	OnClose ValueChangedCallback[int]
+	// OnReorder is called when the user moves a tab. Tabs can't be moved when
+	// it is nil.
+	OnReorder TabReorderCallback
+}
+
+func (w TabBar) CreateState() State {
+	return &tabBarState{hovered: -1, dragging: -1}
+}
+
+type tabBarState struct {
+	StateBase
+	link       tabControllerLink
+	node       FocusNode
+	hovered    int
+	hoverClose bool
+	dragging   int
+}
+
+func (s *tabBarState) InitState() {
+	s.link.attach(s.Widget().(TabBar).Controller, s, s.MarkNeedsBuild)
+}
+
+func (s *tabBarState) DidUpdateWidget(old Widget) {
+	if next := s.Widget().(TabBar).Controller; next != old.(TabBar).Controller {
+		s.link.detach(s)
+		s.link.attach(next, s, s.MarkNeedsBuild)
+	}
+}
+
+func (s *tabBarState) Dispose() {
+	s.link.detach(s)
+}
+
+func (s *tabBarState) Build(ctx BuildContext) Widget {
+	w := s.Widget().(TabBar)
+	s.node.onChange = s.MarkNeedsBuild
+	s.link.controller.sync(tabItemKeys(w.Tabs))
+	render := tabBarRenderWidget{
+		Tabs:       w.Tabs,
+		Closable:   w.OnClose != nil,
+		Selected:   s.link.controller.Index(),
+		Hovered:    s.hovered,
+		HoverClose: s.hoverClose,
+		Focused:    s.node.HasFocus(),
+		Theme:      tabTheme(MustDepend[Theme](ctx)),
+	}
+	if len(w.Tabs) == 0 {
+		return render
+	}
+	return Focus(&s.node, render)
+}
+
+func (s *tabBarState) MouseShape(ctx EventContext, mouse Mouse) MouseShape {
+	r := s.renderObject()
+	if r == nil {
+		return MouseShapeDefault
+	}
+	if hit := r.hitAt(mouse.Col); hit.Index < 0 && hit.Arrow == 0 {
+		return MouseShapeDefault
+	}
+	return tabTheme(MustDepend[Theme](s.Context())).Mouse
+}
+
+func (s *tabBarState) HandleEvent(ctx EventContext, ev Event) EventResult {
+	w := s.Widget().(TabBar)
+	if len(w.Tabs) == 0 {
+		return EventIgnored
+	}
+	if ctx.Phase() != TargetPhase && ctx.Phase() != BubblePhase {
+		return EventIgnored
+	}
+	switch ev := ev.(type) {
+	case Key:
+		if keyIsRelease(ev) {
+			return EventIgnored
+		}
+		return s.handleKey(ctx, w, ev)
+	case hoverExit:
+		if s.hovered >= 0 {
+			s.SetState(func() { s.hovered, s.hoverClose = -1, false })
+		}
+		return EventIgnored
+	case Mouse:
+		return s.handleMouse(ctx, w, ev)
+	default:
+		return EventIgnored
+	}
+}
+
+func (s *tabBarState) handleKey(ctx EventContext, w TabBar, key Key) EventResult {
+	index := s.link.controller.Index()
+	switch {
+	case key.MatchString("Shift+Left"):
+		return s.reorder(ctx, w, index, index-1)
+	case key.MatchString("Shift+Right"):
+		return s.reorder(ctx, w, index, index+1)
+	case key.Keycode == KeyLeft || key.MatchString("h"):
+		return s.selectIndex(ctx, w, (index-1+len(w.Tabs))%len(w.Tabs))
+	case key.Keycode == KeyRight || key.MatchString("l"):
+		return s.selectIndex(ctx, w, (index+1)%len(w.Tabs))
+	case key.Keycode == KeyHome:
+		return s.selectIndex(ctx, w, 0)
+	case key.Keycode == KeyEnd:
+		return s.selectIndex(ctx, w, len(w.Tabs)-1)
+	case key.MatchString("Ctrl+w") || key.Keycode == KeyDelete:
+		return s.closeIndex(ctx, w, index)
+	default:
+		return EventIgnored
+	}
+}
+
+func (s *tabBarState) handleMouse(ctx EventContext, w TabBar, mouse Mouse) EventResult {
+	r := s.renderObject()
+	if r == nil {
+		return EventIgnored
+	}
+	hit := r.hitAt(mouse.Col)
+	switch mouse.EventType {
+	case EventMotion:
+		if s.dragging >= 0 {
+			if mouse.Button == MouseNoButton {
+				s.stopDragging(ctx)
+				return EventHandled
+			}
+			if hit.Index >= 0 && hit.Index != s.dragging {
+				from := s.dragging
+				s.dragging = hit.Index
+				s.reorder(ctx, w, from, hit.Index)
+			}
+			return EventHandled
+		}
+		if s.hovered != hit.Index || s.hoverClose != hit.Close {
+			s.SetState(func() { s.hovered, s.hoverClose = hit.Index, hit.Close })
+		}
+		return EventIgnored
+	case EventRelease:
+		if s.dragging >= 0 {
+			s.stopDragging(ctx)
+			return EventHandled
+		}
+		return EventIgnored
+	case EventPress:
+	default:
+		return EventIgnored
+	}
+	switch mouse.Button {
+	case MouseWheelUp, MouseWheelLeft:
+		r.scrollTabs(-1)
+		return EventHandled
+	case MouseWheelDown, MouseWheelRight:
+		r.scrollTabs(1)
+		return EventHandled
+	case MouseMiddleButton:
+		if hit.Index < 0 {
+			return EventIgnored
+		}
+		return s.closeIndex(ctx, w, hit.Index)
+	case MouseLeftButton:
+	default:
+		return EventIgnored
+	}
+	switch {
+	case hit.Arrow != 0:
+		r.scrollTabs(hit.Arrow)
+		return EventHandled
+	case hit.Index < 0:
+		return EventIgnored
+	case hit.Close:
+		return s.closeIndex(ctx, w, hit.Index)
+	}
+	s.selectIndex(ctx, w, hit.Index)
+	if w.OnReorder != nil {
+		s.dragging = hit.Index
+		if ctx.app != nil {
+			ctx.app.captureMouse(s.element)
+		}
+	}
+	return EventHandled
+}
+
+func (s *tabBarState) stopDragging(ctx EventContext) {
+	s.dragging = -1
+	if ctx.app != nil {
+		ctx.app.releaseMouseCapture(s.element)
+	}
+}
+
+func (s *tabBarState) selectIndex(ctx EventContext, w TabBar, index int) EventResult {
+	c := s.link.controller
+	if index == c.Index() {
+		return EventHandled
+	}
+	if !c.Select(index) {
+		return EventIgnored
+	}
+	if w.OnChanged != nil {
+		w.OnChanged(ctx, index)
+	}
+	return EventHandled
+}
+
+func (s *tabBarState) closeIndex(ctx EventContext, w TabBar, index int) EventResult {
+	if w.OnClose == nil || index < 0 || index >= len(w.Tabs) || !w.Tabs[index].Closable {
+		return EventIgnored
+	}
+	w.OnClose(ctx, index)
+	return EventHandled
+}
+
+func (s *tabBarState) reorder(ctx EventContext, w TabBar, from, to int) EventResult {
+	if w.OnReorder == nil || from < 0 || from >= len(w.Tabs) {
+		return EventIgnored
+	}
+	if to < 0 || to >= len(w.Tabs) {
+		return EventHandled
+	}
+	w.OnReorder(ctx, from, to)
+	return EventHandled
+}
+
+func (s *tabBarState) renderObject() *renderTabBar {
+	ro := s.Context().FindRenderObject()
+	if r, ok := ro.(*renderTabBar); ok {
+		return r
+	}
+	return nil
+}
+
+type tabBarRenderWidget struct {
+	Tabs       []TabItem
+	Closable   bool
+	Selected   int
+	Hovered    int
+	HoverClose bool
+	Focused    bool
+	Theme      TabTheme
+}
+
+func (w tabBarRenderWidget) CreateRenderObject(BuildContext) RenderObject {
+	r := &renderTabBar{revealed: -1}
+	w.update(r)
+	return r
+}
+
+func (w tabBarRenderWidget) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	r := ro.(*renderTabBar)
+	w.update(r)
+	r.MarkNeedsLayout()
+}
+
+func (w tabBarRenderWidget) update(r *renderTabBar) {
+	r.Tabs = w.Tabs
+	r.Closable = w.Closable
+	r.Selected = w.Selected
+	r.Hovered = w.Hovered
+	r.HoverClose = w.HoverClose
+	r.Focused = w.Focused
+	r.Theme = w.Theme
+}
+
+type renderTabBar struct {
+	LeafRenderObject
+	Tabs       []TabItem
+	Closable   bool
+	Selected   int
+	Hovered    int
+	HoverClose bool
+	Focused    bool
+	Theme      TabTheme
+	ranges     []tabRange
+	offset     int
+	revealed   int
+}
+
+// tabRange is the span of a tab in the scrolled row. Close is the column of
+// its close mark, or -1.
+type tabRange struct {
+	Start int
+	End   int
+	Close int
+}
+
+// tabBarHit is what lies under a column of the bar. Arrow is -1 or 1 over an
+// overflow arrow.
+type tabBarHit struct {
+	Index int
+	Close bool
+	Arrow int
+}
+
+func (r *renderTabBar) Layout(_ LayoutContext, c Constraints) {
+	r.computeRanges()
+	width := r.contentWidth()
+	if c.HasBoundedWidth() {
+		width = c.MaxWidth
+	}
+	size := c.Constrain(Size{Width: width, Height: 1})
+	if size != r.Size() {
+		r.revealed = -1
+	}
+	r.SetSize(size)
+	if r.revealed != r.Selected {
+		r.reveal(r.Selected)
+		r.revealed = r.Selected
+	}
+	r.offset = clamp(r.offset, 0, r.maxOffset())
+}
+
+func (r *renderTabBar) DryLayout(_ LayoutContext, c Constraints) Size {
+	r.computeRanges()
+	width := r.contentWidth()
+	if c.HasBoundedWidth() {
+		width = c.MaxWidth
+	}
+	return c.Constrain(Size{Width: width, Height: 1})
+}
+
+func (r *renderTabBar) Paint(p *Painter, off Offset) {
+	size := r.Size()
+	if size.Width <= 0 || size.Height <= 0 {
+		return
+	}
+	if len(r.ranges) != len(r.Tabs) {
+		r.computeRanges()
+	}
+	p.Fill(Rect{X: off.X, Y: off.Y, Width: size.Width, Height: size.Height}, Cell{
+		Character: Character{Grapheme: " ", Width: 1},
+		Style:     r.Theme.Bar,
+	})
+	start, width := r.view()
+	p.PushClip(Rect{X: off.X + start, Y: off.Y, Width: width, Height: 1})
+	x := off.X + start - r.offset
+	for i, tab := range r.Tabs {
+		rng := r.ranges[i]
+		if i > 0 {
+			p.DrawText(Offset{X: x + rng.Start - 1, Y: off.Y}, "│", r.Theme.Separator)
+		}
+		style := r.tabStyle(i)
+		p.DrawText(Offset{X: x + rng.Start, Y: off.Y}, " "+tab.Label+" ", style)
+		if rng.Close >= 0 {
+			closeStyle := r.Theme.Close
+			if i == r.Hovered && r.HoverClose {
+				closeStyle = r.Theme.CloseHovered
+			}
+			p.DrawText(Offset{X: x + rng.Close, Y: off.Y}, tabCloseMark, mergeStyle(style, closeStyle))
+			p.DrawText(Offset{X: x + rng.Close + 1, Y: off.Y}, " ", style)
+		}
+	}
+	p.PopClip()
+	if !r.overflowing() {
+		return
+	}
+	if r.offset > 0 {
+		p.DrawText(off, tabOverflowLeft, r.Theme.Overflow)
+	}
+	if r.offset < r.maxOffset() {
+		p.DrawText(Offset{X: off.X + size.Width - 1, Y: off.Y}, tabOverflowRight, r.Theme.Overflow)
+	}
+}
+
+func (r *renderTabBar) HitTest(*HitTestResult, Point) bool {
+	return true
+}
+
+func (r *renderTabBar) tabStyle(index int) Style {
+	style := r.Theme.Normal
+	if index == r.Hovered {
+		style = mergeStyle(style, r.Theme.Hovered)
+	}
+	if index == r.Selected {
+		style = mergeStyle(style, r.Theme.Selected)
+		if r.Focused {
+			style = mergeStyle(style, r.Theme.Focused)
+		}
+	}
+	return style
+}
+
+func (r *renderTabBar) computeRanges() {
+	r.ranges = make([]tabRange, len(r.Tabs))
+	x := 0
+	for i, tab := range r.Tabs {
+		if i > 0 {
+			x++
+		}
+		rng := tabRange{Start: x, Close: -1}
+		x += textWidth(tab.Label) + 2
+		if r.Closable && tab.Closable {
+			rng.Close = x
+			x += textWidth(tabCloseMark) + 1
+		}
+		rng.End = x
+		r.ranges[i] = rng
+	}
+}
+
+func (r *renderTabBar) contentWidth() int {
+	if len(r.ranges) == 0 {
+		return 0
+	}
+	return r.ranges[len(r.ranges)-1].End
+}
+
+func (r *renderTabBar) overflowing() bool {
+	return r.contentWidth() > r.Size().Width
+}
+
+// view returns the columns of the bar which show tabs. When the tabs overflow,
+// the first and last columns are kept for the arrows.
+func (r *renderTabBar) view() (start, width int) {
+	width = r.Size().Width
+	if r.overflowing() && width > 2 {
+		return 1, width - 2
+	}
+	return 0, width
+}
+
+func (r *renderTabBar) maxOffset() int {
+	_, width := r.view()
+	return max(0, r.contentWidth()-width)
+}
+
+// reveal scrolls the least needed to show the tab at index.
+func (r *renderTabBar) reveal(index int) {
+	if index < 0 || index >= len(r.ranges) {
+		return
+	}
+	_, width := r.view()
+	rng := r.ranges[index]
+	if rng.End > r.offset+width {
+		r.offset = rng.End - width
+	}
+	if rng.Start < r.offset {
+		r.offset = rng.Start
+	}
+}
+
+// scrollTabs scrolls by one tab: delta < 0 aligns the start of the view with
+// the tab cut off on the left, delta > 0 aligns the end of the view with the
+// tab cut off on the right.
+func (r *renderTabBar) scrollTabs(delta int) {
+	_, width := r.view()
+	next := r.offset
+	switch {
+	case delta < 0:
+		for i := len(r.ranges) - 1; i >= 0; i-- {
+			if r.ranges[i].Start < r.offset {
+				next = r.ranges[i].Start
+				break
+			}
+		}
+	case delta > 0:
+		for _, rng := range r.ranges {
+			if rng.End > r.offset+width {
+				next = rng.End - width
+				break
+			}
+		}
+	}
+	next = clamp(next, 0, r.maxOffset())
+	if next != r.offset {
+		r.offset = next
+		r.MarkNeedsPaint()
+	}
+}
+
+func (r *renderTabBar) hitAt(col int) tabBarHit {
+	size := r.Size()
+	if r.overflowing() && size.Width > 2 {
+		switch col {
+		case 0:
+			if r.offset > 0 {
+				return tabBarHit{Index: -1, Arrow: -1}
+			}
+			return tabBarHit{Index: -1}
+		case size.Width - 1:
+			if r.offset < r.maxOffset() {
+				return tabBarHit{Index: -1, Arrow: 1}
+			}
+			return tabBarHit{Index: -1}
+		}
+	}
+	start, width := r.view()
+	if col < start || col >= start+width {
+		return tabBarHit{Index: -1}
+	}
+	x := col - start + r.offset
+	for i, rng := range r.ranges {
+		if x >= rng.Start && x < rng.End {
+			return tabBarHit{Index: i, Close: rng.Close >= 0 && x == rng.Close}
+		}
+	}
+	return tabBarHit{Index: -1}
+}
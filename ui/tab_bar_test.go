@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go.rockorager.dev/vaxis"
+)
+
+// tabsHarness is a TabBar and TabView sharing a controller, with a tab list
+// the callbacks edit.
+type tabsHarness struct {
+	controller *TabController
+	tabs       *[]TabItem
+}
+
+func (w tabsHarness) CreateState() State {
+	return &tabsHarnessState{}
+}
+
+type tabsHarnessState struct {
+	StateBase
+}
+
+func (s *tabsHarnessState) Build(BuildContext) Widget {
+	w := s.Widget().(tabsHarness)
+	return Column(
+		TabBar{
+			Tabs:       *w.tabs,
+			Controller: w.controller,
+			OnClose: func(ctx EventContext, index int) {
+				s.SetState(func() {
+					*w.tabs = append((*w.tabs)[:index:index], (*w.tabs)[index+1:]...)
+				})
+			},
+			OnReorder: func(ctx EventContext, from, to int) {
+				s.SetState(func() {
+					tabs := *w.tabs
+					tabs[from], tabs[to] = tabs[to], tabs[from]
+				})
+			},
+		},
+		Expanded(TabView{Tabs: *w.tabs, Controller: w.controller}),
+	)
+}
+
+func tabPageText(label string, builds map[string]int) func(BuildContext) Widget {
+	return func(BuildContext) Widget {
+		builds[label]++
+		return Text{Value: "page " + label}
+	}
+}
+
+func TestTabViewBuildsPagesLazilyAndKeepsState(t *testing.T) {
+	var counter *navigatorCounterState
+	builds := map[string]int{}
+	tabs := []TabItem{
+		{Label: "one", Builder: func(BuildContext) Widget { return navigatorCounter{state: &counter} }},
+		{Label: "two", Builder: tabPageText("two", builds)},
+		{Label: "three", Builder: tabPageText("three", builds)},
+	}
+	controller := &TabController{}
+	app := NewApp(tabsHarness{controller: controller, tabs: &tabs})
+	size := Size{Width: 30, Height: 3}
+	app.Pump(size)
+	if builds["two"] != 0 || builds["three"] != 0 {
+		t.Fatalf("unselected pages were built: %v", builds)
+	}
+	first := counter
+	first.count = 5
+
+	app.Send(vaxis.Key{Keycode: vaxis.KeyTab})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyRight})
+	app.Pump(size)
+	if controller.Index() != 1 || builds["two"] == 0 || builds["three"] != 0 {
+		t.Fatalf("index %d after Right, builds %v", controller.Index(), builds)
+	}
+	p := NewPainter(size)
+	app.Paint(p)
+	if got := debugRenderedText(p); !strings.Contains(got, "page two") || strings.Contains(got, "count") {
+		t.Fatalf("rendered %q, want only the second page", got)
+	}
+
+	app.Send(vaxis.Key{Keycode: vaxis.KeyHome})
+	app.Pump(size)
+	if controller.Index() != 0 || counter != first || counter.count != 5 {
+		t.Fatal("first page lost its state while hidden")
+	}
+
+	if !controller.SelectKey("three") {
+		t.Fatal("SelectKey failed")
+	}
+	app.Pump(size)
+	if builds["three"] == 0 || controller.Key() != "three" {
+		t.Fatalf("programmatic selection didn't build the page: %v", builds)
+	}
+	if controller.Select(3) || controller.SelectKey("missing") {
+		t.Fatal("selected a tab that doesn't exist")
+	}
+}
+
+func TestTabBarCloseAndReorderFollowSelection(t *testing.T) {
+	tabs := []TabItem{
+		{Label: "a", Closable: true},
+		{Label: "b", Closable: true},
+		{Label: "c"},
+	}
+	controller := &TabController{}
+	app := NewApp(tabsHarness{controller: controller, tabs: &tabs})
+	size := Size{Width: 30, Height: 2}
+	app.Pump(size)
+	p := NewPainter(size)
+	app.Paint(p)
+	if got := strings.SplitN(debugRenderedText(p), "\n", 2)[0]; got != " a × │ b × │ c" {
+		t.Fatalf("rendered bar = %q", got)
+	}
+
+	// Select b, then drag it to the front
+	app.Send(Mouse{Col: 7, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	app.Pump(size)
+	if controller.Key() != "b" {
+		t.Fatalf("selected %q after clicking b", controller.Key())
+	}
+	app.Send(Mouse{Col: 1, Row: 0, Button: MouseLeftButton, EventType: EventMotion})
+	app.Send(Mouse{Col: 1, Row: 0, Button: MouseLeftButton, EventType: EventRelease})
+	app.Pump(size)
+	if tabs[0].Label != "b" || controller.Index() != 0 || controller.Key() != "b" {
+		t.Fatalf("after dragging, tabs %v selection %d %q", tabItemKeys(tabs), controller.Index(), controller.Key())
+	}
+
+	// Shift+Right moves it back
+	app.Send(vaxis.Key{Keycode: vaxis.KeyRight, Modifiers: vaxis.ModShift})
+	app.Pump(size)
+	if tabs[1].Label != "b" || controller.Index() != 1 {
+		t.Fatalf("after Shift+Right, tabs %v selection %d", tabItemKeys(tabs), controller.Index())
+	}
+
+	// Closing the selected tab selects the one taking its place
+	app.Send(vaxis.Key{Text: "w", Keycode: 'w', Modifiers: vaxis.ModCtrl})
+	app.Pump(size)
+	if len(tabs) != 2 || controller.Key() != "c" {
+		t.Fatalf("after closing, tabs %v selection %q", tabItemKeys(tabs), controller.Key())
+	}
+	// c isn't closable; closing a by its mark keeps c selected
+	app.Send(vaxis.Key{Keycode: vaxis.KeyDelete})
+	app.Send(Mouse{Col: 3, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	app.Pump(size)
+	if len(tabs) != 1 || tabs[0].Label != "c" || controller.Index() != 0 {
+		t.Fatalf("after clicking a's close mark, tabs %v selection %d", tabItemKeys(tabs), controller.Index())
+	}
+}
+
+func TestTabBarScrollsOverflowingTabs(t *testing.T) {
+	var tabs []TabItem
+	for _, label := range []string{"alpha", "beta", "gamma", "delta"} {
+		tabs = append(tabs, TabItem{Label: label})
+	}
+	controller := &TabController{}
+	app := NewApp(TabBar{Tabs: tabs, Controller: controller})
+	size := Size{Width: 16, Height: 1}
+	app.Pump(size)
+	p := NewPainter(size)
+	app.Paint(p)
+	if got := debugRenderedText(p); !strings.HasPrefix(got, "  alpha") || !strings.HasSuffix(got, "›") {
+		t.Fatalf("rendered %q, want the first tabs and a right arrow", got)
+	}
+
+	controller.Select(3)
+	app.Pump(size)
+	p = NewPainter(size)
+	app.Paint(p)
+	if got := debugRenderedText(p); !strings.HasPrefix(got, "‹") || !strings.HasSuffix(got, "delta") {
+		t.Fatalf("rendered %q, want the selected last tab scrolled into view", got)
+	}
+
+	app.Send(Mouse{Col: 0, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	p = NewPainter(size)
+	app.Paint(p)
+	if got := debugRenderedText(p); !strings.Contains(got, "gamma") || controller.Index() != 3 {
+		t.Fatalf("rendered %q after clicking the left arrow", got)
+	}
+}
+
+func TestTabViewSkipsHiddenPagesInTraversal(t *testing.T) {
+	tabs := []TabItem{
+		{Label: "one", Builder: func(BuildContext) Widget { return Button{Label: "first"} }},
+		{Label: "two", Builder: func(BuildContext) Widget { return Button{Label: "second"} }},
+	}
+	controller := &TabController{}
+	app := NewApp(tabsHarness{controller: controller, tabs: &tabs})
+	size := Size{Width: 30, Height: 3}
+	app.Pump(size)
+	controller.Select(1)
+	app.Pump(size)
+	controller.Select(0)
+	app.Pump(size)
+
+	var labels []string
+	for i := 0; i < 4; i++ {
+		app.Send(vaxis.Key{Keycode: vaxis.KeyTab})
+		app.Pump(size)
+		labels = append(labels, focusedDebugLabel(app))
+	}
+	for _, label := range labels {
+		if strings.Contains(label, "second") {
+			t.Fatalf("Tab reached the hidden page: %q", labels)
+		}
+	}
+	if !strings.Contains(strings.Join(labels, " "), "first") {
+		t.Fatalf("Tab didn't reach the visible page: %q", labels)
+	}
+}
+
+func TestTabThemeOverridesDerivedStyles(t *testing.T) {
+	theme := DefaultTheme()
+	theme.Tab.Selected = Style{Foreground: RGB(1, 2, 3)}
+	got := tabTheme(theme)
+	if got.Selected.Foreground != RGB(1, 2, 3) || got.Selected.Background != theme.Background {
+		t.Fatalf("selected style = %+v, want the override over the derived style", got.Selected)
+	}
+	if got.Normal != tabTheme(DefaultTheme()).Normal || got.Mouse != defaultTabMouseShape {
+		t.Fatal("unset fields lost their defaults")
+	}
+}
@@ -0,0 +1,103 @@
+package ui
+
+// TabView shows the page of the selected tab.
+//
+// Pages are built lazily: a tab's Builder is first called when the tab is
+// selected. Pages stay mounted once built, like the children of an
+// IndexedStack, so inactive pages keep their state and are left out of Tab
+// traversal. A page is unmounted when its tab is removed from Tabs.
+//
+// Pair it with a TabBar sharing the same Controller:
+//
+//	controller := &TabController{}
+//	Column(
+//		TabBar{Tabs: tabs, Controller: controller},
+//		Expanded(TabView{Tabs: tabs, Controller: controller}),
+//	)
+type TabView struct {
+	// Tabs is the ordered list of tabs, usually the same list given to the
+	// TabBar.
+	Tabs []TabItem
+	// Controller selects the page. When nil, the first page is shown.
+	Controller *TabController
+}
+
+func (w TabView) CreateState() State {
+	return &tabViewState{}
+}
+
+type tabViewState struct {
+	StateBase
+	link  tabControllerLink
+	built map[KeyValue]bool
+}
+
+func (s *tabViewState) InitState() {
+	s.link.attach(s.Widget().(TabView).Controller, s, s.MarkNeedsBuild)
+}
+
+func (s *tabViewState) DidUpdateWidget(old Widget) {
+	if next := s.Widget().(TabView).Controller; next != old.(TabView).Controller {
+		s.link.detach(s)
+		s.link.attach(next, s, s.MarkNeedsBuild)
+	}
+}
+
+func (s *tabViewState) Dispose() {
+	s.link.detach(s)
+}
+
+func (s *tabViewState) Build(BuildContext) Widget {
+	w := s.Widget().(TabView)
+	keys := tabItemKeys(w.Tabs)
+	s.link.controller.sync(keys)
+	index := s.link.controller.Index()
+
+	live := make(map[KeyValue]bool, len(keys))
+	for _, key := range keys {
+		live[key] = true
+	}
+	if s.built == nil {
+		s.built = make(map[KeyValue]bool)
+	}
+	for key := range s.built {
+		if !live[key] {
+			delete(s.built, key)
+		}
+	}
+
+	children := make([]Widget, len(w.Tabs))
+	for i, tab := range w.Tabs {
+		if i == index {
+			s.built[keys[i]] = true
+		}
+		children[i] = tabPage{
+			key:     keys[i],
+			builder: tab.Builder,
+			built:   s.built[keys[i]],
+			active:  i == index,
+		}
+	}
+	return IndexedStack{Index: index, Alignment: TopLeft, Children: children}
+}
+
+// tabPage builds one page of a TabView once it has been selected. The key
+// keeps the page mounted as tabs around it are reordered or closed.
+type tabPage struct {
+	key     KeyValue
+	builder func(BuildContext) Widget
+	built   bool
+	active  bool
+}
+
+func (w tabPage) WidgetKey() KeyValue {
+	return w.key
+}
+
+func (w tabPage) Build(ctx BuildContext) Widget {
+	var child Widget
+	if w.built && w.builder != nil {
+		child = w.builder(ctx)
+	}
+	return FocusScope{SkipTraversal: !w.active, Child: child}
+}
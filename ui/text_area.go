@@ -27,6 +27,13 @@ type TextArea struct {
 	CursorShape CursorStyle
 	// AutoFocus requests focus when the text area is mounted.
 	AutoFocus bool
+	// HistoryLimit is the number of edits Ctrl+Z can undo when greater than
+	// zero. Zero keeps 100 and a negative limit turns undo off.
+	HistoryLimit int
+	// Highlighter styles the text, such as with SyntaxHighlighter. Keep the
+	// same highlighter between builds: a different one highlights every line
+	// again.
+	Highlighter TextHighlighter
 }
 
 func (w TextArea) CreateState() State {
@@ -39,18 +46,22 @@ type textAreaState struct {
 	layout    TextLayout
 	scrollRow int
 	scrollCol int
+	highlight textAreaHighlight
 }
 
 func (s *textAreaState) Build(ctx BuildContext) Widget {
 	w := s.Widget().(TextArea)
 	s.editor.SyncValue(w.Value)
+	s.editor.SetHistoryLimit(w.HistoryLimit)
 	if w.Selection != nil {
 		s.editor.SetSelection(*w.Selection)
 	} else if w.CursorOffset != nil {
 		s.editor.SetCursorOffset(*w.CursorOffset)
 	}
 	s.editor.SetFocusChange(s.MarkNeedsBuild)
-	theme := textFieldTheme(MustDepend[Theme](ctx))
+	s.highlight.update(w.Highlighter, s.editor.Text())
+	appTheme := MustDepend[Theme](ctx)
+	theme := textFieldTheme(appTheme)
 	padding := textAreaPadding(w, theme)
 	style := theme.Normal
 	if s.editor.HasFocus() {
@@ -73,6 +84,8 @@ func (s *textAreaState) Build(ctx BuildContext) Widget {
 			MaxHeight:        textAreaMaxHeight(w, padding),
 			SoftWrap:         w.SoftWrap,
 			CursorShape:      textAreaCursorShape(w),
+			Syntax:           syntaxTheme(appTheme),
+			Highlight:        s.highlight.cache.generation,
 		}),
 	))
 	if w.AutoFocus {
@@ -204,6 +217,8 @@ type textAreaView struct {
 	MaxHeight        int
 	SoftWrap         bool
 	CursorShape      CursorStyle
+	Syntax           SyntaxTheme
+	Highlight        int
 }
 
 func (w textAreaView) CreateRenderObject(BuildContext) RenderObject {
@@ -222,6 +237,8 @@ func (w textAreaView) CreateRenderObject(BuildContext) RenderObject {
 		MaxHeight:        max(0, w.MaxHeight),
 		SoftWrap:         w.SoftWrap,
 		CursorShape:      w.CursorShape,
+		Syntax:           w.Syntax,
+		Highlight:        w.Highlight,
 	}
 }
 
@@ -230,7 +247,8 @@ func (w textAreaView) UpdateRenderObject(_ BuildContext, ro RenderObject) {
 	if r.State != w.State || r.Value != w.Value || r.Placeholder != w.Placeholder || r.CursorOffset != w.CursorOffset ||
 		r.Selection != w.Selection || r.Focused != w.Focused || r.Style != w.Style || r.PlaceholderStyle != w.PlaceholderStyle ||
 		r.SelectionStyle != w.SelectionStyle ||
-		r.MinWidth != max(1, w.MinWidth) || r.MinHeight != max(1, w.MinHeight) || r.MaxHeight != max(0, w.MaxHeight) || r.SoftWrap != w.SoftWrap || r.CursorShape != w.CursorShape {
+		r.MinWidth != max(1, w.MinWidth) || r.MinHeight != max(1, w.MinHeight) || r.MaxHeight != max(0, w.MaxHeight) || r.SoftWrap != w.SoftWrap || r.CursorShape != w.CursorShape ||
+		r.Syntax != w.Syntax || r.Highlight != w.Highlight {
 		r.State = w.State
 		r.Value = w.Value
 		r.Placeholder = w.Placeholder
@@ -245,6 +263,8 @@ func (w textAreaView) UpdateRenderObject(_ BuildContext, ro RenderObject) {
 		r.MaxHeight = max(0, w.MaxHeight)
 		r.SoftWrap = w.SoftWrap
 		r.CursorShape = w.CursorShape
+		r.Syntax = w.Syntax
+		r.Highlight = w.Highlight
 		r.MarkNeedsLayout()
 	}
 }
@@ -265,6 +285,8 @@ type renderTextArea struct {
 	MaxHeight        int
 	SoftWrap         bool
 	CursorShape      CursorStyle
+	Syntax           SyntaxTheme
+	Highlight        int
 	layout           TextLayout
 }
 
@@ -299,6 +321,7 @@ func (r *renderTextArea) Paint(p *Painter, off Offset) {
 		ScrollCol:      r.scrollCol(),
 		Selection:      selection,
 		SelectionStyle: selectionStyle,
+		CellStyle:      r.highlightStyle(size),
 	})
 	if r.Focused && r.Value != "" {
 		if row, col, ok := r.layout.CursorCell(r.cursorPosition(), TextCursorCellOptions{SoftWrap: r.SoftWrap, WrapWidth: size.Width}); ok {
@@ -309,6 +332,23 @@ func (r *renderTextArea) Paint(p *Painter, off Offset) {
 	}
 }
 
+// highlightStyle highlights the visible lines and returns their cell styles,
+// or nil when there is no highlighter or the placeholder is shown.
+func (r *renderTextArea) highlightStyle(size Size) func(TextCell) Style {
+	if r.State == nil || r.State.highlight.cache.highlighter == nil || r.Value == "" || r.State.highlight.text != r.Value {
+		return nil
+	}
+	highlight := &r.State.highlight
+	last := min(len(r.layout.Lines), r.scrollRow()+size.Height) - 1
+	if last < 0 {
+		return nil
+	}
+	highlight.highlight(r.layout.Lines[last].End.ByteOffset)
+	return func(cell TextCell) Style {
+		return highlight.style(cell.Position.ByteOffset, r.Syntax)
+	}
+}
+
 func (r *renderTextArea) HitTest(*HitTestResult, Point) bool {
 	return true
 }
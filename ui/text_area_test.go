@@ -789,3 +789,24 @@ func TestTextAreaExternalReplacementResetsStaleHorizontalScroll(t *testing.T) {
 		t.Fatalf("replacement cursor = %#v ok=%v, want 2,0", cursor, ok)
 	}
 }
+
+func TestTextAreaPaintsHighlightedText(t *testing.T) {
+	theme := ui.DefaultTheme()
+	theme.AccentText = vaxis.IndexColor(5)
+	theme.MutedForeground = vaxis.ColorGray
+	app := ui.NewApp(ui.TextArea{Value: "port = 80 # web\n[server]", Highlighter: ui.SyntaxHighlighter("toml")}, ui.WithTheme(theme))
+	size := ui.Size{Width: 20, Height: 3}
+	app.Pump(size)
+	p := ui.NewPainter(size)
+	app.Paint(p)
+
+	if got := p.Cell(11, 0); got.Grapheme != "#" || got.Style.Foreground != theme.MutedForeground || got.Style.Attribute&ui.AttrItalic == 0 {
+		t.Fatalf("comment cell = %#v, want italic muted #", got)
+	}
+	if got := p.Cell(1, 1); got.Grapheme != "[" || got.Style.Foreground != theme.AccentText {
+		t.Fatalf("table cell = %#v, want accent [", got)
+	}
+	if got := p.Cell(6, 0); got.Grapheme != "=" || got.Style.Foreground != theme.Foreground {
+		t.Fatalf("unhighlighted cell = %#v, want the plain foreground", got)
+	}
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"strings"
 	"unicode"
 	"unicode/utf8"
 )
@@ -12,40 +13,57 @@ type TextCursor struct {
 }
 
 // TextBuffer stores editable text, cursor position, and selection state.
+//
+// Edits are recorded for Undo and Redo. Typing and deleting character by
+// character is grouped into one step per word while the keystrokes keep
+// coming; moving the cursor starts a new step.
 type TextBuffer struct {
-	chars              []Character
+	text               textRope
 	anchor             int
 	cursor             int
 	preferredColumn    int
 	hasPreferredColumn bool
+	history            textHistory
+	// keepTabs stores tabs as single characters instead of the spaces
+	// vaxis expands them to, for editors that lay out tab stops themselves.
+	keepTabs bool
+	// onLinesChanged is told which lines each edit replaced: removed lines
+	// from line became inserted ones.
+	onLinesChanged func(line, removed, inserted int)
 }
 
 // NewTextBuffer creates a text buffer initialized with text.
 func NewTextBuffer(text string) TextBuffer {
-	return TextBuffer{chars: vaxisCharacters(text)}
+	return TextBuffer{text: newTextRope(vaxisCharacters(text))}
 }
 
 // SetText replaces the buffer contents and clamps the cursor and selection.
+// The edit history is cleared.
 func (b *TextBuffer) SetText(text string) {
-	b.chars = vaxisCharacters(text)
-	b.anchor = clampInt(b.anchor, 0, len(b.chars))
-	b.cursor = clampInt(b.cursor, 0, len(b.chars))
+	lines := b.text.lineCount()
+	b.text = newTextRope(b.characters(text))
+	if b.onLinesChanged != nil {
+		b.onLinesChanged(0, lines, b.text.lineCount())
+	}
+	b.anchor = clampInt(b.anchor, 0, b.text.Len())
+	b.cursor = clampInt(b.cursor, 0, b.text.Len())
 	b.clearPreferredColumn()
+	b.history.clear()
 }
 
 // Text returns the buffer contents as a string.
 func (b TextBuffer) Text() string {
-	return charactersString(b.chars)
+	return b.text.String()
 }
 
 // Len returns the number of grapheme characters in the buffer.
 func (b TextBuffer) Len() int {
-	return len(b.chars)
+	return b.text.Len()
 }
 
 // CursorOffset returns the cursor offset in grapheme characters.
 func (b TextBuffer) CursorOffset() int {
-	return clampInt(b.cursor, 0, len(b.chars))
+	return clampInt(b.cursor, 0, b.text.Len())
 }
 
 // SetCursorOffset moves the cursor to offset and clears selection.
@@ -72,6 +90,7 @@ func (b *TextBuffer) SetSelection(selection TextSelection) bool {
 	b.anchor = base
 	b.cursor = extent
 	b.clearPreferredColumn()
+	b.history.seal()
 	return true
 }
 
@@ -109,21 +128,14 @@ func (b TextBuffer) SelectedText() string {
 	if start == end {
 		return ""
 	}
-	return charactersString(b.chars[start:end])
+	return b.text.substring(start, end)
 }
 
 // Cursor returns the cursor as a logical line and column.
 func (b TextBuffer) Cursor() TextCursor {
-	line, col := 0, 0
-	for _, ch := range b.chars[:b.CursorOffset()] {
-		if ch.Grapheme == "\n" {
-			line++
-			col = 0
-			continue
-		}
-		col++
-	}
-	return TextCursor{Line: line, Column: col}
+	offset := b.CursorOffset()
+	line := b.text.lineOf(offset)
+	return TextCursor{Line: line, Column: offset - b.text.lineStart(line)}
 }
 
 // SetCursor moves the cursor to a logical line and column.
@@ -134,24 +146,18 @@ func (b *TextBuffer) SetCursor(cursor TextCursor) {
 
 // Insert replaces the selection with text or inserts text at the cursor.
 func (b *TextBuffer) Insert(text string) bool {
-	insert := vaxisCharacters(text)
+	insert := b.characters(text)
 	if len(insert) == 0 {
 		return false
 	}
 	start, end := b.selectionOffsets()
-	next := make([]Character, 0, len(b.chars)+len(insert))
-	next = append(next, b.chars[:start]...)
-	next = append(next, insert...)
-	next = append(next, b.chars[end:]...)
-	b.chars = next
-	b.setCursorOffset(start+len(insert), false)
-	b.clearPreferredColumn()
+	b.replace(start, end, insert, start+len(insert), textInsertKind(insert))
 	return true
 }
 
 // InsertSingleLine inserts text after removing newline characters.
 func (b *TextBuffer) InsertSingleLine(text string) bool {
-	chars := vaxisCharacters(text)
+	chars := b.characters(text)
 	if len(chars) == 0 {
 		return false
 	}
@@ -166,13 +172,7 @@ func (b *TextBuffer) InsertSingleLine(text string) bool {
 		return false
 	}
 	start, end := b.selectionOffsets()
-	next := make([]Character, 0, len(b.chars)+len(out))
-	next = append(next, b.chars[:start]...)
-	next = append(next, out...)
-	next = append(next, b.chars[end:]...)
-	b.chars = next
-	b.setCursorOffset(start+len(out), false)
-	b.clearPreferredColumn()
+	b.replace(start, end, out, start+len(out), textInsertKind(out))
 	return true
 }
 
@@ -185,9 +185,7 @@ func (b *TextBuffer) DeleteBackward() bool {
 	if cursor == 0 {
 		return false
 	}
-	b.chars = append(b.chars[:cursor-1], b.chars[cursor:]...)
-	b.setCursorOffset(cursor-1, false)
-	b.clearPreferredColumn()
+	b.replace(cursor-1, cursor, nil, cursor-1, textEditDeleteBackward)
 	return true
 }
 
@@ -197,11 +195,10 @@ func (b *TextBuffer) DeleteForward() bool {
 		return true
 	}
 	cursor := b.CursorOffset()
-	if cursor >= len(b.chars) {
+	if cursor >= b.text.Len() {
 		return false
 	}
-	b.chars = append(b.chars[:cursor], b.chars[cursor+1:]...)
-	b.clearPreferredColumn()
+	b.replace(cursor, cursor+1, nil, cursor, textEditDeleteForward)
 	return true
 }
 
@@ -229,7 +226,7 @@ func (b *TextBuffer) MoveRight() bool {
 		b.clearPreferredColumn()
 		return true
 	}
-	if b.CursorOffset() >= len(b.chars) {
+	if b.CursorOffset() >= b.text.Len() {
 		return false
 	}
 	b.setCursorOffset(b.cursor+1, false)
@@ -249,7 +246,7 @@ func (b *TextBuffer) ExtendLeft() bool {
 
 // ExtendRight extends the selection one character to the right.
 func (b *TextBuffer) ExtendRight() bool {
-	if b.CursorOffset() >= len(b.chars) {
+	if b.CursorOffset() >= b.text.Len() {
 		return false
 	}
 	b.setCursorOffset(b.cursor+1, true)
@@ -323,9 +320,7 @@ func (b *TextBuffer) DeleteWordBackward() bool {
 	if next == cursor {
 		return false
 	}
-	b.chars = append(b.chars[:next], b.chars[cursor:]...)
-	b.setCursorOffset(next, false)
-	b.clearPreferredColumn()
+	b.replace(next, cursor, nil, next, textEditOther)
 	return true
 }
 
@@ -339,9 +334,7 @@ func (b *TextBuffer) DeleteWordForward() bool {
 	if next == cursor {
 		return false
 	}
-	b.chars = append(b.chars[:cursor], b.chars[next:]...)
-	b.setCursorOffset(cursor, false)
-	b.clearPreferredColumn()
+	b.replace(cursor, next, nil, cursor, textEditOther)
 	return true
 }
 
@@ -433,35 +426,36 @@ func (b *TextBuffer) ExtendLineDown() bool {
 
 // SelectAll selects the full buffer.
 func (b *TextBuffer) SelectAll() bool {
-	if len(b.chars) == 0 && b.anchor == 0 && b.cursor == 0 {
+	if b.text.Len() == 0 && b.anchor == 0 && b.cursor == 0 {
 		return false
 	}
 	b.anchor = 0
-	b.cursor = len(b.chars)
+	b.cursor = b.text.Len()
 	b.clearPreferredColumn()
+	b.history.seal()
 	return true
 }
 
 // SelectWordAt selects the word-like run containing pos.
 func (b *TextBuffer) SelectWordAt(pos TextPosition) bool {
-	if len(b.chars) == 0 {
+	if b.text.Len() == 0 {
 		return false
 	}
 	offset, ok := b.offsetForPosition(pos)
 	if !ok {
 		return false
 	}
-	offset = clampInt(offset, 0, len(b.chars))
-	if offset == len(b.chars) {
+	offset = clampInt(offset, 0, b.text.Len())
+	if offset == b.text.Len() {
 		offset--
 	}
-	kind := textBufferKind(b.chars[offset])
+	kind := textBufferKind(b.text.at(offset))
 	start := offset
-	for start > 0 && textBufferKind(b.chars[start-1]) == kind {
+	for start > 0 && textBufferKind(b.text.at(start-1)) == kind {
 		start--
 	}
 	end := offset + 1
-	for end < len(b.chars) && textBufferKind(b.chars[end]) == kind {
+	for end < b.text.Len() && textBufferKind(b.text.at(end)) == kind {
 		end++
 	}
 	if start == end {
@@ -470,25 +464,26 @@ func (b *TextBuffer) SelectWordAt(pos TextPosition) bool {
 	b.anchor = start
 	b.cursor = end
 	b.clearPreferredColumn()
+	b.history.seal()
 	return true
 }
 
 // SelectLineAt selects the logical line containing pos, including its newline.
 func (b *TextBuffer) SelectLineAt(pos TextPosition) bool {
-	if len(b.chars) == 0 {
+	if b.text.Len() == 0 {
 		return false
 	}
 	offset, ok := b.offsetForPosition(pos)
 	if !ok {
 		return false
 	}
-	offset = clampInt(offset, 0, len(b.chars))
-	if offset == len(b.chars) && offset > 0 && b.chars[offset-1].Grapheme != "\n" {
+	offset = clampInt(offset, 0, b.text.Len())
+	if offset == b.text.Len() && offset > 0 && b.text.at(offset-1).Grapheme != "\n" {
 		offset--
 	}
 	start := b.lineStart(offset)
 	end := b.lineEnd(offset)
-	if end < len(b.chars) && b.chars[end].Grapheme == "\n" {
+	if end < b.text.Len() && b.text.at(end).Grapheme == "\n" {
 		end++
 	}
 	if start == end {
@@ -497,18 +492,13 @@ func (b *TextBuffer) SelectLineAt(pos TextPosition) bool {
 	b.anchor = start
 	b.cursor = end
 	b.clearPreferredColumn()
+	b.history.seal()
 	return true
 }
 
 // Position returns the current cursor as a text position.
 func (b TextBuffer) Position() TextPosition {
-	pos := TextPosition{}
-	for _, ch := range b.chars[:b.CursorOffset()] {
-		pos.ByteOffset += len(ch.Grapheme)
-		pos.RuneOffset += utf8.RuneCountInString(ch.Grapheme)
-		pos.GraphemeOffset++
-	}
-	return pos
+	return b.text.position(b.CursorOffset())
 }
 
 // SetPosition moves the cursor to pos and clears selection.
@@ -600,86 +590,71 @@ func (b *TextBuffer) moveLine(delta int, extend bool) bool {
 }
 
 func (b TextBuffer) offsetForCursor(cursor TextCursor) int {
-	if cursor.Line <= 0 {
-		return min(max(0, cursor.Column), b.lineLengthAtOffset(0))
-	}
-	line, col := 0, 0
-	for i, ch := range b.chars {
-		if line == cursor.Line {
-			if ch.Grapheme == "\n" || col >= cursor.Column {
-				return i
-			}
-			col++
-			continue
-		}
-		if ch.Grapheme == "\n" {
-			line++
-			col = 0
-		}
-	}
-	if line < cursor.Line {
-		return len(b.chars)
+	line := max(0, cursor.Line)
+	if line >= b.lineCount() {
+		return b.text.Len()
 	}
-	return len(b.chars)
-}
-
-func (b TextBuffer) lineLengthAtOffset(offset int) int {
-	end := b.lineEnd(offset)
-	start := b.lineStart(offset)
-	return end - start
+	start := b.text.lineStart(line)
+	return start + clampInt(cursor.Column, 0, b.text.lineEnd(line)-start)
 }
 
 func (b TextBuffer) lineStart(offset int) int {
-	offset = clampInt(offset, 0, len(b.chars))
-	for offset > 0 && b.chars[offset-1].Grapheme != "\n" {
-		offset--
-	}
-	return offset
+	return b.text.lineStart(b.text.lineOf(clampInt(offset, 0, b.text.Len())))
 }
 
 func (b TextBuffer) lineEnd(offset int) int {
-	offset = clampInt(offset, 0, len(b.chars))
-	for offset < len(b.chars) && b.chars[offset].Grapheme != "\n" {
-		offset++
-	}
-	return offset
+	return b.text.lineEnd(b.text.lineOf(clampInt(offset, 0, b.text.Len())))
 }
 
 func (b TextBuffer) lineCount() int {
-	lines := 1
-	for _, ch := range b.chars {
-		if ch.Grapheme == "\n" {
-			lines++
+	return b.text.lineCount()
+}
+
+// characters splits text into the characters the buffer stores.
+func (b TextBuffer) characters(text string) []Character {
+	if !b.keepTabs || !strings.Contains(text, "\t") {
+		return vaxisCharacters(text)
+	}
+	var chars []Character
+	for i, part := range strings.Split(text, "\t") {
+		if i > 0 {
+			chars = append(chars, Character{Grapheme: "\t", Width: 1})
 		}
+		chars = append(chars, vaxisCharacters(part)...)
 	}
-	return lines
+	return chars
+}
+
+// lineText returns line without its line break.
+func (b TextBuffer) lineText(line int) string {
+	return b.text.substring(b.text.lineStart(line), b.text.lineEnd(line))
 }
 
 func (b TextBuffer) previousWordBoundary(offset int) int {
-	offset = clampInt(offset, 0, len(b.chars))
-	for offset > 0 && textBufferKind(b.chars[offset-1]) == textBufferSpace {
+	offset = clampInt(offset, 0, b.text.Len())
+	for offset > 0 && textBufferKind(b.text.at(offset-1)) == textBufferSpace {
 		offset--
 	}
 	if offset == 0 {
 		return 0
 	}
-	kind := textBufferKind(b.chars[offset-1])
-	for offset > 0 && textBufferKind(b.chars[offset-1]) == kind {
+	kind := textBufferKind(b.text.at(offset - 1))
+	for offset > 0 && textBufferKind(b.text.at(offset-1)) == kind {
 		offset--
 	}
 	return offset
 }
 
 func (b TextBuffer) nextWordBoundary(offset int) int {
-	offset = clampInt(offset, 0, len(b.chars))
-	for offset < len(b.chars) && textBufferKind(b.chars[offset]) == textBufferSpace {
+	offset = clampInt(offset, 0, b.text.Len())
+	for offset < b.text.Len() && textBufferKind(b.text.at(offset)) == textBufferSpace {
 		offset++
 	}
-	if offset >= len(b.chars) {
-		return len(b.chars)
+	if offset >= b.text.Len() {
+		return b.text.Len()
 	}
-	kind := textBufferKind(b.chars[offset])
-	for offset < len(b.chars) && textBufferKind(b.chars[offset]) == kind {
+	kind := textBufferKind(b.text.at(offset))
+	for offset < b.text.Len() && textBufferKind(b.text.at(offset)) == kind {
 		offset++
 	}
 	return offset
@@ -720,14 +695,59 @@ func (b *TextBuffer) clearPreferredColumn() {
 }
 
 func (b *TextBuffer) setCursorOffset(offset int, extend bool) {
-	b.cursor = clampInt(offset, 0, len(b.chars))
+	b.cursor = clampInt(offset, 0, b.text.Len())
 	if !extend {
 		b.anchor = b.cursor
 	}
+	b.history.seal()
+}
+
+// replace replaces the characters from start to end with insert, leaves the
+// cursor at cursor with no selection, and records the edit in the history.
+func (b *TextBuffer) replace(start, end int, insert []Character, cursor int, kind textEditKind) {
+	b.replaceSelecting(start, end, insert, cursor, cursor, kind)
+}
+
+// replaceSelecting is replace leaving the selection from anchor to cursor.
+func (b *TextBuffer) replaceSelecting(start, end int, insert []Character, anchor, cursor int, kind textEditKind) {
+	before := [2]int{clampInt(b.anchor, 0, b.text.Len()), b.CursorOffset()}
+	edit := textEdit{start: start, removed: b.text.slice(start, end), inserted: insert}
+	b.splice(start, end, insert)
+	b.cursor = clampInt(cursor, 0, b.text.Len())
+	b.anchor = clampInt(anchor, 0, b.text.Len())
+	b.clearPreferredColumn()
+	b.history.record(edit, kind, before, [2]int{b.anchor, b.cursor})
+}
+
+// splice replaces the characters from start to end with insert.
+func (b *TextBuffer) splice(start, end int, insert []Character) {
+	if b.onLinesChanged == nil {
+		b.text = b.text.splice(start, end, insert)
+		return
+	}
+	line := b.text.lineOf(start)
+	removed := b.text.lineOf(end) - line + 1
+	inserted := 1
+	for _, ch := range insert {
+		if ch.Grapheme == "\n" {
+			inserted++
+		}
+	}
+	b.text = b.text.splice(start, end, insert)
+	b.onLinesChanged(line, removed, inserted)
+}
+
+// textInsertKind returns textEditTyping for a single typed character, which
+// may join the previous undo step.
+func textInsertKind(insert []Character) textEditKind {
+	if len(insert) == 1 && insert[0].Grapheme != "\n" {
+		return textEditTyping
+	}
+	return textEditOther
 }
 
 func (b TextBuffer) selectionOffsets() (int, int) {
-	anchor := clampInt(b.anchor, 0, len(b.chars))
+	anchor := clampInt(b.anchor, 0, b.text.Len())
 	cursor := b.CursorOffset()
 	if anchor <= cursor {
 		return anchor, cursor
@@ -740,40 +760,19 @@ func (b *TextBuffer) deleteSelection() bool {
 	if start == end {
 		return false
 	}
-	b.chars = append(b.chars[:start], b.chars[end:]...)
-	b.setCursorOffset(start, false)
-	b.clearPreferredColumn()
+	b.replace(start, end, nil, start, textEditOther)
 	return true
 }
 
 func (b TextBuffer) positionForOffset(offset int) TextPosition {
-	pos := TextPosition{}
-	for _, ch := range b.chars[:clampInt(offset, 0, len(b.chars))] {
-		pos.ByteOffset += len(ch.Grapheme)
-		pos.RuneOffset += utf8.RuneCountInString(ch.Grapheme)
-		pos.GraphemeOffset++
-	}
-	return pos
+	return b.text.position(offset)
 }
 
 func (b TextBuffer) offsetForPosition(pos TextPosition) (int, bool) {
 	if pos.Span != 0 {
 		return 0, false
 	}
-	byteOffset := 0
-	for i, ch := range b.chars {
-		if byteOffset == pos.ByteOffset {
-			return i, true
-		}
-		byteOffset += len(ch.Grapheme)
-		if byteOffset > pos.ByteOffset {
-			return 0, false
-		}
-	}
-	if byteOffset == pos.ByteOffset {
-		return len(b.chars), true
-	}
-	return 0, false
+	return b.text.offsetForByte(pos.ByteOffset)
 }
 
 func clampInt(value, minValue, maxValue int) int {
@@ -1,6 +1,9 @@
 package ui
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestTextBufferInsertDeleteAndCursor(t *testing.T) {
 	var b TextBuffer
@@ -325,3 +328,128 @@ func TestTextBufferSelectLineAt(t *testing.T) {
 		})
 	}
 }
+
+func TestTextBufferUndoGroupsTypingByWord(t *testing.T) {
+	var b TextBuffer
+	now := time.Now()
+	b.history.now = func() time.Time { return now }
+	for _, ch := range "hello world" {
+		b.Insert(string(ch))
+	}
+	if !b.Undo() || b.Text() != "hello " || b.CursorOffset() != 6 {
+		t.Fatalf("after first undo text = %q cursor %d, want the last word undone", b.Text(), b.CursorOffset())
+	}
+	if !b.Undo() || b.Text() != "" || b.CanUndo() {
+		t.Fatalf("after second undo text = %q, want empty", b.Text())
+	}
+	if b.Undo() {
+		t.Fatal("undo with an empty history returned true")
+	}
+	if !b.Redo() || !b.Redo() || b.Text() != "hello world" || b.CursorOffset() != 11 || b.CanRedo() {
+		t.Fatalf("after redo text = %q cursor %d", b.Text(), b.CursorOffset())
+	}
+
+	// A pause, moving the cursor, or a new edit ends the burst, and a new edit
+	// drops the redo steps
+	now = now.Add(2 * textHistoryCoalesceInterval)
+	b.Insert("!")
+	b.MoveLeft()
+	b.MoveRight()
+	b.Insert("?")
+	b.Undo()
+	if b.Text() != "hello world!" || !b.CanRedo() {
+		t.Fatalf("text = %q, want only ? undone", b.Text())
+	}
+	b.Undo()
+	if b.Text() != "hello world" {
+		t.Fatalf("text = %q, want ! undone on its own after the pause", b.Text())
+	}
+	b.Insert("s")
+	if b.CanRedo() {
+		t.Fatal("redo kept after a new edit")
+	}
+}
+
+func TestTextBufferCopiesKeepTheirOwnHistory(t *testing.T) {
+	var b TextBuffer
+	now := time.Now()
+	b.history.now = func() time.Time { return now }
+	b.Insert("a")
+	b.Insert("b")
+	c := b
+	b.Insert("x")
+	c.Insert("y")
+	c.Insert("z")
+	if !b.Undo() || b.Text() != "" {
+		t.Fatalf("original after undo = %q, want empty", b.Text())
+	}
+	if !c.Undo() || c.Text() != "" {
+		t.Fatalf("copy after undo = %q, want empty", c.Text())
+	}
+	if !b.Redo() || b.Text() != "abx" {
+		t.Fatalf("original after redo = %q, want abx", b.Text())
+	}
+	if !c.Redo() || c.Text() != "abyz" {
+		t.Fatalf("copy after redo = %q, want abyz", c.Text())
+	}
+}
+
+func TestTextBufferUndoGroupsDeletionAndRestoresSelection(t *testing.T) {
+	b := NewTextBuffer("one two three")
+	b.SetCursorOffset(b.Len())
+	for i := 0; i < 8; i++ {
+		b.DeleteBackward()
+	}
+	if b.Text() != "one t" {
+		t.Fatalf("text = %q", b.Text())
+	}
+	b.Undo()
+	if b.Text() != "one two" {
+		t.Fatalf("after undo text = %q, want back to the word start", b.Text())
+	}
+	b.Undo()
+	if b.Text() != "one two three" || b.CursorOffset() != 13 {
+		t.Fatalf("after undo text = %q cursor %d", b.Text(), b.CursorOffset())
+	}
+
+	// Replacing a selection restores it on undo, and the cursor after the
+	// edit on redo
+	b.SetSelection(TextSelection{Base: b.positionForOffset(4), Extent: b.positionForOffset(7)})
+	b.Insert("2")
+	b.Insert("!")
+	if b.Text() != "one 2! three" {
+		t.Fatalf("text = %q", b.Text())
+	}
+	b.Undo()
+	if b.Text() != "one two three" || b.SelectedText() != "two" {
+		t.Fatalf("after undo text = %q selection %q, want two selected", b.Text(), b.SelectedText())
+	}
+	b.Redo()
+	if b.Text() != "one 2! three" || b.HasSelection() || b.CursorOffset() != 6 {
+		t.Fatalf("after redo text = %q cursor %d", b.Text(), b.CursorOffset())
+	}
+
+	// SetText clears the history
+	b.SetText("new")
+	if b.CanUndo() || b.CanRedo() {
+		t.Fatal("history kept after SetText")
+	}
+}
+
+func TestTextBufferHistoryLimit(t *testing.T) {
+	var b TextBuffer
+	b.SetHistoryLimit(2)
+	for _, text := range []string{"a ", "b ", "c "} {
+		b.Insert(text)
+	}
+	for b.Undo() {
+	}
+	if b.Text() != "a " {
+		t.Fatalf("text = %q, want the oldest edit kept", b.Text())
+	}
+	b.SetHistoryLimit(-1)
+	b.Insert("x")
+	if b.CanUndo() || b.CanRedo() {
+		t.Fatal("history recorded while off")
+	}
+}
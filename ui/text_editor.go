@@ -12,8 +12,10 @@ const (
 const textEditorMultiClickInterval = 500 * time.Millisecond
 
 type textEditorState struct {
-	node         FocusNode
-	buffer       TextBuffer
+	node   FocusNode
+	buffer TextBuffer
+	// shared replaces buffer when set, as for a buffer owned by a controller.
+	shared       *TextBuffer
 	selecting    bool
 	now          func() time.Time
 	lastClick    time.Time
@@ -32,14 +34,35 @@ type textEditorHandleOptions struct {
 	moveDown         func() bool
 	extendUp         func() bool
 	extendDown       func() bool
+	// changed replaces onChanged, for editors that don't pass their text
+	// around as a string.
+	changed func(EventContext)
+	// insertLineBreak replaces inserting a plain line break in multiline mode.
+	insertLineBreak func() bool
+}
+
+// UseBuffer edits b instead of the editor's own buffer.
+func (s *textEditorState) UseBuffer(b *TextBuffer) {
+	s.shared = b
+}
+
+func (s *textEditorState) buf() *TextBuffer {
+	if s.shared != nil {
+		return s.shared
+	}
+	return &s.buffer
 }
 
 func (s *textEditorState) SyncValue(value string) {
-	if s.buffer.Text() != value {
-		s.buffer.SetText(value)
+	if s.buf().Text() != value {
+		s.buf().SetText(value)
 	}
 }
 
+func (s *textEditorState) SetHistoryLimit(limit int) {
+	s.buf().SetHistoryLimit(limit)
+}
+
 func (s *textEditorState) SetFocusChange(fn func()) {
 	s.node.onChange = fn
 }
@@ -70,33 +93,39 @@ func (s *textEditorState) DefaultActions(opts textEditorHandleOptions, child Wid
 			CopySelectionTextIntentType: func(ctx EventContext, intent Intent) EventResult {
 				return h.copySelection(ctx, intent)
 			},
+			UndoIntentType: func(ctx EventContext, intent Intent) EventResult {
+				return h.finishChanged(ctx, h.buffer.Undo())
+			},
+			RedoIntentType: func(ctx EventContext, intent Intent) EventResult {
+				return h.finishChanged(ctx, h.buffer.Redo())
+			},
 		},
 		Child: child,
 	}
 }
 
 func (s *textEditorState) Text() string {
-	return s.buffer.Text()
+	return s.buf().Text()
 }
 
 func (s *textEditorState) Len() int {
-	return s.buffer.Len()
+	return s.buf().Len()
 }
 
 func (s *textEditorState) CursorOffset() int {
-	return s.buffer.CursorOffset()
+	return s.buf().CursorOffset()
 }
 
 func (s *textEditorState) SetCursorOffset(offset int) {
-	s.buffer.SetCursorOffset(offset)
+	s.buf().SetCursorOffset(offset)
 }
 
 func (s *textEditorState) SetSelection(selection TextSelection) bool {
-	return s.buffer.SetSelection(selection)
+	return s.buf().SetSelection(selection)
 }
 
 func (s *textEditorState) Selection() TextSelection {
-	return s.buffer.Selection()
+	return s.buf().Selection()
 }
 
 func (s *textEditorState) HasFocus() bool {
@@ -104,35 +133,35 @@ func (s *textEditorState) HasFocus() bool {
 }
 
 func (s *textEditorState) PositionForOffset(offset int) TextPosition {
-	return s.buffer.positionForOffset(offset)
+	return s.buf().positionForOffset(offset)
 }
 
 func (s *textEditorState) MoveVisualUp(layout TextLayout) bool {
 	if len(layout.Lines) > 0 {
-		return s.buffer.MoveVisualUp(layout)
+		return s.buf().MoveVisualUp(layout)
 	}
-	return s.buffer.MoveLineUp()
+	return s.buf().MoveLineUp()
 }
 
 func (s *textEditorState) MoveVisualDown(layout TextLayout) bool {
 	if len(layout.Lines) > 0 {
-		return s.buffer.MoveVisualDown(layout)
+		return s.buf().MoveVisualDown(layout)
 	}
-	return s.buffer.MoveLineDown()
+	return s.buf().MoveLineDown()
 }
 
 func (s *textEditorState) ExtendVisualUp(layout TextLayout) bool {
 	if len(layout.Lines) > 0 {
-		return s.buffer.ExtendVisualUp(layout)
+		return s.buf().ExtendVisualUp(layout)
 	}
-	return s.buffer.ExtendLineUp()
+	return s.buf().ExtendLineUp()
 }
 
 func (s *textEditorState) ExtendVisualDown(layout TextLayout) bool {
 	if len(layout.Lines) > 0 {
-		return s.buffer.ExtendVisualDown(layout)
+		return s.buf().ExtendVisualDown(layout)
 	}
-	return s.buffer.ExtendLineDown()
+	return s.buf().ExtendLineDown()
 }
 
 func (s *textEditorState) HandleEvent(ctx EventContext, ev Event, opts textEditorHandleOptions) EventResult {
@@ -141,29 +170,33 @@ func (s *textEditorState) HandleEvent(ctx EventContext, ev Event, opts textEdito
 
 func (s *textEditorState) eventHandler(opts textEditorHandleOptions) textEditorEventHandler {
 	return textEditorEventHandler{
-		buffer:           &s.buffer,
+		buffer:           s.buf(),
 		selecting:        &s.selecting,
 		clickCount:       s.mouseClickCount,
 		insertMode:       opts.insertMode,
 		requestFocus:     s.node.RequestFocus,
 		markNeedsBuild:   opts.markNeedsBuild,
-		change:           s.change(opts.onChanged, opts.markNeedsBuild),
+		change:           s.change(opts),
 		submit:           opts.submit,
 		positionForMouse: opts.positionForMouse,
 		moveUp:           opts.moveUp,
 		moveDown:         opts.moveDown,
 		extendUp:         opts.extendUp,
 		extendDown:       opts.extendDown,
+		lineBreak:        opts.insertLineBreak,
 	}
 }
 
-func (s *textEditorState) change(onChanged TextChangedCallback, markNeedsBuild func()) func(EventContext) {
+func (s *textEditorState) change(opts textEditorHandleOptions) func(EventContext) {
 	return func(ctx EventContext) {
-		if onChanged != nil {
-			onChanged(ctx, s.buffer.Text())
-			return
+		switch {
+		case opts.changed != nil:
+			opts.changed(ctx)
+		case opts.onChanged != nil:
+			opts.onChanged(ctx, s.buf().Text())
+		default:
+			opts.markNeedsBuild()
 		}
-		markNeedsBuild()
 	}
 }
 
@@ -197,6 +230,7 @@ type textEditorEventHandler struct {
 	moveDown         func() bool
 	extendUp         func() bool
 	extendDown       func() bool
+	lineBreak        func() bool
 }
 
 func (h textEditorEventHandler) HandleEvent(ctx EventContext, ev Event) EventResult {
@@ -222,6 +256,10 @@ func (h textEditorEventHandler) handleKey(ctx EventContext, key Key) EventResult
 		return ctx.Invoke(SelectAllTextIntent{})
 	case key.MatchString("Ctrl+c"):
 		return ctx.Invoke(CopySelectionTextIntent{})
+	case key.MatchString("Ctrl+Shift+z") || key.MatchString("Ctrl+y"):
+		return ctx.Invoke(RedoIntent{})
+	case key.MatchString("Ctrl+z"):
+		return ctx.Invoke(UndoIntent{})
 	case key.MatchString("Ctrl+Shift+Left"):
 		return ctx.Invoke(MoveCaretIntent{Motion: TextMotionLeft, Unit: TextMotionWord, ExtendSelection: true})
 	case key.MatchString("Ctrl+Shift+Right"):
@@ -384,6 +422,9 @@ func (h textEditorEventHandler) insertLineBreak(ctx EventContext, intent Intent)
 	if _, ok := intent.(InsertLineBreakIntent); !ok {
 		return EventIgnored
 	}
+	if h.insertMode == textEditorMultiline && h.lineBreak != nil {
+		return h.finishChanged(ctx, h.lineBreak())
+	}
 	if h.insertMode == textEditorMultiline {
 		return h.finishChanged(ctx, h.buffer.Insert("\n"))
 	}
@@ -23,6 +23,9 @@ type TextField struct {
 	CursorOffset *int
 	// AutoFocus requests focus when the text field is mounted.
 	AutoFocus bool
+	// HistoryLimit is the number of edits Ctrl+Z can undo when greater than
+	// zero. Zero keeps 100 and a negative limit turns undo off.
+	HistoryLimit int
 }
 
 func (w TextField) CreateState() State {
@@ -38,6 +41,7 @@ type textFieldState struct {
 func (s *textFieldState) Build(ctx BuildContext) Widget {
 	w := s.Widget().(TextField)
 	s.editor.SyncValue(w.Value)
+	s.editor.SetHistoryLimit(w.HistoryLimit)
 	if w.CursorOffset != nil {
 		s.editor.SetCursorOffset(*w.CursorOffset)
 	}
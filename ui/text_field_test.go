@@ -653,3 +653,38 @@ func TestTextFieldObscuresDisplayedValue(t *testing.T) {
 		t.Fatalf("cursor after obscured text = %#v, ok = %v; want at 7,0", cursor, ok)
 	}
 }
+
+func TestTextFieldUndoAndRedo(t *testing.T) {
+	h := &textFieldHarness{value: "hi"}
+	app := ui.NewApp(h)
+	size := ui.Size{Width: 12, Height: 1}
+	app.Pump(size)
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEnd})
+	for _, key := range []vaxis.Key{
+		{Text: " ", Keycode: ' '},
+		{Text: "y", Keycode: 'y'},
+		{Text: "o", Keycode: 'o'},
+		{Keycode: 'z', Modifiers: vaxis.ModCtrl},
+	} {
+		app.Send(key)
+		app.UpdateRoot(h)
+		app.Pump(size)
+	}
+	if h.value != "hi " {
+		t.Fatalf("value after Ctrl+Z = %q, want the last word undone", h.value)
+	}
+	for _, key := range []vaxis.Key{{Keycode: 'z', Modifiers: vaxis.ModCtrl}, {Keycode: 'z', Modifiers: vaxis.ModCtrl | vaxis.ModShift}} {
+		app.Send(key)
+		app.UpdateRoot(h)
+		app.Pump(size)
+	}
+	if h.value != "hi " {
+		t.Fatalf("value after Ctrl+Z and Ctrl+Shift+Z = %q, want hi ", h.value)
+	}
+	app.Send(vaxis.Key{Keycode: 'y', Modifiers: vaxis.ModCtrl})
+	app.UpdateRoot(h)
+	app.Pump(size)
+	if h.value != "hi yo" {
+		t.Fatalf("value after Ctrl+Y = %q, want hi yo", h.value)
+	}
+}
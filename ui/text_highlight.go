@@ -0,0 +1,333 @@
+package ui
+
+import (
+	"reflect"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+// SyntaxToken classifies a highlighted range so SyntaxTheme can style it.
+type SyntaxToken int
+
+const (
+	SyntaxNone SyntaxToken = iota
+	SyntaxKeyword
+	SyntaxString
+	SyntaxNumber
+	SyntaxComment
+	SyntaxKey
+	SyntaxConstant
+	SyntaxPunctuation
+)
+
+// HighlightRange styles the bytes Start to End of one line.
+type HighlightRange struct {
+	Start int
+	End   int
+	// Token picks the range's style from the theme's SyntaxTheme.
+	Token SyntaxToken
+	// Style is merged over the token's style.
+	Style Style
+}
+
+// TextHighlighter provides syntax highlighting for TextArea, one logical line
+// at a time. Only the visible lines, and the lines before them, are
+// highlighted, and results are kept until an edit touches the line.
+type TextHighlighter interface {
+	// HighlightLine returns the styled ranges of line, without its line
+	// break, in order and without overlap. state is what the previous line
+	// returned, or nil for the first line, and lets constructs such as block
+	// comments span lines. The returned state must be comparable: after an
+	// edit, later lines are highlighted again until one starts in the same
+	// state as before.
+	HighlightLine(line string, state any) ([]HighlightRange, any)
+}
+
+// textHighlightCache keeps highlighted lines between paints. Edits splice
+// the entries of the lines they replaced.
+type textHighlightCache struct {
+	highlighter TextHighlighter
+	// generation changes when the highlighter does, to repaint unchanged text.
+	generation int
+	entries    []textHighlightEntry
+	// from is the first line that may be stale. The lines before it were
+	// highlighted from the states of the lines before them.
+	from int
+}
+
+type textHighlightEntry struct {
+	ranges []HighlightRange
+	in     any
+	out    any
+	done   bool
+}
+
+// reset starts over with h and lines unhighlighted lines when h isn't the
+// current highlighter, and reports whether it did.
+func (c *textHighlightCache) reset(h TextHighlighter, lines int) bool {
+	if sameTextHighlighter(c.highlighter, h) {
+		return false
+	}
+	*c = textHighlightCache{highlighter: h, generation: c.generation + 1}
+	if h != nil {
+		c.entries = make([]textHighlightEntry, lines)
+	}
+	return true
+}
+
+// splice replaces the entries of removed lines from line with inserted
+// unhighlighted ones.
+func (c *textHighlightCache) splice(line, removed, inserted int) {
+	if c.highlighter == nil {
+		return
+	}
+	line = clampInt(line, 0, len(c.entries))
+	removed = clampInt(removed, 0, len(c.entries)-line)
+	if removed == inserted {
+		for i := line; i < line+removed; i++ {
+			c.entries[i] = textHighlightEntry{}
+		}
+	} else {
+		entries := make([]textHighlightEntry, 0, len(c.entries)-removed+inserted)
+		entries = append(entries, c.entries[:line]...)
+		entries = append(entries, make([]textHighlightEntry, inserted)...)
+		c.entries = append(entries, c.entries[line+removed:]...)
+	}
+	c.from = min(c.from, line)
+}
+
+// highlight brings the lines up to last up to date, reading them from line.
+func (c *textHighlightCache) highlight(last int, line func(int) string) {
+	last = min(last, len(c.entries)-1)
+	for i := c.from; i <= last; i++ {
+		var state any
+		if i > 0 {
+			state = c.entries[i-1].out
+		}
+		entry := &c.entries[i]
+		if entry.done && entry.in == state {
+			continue
+		}
+		ranges, out := c.highlighter.HighlightLine(line(i), state)
+		*entry = textHighlightEntry{ranges: ranges, in: state, out: out, done: true}
+	}
+	c.from = max(c.from, last+1)
+}
+
+// ranges returns the highlighted ranges of line, or nil while it is stale.
+func (c *textHighlightCache) ranges(line int) []HighlightRange {
+	if line < 0 || line >= c.from || line >= len(c.entries) {
+		return nil
+	}
+	return c.entries[line].ranges
+}
+
+// highlightStyleAt returns the style of the byte col of a line.
+func highlightStyleAt(ranges []HighlightRange, col int, theme SyntaxTheme) Style {
+	for _, r := range ranges {
+		if col >= r.Start && col < r.End {
+			return mergeStyle(theme.Style(r.Token), r.Style)
+		}
+	}
+	return Style{}
+}
+
+// textAreaHighlight highlights the value of a TextArea. Lines an edit didn't
+// touch are found by comparing the value with the last one.
+type textAreaHighlight struct {
+	cache  textHighlightCache
+	text   string
+	lines  []string
+	starts []int
+}
+
+func (h *textAreaHighlight) update(highlighter TextHighlighter, text string) {
+	if h.cache.reset(highlighter, 0) {
+		h.text, h.lines, h.starts = "", nil, nil
+	} else if text == h.text && h.lines != nil {
+		return
+	}
+	if highlighter == nil {
+		return
+	}
+	lines := strings.Split(text, "\n")
+	prefix := 0
+	for prefix < len(lines) && prefix < len(h.lines) && lines[prefix] == h.lines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(lines)-prefix && suffix < len(h.lines)-prefix && lines[len(lines)-1-suffix] == h.lines[len(h.lines)-1-suffix] {
+		suffix++
+	}
+	h.cache.splice(prefix, len(h.lines)-prefix-suffix, len(lines)-prefix-suffix)
+	starts := make([]int, len(lines))
+	offset := 0
+	for i, line := range lines {
+		starts[i] = offset
+		offset += len(line) + 1
+	}
+	h.text, h.lines, h.starts = text, lines, starts
+}
+
+// highlight brings the lines up to the one holding the byte offset up to date.
+func (h *textAreaHighlight) highlight(offset int) {
+	h.cache.highlight(h.line(offset), func(i int) string { return h.lines[i] })
+}
+
+// line returns the line holding the byte offset of the text.
+func (h *textAreaHighlight) line(offset int) int {
+	return max(0, sort.Search(len(h.starts), func(i int) bool { return h.starts[i] > offset })-1)
+}
+
+// style returns the highlighted style of the byte offset of the text.
+func (h *textAreaHighlight) style(offset int, theme SyntaxTheme) Style {
+	line := h.line(offset)
+	if line >= len(h.starts) {
+		return Style{}
+	}
+	return highlightStyleAt(h.cache.ranges(line), offset-h.starts[line], theme)
+}
+
+// sameTextHighlighter compares highlighters without panicking on types that
+// can't be compared, which count as different.
+func sameTextHighlighter(a, b TextHighlighter) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
+
+// HighlightRule highlights the matches of a regular expression.
+type HighlightRule struct {
+	Pattern *regexp.Regexp
+	// Group styles only that submatch of Pattern when greater than zero. The
+	// whole match is still consumed.
+	Group int
+	// End makes a match of Pattern start a region that continues until End
+	// matches, on the same line or a later one, as for block comments.
+	End   *regexp.Regexp
+	Token SyntaxToken
+	Style Style
+}
+
+// RegexpHighlighter is a TextHighlighter driven by regular expressions. At
+// each point of a line, the rule matching earliest wins, ties going to the
+// earlier rule, and highlighting continues after its match. Rules without ^
+// are matched again after a match that hid theirs.
+type RegexpHighlighter struct {
+	Rules []HighlightRule
+}
+
+// NewRegexpHighlighter returns a highlighter for rules.
+func NewRegexpHighlighter(rules ...HighlightRule) *RegexpHighlighter {
+	return &RegexpHighlighter{Rules: rules}
+}
+
+// HighlightLine implements TextHighlighter. Its state is the index of the rule
+// whose region is still open, plus one.
+func (h *RegexpHighlighter) HighlightLine(line string, state any) ([]HighlightRange, any) {
+	if h == nil {
+		return nil, nil
+	}
+	var ranges []HighlightRange
+	pos := 0
+	if open, ok := state.(int); ok && open > 0 && open <= len(h.Rules) && h.Rules[open-1].End != nil {
+		rule := h.Rules[open-1]
+		end := rule.End.FindStringIndex(line)
+		if end == nil {
+			return appendHighlightRange(ranges, 0, len(line), rule), open
+		}
+		ranges = appendHighlightRange(ranges, 0, end[1], rule)
+		pos = end[1]
+	}
+	matches := make([][][]int, len(h.Rules))
+	for i, rule := range h.Rules {
+		if rule.Pattern != nil {
+			matches[i] = rule.Pattern.FindAllStringSubmatchIndex(line, -1)
+		}
+	}
+	for pos < len(line) {
+		best := -1
+		var loc []int
+		for i := range h.Rules {
+			overlapped := false
+			for len(matches[i]) > 0 && (matches[i][0][0] < pos || matches[i][0][0] == matches[i][0][1]) {
+				overlapped = overlapped || matches[i][0][1] > pos
+				matches[i] = matches[i][1:]
+			}
+			if overlapped && !highlightAnchored(h.Rules[i].Pattern) {
+				// The match began inside an earlier one, hiding any after it
+				matches[i] = highlightMatchesFrom(h.Rules[i].Pattern, line, pos)
+			}
+			if len(matches[i]) > 0 && (best < 0 || matches[i][0][0] < loc[0]) {
+				best, loc = i, matches[i][0]
+			}
+		}
+		if best < 0 {
+			break
+		}
+		rule := h.Rules[best]
+		start, end := loc[0], loc[1]
+		if rule.End != nil {
+			stop := rule.End.FindStringIndex(line[end:])
+			if stop == nil {
+				return appendHighlightRange(ranges, start, len(line), rule), best + 1
+			}
+			end += stop[1]
+		} else if g := rule.Group; g > 0 && 2*g+1 < len(loc) {
+			if loc[2*g] >= 0 {
+				ranges = appendHighlightRange(ranges, loc[2*g], loc[2*g+1], rule)
+			}
+			pos = end
+			continue
+		}
+		ranges = appendHighlightRange(ranges, start, end, rule)
+		pos = end
+	}
+	return ranges, nil
+}
+
+// highlightMatchesFrom returns the matches of re in line from pos on.
+func highlightMatchesFrom(re *regexp.Regexp, line string, pos int) [][]int {
+	matches := re.FindAllStringSubmatchIndex(line[pos:], -1)
+	for _, loc := range matches {
+		for j := range loc {
+			if loc[j] >= 0 {
+				loc[j] += pos
+			}
+		}
+	}
+	return matches
+}
+
+// highlightAnchored reports whether re matches at the start of a line, which
+// matching the rest of one would wrongly allow.
+func highlightAnchored(re *regexp.Regexp) bool {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return true
+	}
+	var anchored func(*syntax.Regexp) bool
+	anchored = func(r *syntax.Regexp) bool {
+		if r.Op == syntax.OpBeginLine || r.Op == syntax.OpBeginText {
+			return true
+		}
+		for _, sub := range r.Sub {
+			if anchored(sub) {
+				return true
+			}
+		}
+		return false
+	}
+	return anchored(parsed)
+}
+
+func appendHighlightRange(ranges []HighlightRange, start, end int, rule HighlightRule) []HighlightRange {
+	if end <= start {
+		return ranges
+	}
+	return append(ranges, HighlightRange{Start: start, End: end, Token: rule.Token, Style: rule.Style})
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	highlightDoubleQuoted = `"(?:[^"\\]|\\.)*"`
+	highlightSingleQuoted = `'[^']*'`
+	highlightNumber       = `-?\b(?:0[xX][0-9a-fA-F_]+|\d[\d_]*(?:\.\d+)?(?:[eE][+-]?\d+)?)\b`
+)
+
+// SyntaxHighlighter returns a highlighter for a few common configuration and
+// scripting languages: json, yaml, toml, ini, sh and go. Names are matched
+// without case, and yml, bash, shell and golang are accepted as well. It
+// returns nil for other languages.
+func SyntaxHighlighter(language string) *RegexpHighlighter {
+	switch strings.ToLower(language) {
+	case "json":
+		return NewRegexpHighlighter(
+			highlightRule(`(`+highlightDoubleQuoted+`)\s*:`, 1, SyntaxKey),
+			highlightRule(highlightDoubleQuoted, 0, SyntaxString),
+			highlightRule(`\b(?:true|false|null)\b`, 0, SyntaxConstant),
+			highlightRule(highlightNumber, 0, SyntaxNumber),
+			highlightRule(`[{}\[\],:]`, 0, SyntaxPunctuation),
+		)
+	case "yaml", "yml":
+		return NewRegexpHighlighter(
+			highlightRule(`^\s*#.*`, 0, SyntaxComment),
+			highlightRule(`\s(#.*)`, 1, SyntaxComment),
+			highlightRule(`^(?:---|\.\.\.)\s*$`, 0, SyntaxKeyword),
+			highlightRule(`^\s*(?:-\s+)?(`+highlightDoubleQuoted+`|`+highlightSingleQuoted+`|[^\s#'"\-][^:#]*?)\s*:(?:\s|$)`, 1, SyntaxKey),
+			highlightRule(highlightDoubleQuoted+`|`+highlightSingleQuoted, 0, SyntaxString),
+			highlightRule(`[&*][\w-]+|!!?[\w-]+`, 0, SyntaxKeyword),
+			highlightRule(`\b(?:true|false|yes|no|on|off|null)\b|~`, 0, SyntaxConstant),
+			highlightRule(highlightNumber, 0, SyntaxNumber),
+			highlightRule(`^\s*(-)\s`, 1, SyntaxPunctuation),
+		)
+	case "toml":
+		return NewRegexpHighlighter(
+			highlightRule(`#.*`, 0, SyntaxComment),
+			highlightRule(`^\s*(\[\[?[^\]]*\]\]?)`, 1, SyntaxKeyword),
+			highlightRule(`^\s*([\w.\-]+|`+highlightDoubleQuoted+`)\s*=`, 1, SyntaxKey),
+			highlightRegion(`"""`, `"""`, SyntaxString),
+			highlightRegion(`'''`, `'''`, SyntaxString),
+			highlightRule(highlightDoubleQuoted+`|`+highlightSingleQuoted, 0, SyntaxString),
+			highlightRule(`\b(?:true|false|inf|nan)\b`, 0, SyntaxConstant),
+			highlightRule(`\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})?)?`, 0, SyntaxConstant),
+			highlightRule(highlightNumber, 0, SyntaxNumber),
+		)
+	case "ini":
+		return NewRegexpHighlighter(
+			highlightRule(`^\s*[;#].*`, 0, SyntaxComment),
+			highlightRule(`^\s*\[[^\]]*\]`, 0, SyntaxKeyword),
+			highlightRule(`^\s*([^=:\s][^=:]*?)\s*[=:]`, 1, SyntaxKey),
+			highlightRule(highlightDoubleQuoted, 0, SyntaxString),
+		)
+	case "sh", "bash", "shell":
+		return NewRegexpHighlighter(
+			highlightRule(`^\s*#.*`, 0, SyntaxComment),
+			highlightRule(`\s(#.*)`, 1, SyntaxComment),
+			highlightRule(highlightDoubleQuoted+`|`+highlightSingleQuoted, 0, SyntaxString),
+			highlightRule(`\$\{[^}]*\}|\$[\w@*#?$!-]\w*`, 0, SyntaxConstant),
+			highlightRule(`\b(?:if|then|else|elif|fi|for|while|until|do|done|case|esac|in|function|return|export|local|readonly)\b`, 0, SyntaxKeyword),
+			highlightRule(highlightNumber, 0, SyntaxNumber),
+		)
+	case "go", "golang":
+		return NewRegexpHighlighter(
+			highlightRule(`//.*`, 0, SyntaxComment),
+			highlightRegion(`/\*`, `\*/`, SyntaxComment),
+			highlightRegion("`", "`", SyntaxString),
+			highlightRule(highlightDoubleQuoted+`|'(?:[^'\\]|\\.)*'`, 0, SyntaxString),
+			highlightRule(`\b(?:break|case|chan|const|continue|default|defer|else|fallthrough|for|func|go|goto|if|import|interface|map|package|range|return|select|struct|switch|type|var)\b`, 0, SyntaxKeyword),
+			highlightRule(`\b(?:true|false|nil|iota)\b`, 0, SyntaxConstant),
+			highlightRule(highlightNumber, 0, SyntaxNumber),
+		)
+	}
+	return nil
+}
+
+func highlightRule(pattern string, group int, token SyntaxToken) HighlightRule {
+	return HighlightRule{Pattern: regexp.MustCompile(pattern), Group: group, Token: token}
+}
+
+func highlightRegion(start, end string, token SyntaxToken) HighlightRule {
+	return HighlightRule{Pattern: regexp.MustCompile(start), End: regexp.MustCompile(end), Token: token}
+}
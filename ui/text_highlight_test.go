@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// countingHighlighter marks lines starting with "/*" as opening a comment that
+// "*/" closes, and counts the lines it highlights.
+type countingHighlighter struct {
+	lines []string
+}
+
+func (h *countingHighlighter) HighlightLine(line string, state any) ([]HighlightRange, any) {
+	h.lines = append(h.lines, line)
+	open := state == true
+	switch {
+	case strings.HasPrefix(line, "/*"):
+		open = true
+	case strings.HasSuffix(line, "*/"):
+		return []HighlightRange{{Start: 0, End: len(line), Token: SyntaxComment}}, false
+	}
+	if open {
+		return []HighlightRange{{Start: 0, End: len(line), Token: SyntaxComment}}, true
+	}
+	return nil, false
+}
+
+func TestTextHighlightCacheInvalidatesEditedLines(t *testing.T) {
+	h := &countingHighlighter{}
+	var area textAreaHighlight
+	highlight := func(last int) {
+		area.cache.highlight(last, func(i int) string { return area.lines[i] })
+	}
+	area.update(h, "a\nb\nc\nd\ne")
+	highlight(2)
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(h.lines, want) {
+		t.Fatalf("highlighted %q, want only the lines up to the last visible one %q", h.lines, want)
+	}
+
+	h.lines = nil
+	highlight(4)
+	if want := []string{"d", "e"}; !reflect.DeepEqual(h.lines, want) {
+		t.Fatalf("highlighted %q, want only the new lines %q", h.lines, want)
+	}
+
+	h.lines = nil
+	area.update(h, "a\nB\nc\nd\ne")
+	highlight(4)
+	if want := []string{"B"}; !reflect.DeepEqual(h.lines, want) {
+		t.Fatalf("highlighted %q after editing a line, want %q", h.lines, want)
+	}
+
+	// Opening a comment changes the state of the lines after it
+	h.lines = nil
+	area.update(h, "a\n/*\nc\n*/\ne")
+	highlight(4)
+	if want := []string{"/*", "c", "*/"}; !reflect.DeepEqual(h.lines, want) {
+		t.Fatalf("highlighted %q after opening a comment, want %q", h.lines, want)
+	}
+	if got := area.style(len("a\n/*\n"), SyntaxTheme{Comment: Style{Attribute: AttrItalic}}); got.Attribute != AttrItalic {
+		t.Fatalf("style inside the comment = %#v", got)
+	}
+
+	// Inserting lines keeps the entries of the lines after them
+	h.lines = nil
+	area.update(h, "a\n/*\nx\ny\nc\n*/\ne")
+	highlight(6)
+	if want := []string{"x", "y"}; !reflect.DeepEqual(h.lines, want) {
+		t.Fatalf("highlighted %q after inserting lines, want %q", h.lines, want)
+	}
+}
+
+func TestRegexpHighlighterRules(t *testing.T) {
+	type token struct {
+		text  string
+		token SyntaxToken
+	}
+	highlight := func(h TextHighlighter, lines ...string) [][]token {
+		var state any
+		out := make([][]token, len(lines))
+		for i, line := range lines {
+			var ranges []HighlightRange
+			ranges, state = h.HighlightLine(line, state)
+			for _, r := range ranges {
+				out[i] = append(out[i], token{line[r.Start:r.End], r.Token})
+			}
+		}
+		return out
+	}
+
+	got := highlight(SyntaxHighlighter("json"), `{"port": 8080, "name": "a:b", "on": true}`)
+	want := [][]token{{
+		{"{", SyntaxPunctuation}, {`"port"`, SyntaxKey}, {"8080", SyntaxNumber}, {",", SyntaxPunctuation},
+		{`"name"`, SyntaxKey}, {`"a:b"`, SyntaxString}, {",", SyntaxPunctuation},
+		{`"on"`, SyntaxKey}, {"true", SyntaxConstant}, {"}", SyntaxPunctuation},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("json = %v, want %v", got, want)
+	}
+
+	got = highlight(SyntaxHighlighter("Go"), `x := 1 /* a`, `b */ return "s"`)
+	want = [][]token{
+		{{"1", SyntaxNumber}, {"/* a", SyntaxComment}},
+		{{"b */", SyntaxComment}, {"return", SyntaxKeyword}, {`"s"`, SyntaxString}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("go = %v, want %v", got, want)
+	}
+
+	got = highlight(SyntaxHighlighter("yaml"), `- name: "x # y" # note`)
+	want = [][]token{{{"name", SyntaxKey}, {`"x # y"`, SyntaxString}, {"# note", SyntaxComment}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("yaml = %v, want %v", got, want)
+	}
+
+	if SyntaxHighlighter("cobol") != nil {
+		t.Fatal("unknown language has a highlighter")
+	}
+}
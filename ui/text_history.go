@@ -0,0 +1,236 @@
+package ui
+
+import "time"
+
+const (
+	// defaultTextHistoryLimit is the number of undo steps a TextBuffer keeps
+	// unless SetHistoryLimit changes it.
+	defaultTextHistoryLimit = 100
+	// textHistoryCoalesceInterval is the longest pause between keystrokes of
+	// one typing burst.
+	textHistoryCoalesceInterval = time.Second
+)
+
+// textEditKind classifies an edit for coalescing into the previous undo step.
+type textEditKind int
+
+const (
+	// textEditOther edits, such as pastes, line breaks and word deletions,
+	// are undone on their own.
+	textEditOther textEditKind = iota
+	textEditTyping
+	textEditDeleteBackward
+	textEditDeleteForward
+)
+
+// textEdit replaces removed at start with inserted.
+type textEdit struct {
+	start    int
+	removed  []Character
+	inserted []Character
+}
+
+// textEditGroup is one undo step: the edits it made in order, and the cursor
+// and selection either side of them.
+type textEditGroup struct {
+	edits        []textEdit
+	kind         textEditKind
+	at           time.Time
+	beforeAnchor int
+	beforeCursor int
+	afterAnchor  int
+	afterCursor  int
+}
+
+// textHistory records the undo and redo steps of a TextBuffer. The zero value
+// keeps defaultTextHistoryLimit steps.
+//
+// Like the text, the history is shared by copies of a TextBuffer: steps and
+// the slices holding them are never changed once recorded. Adding or joining
+// a step copies what it changes, so copies of a buffer keep their own history.
+type textHistory struct {
+	undo     []textEditGroup
+	redo     []textEditGroup
+	limit    int
+	disabled bool
+	// sealed stops the next edit joining the last step, as after the cursor
+	// moves.
+	sealed bool
+	now    func() time.Time
+}
+
+func (h *textHistory) seal() {
+	h.sealed = true
+}
+
+func (h *textHistory) clear() {
+	h.undo, h.redo = nil, nil
+}
+
+func (h *textHistory) setLimit(limit int) {
+	h.disabled = limit < 0
+	h.limit = max(0, limit)
+	if h.disabled {
+		h.clear()
+		return
+	}
+	h.trim()
+}
+
+func (h *textHistory) trim() {
+	limit := h.limit
+	if limit == 0 {
+		limit = defaultTextHistoryLimit
+	}
+	if extra := len(h.undo) - limit; extra > 0 {
+		h.undo = append([]textEditGroup(nil), h.undo[extra:]...)
+	}
+}
+
+// record adds edit as a new undo step, or joins it to the last step when it
+// continues the same typing or deleting burst.
+func (h *textHistory) record(edit textEdit, kind textEditKind, before, after [2]int) {
+	if h.disabled {
+		return
+	}
+	now := time.Now()
+	if h.now != nil {
+		now = h.now()
+	}
+	h.redo = nil
+	if n := len(h.undo); n > 0 && !h.sealed {
+		last := h.undo[n-1]
+		if last.kind == kind && kind != textEditOther && now.Sub(last.at) <= textHistoryCoalesceInterval &&
+			last.afterAnchor == before[0] && last.afterCursor == before[1] && last.join(edit) {
+			last.at = now
+			last.afterAnchor, last.afterCursor = after[0], after[1]
+			h.undo = pushTextEditGroup(h.undo[:n-1], last)
+			return
+		}
+	}
+	h.sealed = false
+	h.undo = pushTextEditGroup(h.undo, textEditGroup{
+		edits:        []textEdit{edit},
+		kind:         kind,
+		at:           now,
+		beforeAnchor: before[0],
+		beforeCursor: before[1],
+		afterAnchor:  after[0],
+		afterCursor:  after[1],
+	})
+	h.trim()
+}
+
+// pushTextEditGroup returns stack with g added, without writing to the array
+// backing stack, which copies of the history may share.
+func pushTextEditGroup(stack []textEditGroup, g textEditGroup) []textEditGroup {
+	return append(stack[:len(stack):len(stack)], g)
+}
+
+// join merges edit into the last edit of the group when it continues it. A
+// burst ends where a new word starts, so each word is undone on its own. The
+// group gets its own edits, leaving the ones it was copied from unchanged.
+func (g *textEditGroup) join(edit textEdit) bool {
+	n := len(g.edits)
+	joined := g.edits[n-1]
+	last := &joined
+	switch g.kind {
+	case textEditTyping:
+		if edit.start != last.start+len(last.inserted) || len(edit.removed) > 0 || len(last.inserted) == 0 {
+			return false
+		}
+		if textWordStarts(last.inserted[len(last.inserted)-1], edit.inserted[0]) {
+			return false
+		}
+		last.inserted = append(last.inserted[:len(last.inserted):len(last.inserted)], edit.inserted...)
+	case textEditDeleteBackward:
+		if edit.start+len(edit.removed) != last.start || len(last.removed) == 0 {
+			return false
+		}
+		if textWordStarts(last.removed[0], edit.removed[len(edit.removed)-1]) {
+			return false
+		}
+		last.start = edit.start
+		last.removed = append(append([]Character(nil), edit.removed...), last.removed...)
+	case textEditDeleteForward:
+		if edit.start != last.start || len(last.removed) == 0 {
+			return false
+		}
+		if textWordStarts(last.removed[len(last.removed)-1], edit.removed[0]) {
+			return false
+		}
+		last.removed = append(last.removed[:len(last.removed):len(last.removed)], edit.removed...)
+	default:
+		return false
+	}
+	g.edits = append(g.edits[:n-1:n-1], joined)
+	return true
+}
+
+// textWordStarts reports whether next, edited after prev, starts a new word.
+func textWordStarts(prev, next Character) bool {
+	return textBufferKind(prev) == textBufferSpace && textBufferKind(next) != textBufferSpace
+}
+
+// CanUndo reports whether there is an edit to undo.
+func (b TextBuffer) CanUndo() bool {
+	return len(b.history.undo) > 0
+}
+
+// CanRedo reports whether there is an undone edit to redo.
+func (b TextBuffer) CanRedo() bool {
+	return len(b.history.redo) > 0
+}
+
+// Undo reverts the last undo step and restores the cursor and selection from
+// before it. Typing and deleting bursts are undone a word at a time.
+func (b *TextBuffer) Undo() bool {
+	n := len(b.history.undo)
+	if n == 0 {
+		return false
+	}
+	g := b.history.undo[n-1]
+	b.history.undo = b.history.undo[:n-1]
+	for i := len(g.edits) - 1; i >= 0; i-- {
+		edit := g.edits[i]
+		b.splice(edit.start, edit.start+len(edit.inserted), edit.removed)
+	}
+	b.anchor = clampInt(g.beforeAnchor, 0, b.text.Len())
+	b.cursor = clampInt(g.beforeCursor, 0, b.text.Len())
+	b.clearPreferredColumn()
+	b.history.redo = pushTextEditGroup(b.history.redo, g)
+	b.history.seal()
+	return true
+}
+
+// Redo reapplies the last undone step and restores the cursor and selection
+// from after it.
+func (b *TextBuffer) Redo() bool {
+	n := len(b.history.redo)
+	if n == 0 {
+		return false
+	}
+	g := b.history.redo[n-1]
+	b.history.redo = b.history.redo[:n-1]
+	for _, edit := range g.edits {
+		b.splice(edit.start, edit.start+len(edit.removed), edit.inserted)
+	}
+	b.anchor = clampInt(g.afterAnchor, 0, b.text.Len())
+	b.cursor = clampInt(g.afterCursor, 0, b.text.Len())
+	b.clearPreferredColumn()
+	b.history.undo = pushTextEditGroup(b.history.undo, g)
+	b.history.seal()
+	return true
+}
+
+// ClearHistory forgets every undo and redo step.
+func (b *TextBuffer) ClearHistory() {
+	b.history.clear()
+}
+
+// SetHistoryLimit sets the number of undo steps kept, dropping the oldest
+// beyond it. Zero restores the default of 100 and a negative limit turns the
+// history off.
+func (b *TextBuffer) SetHistoryLimit(limit int) {
+	b.history.setLimit(limit)
+}
@@ -85,6 +85,8 @@ type textLayoutPaintOptions struct {
 	ScrollCol      int
 	Selection      TextSelection
 	SelectionStyle Style
+	// CellStyle, when set, returns a style merged over each cell's own.
+	CellStyle func(TextCell) Style
 }
 
 func textLayoutSpanRect(layout TextLayout, spanIndex int) (Rect, bool) {
@@ -425,6 +427,9 @@ func paintVisibleTextLayout(p *Painter, off Offset, layout TextLayout, opts text
 		x := line.Offset - opts.ScrollCol
 		for _, cell := range line.Cells {
 			style := cell.Style
+			if opts.CellStyle != nil {
+				style = mergeStyle(style, opts.CellStyle(cell))
+			}
 			if opts.Selection.IntersectsCell(cell) {
 				style = mergeStyle(style, opts.SelectionStyle)
 			}
@@ -0,0 +1,381 @@
+package ui
+
+import (
+	"math/bits"
+	"strings"
+	"unicode/utf8"
+)
+
+// textRopeLeafSize is the most characters one rope leaf holds.
+const textRopeLeafSize = 512
+
+// textRope stores the characters of a TextBuffer in a balanced tree of
+// leaves, so edits, line lookups and offset conversions take time
+// logarithmic in the length of the text. Nodes are never changed once built:
+// an edit copies the path to the leaves it touches, and copies of a rope share
+// everything else.
+type textRope struct {
+	root *textRopeNode
+}
+
+// textRopeNode is a leaf holding chars, or a branch with both children set.
+type textRopeNode struct {
+	left    *textRopeNode
+	right   *textRopeNode
+	chars   []Character
+	metrics textRopeMetrics
+	depth   int
+}
+
+type textRopeMetrics struct {
+	chars int
+	bytes int
+	runes int
+	// lines counts line breaks.
+	lines int
+}
+
+func (m textRopeMetrics) add(other textRopeMetrics) textRopeMetrics {
+	return textRopeMetrics{
+		chars: m.chars + other.chars,
+		bytes: m.bytes + other.bytes,
+		runes: m.runes + other.runes,
+		lines: m.lines + other.lines,
+	}
+}
+
+func newTextRope(chars []Character) textRope {
+	return textRope{root: textRopeBuild(chars)}
+}
+
+// Len returns the number of characters.
+func (r textRope) Len() int {
+	if r.root == nil {
+		return 0
+	}
+	return r.root.metrics.chars
+}
+
+// String returns the text.
+func (r textRope) String() string {
+	if r.root == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.Grow(r.root.metrics.bytes)
+	textRopeEachLeaf(r.root, func(leaf *textRopeNode) {
+		for _, ch := range leaf.chars {
+			sb.WriteString(ch.Grapheme)
+		}
+	})
+	return sb.String()
+}
+
+// at returns the character at offset, which must be in range.
+func (r textRope) at(offset int) Character {
+	n := r.root
+	for n.left != nil {
+		if offset < n.left.metrics.chars {
+			n = n.left
+		} else {
+			offset -= n.left.metrics.chars
+			n = n.right
+		}
+	}
+	return n.chars[offset]
+}
+
+// slice returns a copy of the characters from start to end.
+func (r textRope) slice(start, end int) []Character {
+	start = clampInt(start, 0, r.Len())
+	end = clampInt(end, start, r.Len())
+	return textRopeAppendRange(make([]Character, 0, end-start), r.root, start, end)
+}
+
+// substring returns the text from start to end.
+func (r textRope) substring(start, end int) string {
+	var sb strings.Builder
+	for _, ch := range r.slice(start, end) {
+		sb.WriteString(ch.Grapheme)
+	}
+	return sb.String()
+}
+
+// splice returns the rope with the characters from start to end replaced by
+// insert.
+func (r textRope) splice(start, end int, insert []Character) textRope {
+	left, rest := textRopeSplit(r.root, start)
+	_, right := textRopeSplit(rest, end-start)
+	root := textRopeConcat(textRopeConcat(left, textRopeBuild(insert)), right)
+	if root != nil && root.depth > 2*bits.Len(uint(root.metrics.chars/textRopeLeafSize))+8 {
+		root = textRopeJoin(textRopeLeaves(root))
+	}
+	return textRope{root: root}
+}
+
+// lineCount returns the number of lines, which is one more than the number of
+// line breaks.
+func (r textRope) lineCount() int {
+	if r.root == nil {
+		return 1
+	}
+	return r.root.metrics.lines + 1
+}
+
+// lineOf returns the line holding offset.
+func (r textRope) lineOf(offset int) int {
+	lines := 0
+	for n := r.root; n != nil; {
+		if n.left == nil {
+			for _, ch := range n.chars[:clampInt(offset, 0, len(n.chars))] {
+				if ch.Grapheme == "\n" {
+					lines++
+				}
+			}
+			break
+		}
+		if offset < n.left.metrics.chars {
+			n = n.left
+		} else {
+			lines += n.left.metrics.lines
+			offset -= n.left.metrics.chars
+			n = n.right
+		}
+	}
+	return lines
+}
+
+// lineStart returns the offset of the first character of line, clamped to
+// the text.
+func (r textRope) lineStart(line int) int {
+	if line <= 0 || r.root == nil {
+		return 0
+	}
+	if line > r.root.metrics.lines {
+		return r.Len()
+	}
+	offset := 0
+	n := r.root
+	for n.left != nil {
+		if line <= n.left.metrics.lines {
+			n = n.left
+		} else {
+			line -= n.left.metrics.lines
+			offset += n.left.metrics.chars
+			n = n.right
+		}
+	}
+	for i, ch := range n.chars {
+		if ch.Grapheme == "\n" {
+			line--
+			if line == 0 {
+				return offset + i + 1
+			}
+		}
+	}
+	return offset + len(n.chars)
+}
+
+// lineEnd returns the offset of the line break ending line, or the end of the
+// text for the last line.
+func (r textRope) lineEnd(line int) int {
+	if line < 0 {
+		return 0
+	}
+	if line >= r.lineCount()-1 {
+		return r.Len()
+	}
+	return r.lineStart(line+1) - 1
+}
+
+// position returns offset as a text position.
+func (r textRope) position(offset int) TextPosition {
+	offset = clampInt(offset, 0, r.Len())
+	pos := TextPosition{GraphemeOffset: offset}
+	for n := r.root; n != nil; {
+		if n.left == nil {
+			for _, ch := range n.chars[:offset] {
+				pos.ByteOffset += len(ch.Grapheme)
+				pos.RuneOffset += utf8.RuneCountInString(ch.Grapheme)
+			}
+			break
+		}
+		if offset < n.left.metrics.chars {
+			n = n.left
+		} else {
+			pos.ByteOffset += n.left.metrics.bytes
+			pos.RuneOffset += n.left.metrics.runes
+			offset -= n.left.metrics.chars
+			n = n.right
+		}
+	}
+	return pos
+}
+
+// offsetForByte returns the character offset starting at byteOffset, and
+// false when byteOffset falls inside a character or outside the text.
+func (r textRope) offsetForByte(byteOffset int) (int, bool) {
+	if r.root == nil || byteOffset < 0 || byteOffset > r.root.metrics.bytes {
+		return 0, byteOffset == 0
+	}
+	if byteOffset == r.root.metrics.bytes {
+		return r.Len(), true
+	}
+	offset := 0
+	n := r.root
+	for n.left != nil {
+		if byteOffset < n.left.metrics.bytes {
+			n = n.left
+		} else {
+			byteOffset -= n.left.metrics.bytes
+			offset += n.left.metrics.chars
+			n = n.right
+		}
+	}
+	for i, ch := range n.chars {
+		if byteOffset == 0 {
+			return offset + i, true
+		}
+		byteOffset -= len(ch.Grapheme)
+		if byteOffset < 0 {
+			return 0, false
+		}
+	}
+	return offset + len(n.chars), byteOffset == 0
+}
+
+func textRopeLeaf(chars []Character) *textRopeNode {
+	n := &textRopeNode{chars: chars}
+	n.metrics.chars = len(chars)
+	for _, ch := range chars {
+		n.metrics.bytes += len(ch.Grapheme)
+		n.metrics.runes += utf8.RuneCountInString(ch.Grapheme)
+		if ch.Grapheme == "\n" {
+			n.metrics.lines++
+		}
+	}
+	return n
+}
+
+func textRopeBranch(left, right *textRopeNode) *textRopeNode {
+	return &textRopeNode{
+		left:    left,
+		right:   right,
+		metrics: left.metrics.add(right.metrics),
+		depth:   max(left.depth, right.depth) + 1,
+	}
+}
+
+// textRopeBuild returns a balanced tree of copies of chars.
+func textRopeBuild(chars []Character) *textRopeNode {
+	if len(chars) == 0 {
+		return nil
+	}
+	leaves := make([]*textRopeNode, 0, (len(chars)+textRopeLeafSize-1)/textRopeLeafSize)
+	for start := 0; start < len(chars); start += textRopeLeafSize {
+		end := min(start+textRopeLeafSize, len(chars))
+		leaves = append(leaves, textRopeLeaf(append([]Character(nil), chars[start:end]...)))
+	}
+	return textRopeJoin(leaves)
+}
+
+// textRopeJoin returns a balanced tree of leaves in order.
+func textRopeJoin(leaves []*textRopeNode) *textRopeNode {
+	switch len(leaves) {
+	case 0:
+		return nil
+	case 1:
+		return leaves[0]
+	}
+	mid := len(leaves) / 2
+	return textRopeBranch(textRopeJoin(leaves[:mid]), textRopeJoin(leaves[mid:]))
+}
+
+// textRopeLeaves returns the leaves of n, merging neighbours that fit in one.
+func textRopeLeaves(n *textRopeNode) []*textRopeNode {
+	var leaves []*textRopeNode
+	textRopeEachLeaf(n, func(leaf *textRopeNode) {
+		if last := len(leaves) - 1; last >= 0 && leaves[last].metrics.chars+leaf.metrics.chars <= textRopeLeafSize {
+			leaves[last] = textRopeMergeLeaves(leaves[last], leaf)
+			return
+		}
+		leaves = append(leaves, leaf)
+	})
+	return leaves
+}
+
+func textRopeEachLeaf(n *textRopeNode, fn func(*textRopeNode)) {
+	if n == nil {
+		return
+	}
+	if n.left == nil {
+		fn(n)
+		return
+	}
+	textRopeEachLeaf(n.left, fn)
+	textRopeEachLeaf(n.right, fn)
+}
+
+func textRopeMergeLeaves(a, b *textRopeNode) *textRopeNode {
+	chars := make([]Character, 0, len(a.chars)+len(b.chars))
+	return textRopeLeaf(append(append(chars, a.chars...), b.chars...))
+}
+
+// textRopeConcat joins a and b, merging small leaves where they meet so
+// typing doesn't grow a leaf per character.
+func textRopeConcat(a, b *textRopeNode) *textRopeNode {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.left == nil && b.left == nil && a.metrics.chars+b.metrics.chars <= textRopeLeafSize:
+		return textRopeMergeLeaves(a, b)
+	case b.left == nil && a.left != nil && a.right.left == nil && a.right.metrics.chars+b.metrics.chars <= textRopeLeafSize:
+		return textRopeBranch(a.left, textRopeMergeLeaves(a.right, b))
+	case a.left == nil && b.left != nil && b.left.left == nil && a.metrics.chars+b.left.metrics.chars <= textRopeLeafSize:
+		return textRopeBranch(textRopeMergeLeaves(a, b.left), b.right)
+	}
+	return textRopeBranch(a, b)
+}
+
+// textRopeSplit returns the first offset characters of n and the rest.
+func textRopeSplit(n *textRopeNode, offset int) (*textRopeNode, *textRopeNode) {
+	switch {
+	case n == nil:
+		return nil, nil
+	case offset <= 0:
+		return nil, n
+	case offset >= n.metrics.chars:
+		return n, nil
+	case n.left == nil:
+		return textRopeLeaf(n.chars[:offset:offset]), textRopeLeaf(n.chars[offset:])
+	}
+	leftChars := n.left.metrics.chars
+	switch {
+	case offset < leftChars:
+		a, b := textRopeSplit(n.left, offset)
+		return a, textRopeConcat(b, n.right)
+	case offset == leftChars:
+		return n.left, n.right
+	}
+	a, b := textRopeSplit(n.right, offset-leftChars)
+	return textRopeConcat(n.left, a), b
+}
+
+func textRopeAppendRange(out []Character, n *textRopeNode, start, end int) []Character {
+	if n == nil || start >= end {
+		return out
+	}
+	if n.left == nil {
+		return append(out, n.chars[max(0, start):min(end, len(n.chars))]...)
+	}
+	leftChars := n.left.metrics.chars
+	if start < leftChars {
+		out = textRopeAppendRange(out, n.left, start, min(end, leftChars))
+	}
+	if end > leftChars {
+		out = textRopeAppendRange(out, n.right, max(0, start-leftChars), end-leftChars)
+	}
+	return out
+}
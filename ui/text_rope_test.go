@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestTextRopeMatchesSpliceModel(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	pieces := []string{"a", "bc", "\n", "é", "👍", "\t", "line\n", strings.Repeat("x", 700), strings.Repeat("y\n", 300)}
+	var model []Character
+	rope := newTextRope(nil)
+	for i := 0; i < 1000; i++ {
+		start := rng.Intn(len(model) + 1)
+		end := start + rng.Intn(min(len(model)-start, 40)+1)
+		if rng.Intn(20) == 0 {
+			end = start + rng.Intn(len(model)-start+1)
+		}
+		insert := vaxisCharacters(pieces[rng.Intn(len(pieces))])
+		if rng.Intn(3) == 0 {
+			insert = nil
+		}
+		model = append(append(append([]Character(nil), model[:start]...), insert...), model[end:]...)
+		rope = rope.splice(start, end, insert)
+		if rope.Len() != len(model) {
+			t.Fatalf("step %d: Len = %d, want %d", i, rope.Len(), len(model))
+		}
+		if i%100 == 0 {
+			checkTextRope(t, rope, model)
+		}
+	}
+	checkTextRope(t, rope, model)
+}
+
+func checkTextRope(t *testing.T, rope textRope, model []Character) {
+	t.Helper()
+	text := charactersString(model)
+	if got := rope.String(); got != text {
+		t.Fatalf("String differs from the model at length %d", len(model))
+	}
+	lines := strings.Split(text, "\n")
+	if got := rope.lineCount(); got != len(lines) {
+		t.Fatalf("lineCount = %d, want %d", got, len(lines))
+	}
+	offset, bytes, runes := 0, 0, 0
+	for line, s := range lines {
+		if got := rope.lineStart(line); got != offset {
+			t.Fatalf("lineStart(%d) = %d, want %d", line, got, offset)
+		}
+		n := len(vaxisCharacters(s))
+		if got := rope.lineEnd(line); got != offset+n {
+			t.Fatalf("lineEnd(%d) = %d, want %d", line, got, offset+n)
+		}
+		offset += n + 1
+	}
+	line := 0
+	for i, ch := range model {
+		if got := rope.lineOf(i); got != line {
+			t.Fatalf("lineOf(%d) = %d, want %d", i, got, line)
+		}
+		if got := rope.at(i); got != ch {
+			t.Fatalf("at(%d) = %q, want %q", i, got.Grapheme, ch.Grapheme)
+		}
+		pos := rope.position(i)
+		if pos.ByteOffset != bytes || pos.RuneOffset != runes {
+			t.Fatalf("position(%d) = %+v, want byte %d rune %d", i, pos, bytes, runes)
+		}
+		if got, ok := rope.offsetForByte(bytes); !ok || got != i {
+			t.Fatalf("offsetForByte(%d) = %d, %v; want %d", bytes, got, ok, i)
+		}
+		if len(ch.Grapheme) > 1 {
+			if _, ok := rope.offsetForByte(bytes + 1); ok {
+				t.Fatalf("offsetForByte(%d) inside %q succeeded", bytes+1, ch.Grapheme)
+			}
+		}
+		if ch.Grapheme == "\n" {
+			line++
+		}
+		bytes += len(ch.Grapheme)
+		runes += len([]rune(ch.Grapheme))
+	}
+	if got, ok := rope.offsetForByte(bytes); !ok || got != len(model) {
+		t.Fatalf("offsetForByte(end) = %d, %v; want %d", got, ok, len(model))
+	}
+	if start, end := len(model)/3, 2*len(model)/3; rope.substring(start, end) != charactersString(model[start:end]) {
+		t.Fatalf("substring(%d, %d) differs from the model", start, end)
+	}
+}
+
+func TestTextRopeStaysShallow(t *testing.T) {
+	rope := newTextRope(nil)
+	for i := 0; i < 20000; i++ {
+		rope = rope.splice(rope.Len(), rope.Len(), []Character{{Grapheme: "x", Width: 1}})
+	}
+	if rope.root.depth > 20 {
+		t.Fatalf("depth after appending one character at a time = %d", rope.root.depth)
+	}
+	if leaves := len(textRopeLeaves(rope.root)); leaves > 2*20000/textRopeLeafSize+1 {
+		t.Fatalf("%d leaves for %d characters", leaves, rope.Len())
+	}
+}
@@ -2,7 +2,8 @@ package ui
 
 import (
 	"context"
-	"math"
+
+	"go.rockorager.dev/vaxis"
 )
 
 // ThemeMode selects how a palette is mapped to semantic UI colors.
@@ -127,6 +128,10 @@ type Theme struct {
 
 	// Border is a subtle divider/border color.
 	Border Color
+
+	// Tab overrides the styling TabBar derives from the colors above. Zero
+	// fields keep the derived defaults.
+	Tab TabTheme
 }
 
 // ThemeSet contains resolved themes for light and dark appearances.
@@ -528,79 +533,43 @@ func blendOr(a, b Color, percentB int, fallback Color) Color {
 }
 
 func blendColor(a, b Color, percentB int) (Color, bool) {
-	ap := a.Params()
-	bp := b.Params()
-	if len(ap) != 3 || len(bp) != 3 {
+	if _, _, _, ok := a.RGB(); !ok {
+		return 0, false
+	}
+	if _, _, _, ok := b.RGB(); !ok {
 		return 0, false
 	}
-	aa := rgbToOKLab(float64(ap[0])/255, float64(ap[1])/255, float64(ap[2])/255)
-	bb := rgbToOKLab(float64(bp[0])/255, float64(bp[1])/255, float64(bp[2])/255)
-	t := float64(percentB) / 100
-	return okLabToColor(okLab{
-		l: aa.l + (bb.l-aa.l)*t,
-		a: aa.a + (bb.a-aa.a)*t,
-		b: aa.b + (bb.b-aa.b)*t,
-	}), true
+	return a.Mix(b, float64(percentB)/100), true
 }
 
 func contrastRatio(a, b Color) float64 {
-	la := colorLuminance(a)
-	lb := colorLuminance(b)
-	if la < lb {
-		la, lb = lb, la
-	}
-	return (la + 0.05) / (lb + 0.05)
+	return vaxis.ContrastRatio(a, b)
 }
 
 func colorLuminance(c Color) float64 {
-	p := c.Params()
-	if len(p) != 3 {
-		return 0
-	}
-	r := linearColor(float64(p[0]) / 255)
-	g := linearColor(float64(p[1]) / 255)
-	b := linearColor(float64(p[2]) / 255)
-	return 0.2126*r + 0.7152*g + 0.0722*b
-}
-
-func linearColor(v float64) float64 {
-	if v <= 0.04045 {
-		return v / 12.92
-	}
-	return math.Pow((v+0.055)/1.055, 2.4)
-}
-
-type okLab struct {
-	l float64
-	a float64
-	b float64
-}
-
-type okLCH struct {
-	l float64
-	c float64
-	h float64
+	return c.Luminance()
 }
 
 func oklchColorScale(base, black, white Color) (ColorScale, bool) {
-	bp := base.Params()
-	blackp := black.Params()
-	whitep := white.Params()
-	if len(bp) != 3 || len(blackp) != 3 || len(whitep) != 3 {
+	bl, bc, bh, ok := base.OKLCH()
+	if !ok {
 		return ColorScale{}, false
 	}
-	b := rgbToOKLCH(float64(bp[0])/255, float64(bp[1])/255, float64(bp[2])/255)
-	blackLab := rgbToOKLab(float64(blackp[0])/255, float64(blackp[1])/255, float64(blackp[2])/255)
-	whiteLab := rgbToOKLab(float64(whitep[0])/255, float64(whitep[1])/255, float64(whitep[2])/255)
-	lo := min(blackLab.l, whiteLab.l)
-	hi := max(blackLab.l, whiteLab.l)
+	blackL, _, _, ok := black.OKLCH()
+	if !ok {
+		return ColorScale{}, false
+	}
+	whiteL, _, _, ok := white.OKLCH()
+	if !ok {
+		return ColorScale{}, false
+	}
+	lo := min(blackL, whiteL)
+	hi := max(blackL, whiteL)
 	light := func(t, chroma float64) Color {
-		l := b.l + (hi-b.l)*t
-		return okLCHToColor(okLCH{l: l, c: b.c * chroma, h: b.h})
+		return vaxis.OKLCHColor(bl+(hi-bl)*t, bc*chroma, bh)
 	}
 	dark := func(t, chroma float64) Color {
-		l := b.l + (lo-b.l)*t
-		return okLCHToColor(okLCH{l: l, c: b.c * chroma, h: b.h})
+		return vaxis.OKLCHColor(bl+(lo-bl)*t, bc*chroma, bh)
 	}
 	return ColorScale{
 		Tone50:  light(0.90, 0.25),
@@ -617,74 +586,6 @@ func oklchColorScale(base, black, white Color) (ColorScale, bool) {
 	}, true
 }
 
-func rgbToOKLCH(r, g, b float64) okLCH {
-	lab := rgbToOKLab(r, g, b)
-	return okLCH{
-		l: lab.l,
-		c: math.Hypot(lab.a, lab.b),
-		h: math.Atan2(lab.b, lab.a),
-	}
-}
-
-func rgbToOKLab(r, g, b float64) okLab {
-	r = linearColor(r)
-	g = linearColor(g)
-	b = linearColor(b)
-
-	l := 0.4122214708*r + 0.5363325363*g + 0.0514459929*b
-	m := 0.2119034982*r + 0.6806995451*g + 0.1073969566*b
-	s := 0.0883024619*r + 0.2817188376*g + 0.6299787005*b
-
-	l_ := math.Cbrt(l)
-	m_ := math.Cbrt(m)
-	s_ := math.Cbrt(s)
-
-	return okLab{
-		l: 0.2104542553*l_ + 0.7936177850*m_ - 0.0040720468*s_,
-		a: 1.9779984951*l_ - 2.4285922050*m_ + 0.4505937099*s_,
-		b: 0.0259040371*l_ + 0.7827717662*m_ - 0.8086757660*s_,
-	}
-}
-
-func okLCHToColor(c okLCH) Color {
-	return okLabToColor(okLab{
-		l: c.l,
-		a: math.Cos(c.h) * c.c,
-		b: math.Sin(c.h) * c.c,
-	})
-}
-
-func okLabToColor(c okLab) Color {
-	l_ := c.l + 0.3963377774*c.a + 0.2158037573*c.b
-	m_ := c.l - 0.1055613458*c.a - 0.0638541728*c.b
-	s_ := c.l - 0.0894841775*c.a - 1.2914855480*c.b
-
-	l := l_ * l_ * l_
-	m := m_ * m_ * m_
-	s := s_ * s_ * s_
-
-	r := 4.0767416621*l - 3.3077115913*m + 0.2309699292*s
-	g := -1.2684380046*l + 2.6097574011*m - 0.3413193965*s
-	b := -0.0041960863*l - 0.7034186147*m + 1.7076147010*s
-
-	return RGB(
-		uint8(math.Round(clampThemeFloat(gammaEncode(r), 0, 1)*255)),
-		uint8(math.Round(clampThemeFloat(gammaEncode(g), 0, 1)*255)),
-		uint8(math.Round(clampThemeFloat(gammaEncode(b), 0, 1)*255)),
-	)
-}
-
-func gammaEncode(v float64) float64 {
-	if v <= 0.0031308 {
-		return 12.92 * v
-	}
-	return 1.055*math.Pow(v, 1.0/2.4) - 0.055
-}
-
-func clampThemeFloat(v, lo, hi float64) float64 {
-	return math.Max(lo, math.Min(hi, v))
-}
-
 const (
 	defaultButtonMinWidth     = 5
 	defaultListTileGap        = 1
@@ -695,6 +596,10 @@ const (
 	defaultButtonMouseShape   = MouseShapeClickable
 	defaultListTileMouseShape = MouseShapeClickable
 	defaultSegmentMouseShape  = MouseShapeClickable
+	defaultTabMouseShape      = MouseShapeClickable
+	defaultTreeMouseShape     = MouseShapeClickable
+	defaultMenuMouseShape     = MouseShapeClickable
+	defaultDropdownMouseShape = MouseShapeClickable
 )
 
 // ButtonTheme contains derived styling and sizing defaults for Button.
@@ -729,6 +634,27 @@ type SegmentedControlTheme struct {
 	Mouse           MouseShape
 }
 
+// TabTheme contains styling for TabBar. Set Theme.Tab to override the
+// defaults derived from the semantic colors.
+type TabTheme struct {
+	// Bar fills the row behind the tabs.
+	Bar Style
+	// Normal, Hovered and Selected style a tab's label. Focused is layered
+	// over the selected tab while the bar has focus.
+	Normal   Style
+	Hovered  Style
+	Selected Style
+	Focused  Style
+	// Close and CloseHovered style the close mark of closable tabs.
+	Close        Style
+	CloseHovered Style
+	// Separator styles the divider between tabs.
+	Separator Style
+	// Overflow styles the arrows shown when tabs are scrolled out of view.
+	Overflow Style
+	Mouse    MouseShape
+}
+
 // ListTileTheme contains derived styling and sizing defaults for ListTile.
 type ListTileTheme struct {
 	Normal          Style
@@ -744,6 +670,109 @@ type ListTileTheme struct {
 	Mouse           MouseShape
 }
 
+// TreeViewTheme contains derived styling defaults for TreeView.
+type TreeViewTheme struct {
+	Normal         Style
+	Cursor         Style
+	Selected       Style
+	SelectedCursor Style
+	Guide          Style
+	Marker         Style
+	Loading        Style
+	Error          Style
+	Mouse          MouseShape
+}
+
+// MenuTheme contains derived styling defaults for MenuBar and ContextMenu.
+type MenuTheme struct {
+	Bar       Style
+	BarActive Style
+	Panel     Style
+	Border    Style
+	Active    Style
+	Disabled  Style
+	Mnemonic  Style
+	Hint      Style
+	Mouse     MouseShape
+}
+
+// TooltipTheme contains derived styling defaults for Tooltip and Popover.
+type TooltipTheme struct {
+	Tooltip       Style
+	Popover       Style
+	PopoverBorder Style
+}
+
+// DropdownTheme contains derived styling defaults for Dropdown and ComboBox.
+type DropdownTheme struct {
+	Field        Style
+	FieldFocused Style
+	FieldHovered Style
+	Placeholder  Style
+	Chip         Style
+	Panel        Style
+	Border       Style
+	Highlight    Style
+	Disabled     Style
+	Message      Style
+	Error        Style
+	Mouse        MouseShape
+}
+
+// SplitViewTheme contains derived styling defaults for SplitView dividers.
+type SplitViewTheme struct {
+	Divider Style
+	Hovered Style
+	Focused Style
+	Weight  LineWeight
+}
+
+// CodeEditorTheme contains derived styling defaults for CodeEditor.
+type CodeEditorTheme struct {
+	Text            Style
+	Gutter          Style
+	CurrentLine     Style
+	Selection       Style
+	MatchingBracket Style
+	Error           Style
+	Warning         Style
+	Info            Style
+	Panel           Style
+}
+
+// SyntaxTheme contains derived styling defaults for syntax highlighted text,
+// one style per SyntaxToken.
+type SyntaxTheme struct {
+	Keyword     Style
+	String      Style
+	Number      Style
+	Comment     Style
+	Key         Style
+	Constant    Style
+	Punctuation Style
+}
+
+// Style returns the style of token, or an empty style for SyntaxNone.
+func (t SyntaxTheme) Style(token SyntaxToken) Style {
+	switch token {
+	case SyntaxKeyword:
+		return t.Keyword
+	case SyntaxString:
+		return t.String
+	case SyntaxNumber:
+		return t.Number
+	case SyntaxComment:
+		return t.Comment
+	case SyntaxKey:
+		return t.Key
+	case SyntaxConstant:
+		return t.Constant
+	case SyntaxPunctuation:
+		return t.Punctuation
+	}
+	return Style{}
+}
+
 // TextFieldTheme contains derived styling and sizing defaults for TextField and TextArea.
 type TextFieldTheme struct {
 	Normal      Style
@@ -811,6 +840,71 @@ func segmentedControlTheme(theme Theme) SegmentedControlTheme {
 	}
 }
 
+func treeViewTheme(theme Theme) TreeViewTheme {
+	return TreeViewTheme{
+		Normal:         Style{Foreground: theme.Foreground},
+		Cursor:         Style{Foreground: theme.Foreground, Background: theme.SurfaceHovered},
+		Selected:       Style{Foreground: theme.Foreground, Background: theme.Primary},
+		SelectedCursor: Style{Foreground: theme.Foreground, Background: theme.PrimaryHovered},
+		Guide:          Style{Foreground: theme.Border},
+		Marker:         Style{Foreground: theme.MutedForeground},
+		Loading:        Style{Foreground: theme.MutedForeground, Attribute: AttrItalic},
+		Error:          Style{Foreground: theme.DangerText},
+		Mouse:          defaultTreeMouseShape,
+	}
+}
+
+func menuTheme(theme Theme) MenuTheme {
+	return MenuTheme{
+		Bar:       Style{Foreground: theme.Foreground, Background: theme.Surface},
+		BarActive: Style{Foreground: theme.Foreground, Background: theme.SurfaceHovered},
+		Panel:     Style{Foreground: theme.Foreground, Background: theme.SurfaceRaised},
+		Border:    Style{Foreground: theme.Border, Background: theme.SurfaceRaised},
+		Active:    Style{Foreground: theme.Foreground, Background: theme.Primary},
+		Disabled:  Style{Foreground: theme.DisabledForeground, Background: theme.SurfaceRaised},
+		Mnemonic:  Style{UnderlineStyle: UnderlineSingle},
+		Hint:      Style{Foreground: theme.MutedForeground},
+		Mouse:     defaultMenuMouseShape,
+	}
+}
+
+func tooltipTheme(theme Theme) TooltipTheme {
+	return TooltipTheme{
+		Tooltip:       Style{Foreground: theme.Foreground, Background: theme.SurfaceHovered},
+		Popover:       Style{Foreground: theme.Foreground, Background: theme.SurfaceRaised},
+		PopoverBorder: Style{Foreground: theme.Border, Background: theme.SurfaceRaised},
+	}
+}
+
+func tabTheme(theme Theme) TabTheme {
+	t := TabTheme{
+		Bar:          Style{Background: theme.Surface},
+		Normal:       Style{Foreground: theme.MutedForeground, Background: theme.Surface},
+		Hovered:      Style{Foreground: theme.Foreground, Background: theme.SurfaceHovered},
+		Selected:     Style{Foreground: theme.Foreground, Background: theme.Background, Attribute: AttrBold},
+		Focused:      Style{UnderlineStyle: UnderlineSingle},
+		Close:        Style{Foreground: theme.MutedForeground},
+		CloseHovered: Style{Foreground: theme.DangerText},
+		Separator:    Style{Foreground: theme.Border, Background: theme.Surface},
+		Overflow:     Style{Foreground: theme.AccentText, Background: theme.Surface},
+		Mouse:        defaultTabMouseShape,
+	}
+	o := theme.Tab
+	t.Bar = mergeStyle(t.Bar, o.Bar)
+	t.Normal = mergeStyle(t.Normal, o.Normal)
+	t.Hovered = mergeStyle(t.Hovered, o.Hovered)
+	t.Selected = mergeStyle(t.Selected, o.Selected)
+	t.Focused = mergeStyle(t.Focused, o.Focused)
+	t.Close = mergeStyle(t.Close, o.Close)
+	t.CloseHovered = mergeStyle(t.CloseHovered, o.CloseHovered)
+	t.Separator = mergeStyle(t.Separator, o.Separator)
+	t.Overflow = mergeStyle(t.Overflow, o.Overflow)
+	if o.Mouse != "" {
+		t.Mouse = o.Mouse
+	}
+	return t
+}
+
 func progressBarTheme(theme Theme) ProgressBarTheme {
 	return ProgressBarTheme{
 		Filled: Style{Foreground: theme.Accent, Background: theme.Surface},
@@ -818,6 +912,58 @@ func progressBarTheme(theme Theme) ProgressBarTheme {
 	}
 }
 
+func dropdownTheme(theme Theme) DropdownTheme {
+	return DropdownTheme{
+		Field:        Style{Foreground: theme.Foreground, Background: theme.Surface},
+		FieldFocused: Style{Foreground: theme.Foreground, Background: theme.SurfaceHovered},
+		FieldHovered: Style{Foreground: theme.Foreground, Background: theme.SurfaceHovered},
+		Placeholder:  Style{Foreground: theme.MutedForeground},
+		Chip:         Style{Foreground: theme.Foreground, Background: theme.SurfacePressed},
+		Panel:        Style{Foreground: theme.Foreground, Background: theme.SurfaceRaised},
+		Border:       Style{Foreground: theme.Border, Background: theme.SurfaceRaised},
+		Highlight:    Style{Foreground: theme.Foreground, Background: theme.Primary},
+		Disabled:     Style{Foreground: theme.DisabledForeground, Background: theme.SurfaceRaised},
+		Message:      Style{Foreground: theme.MutedForeground, Background: theme.SurfaceRaised, Attribute: AttrItalic},
+		Error:        Style{Foreground: theme.DangerText, Background: theme.SurfaceRaised},
+		Mouse:        defaultDropdownMouseShape,
+	}
+}
+
+func splitViewTheme(theme Theme) SplitViewTheme {
+	return SplitViewTheme{
+		Divider: Style{Foreground: theme.Border},
+		Hovered: Style{Foreground: theme.Foreground},
+		Focused: Style{Foreground: theme.AccentText},
+		Weight:  LineLight,
+	}
+}
+
+func codeEditorTheme(theme Theme) CodeEditorTheme {
+	return CodeEditorTheme{
+		Text:            Style{Foreground: theme.Foreground, Background: theme.Surface},
+		Gutter:          Style{Foreground: theme.MutedForeground, Background: theme.Surface},
+		CurrentLine:     Style{Foreground: theme.Foreground, Attribute: AttrBold},
+		Selection:       Style{Foreground: theme.Foreground, Background: theme.Selection},
+		MatchingBracket: Style{Attribute: AttrBold | AttrReverse},
+		Error:           Style{Foreground: theme.DangerText},
+		Warning:         Style{Foreground: theme.WarningText},
+		Info:            Style{Foreground: theme.PrimaryText},
+		Panel:           Style{Foreground: theme.Foreground, Background: theme.SurfaceRaised},
+	}
+}
+
+func syntaxTheme(theme Theme) SyntaxTheme {
+	return SyntaxTheme{
+		Keyword:     Style{Foreground: theme.AccentText, Attribute: AttrBold},
+		String:      Style{Foreground: theme.SuccessText},
+		Number:      Style{Foreground: theme.WarningText},
+		Comment:     Style{Foreground: theme.MutedForeground, Attribute: AttrItalic},
+		Key:         Style{Foreground: theme.PrimaryText},
+		Constant:    Style{Foreground: theme.DangerText},
+		Punctuation: Style{Foreground: theme.MutedForeground},
+	}
+}
+
 func textFieldTheme(theme Theme) TextFieldTheme {
 	return TextFieldTheme{
 		Normal:      Style{Foreground: theme.Foreground, Background: theme.Surface},
@@ -2,6 +2,12 @@ package ui
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -237,3 +243,61 @@ func TestStaticThemeIgnoresColorThemeUpdate(t *testing.T) {
 		t.Fatalf("foreground = %#v, want static theme foreground", got)
 	}
 }
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// TestDefaultThemeSetGolden pins every color of the default themes, so
+// changes to the color math can't silently restyle applications.
+func TestDefaultThemeSetGolden(t *testing.T) {
+	var sb strings.Builder
+	set := DefaultThemeSet()
+	writeThemeColors(&sb, "Light", reflect.ValueOf(set.Light))
+	writeThemeColors(&sb, "Dark", reflect.ValueOf(set.Dark))
+	got := sb.String()
+
+	path := filepath.Join("testdata", "default_theme_set.golden")
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if got == string(want) {
+		return
+	}
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+	for i := 0; i < max(len(gotLines), len(wantLines)); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			t.Errorf("line %d = %q, want %q", i+1, g, w)
+		}
+	}
+}
+
+// writeThemeColors writes a line for each color in v, named by its path.
+func writeThemeColors(sb *strings.Builder, path string, v reflect.Value) {
+	if v.Type() == reflect.TypeOf(Color(0)) {
+		fmt.Fprintf(sb, "%s %08x\n", path, v.Uint())
+		return
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Type().Field(i); field.IsExported() {
+			writeThemeColors(sb, path+"."+field.Name, v.Field(i))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package ui
+
+import "time"
+
+const defaultTooltipDelay = 500 * time.Millisecond
+
+// Tooltip shows a short message next to Child after the mouse has rested on
+// it for Delay, and while focus is inside Child.
+//
+// The message is shown in the nearest Overlay above the Tooltip, on Side of
+// Child, flipping to the other side when it doesn't fit. It hides when the
+// mouse leaves Child or presses it, and on Escape. Without an Overlay, or with an empty
+// Message, Child is shown alone.
+type Tooltip struct {
+	// Message is the text shown.
+	Message string
+	// Child is the widget the tooltip describes.
+	Child Widget
+	// Side is the side of Child the message is shown on. The zero value shows
+	// it below.
+	Side AnchorSide
+	// Delay is how long the mouse rests on Child before the message shows.
+	// The zero value uses a half-second delay.
+	Delay time.Duration
+}
+
+func (w Tooltip) CreateState() State {
+	return &tooltipState{}
+}
+
+type tooltipState struct {
+	StateBase
+	popup     anchoredPopup
+	delay     *AnimationController
+	hovered   bool
+	dismissed bool
+}
+
+func (s *tooltipState) InitState() {
+	s.delay = s.NewAnimation(AnimationOptions{Duration: tooltipDelay(s.Widget().(Tooltip).Delay, defaultTooltipDelay)})
+}
+
+func (s *tooltipState) DidUpdateWidget(Widget) {
+	s.delay.duration = tooltipDelay(s.Widget().(Tooltip).Delay, defaultTooltipDelay)
+}
+
+func (s *tooltipState) Dispose() {
+	s.popup.hide()
+}
+
+func (s *tooltipState) focusWithinChanged() {
+	s.dismissed = false
+	s.element.MarkNeedsBuild()
+}
+
+func (s *tooltipState) Build(ctx BuildContext) Widget {
+	w := s.Widget().(Tooltip)
+	focused := s.element.owner.app.focusedWithin(s.element)
+	hovered := s.hovered && s.delay.Status() == AnimationCompleted
+	shown := w.Message != "" && !s.dismissed && (focused || hovered)
+	if shown {
+		style := tooltipTheme(MustDepend[Theme](ctx)).Tooltip
+		s.popup.show(&s.StateBase, w.Side, DecoratedBox(
+			Decoration{Style: style},
+			Padding(Symmetric(1, 0), Text{Value: w.Message, Style: style}),
+		))
+	} else {
+		s.popup.hide()
+	}
+	return Actions{Bindings: popupDismissBindings(shown, s.dismiss), Child: w.Child}
+}
+
+func (s *tooltipState) dismiss() {
+	s.dismissed = true
+	s.MarkNeedsBuild()
+}
+
+func (s *tooltipState) HandleEvent(ctx EventContext, ev Event) EventResult {
+	switch ev := ev.(type) {
+	case hoverExit:
+		s.hovered, s.dismissed = false, false
+		s.delay.Reset()
+	case Mouse:
+		// Watch the mouse on the way down, before Child can handle it
+		if ctx.Phase() != CapturePhase && ctx.Phase() != TargetPhase {
+			return EventIgnored
+		}
+		switch {
+		case ev.EventType == EventPress:
+			s.dismissed = true
+			s.MarkNeedsBuild()
+		case !s.hovered:
+			s.hovered = true
+			s.delay.Forward()
+		}
+	}
+	return EventIgnored
+}
+
+// tooltipDelay returns delay, or fallback when delay is zero.
+func tooltipDelay(delay, fallback time.Duration) time.Duration {
+	if delay == 0 {
+		return fallback
+	}
+	return delay
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.rockorager.dev/vaxis"
+)
+
+func TestTooltipShowsAfterHoverDelay(t *testing.T) {
+	app := NewApp(Overlay{Child: Align{Alignment: TopLeft, Child: Tooltip{
+		Message: "Save file",
+		Child:   SizedBox{Width: 4, Height: 1, Child: Text{Value: "save"}},
+	}}})
+	size := Size{Width: 20, Height: 4}
+	app.Pump(size)
+
+	app.Send(Mouse{Col: 1, Row: 0, EventType: EventMotion})
+	if got := debugRenderedLines(app, size)[1]; strings.TrimSpace(got) != "" {
+		t.Fatalf("tooltip shown before the delay: %q", got)
+	}
+	app.tickAnimations(time.Now().Add(time.Second))
+	if got := debugRenderedLines(app, size)[1]; !strings.HasPrefix(got, " Save file") {
+		t.Fatalf("tooltip row = %q, want the message below the child", got)
+	}
+
+	// Pressing the child hides it until the mouse comes back
+	app.Send(Mouse{Col: 1, Row: 0, Button: MouseLeftButton, EventType: EventPress})
+	if got := debugRenderedLines(app, size)[1]; strings.TrimSpace(got) != "" {
+		t.Fatalf("tooltip shown after press: %q", got)
+	}
+	app.Send(Mouse{Col: 12, Row: 3, EventType: EventMotion})
+	app.Send(Mouse{Col: 2, Row: 0, EventType: EventMotion})
+	app.tickAnimations(time.Now().Add(time.Second))
+	if got := debugRenderedLines(app, size)[1]; !strings.Contains(got, "Save file") {
+		t.Fatalf("tooltip row = %q after hovering again", got)
+	}
+	app.Send(Mouse{Col: 12, Row: 3, EventType: EventMotion})
+	if got := debugRenderedLines(app, size)[1]; strings.TrimSpace(got) != "" {
+		t.Fatalf("tooltip shown after the mouse left: %q", got)
+	}
+}
+
+func TestTooltipShowsForFocusedChildAndFlips(t *testing.T) {
+	app := NewApp(Overlay{Child: Align{Alignment: BottomRight, Child: Row(
+		Button{Label: "a"},
+		Tooltip{Message: "Bold", Side: AnchorBelow, Child: Button{Label: "b"}},
+	)}})
+	size := Size{Width: 20, Height: 4}
+	app.Pump(size)
+	if got := strings.Join(debugRenderedLines(app, size), "\n"); strings.Contains(got, "Bold") {
+		t.Fatalf("tooltip shown without focus:\n%s", got)
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyTab})
+	lines := debugRenderedLines(app, size)
+	if !strings.HasSuffix(strings.TrimRight(lines[2], " "), "Bold") {
+		t.Fatalf("tooltip not flipped above the focused child at the bottom edge:\n%s", strings.Join(lines, "\n"))
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEsc})
+	if got := strings.Join(debugRenderedLines(app, size), "\n"); strings.Contains(got, "Bold") {
+		t.Fatalf("tooltip still shown after Escape:\n%s", got)
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyTab})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyTab})
+	if got := strings.Join(debugRenderedLines(app, size), "\n"); !strings.Contains(got, "Bold") {
+		t.Fatalf("tooltip not shown after focus came back:\n%s", got)
+	}
+}
+
+func TestPopoverStaysOpenWhileHovered(t *testing.T) {
+	pressed := 0
+	app := NewApp(Overlay{Child: Align{Alignment: TopLeft, Child: Popover{
+		Content: Button{Label: "Pin", OnPressed: func(EventContext) { pressed++ }},
+		Child:   SizedBox{Width: 4, Height: 1, Child: Text{Value: "info"}},
+	}}})
+	size := Size{Width: 20, Height: 6}
+	app.Pump(size)
+
+	app.Send(Mouse{Col: 1, Row: 0, EventType: EventMotion})
+	app.tickAnimations(time.Now().Add(time.Second))
+	lines := debugRenderedLines(app, size)
+	if !strings.HasPrefix(lines[1], "┌") || !strings.Contains(lines[2], "Pin") {
+		t.Fatalf("popover not shown below the child:\n%s", strings.Join(lines, "\n"))
+	}
+
+	// Moving onto the panel keeps it open past the close delay, and its
+	// content can be used
+	col := strings.Index(lines[2], "Pin")
+	app.Send(Mouse{Col: col, Row: 2, EventType: EventMotion})
+	app.tickAnimations(time.Now().Add(time.Second))
+	app.Send(Mouse{Col: col, Row: 2, Button: MouseLeftButton, EventType: EventPress})
+	app.Send(Mouse{Col: col, Row: 2, Button: MouseLeftButton, EventType: EventRelease})
+	if lines := debugRenderedLines(app, size); !strings.Contains(lines[2], "Pin") || pressed != 1 {
+		t.Fatalf("pressed %d times, popover:\n%s", pressed, strings.Join(lines, "\n"))
+	}
+
+	// Focus inside the panel keeps it open after the mouse leaves, until
+	// Escape
+	app.Send(Mouse{Col: 18, Row: 5, EventType: EventMotion})
+	app.tickAnimations(time.Now().Add(time.Second))
+	if lines := debugRenderedLines(app, size); !strings.Contains(lines[2], "Pin") {
+		t.Fatal("popover closed while focus was inside it")
+	}
+	app.Send(vaxis.Key{Keycode: vaxis.KeyEsc})
+	if lines := debugRenderedLines(app, size); strings.Contains(strings.Join(lines, "\n"), "Pin") {
+		t.Fatalf("popover still open after Escape:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestPopoverClosesAfterMouseLeaves(t *testing.T) {
+	app := NewApp(Overlay{Child: Align{Alignment: TopLeft, Child: Popover{
+		Content: Text{Value: "details"},
+		Child:   SizedBox{Width: 4, Height: 1, Child: Text{Value: "info"}},
+	}}})
+	size := Size{Width: 20, Height: 6}
+	app.Pump(size)
+
+	app.Send(Mouse{Col: 1, Row: 0, EventType: EventMotion})
+	app.tickAnimations(time.Now().Add(time.Second))
+	if lines := debugRenderedLines(app, size); !strings.Contains(lines[2], "details") {
+		t.Fatalf("popover not shown:\n%s", strings.Join(lines, "\n"))
+	}
+	app.Send(Mouse{Col: 18, Row: 5, EventType: EventMotion})
+	if lines := debugRenderedLines(app, size); !strings.Contains(lines[2], "details") {
+		t.Fatal("popover closed before the close delay")
+	}
+	app.tickAnimations(time.Now().Add(time.Second))
+	if lines := debugRenderedLines(app, size); strings.Contains(strings.Join(lines, "\n"), "details") {
+		t.Fatalf("popover still open:\n%s", strings.Join(lines, "\n"))
+	}
+}
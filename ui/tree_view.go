@@ -0,0 +1,841 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"go.rockorager.dev/vaxis"
+)
+
+// TreeController controls a mounted TreeView.
+//
+// Methods return false when the controller is not attached to a mounted view.
+type TreeController[T comparable] struct {
+	state *treeViewState[T]
+}
+
+// Attached reports whether the controller is attached to a mounted view.
+func (c *TreeController[T]) Attached() bool {
+	return c != nil && c.state != nil
+}
+
+// Expand expands node, loading its children if they haven't been loaded.
+func (c *TreeController[T]) Expand(node T) bool {
+	if !c.Attached() {
+		return false
+	}
+	c.state.SetState(func() { c.state.expand(node) })
+	return true
+}
+
+// Collapse collapses node.
+func (c *TreeController[T]) Collapse(node T) bool {
+	if !c.Attached() {
+		return false
+	}
+	c.state.SetState(func() { c.state.collapse(node) })
+	return true
+}
+
+// IsExpanded reports whether node is expanded.
+func (c *TreeController[T]) IsExpanded(node T) bool {
+	return c.Attached() && c.state.expanded[node]
+}
+
+// Reload drops the loaded children of node and loads them again if node is
+// expanded.
+func (c *TreeController[T]) Reload(node T) bool {
+	if !c.Attached() {
+		return false
+	}
+	c.state.SetState(func() {
+		delete(c.state.children, node)
+		if c.state.expanded[node] {
+			c.state.load(node)
+		}
+	})
+	return true
+}
+
+// Reveal expands the nodes along path, which starts at a root, then moves the
+// cursor to the last node and scrolls it into view. Children that load
+// asynchronously finish the reveal once they arrive. Reveal returns false when
+// path doesn't start at a root.
+func (c *TreeController[T]) Reveal(path ...T) bool {
+	if !c.Attached() || len(path) == 0 || !treeContains(c.state.Widget().(TreeView[T]).Roots, path[0]) {
+		return false
+	}
+	c.state.SetState(func() {
+		c.state.revealPath = path
+		c.state.continueReveal()
+	})
+	return true
+}
+
+// Cursor returns the node under the cursor.
+func (c *TreeController[T]) Cursor() (T, bool) {
+	if !c.Attached() || !c.state.hasCursor {
+		var zero T
+		return zero, false
+	}
+	return c.state.cursor, true
+}
+
+// Selected returns the selected nodes in the order they were selected.
+func (c *TreeController[T]) Selected() []T {
+	if !c.Attached() {
+		return nil
+	}
+	return append([]T(nil), c.state.selected...)
+}
+
+// SetSelected replaces the selection. A TreeView without MultiSelect keeps only
+// the last node.
+func (c *TreeController[T]) SetSelected(nodes ...T) bool {
+	if !c.Attached() {
+		return false
+	}
+	if !c.state.Widget().(TreeView[T]).MultiSelect && len(nodes) > 1 {
+		nodes = nodes[len(nodes)-1:]
+	}
+	c.state.SetState(func() { c.state.selected = append([]T(nil), nodes...) })
+	return true
+}
+
+// TreeView shows a hierarchy of nodes as an expandable, scrollable list.
+//
+// Expanded nodes are flattened into the rows of a SliverListBuilder, so only
+// the visible rows are built. Children are loaded the first time a node is
+// expanded, through Children or, asynchronously, LoadChildren; a loading row
+// shows under the node until they arrive.
+//
+// Up and Down move the cursor, Right expands the node or moves to its first
+// child, and Left collapses it or moves to its parent. Home, End, Page Up and
+// Page Down jump through the rows, and typing a label's first letters moves to
+// the next matching row. Enter activates the node. Without MultiSelect the
+// selection follows the cursor; with it, Space toggles the node under the
+// cursor, Shift extends the selection and Ctrl+click toggles a node.
+type TreeView[T comparable] struct {
+	// Controller can be used to expand, reveal and select nodes after the view is
+	// mounted.
+	Controller *TreeController[T]
+	// Roots are the top-level nodes.
+	Roots []T
+	// Label returns the text of a node. When nil, nodes are formatted with
+	// fmt.Sprint.
+	Label func(node T) string
+	// HasChildren reports whether node can be expanded. When nil, a node can be
+	// expanded until expanding it finds no children.
+	HasChildren func(node T) bool
+	// Children returns the children of node. It is called when node is first
+	// expanded, and again after TreeController.Reload.
+	Children func(node T) []T
+	// LoadChildren loads the children of node asynchronously and is used instead
+	// of Children when set. It must call done once, from any goroutine.
+	LoadChildren func(node T, done func(children []T, err error))
+	// MultiSelect allows more than one node to be selected.
+	MultiSelect bool
+	// OnActivate is called when a node is activated with Enter. When nil,
+	// activating a node toggles it.
+	OnActivate func(ctx EventContext, node T)
+	// OnSelectionChanged is called with the selected nodes when the user changes
+	// the selection.
+	OnSelectionChanged func(ctx EventContext, selected []T)
+}
+
+func (w TreeView[T]) CreateState() State {
+	return &treeViewState[T]{
+		expanded: make(map[T]bool),
+		children: make(map[T]*treeChildren[T]),
+	}
+}
+
+type treeRowKind int
+
+const (
+	treeRowNode treeRowKind = iota
+	treeRowLoading
+	treeRowError
+)
+
+// treeRow is one flattened row. Guides holds, for each ancestor below the
+// roots, whether a guide line continues past the row.
+type treeRow[T comparable] struct {
+	kind       treeRowKind
+	node       T
+	err        error
+	depth      int
+	parent     int
+	last       bool
+	guides     []bool
+	expandable bool
+	expanded   bool
+}
+
+// treeChildren are the loaded children of a node. Gen tells an outdated
+// LoadChildren result from the current one.
+type treeChildren[T comparable] struct {
+	nodes   []T
+	err     error
+	loading bool
+	gen     int
+}
+
+type treeViewState[T comparable] struct {
+	StateBase
+	node       FocusNode
+	scroll     ScrollController
+	expanded   map[T]bool
+	children   map[T]*treeChildren[T]
+	loads      int
+	rows       []treeRow[T]
+	cursor     T
+	cursorRow  int
+	hasCursor  bool
+	anchor     T
+	selected   []T
+	revealPath []T
+	reveal     bool
+	typed      typeAhead
+	disposed   bool
+}
+
+func (s *treeViewState[T]) InitState() {
+	if c := s.Widget().(TreeView[T]).Controller; c != nil {
+		c.state = s
+	}
+}
+
+func (s *treeViewState[T]) DidUpdateWidget(old Widget) {
+	prev := old.(TreeView[T]).Controller
+	next := s.Widget().(TreeView[T]).Controller
+	if prev == next {
+		return
+	}
+	if prev != nil && prev.state == s {
+		prev.state = nil
+	}
+	if next != nil {
+		next.state = s
+	}
+}
+
+func (s *treeViewState[T]) Dispose() {
+	s.disposed = true
+	if c := s.Widget().(TreeView[T]).Controller; c != nil && c.state == s {
+		c.state = nil
+	}
+}
+
+func (s *treeViewState[T]) Build(ctx BuildContext) Widget {
+	w := s.Widget().(TreeView[T])
+	s.node.onChange = s.MarkNeedsBuild
+	s.rows = s.flatten(w)
+	s.syncCursor()
+	theme := treeViewTheme(MustDepend[Theme](ctx))
+	focused := s.node.HasFocus()
+	list := SliverListBuilder{
+		Count:      len(s.rows),
+		ItemExtent: 1,
+		Builder: func(_ BuildContext, index int) Widget {
+			return s.buildRow(w, theme, focused, index)
+		},
+	}
+	return DefaultActions{
+		Bindings: map[IntentType]ActionFunc{
+			ActivateIntentType: func(ctx EventContext, intent Intent) EventResult {
+				return s.activate(ctx)
+			},
+		},
+		Child: Focus(&s.node, treeViewport{
+			onLayout: s.applyReveal,
+			Child: FocusScope{
+				SkipTraversal: true,
+				Child:         CustomScrollView{Controller: &s.scroll, Slivers: []Widget{list}},
+			},
+		}),
+	}
+}
+
+func (s *treeViewState[T]) buildRow(w TreeView[T], theme TreeViewTheme, focused bool, index int) Widget {
+	row := s.rows[index]
+	var b strings.Builder
+	for _, guide := range row.guides {
+		if guide {
+			b.WriteString("│ ")
+		} else {
+			b.WriteString("  ")
+		}
+	}
+	if row.depth > 0 {
+		if row.last {
+			b.WriteString("└─")
+		} else {
+			b.WriteString("├─")
+		}
+	}
+	r := treeRowWidget{Guides: b.String(), Theme: theme}
+	switch row.kind {
+	case treeRowLoading:
+		r.Marker, r.Label, r.LabelStyle = " ", "Loading…", theme.Loading
+		return r
+	case treeRowError:
+		r.Marker, r.Label, r.LabelStyle = "!", row.err.Error(), theme.Error
+		return r
+	}
+	switch {
+	case row.expanded:
+		r.Marker = "▾"
+	case row.expandable:
+		r.Marker = "▸"
+	case row.depth > 0:
+		r.Marker = "─"
+	default:
+		r.Marker = " "
+	}
+	r.Label = s.label(w, row.node)
+	cursor := s.hasCursor && index == s.cursorRow && focused
+	switch selected := s.isSelected(row.node); {
+	case selected && cursor:
+		r.Style = theme.SelectedCursor
+	case selected:
+		r.Style = theme.Selected
+	case cursor:
+		r.Style = theme.Cursor
+	}
+	return r
+}
+
+func (s *treeViewState[T]) flatten(w TreeView[T]) []treeRow[T] {
+	var rows []treeRow[T]
+	var walk func(nodes []T, depth, parent int, guides []bool)
+	walk = func(nodes []T, depth, parent int, guides []bool) {
+		for i, node := range nodes {
+			row := treeRow[T]{
+				node:       node,
+				depth:      depth,
+				parent:     parent,
+				last:       i == len(nodes)-1,
+				guides:     guides,
+				expandable: s.expandable(w, node),
+			}
+			row.expanded = row.expandable && s.expanded[node]
+			index := len(rows)
+			rows = append(rows, row)
+			if !row.expanded {
+				continue
+			}
+			childGuides := guides
+			if depth > 0 {
+				childGuides = append(append([]bool(nil), guides...), !row.last)
+			}
+			loaded := s.children[node]
+			switch {
+			case loaded == nil || loaded.loading:
+				rows = append(rows, treeRow[T]{kind: treeRowLoading, depth: depth + 1, parent: index, last: true, guides: childGuides})
+			case loaded.err != nil:
+				rows = append(rows, treeRow[T]{kind: treeRowError, err: loaded.err, depth: depth + 1, parent: index, last: true, guides: childGuides})
+			default:
+				walk(loaded.nodes, depth+1, index, childGuides)
+			}
+		}
+	}
+	walk(w.Roots, 0, -1, nil)
+	return rows
+}
+
+func (s *treeViewState[T]) expandable(w TreeView[T], node T) bool {
+	if loaded := s.children[node]; loaded != nil && !loaded.loading && loaded.err == nil {
+		return len(loaded.nodes) > 0
+	}
+	if w.HasChildren != nil {
+		return w.HasChildren(node)
+	}
+	return true
+}
+
+func (s *treeViewState[T]) label(w TreeView[T], node T) string {
+	if w.Label != nil {
+		return w.Label(node)
+	}
+	return fmt.Sprint(node)
+}
+
+// syncCursor finds the cursor node in the rows, keeping the cursor at the same
+// row when its node is gone.
+func (s *treeViewState[T]) syncCursor() {
+	if s.hasCursor {
+		for i, row := range s.rows {
+			if row.kind == treeRowNode && row.node == s.cursor {
+				s.cursorRow = i
+				return
+			}
+		}
+	}
+	index := s.nodeRow(clampInt(s.cursorRow, 0, len(s.rows)-1), -1)
+	if index < 0 {
+		s.hasCursor = false
+		s.cursorRow = 0
+		return
+	}
+	s.cursorRow = index
+	s.cursor = s.rows[index].node
+	s.hasCursor = true
+}
+
+// nodeRow returns the node row at or nearest from index in direction delta,
+// or -1.
+func (s *treeViewState[T]) nodeRow(index, delta int) int {
+	for i := index; i >= 0 && i < len(s.rows); i += delta {
+		if s.rows[i].kind == treeRowNode {
+			return i
+		}
+	}
+	for i := index - delta; i >= 0 && i < len(s.rows); i -= delta {
+		if s.rows[i].kind == treeRowNode {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *treeViewState[T]) expand(node T) {
+	s.expanded[node] = true
+	if s.children[node] == nil {
+		s.load(node)
+	}
+}
+
+func (s *treeViewState[T]) collapse(node T) {
+	delete(s.expanded, node)
+	// Keep the cursor visible by moving it up from a collapsed descendant
+	for i := s.cursorRow; s.hasCursor && i >= 0 && i < len(s.rows); i = s.rows[i].parent {
+		if s.rows[i].kind == treeRowNode && s.rows[i].node == node {
+			s.cursor, s.cursorRow = node, i
+			break
+		}
+	}
+}
+
+func (s *treeViewState[T]) load(node T) {
+	w := s.Widget().(TreeView[T])
+	s.loads++
+	gen := s.loads
+	if w.LoadChildren == nil {
+		var nodes []T
+		if w.Children != nil {
+			nodes = w.Children(node)
+		}
+		s.finishLoad(node, gen, nodes, nil)
+		return
+	}
+	s.children[node] = &treeChildren[T]{loading: true, gen: gen}
+	rt := s.Context().Runtime()
+	w.LoadChildren(node, func(nodes []T, err error) {
+		rt.Dispatch(func() {
+			if s.disposed {
+				return
+			}
+			if loaded := s.children[node]; loaded == nil || !loaded.loading || loaded.gen != gen {
+				return
+			}
+			s.SetState(func() {
+				s.finishLoad(node, gen, nodes, err)
+				if s.revealPath != nil {
+					s.continueReveal()
+				}
+			})
+		})
+	})
+}
+
+func (s *treeViewState[T]) finishLoad(node T, gen int, nodes []T, err error) {
+	s.children[node] = &treeChildren[T]{nodes: nodes, err: err, gen: gen}
+	if err == nil && len(nodes) == 0 {
+		delete(s.expanded, node)
+	}
+}
+
+// continueReveal expands the reveal path as far as its children are loaded.
+func (s *treeViewState[T]) continueReveal() {
+	path := s.revealPath
+	for i, node := range path[:len(path)-1] {
+		s.expand(node)
+		loaded := s.children[node]
+		if loaded.loading {
+			return
+		}
+		if loaded.err != nil || !treeContains(loaded.nodes, path[i+1]) {
+			s.revealPath = nil
+			return
+		}
+	}
+	s.revealPath = nil
+	s.cursor, s.hasCursor = path[len(path)-1], true
+	s.reveal = true
+	if !s.Widget().(TreeView[T]).MultiSelect {
+		s.selected = []T{s.cursor}
+	}
+}
+
+// applyReveal scrolls the cursor row into view after the rows are laid out.
+func (s *treeViewState[T]) applyReveal() {
+	if !s.reveal || !s.hasCursor {
+		return
+	}
+	s.reveal = false
+	metrics := s.scroll.Metrics()
+	switch {
+	case s.cursorRow < metrics.ScrollOffset:
+		s.scroll.ScrollToOffset(s.cursorRow)
+	case s.cursorRow >= metrics.ScrollOffset+metrics.ViewportHeight:
+		s.scroll.ScrollToOffset(s.cursorRow - metrics.ViewportHeight + 1)
+	}
+}
+
+func (s *treeViewState[T]) isSelected(node T) bool {
+	return treeContains(s.selected, node)
+}
+
+func (s *treeViewState[T]) MouseShape(ctx EventContext, mouse Mouse) MouseShape {
+	if s.rowAt(mouse.Row) < 0 {
+		return MouseShapeDefault
+	}
+	return treeViewTheme(MustDepend[Theme](s.Context())).Mouse
+}
+
+func (s *treeViewState[T]) HandleEvent(ctx EventContext, ev Event) EventResult {
+	if ctx.Phase() != TargetPhase && ctx.Phase() != BubblePhase {
+		return EventIgnored
+	}
+	switch ev := ev.(type) {
+	case Key:
+		if keyIsRelease(ev) || len(s.rows) == 0 {
+			return EventIgnored
+		}
+		return s.handleKey(ctx, ev)
+	case Mouse:
+		if ev.EventType != EventPress || ev.Button != MouseLeftButton {
+			return EventIgnored
+		}
+		index := s.rowAt(ev.Row)
+		if index < 0 {
+			return EventIgnored
+		}
+		s.node.RequestFocus()
+		row := s.rows[index]
+		if row.expandable && ev.Col >= row.depth*2 && ev.Col < row.depth*2+2 {
+			s.SetState(func() { s.toggle(row) })
+			return EventHandled
+		}
+		switch {
+		case ev.Modifiers&vaxis.ModShift != 0:
+			s.moveCursor(ctx, index, true)
+		case ev.Modifiers&vaxis.ModCtrl != 0:
+			s.moveCursor(ctx, index, false)
+			s.toggleSelected(ctx, row.node)
+		default:
+			s.moveCursor(ctx, index, false)
+			if s.Widget().(TreeView[T]).MultiSelect {
+				s.setSelected(ctx, []T{row.node})
+			}
+		}
+		return EventHandled
+	}
+	return EventIgnored
+}
+
+func (s *treeViewState[T]) handleKey(ctx EventContext, key Key) EventResult {
+	w := s.Widget().(TreeView[T])
+	page := max(1, s.scroll.Metrics().ViewportHeight)
+	extend := w.MultiSelect && key.Modifiers&vaxis.ModShift != 0
+	switch {
+	case key.Keycode == KeyUp:
+		return s.moveCursor(ctx, s.nodeRow(s.cursorRow-1, -1), extend)
+	case key.Keycode == KeyDown:
+		return s.moveCursor(ctx, s.nodeRow(s.cursorRow+1, 1), extend)
+	case key.Keycode == KeyPgUp:
+		return s.moveCursor(ctx, s.nodeRow(max(0, s.cursorRow-page), 1), extend)
+	case key.Keycode == KeyPgDown:
+		return s.moveCursor(ctx, s.nodeRow(min(len(s.rows)-1, s.cursorRow+page), -1), extend)
+	case key.Keycode == KeyHome:
+		return s.moveCursor(ctx, s.nodeRow(0, 1), extend)
+	case key.Keycode == KeyEnd:
+		return s.moveCursor(ctx, s.nodeRow(len(s.rows)-1, -1), extend)
+	case key.Keycode == KeyRight:
+		row := s.rows[s.cursorRow]
+		switch {
+		case row.expandable && !row.expanded:
+			s.SetState(func() { s.expand(row.node) })
+		case row.expanded && s.cursorRow+1 < len(s.rows) && s.rows[s.cursorRow+1].kind == treeRowNode:
+			return s.moveCursor(ctx, s.cursorRow+1, false)
+		}
+		return EventHandled
+	case key.Keycode == KeyLeft:
+		row := s.rows[s.cursorRow]
+		if row.expanded {
+			s.SetState(func() { s.collapse(row.node) })
+			return EventHandled
+		}
+		if row.parent >= 0 {
+			return s.moveCursor(ctx, row.parent, false)
+		}
+		return EventHandled
+	case key.MatchString("Enter"):
+		return ctx.Invoke(ActivateIntent{})
+	case key.MatchString("Space") && w.MultiSelect:
+		s.toggleSelected(ctx, s.cursor)
+		return EventHandled
+	case key.Text != "" && key.Modifiers&(vaxis.ModCtrl|vaxis.ModAlt|vaxis.ModSuper) == 0:
+		return s.typeAhead(ctx, key.Text)
+	}
+	return EventIgnored
+}
+
+func (s *treeViewState[T]) activate(ctx EventContext) EventResult {
+	if !s.hasCursor {
+		return EventIgnored
+	}
+	w := s.Widget().(TreeView[T])
+	if w.OnActivate != nil {
+		w.OnActivate(ctx, s.cursor)
+		return EventHandled
+	}
+	row := s.rows[s.cursorRow]
+	if row.expandable {
+		s.SetState(func() { s.toggle(row) })
+	}
+	return EventHandled
+}
+
+func (s *treeViewState[T]) toggle(row treeRow[T]) {
+	if row.expanded {
+		s.collapse(row.node)
+	} else {
+		s.expand(row.node)
+	}
+}
+
+// moveCursor moves the cursor to the node row at index. Without MultiSelect
+// the selection follows; with it, extend selects the rows from the anchor.
+func (s *treeViewState[T]) moveCursor(ctx EventContext, index int, extend bool) EventResult {
+	if index < 0 || index >= len(s.rows) || s.rows[index].kind != treeRowNode {
+		return EventHandled
+	}
+	w := s.Widget().(TreeView[T])
+	node := s.rows[index].node
+	s.SetState(func() {
+		s.cursor, s.cursorRow, s.hasCursor = node, index, true
+		s.reveal = true
+	})
+	switch {
+	case !w.MultiSelect:
+		s.setSelected(ctx, []T{node})
+	case extend:
+		anchor := s.nodeRow(0, 1)
+		for i, row := range s.rows {
+			if row.kind == treeRowNode && row.node == s.anchor {
+				anchor = i
+			}
+		}
+		var nodes []T
+		for i := min(anchor, index); i <= max(anchor, index); i++ {
+			if s.rows[i].kind == treeRowNode {
+				nodes = append(nodes, s.rows[i].node)
+			}
+		}
+		s.setSelected(ctx, nodes)
+		return EventHandled
+	}
+	s.anchor = node
+	return EventHandled
+}
+
+func (s *treeViewState[T]) toggleSelected(ctx EventContext, node T) {
+	selected := make([]T, 0, len(s.selected)+1)
+	found := false
+	for _, n := range s.selected {
+		if n == node {
+			found = true
+			continue
+		}
+		selected = append(selected, n)
+	}
+	if !found {
+		selected = append(selected, node)
+	}
+	if !s.Widget().(TreeView[T]).MultiSelect {
+		selected = selected[len(selected)-min(1, len(selected)):]
+	}
+	s.anchor = node
+	s.setSelected(ctx, selected)
+}
+
+func (s *treeViewState[T]) setSelected(ctx EventContext, nodes []T) {
+	if treeEqual(s.selected, nodes) {
+		return
+	}
+	s.SetState(func() { s.selected = nodes })
+	if fn := s.Widget().(TreeView[T]).OnSelectionChanged; fn != nil {
+		fn(ctx, append([]T(nil), nodes...))
+	}
+}
+
+// typeAhead moves the cursor to the next row whose label starts with the text
+// typed so far. Typing the same letter again moves on to the next match.
+func (s *treeViewState[T]) typeAhead(ctx EventContext, text string) EventResult {
+	w := s.Widget().(TreeView[T])
+	index, ok := s.typed.find(text, s.cursorRow, len(s.rows), func(i int) (string, bool) {
+		return s.label(w, s.rows[i].node), s.rows[i].kind == treeRowNode
+	})
+	switch {
+	case !ok:
+		return EventIgnored
+	case index < 0:
+		return EventHandled
+	}
+	return s.moveCursor(ctx, index, false)
+}
+
+// rowAt returns the row index under a view-local row, or -1.
+func (s *treeViewState[T]) rowAt(y int) int {
+	index := s.scroll.Metrics().ScrollOffset + y
+	if y < 0 || index >= len(s.rows) || s.rows[index].kind != treeRowNode {
+		return -1
+	}
+	return index
+}
+
+func treeContains[T comparable](nodes []T, node T) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+func treeEqual[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// treeViewport calls onLayout after laying out its child, once the scroll
+// extent matches the current rows.
+type treeViewport struct {
+	onLayout func()
+	Child    Widget
+}
+
+func (w treeViewport) WidgetChild() Widget {
+	return w.Child
+}
+
+func (w treeViewport) CreateRenderObject(BuildContext) RenderObject {
+	return &renderTreeViewport{onLayout: w.onLayout}
+}
+
+func (w treeViewport) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	ro.(*renderTreeViewport).onLayout = w.onLayout
+}
+
+type renderTreeViewport struct {
+	SingleChildRenderObject
+	onLayout func()
+}
+
+func (r *renderTreeViewport) Layout(ctx LayoutContext, c Constraints) {
+	child := r.Child()
+	if child == nil {
+		r.SetSize(c.Constrain(Size{}))
+		return
+	}
+	child.Layout(ctx, c)
+	r.SetSize(child.Base().Size())
+	if r.onLayout != nil {
+		r.onLayout()
+	}
+}
+
+func (r *renderTreeViewport) DryLayout(ctx LayoutContext, c Constraints) Size {
+	if child := r.Child(); child != nil {
+		return DryLayout(ctx, child, c)
+	}
+	return c.Constrain(Size{})
+}
+
+func (r *renderTreeViewport) Paint(p *Painter, off Offset) {
+	if child := r.Child(); child != nil {
+		child.Paint(p, off)
+	}
+}
+
+func (r *renderTreeViewport) HitTest(*HitTestResult, Point) bool {
+	return false
+}
+
+// treeRowWidget paints one row: guide lines, the expand marker and the label.
+type treeRowWidget struct {
+	Guides     string
+	Marker     string
+	Label      string
+	Style      Style
+	LabelStyle Style
+	Theme      TreeViewTheme
+}
+
+func (w treeRowWidget) CreateRenderObject(BuildContext) RenderObject {
+	return &renderTreeRow{row: w}
+}
+
+func (w treeRowWidget) UpdateRenderObject(_ BuildContext, ro RenderObject) {
+	r := ro.(*renderTreeRow)
+	if r.row != w {
+		r.row = w
+		r.MarkNeedsLayout()
+	}
+}
+
+type renderTreeRow struct {
+	LeafRenderObject
+	row treeRowWidget
+}
+
+func (r *renderTreeRow) width() int {
+	return textWidth(r.row.Guides) + textWidth(r.row.Marker) + 1 + textWidth(r.row.Label)
+}
+
+func (r *renderTreeRow) Layout(_ LayoutContext, c Constraints) {
+	r.SetSize(c.Constrain(Size{Width: r.width(), Height: 1}))
+}
+
+func (r *renderTreeRow) DryLayout(_ LayoutContext, c Constraints) Size {
+	return c.Constrain(Size{Width: r.width(), Height: 1})
+}
+
+func (r *renderTreeRow) Paint(p *Painter, off Offset) {
+	row := r.row
+	style := mergeStyle(row.Theme.Normal, row.Style)
+	size := r.Size()
+	p.Fill(Rect{X: off.X, Y: off.Y, Width: size.Width, Height: size.Height}, Cell{
+		Character: Character{Grapheme: " ", Width: 1},
+		Style:     style,
+	})
+	x := off.X
+	p.DrawText(Offset{X: x, Y: off.Y}, row.Guides, mergeStyle(style, row.Theme.Guide))
+	x += textWidth(row.Guides)
+	markerStyle := row.Theme.Marker
+	if row.Marker == "─" {
+		markerStyle = row.Theme.Guide
+	}
+	p.DrawText(Offset{X: x, Y: off.Y}, row.Marker, mergeStyle(style, markerStyle))
+	x += textWidth(row.Marker) + 1
+	p.DrawText(Offset{X: x, Y: off.Y}, row.Label, mergeStyle(style, row.LabelStyle))
+}
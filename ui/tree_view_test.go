@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.rockorager.dev/vaxis"
+)
+
+var treeViewFiles = map[string][]string{
+	"src": {"ui", "main.go"},
+	"ui":  {"tree.go", "tabs.go"},
+}
+
+func TestTreeViewExpandsWithGuides(t *testing.T) {
+	app := NewApp(TreeView[string]{
+		Roots:       []string{"src", "docs"},
+		HasChildren: func(node string) bool { return treeViewFiles[node] != nil },
+		Children:    func(node string) []string { return treeViewFiles[node] },
+	})
+	size := Size{Width: 20, Height: 6}
+	app.Pump(size)
+	for _, key := range []vaxis.Key{{Keycode: vaxis.KeyRight}, {Keycode: vaxis.KeyRight}, {Keycode: vaxis.KeyRight}} {
+		app.Send(key)
+		app.Pump(size)
+	}
+	want := []string{
+		"▾ src",
+		"├─▾ ui",
+		"│ ├── tree.go",
+		"│ └── tabs.go",
+		"└── main.go",
+		"  docs",
+	}
+	if got := debugRenderedLines(app, size); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("rendered\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Left moves to the parent, then collapses it
+	app.Send(vaxis.Key{Keycode: vaxis.KeyDown})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyLeft})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyLeft})
+	app.Pump(size)
+	if got := debugRenderedLines(app, size); got[1] != "├─▸ ui" || got[3] != "  docs" || got[4] != "" {
+		t.Fatalf("after collapsing ui: %q", got)
+	}
+}
+
+func TestTreeViewLoadsChildrenAsynchronously(t *testing.T) {
+	var pending []func([]string, error)
+	controller := &TreeController[string]{}
+	app := NewApp(TreeView[string]{
+		Controller: controller,
+		Roots:      []string{"remote", "broken"},
+		LoadChildren: func(node string, done func([]string, error)) {
+			pending = append(pending, done)
+		},
+	})
+	size := Size{Width: 20, Height: 4}
+	app.Pump(size)
+	controller.Expand("remote")
+	controller.Expand("broken")
+	app.Pump(size)
+	if got := debugRenderedLines(app, size); len(got) != 4 || !strings.Contains(got[1], "Loading…") {
+		t.Fatalf("rendered %q, want a loading row", got)
+	}
+
+	pending[0]([]string{"a", "b"}, nil)
+	pending[1](nil, errors.New("offline"))
+	pending[0]([]string{"stale"}, nil)
+	app.Pump(size)
+	got := debugRenderedLines(app, size)
+	if len(got) != 4 || got[1] != "├─▸ a" || got[2] != "└─▸ b" || !strings.Contains(got[3], "broken") {
+		t.Fatalf("rendered %q after loading", got)
+	}
+	if !controller.IsExpanded("broken") {
+		t.Fatal("failed node collapsed")
+	}
+}
+
+func TestTreeViewRevealScrollsToNode(t *testing.T) {
+	var roots []string
+	for i := 0; i < 40; i++ {
+		roots = append(roots, fmt.Sprintf("dir%02d", i))
+	}
+	controller := &TreeController[string]{}
+	app := NewApp(TreeView[string]{
+		Controller: controller,
+		Roots:      roots,
+		Children: func(node string) []string {
+			if strings.HasPrefix(node, "dir") {
+				return []string{node + "/file"}
+			}
+			return nil
+		},
+	})
+	size := Size{Width: 20, Height: 5}
+	app.Pump(size)
+	if controller.Reveal("missing") {
+		t.Fatal("revealed a path outside the roots")
+	}
+	if !controller.Reveal("dir30", "dir30/file") {
+		t.Fatal("Reveal failed")
+	}
+	app.Pump(size)
+	app.Pump(size)
+	if cursor, _ := controller.Cursor(); cursor != "dir30/file" {
+		t.Fatalf("cursor = %q", cursor)
+	}
+	if got := strings.Join(debugRenderedLines(app, size), "\n"); !strings.Contains(got, "dir30/file") {
+		t.Fatalf("revealed node isn't visible:\n%s", got)
+	}
+	if selected := controller.Selected(); len(selected) != 1 || selected[0] != "dir30/file" {
+		t.Fatalf("selected = %q", selected)
+	}
+}
+
+func TestTreeViewMultiSelectAndTypeAhead(t *testing.T) {
+	var changed []string
+	controller := &TreeController[string]{}
+	app := NewApp(TreeView[string]{
+		Controller:  controller,
+		Roots:       []string{"apple", "banana", "blueberry", "cherry"},
+		HasChildren: func(string) bool { return false },
+		MultiSelect: true,
+		OnSelectionChanged: func(ctx EventContext, selected []string) {
+			changed = selected
+		},
+	})
+	size := Size{Width: 20, Height: 4}
+	app.Pump(size)
+
+	app.Send(vaxis.Key{Text: "b", Keycode: 'b'})
+	app.Send(vaxis.Key{Text: "l", Keycode: 'l'})
+	app.Pump(size)
+	if cursor, _ := controller.Cursor(); cursor != "blueberry" {
+		t.Fatalf("type-ahead moved to %q", cursor)
+	}
+
+	app.Send(vaxis.Key{Keycode: ' ', Text: " "})
+	app.Send(vaxis.Key{Keycode: vaxis.KeyDown, Modifiers: vaxis.ModShift})
+	app.Pump(size)
+	if strings.Join(changed, ",") != "blueberry,cherry" {
+		t.Fatalf("selection after Shift+Down = %q", changed)
+	}
+	app.Send(Mouse{Col: 3, Row: 0, Button: MouseLeftButton, EventType: EventPress, Modifiers: vaxis.ModCtrl})
+	app.Pump(size)
+	if strings.Join(controller.Selected(), ",") != "blueberry,cherry,apple" {
+		t.Fatalf("selection after Ctrl+click = %q", controller.Selected())
+	}
+	app.Send(Mouse{Col: 3, Row: 1, Button: MouseLeftButton, EventType: EventPress})
+	app.Pump(size)
+	if strings.Join(changed, ",") != "banana" {
+		t.Fatalf("selection after click = %q", changed)
+	}
+}
@@ -21,6 +21,10 @@ type (
 	UnderlineStyle = vaxis.UnderlineStyle
 	// Segment aliases vaxis.Segment for convenience in ui code.
 	Segment = vaxis.Segment
+	// Lines aliases vaxis.Lines for convenience in ui code.
+	Lines = vaxis.Lines
+	// LineWeight aliases vaxis.LineWeight for convenience in ui code.
+	LineWeight = vaxis.LineWeight
 )
 
 type (
@@ -38,6 +42,8 @@ type (
 	FocusOut = vaxis.FocusOut
 	// VisibilityUpdate aliases vaxis.VisibilityUpdate.
 	VisibilityUpdate = vaxis.VisibilityUpdate
+	// BandwidthUpdate aliases vaxis.BandwidthUpdate.
+	BandwidthUpdate = vaxis.BandwidthUpdate
 	// Resize aliases vaxis.Resize.
 	Resize = vaxis.Resize
 	// Redraw aliases vaxis.Redraw.
@@ -115,6 +121,15 @@ const (
 	UnderlineDashed = vaxis.UnderlineDashed
 )
 
+const (
+	// LineNone aliases vaxis.LineNone.
+	LineNone    = vaxis.LineNone
+	LineLight   = vaxis.LineLight
+	LineHeavy   = vaxis.LineHeavy
+	LineDouble  = vaxis.LineDouble
+	LineRounded = vaxis.LineRounded
+)
+
 const (
 	// MouseShapeDefault aliases vaxis.MouseShapeDefault.
 	MouseShapeDefault          = vaxis.MouseShapeDefault
@@ -11,6 +11,7 @@ import (
 	"os/signal"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.rockorager.dev/vaxis/ansi"
@@ -65,6 +66,15 @@ type Console interface {
 	Close() error
 }
 
+// ResizeNotifier is an optional interface a [Console] can implement when it
+// is able to learn about size changes, for example from a remote session. A
+// value sent on the channel causes Vaxis to measure the console again and
+// deliver a [Resize] event if the size changed, the same as SIGWINCH does for
+// a tty
+type ResizeNotifier interface {
+	ResizeNotify() <-chan struct{}
+}
+
 // Options are the runtime options which must be supplied to a new [Vaxis]
 // object at instantiation
 type Options struct {
@@ -88,14 +98,36 @@ type Options struct {
 	// on. If the file is not a TTY, an error will be returned when calling
 	// New
 	WithTTY string
-	// NoSignals causes Vaxis to not install any signal handlers
+	// NoSignals causes Vaxis to not install any signal handlers. This
+	// also disables JobControl
 	NoSignals bool
+	// JobControl enables suspending the application with a key, the same
+	// way a job is suspended with Ctrl+Z in a cooked terminal. The terminal
+	// is restored before the process is stopped, and raw mode and the
+	// screen are restored when it is continued. Job control is not
+	// supported on Windows
+	JobControl *JobControlOptions
+	// WidthProbe enables measuring the width of graphemes which terminals
+	// commonly render with non-standard widths. The measured widths are
+	// cached per terminal and version, and are used instead of the widths
+	// Vaxis computes. See [Vaxis.ProbeWidths]
+	WidthProbe *WidthProbeOptions
+	// Bandwidth enables adapting the output to a slow link. See
+	// [BandwidthOptions]
+	Bandwidth *BandwidthOptions
 
 	// Deprecated: Vaxis now enables all supported Kitty keyboard flags.
 	CSIuBitMask CSIuBitMask
 
 	// WithConsole provides the ability to use a custom console.
 	WithConsole Console
+	// Getenv looks up the terminal's environment variables, such as TERM
+	// and COLORTERM, which are used to detect capabilities, and the VAXIS_*
+	// overrides such as VAXIS_GRAPHICS. It defaults to os.Getenv, which is
+	// wrong for a terminal that isn't the process's own, such as a remote
+	// client. VAXIS_LOG_LEVEL and VAXIS_FORCE_LEGACY_SGR affect the whole
+	// process and are always read with os.Getenv
+	Getenv func(key string) string
 
 	// EnableSGRPixels provides pixel level precision of mouse movement. This has
 	// no effect if DisableMouse is true
@@ -114,6 +146,17 @@ type PrimaryScreenOptions struct {
 	RegionHeight int
 }
 
+// JobControlOptions configures job control.
+type JobControlOptions struct {
+	// SuspendKey is the key which suspends the application. When unset,
+	// Ctrl+Z is used, and applications never see it: the undo binding of
+	// the ui text widgets needs another suspend key
+	SuspendKey rune
+	// SuspendModifiers are the modifiers which must be held with
+	// SuspendKey
+	SuspendModifiers ModifierMask
+}
+
 type CSIuBitMask int
 
 const (
@@ -133,6 +176,9 @@ type Vaxis struct {
 	tw               *writer
 	screenNext       *screen
 	screenLast       *screen
+	screenComposite  *screen
+	layers           []*Layer
+	layerSeq         uint64
 	primaryScreen    *primaryScreen
 	graphicsNext     []*placement
 	graphicsLast     []*placement
@@ -143,6 +189,10 @@ type Vaxis struct {
 	chClipboard      chan string
 	chSigWinSz       chan os.Signal
 	chSigKill        chan os.Signal
+	chSigCont        chan os.Signal
+	chJobSuspend     chan struct{}
+	jobControl       *JobControlOptions
+	jobSuspended     bool
 	chCursorPos      chan [2]int
 	chQuit           chan bool
 	winSize          Resize
@@ -152,7 +202,9 @@ type Vaxis struct {
 	graphicsProtocol int
 	graphicsIDNext   uint64
 	reqCursorPos     bool
+	widthMu          sync.Mutex // guards charCache and widthTable
 	charCache        map[string]int
+	widthTable       map[string]int
 	cursorNext       cursorState
 	cursorLast       cursorState
 	closed           bool
@@ -172,11 +224,18 @@ type Vaxis struct {
 
 	withTty     string
 	withConsole Console
+	getenv      func(key string) string
 
 	termID terminalID
 
-	renders int
-	elapsed time.Duration
+	renders     int
+	elapsed     time.Duration
+	frame       FrameStats
+	renderStats RenderStats
+	onRender    func(FrameStats)
+	bandwidth   *bandwidth
+
+	diag atomic.Pointer[startupDiagnostics]
 
 	mu sync.Mutex
 
@@ -247,6 +306,15 @@ func New(opts Options) (*Vaxis, error) {
 
 	vx.noSignals = opts.NoSignals
 
+	vx.getenv = opts.Getenv
+	if vx.getenv == nil {
+		vx.getenv = os.Getenv
+	}
+
+	if opts.Bandwidth != nil {
+		vx.bandwidth = newBandwidth(*opts.Bandwidth)
+	}
+
 	switch {
 	case opts.WithConsole != nil:
 		vx.withConsole = opts.WithConsole
@@ -260,6 +328,8 @@ func New(opts Options) (*Vaxis, error) {
 	vx.chClipboard = make(chan string)
 	vx.chSigWinSz = make(chan os.Signal, 1)
 	vx.chSigKill = make(chan os.Signal, 1)
+	vx.chSigCont = make(chan os.Signal, 1)
+	vx.chJobSuspend = make(chan struct{}, 1)
 	vx.chCursorPos = make(chan [2]int)
 	vx.chQuit = make(chan bool)
 	vx.chSizeReport = make(chan sizeReport, 8)
@@ -393,16 +463,26 @@ outer:
 		}
 	}
 
+	if d := vx.diag.Load(); d != nil {
+		d.finish()
+	}
+
 	if vx.primaryScreen == nil {
 		vx.enterAltScreen()
 	}
 	vx.enableModes()
 	if !vx.noSignals {
 		vx.setupSignals()
+		if opts.JobControl != nil {
+			vx.setupJobControl(*opts.JobControl)
+		}
 	}
 	vx.applyQuirks()
+	if opts.WidthProbe != nil {
+		vx.setupWidthTable(*opts.WidthProbe)
+	}
 
-	switch os.Getenv("VAXIS_GRAPHICS") {
+	switch vx.getenv("VAXIS_GRAPHICS") {
 	case "none":
 		vx.graphicsProtocol = noGraphics
 	case "full":
@@ -507,11 +587,13 @@ func (vx *Vaxis) Close() {
 	_ = vx.Suspend()
 	_ = vx.tty.Close()
 
-	log.Info("Renders: %d", vx.renders)
+	log.Render.Info("Renders: %d", vx.renders)
 	if vx.renders != 0 {
-		log.Info("Time/render: %s", vx.elapsed/time.Duration(vx.renders))
+		log.Render.Info("Time/render: %s", vx.elapsed/time.Duration(vx.renders))
 	}
+	vx.widthMu.Lock()
 	log.Info("Cached characters: %d", len(vx.charCache))
+	vx.widthMu.Unlock()
 }
 
 func (vx *Vaxis) surfaceSize(size Resize) (cols int, rows int) {
@@ -616,20 +698,38 @@ func (vx *Vaxis) Render() {
 	if vx.renderSuppressed() {
 		return
 	}
+	if vx.coalesceFrame() {
+		return
+	}
 	start := time.Now()
+	vx.mu.Lock()
+	vx.frame = FrameStats{
+		Refresh:    vx.refresh,
+		QueueDepth: len(vx.queue),
+	}
+	vx.mu.Unlock()
 	// defer renderBuf.Reset()
 	if vx.primaryScreen != nil {
 		vx.renderPrimary()
 	} else {
 		vx.render()
 	}
-	_, _ = vx.tw.Flush()
+	flushStart := time.Now()
+	n, _ := vx.tw.Flush()
+	flush := time.Since(flushStart)
+	// finishBandwidth may ask for a refresh of the next frame
+	vx.refresh = false
+	vx.finishBandwidth(n, flush)
 	// updating cursor state has to be after Flush, we check state change in
 	// flush.
 	vx.cursorLast = vx.cursorNext
-	vx.elapsed += time.Since(start)
-	vx.renders += 1
-	vx.refresh = false
+	vx.mu.Lock()
+	frame, onRender := vx.finishFrame(time.Since(start), flush, n)
+	vx.mu.Unlock()
+	log.Render.Trace("frame %d: %d cells, %d bytes in %s", frame.Frame, frame.CellsChanged, frame.Bytes, frame.DiffTime+frame.FlushTime)
+	if onRender != nil {
+		onRender(frame)
+	}
 }
 
 func (vx *Vaxis) renderSuppressed() bool {
@@ -648,10 +748,14 @@ func (vx *Vaxis) Refresh() {
 func (vx *Vaxis) render() {
 	vx.mu.Lock()
 	defer vx.mu.Unlock()
+	screenNext := vx.composite()
 	var (
 		reposition bool
 		cursor     Style
 	)
+	// Cheaper encodings while the link is saturated
+	saturated := vx.bandwidthSaturated()
+	useRGB := vx.caps.rgb && !saturated
 outerLast:
 	// Delete any placements we don't have this round
 	for _, p1 := range vx.graphicsLast {
@@ -670,28 +774,42 @@ outerLast:
 	if vx.refresh {
 		vx.graphicsLast = []*placement{}
 	}
+	drawn := vx.graphicsNext
+	if saturated {
+		drawn = make([]*placement, 0, len(vx.graphicsNext))
+	}
 outerNew:
 	// draw new placements
 	for _, p1 := range vx.graphicsNext {
 		for _, p2 := range vx.graphicsLast {
 			if samePlacement(p1, p2) {
 				// don't write existing placements
+				if saturated {
+					drawn = append(drawn, p1)
+				}
 				continue outerNew
 			}
 		}
+		if saturated {
+			// Held back until the link recovers
+			continue
+		}
 		vx.tw.writeCUP(p1.row+1, p1.col+1)
+		n := vx.tw.Len()
 		p1.writeTo(vx.tw)
+		vx.frame.GraphicsPlaced += 1
+		vx.frame.GraphicsBytes += vx.tw.Len() - n
 	}
 	// Save this frame as the last frame
-	vx.graphicsLast = vx.graphicsNext
+	vx.graphicsLast = drawn
 
 	if vx.mouseShapeLast != vx.mouseShapeNext {
 		_, _ = vx.tw.WriteString(tparm(mouseShape, vx.mouseShapeNext))
 		vx.mouseShapeLast = vx.mouseShapeNext
 	}
-	for row := 0; row < vx.screenNext.rows; row += 1 {
+	for row := 0; row < screenNext.rows; row += 1 {
 		reposition = true
-		nextRow := vx.screenNext.row(row)
+		nextRow := screenNext.row(row)
 		lastRow := vx.screenLast.row(row)
 		for col := 0; col < len(nextRow); col += 1 {
 			next := nextRow[col]
@@ -717,6 +835,7 @@ outerNew:
 				continue
 			}
 			lastRow[col] = next
+			vx.frame.CellsChanged += 1
 			if reposition {
 				if vx.caps.osc8 && cursor.Hyperlink != "" {
 					cursor.Hyperlink = ""
@@ -737,7 +856,7 @@ outerNew:
 			if cursor.Foreground != next.Foreground {
 				fg := next.Foreground
 				ps := fg.Params()
-				if !vx.caps.rgb {
+				if !useRGB {
 					ps = fg.asIndex().Params()
 				}
 				switch len(ps) {
@@ -760,7 +879,7 @@ outerNew:
 			if cursor.Background != next.Background {
 				bg := next.Background
 				ps := bg.Params()
-				if !vx.caps.rgb {
+				if !useRGB {
 					ps = bg.asIndex().Params()
 				}
 				switch len(ps) {
@@ -784,7 +903,7 @@ outerNew:
 				if cursor.UnderlineColor != next.UnderlineColor {
 					ul := next.UnderlineColor
 					ps := ul.Params()
-					if !vx.caps.rgb {
+					if !useRGB {
 						ps = ul.asIndex().Params()
 					}
 					switch len(ps) {
@@ -936,12 +1055,13 @@ func (vx *Vaxis) renderPrimary() {
 	if primary == nil {
 		return
 	}
-	regionRows := vx.screenNext.rows
+	screenNext := vx.composite()
+	regionRows := screenNext.rows
 	if regionRows <= 0 || vx.winSize.Rows <= 0 || vx.winSize.Cols <= 0 {
 		primary.append = nil
 		return
 	}
-	regionChanged := vx.primaryRegionChanged()
+	regionChanged := vx.primaryRegionChanged(screenNext)
 	if primary.rendered && len(primary.append) == 0 && !primary.resized && !vx.refresh && !regionChanged {
 		return
 	}
@@ -964,40 +1084,46 @@ func (vx *Vaxis) renderPrimary() {
 	primary.append = nil
 
 	paintedRegion := false
-	for row := 0; row < vx.screenNext.rows; row += 1 {
-		nextRow := vx.screenNext.row(row)
+	for row := 0; row < screenNext.rows; row += 1 {
+		nextRow := screenNext.row(row)
 		lastRow := vx.screenLast.row(row)
 		renderRow := make([]Cell, len(nextRow))
 		changed := vx.refresh || forceRegionPaint
+		cellsChanged := 0
 		for col, cell := range nextRow {
 			renderRow[col] = printablePrimaryCell(cell)
 			if renderRow[col] != printablePrimaryCell(lastRow[col]) {
 				changed = true
+				cellsChanged += 1
 			}
 		}
 		if !changed {
 			continue
 		}
+		if vx.refresh || forceRegionPaint {
+			cellsChanged = len(renderRow)
+		}
+		vx.frame.CellsChanged += cellsChanged
 		copy(lastRow, renderRow)
 		_, _ = vx.tw.WriteString(EncodeCells(trimPrimaryRenderRow(renderRow)))
-		if row < vx.screenNext.rows-1 {
+		if row < screenNext.rows-1 {
 			_, _ = vx.tw.WriteString("\r\n")
 		}
 		paintedRegion = true
 	}
 	if paintedRegion {
 		primary.rendered = true
-		primary.visualRows = vx.primaryVisualRowsForWidth(vx.screenNext.cols)
+		primary.visualRows = vx.primaryVisualRowsForWidth(screenNext.cols)
 	}
 	_, _ = vx.tw.WriteString(sgrReset)
 }
 
-func (vx *Vaxis) primaryRegionChanged() bool {
-	if vx.screenNext.rows != vx.screenLast.rows || vx.screenNext.cols != vx.screenLast.cols {
+func (vx *Vaxis) primaryRegionChanged(screenNext *screen) bool {
+	if screenNext.rows != vx.screenLast.rows || screenNext.cols != vx.screenLast.cols {
 		return true
 	}
-	for row := 0; row < vx.screenNext.rows; row += 1 {
-		nextRow := vx.screenNext.row(row)
+	for row := 0; row < screenNext.rows; row += 1 {
+		nextRow := screenNext.row(row)
 		lastRow := vx.screenLast.row(row)
 		for col, cell := range nextRow {
 			if printablePrimaryCell(cell) != printablePrimaryCell(lastRow[col]) {
@@ -1062,32 +1188,35 @@ func primaryAppendString(s string) string {
 }
 
 func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
-	log.Trace("[stdin] sequence: %s", seq)
+	log.Parser.Trace("[stdin] sequence: %s", seq)
+	if d := vx.diag.Load(); d != nil {
+		d.record(seq)
+	}
 	switch seq := seq.(type) {
 	case ansi.Print:
 		key := decodeKey(seq)
 		if vx.pastePending {
 			key.EventType = EventPaste
 		}
-		vx.PostEventBlocking(key)
+		vx.postKey(key)
 	case ansi.C0:
 		key := decodeKey(seq)
 		if vx.pastePending {
 			key.EventType = EventPaste
 		}
-		vx.PostEventBlocking(key)
+		vx.postKey(key)
 	case ansi.ESC:
 		key := decodeKey(seq)
 		if vx.pastePending {
 			key.EventType = EventPaste
 		}
-		vx.PostEventBlocking(key)
+		vx.postKey(key)
 	case ansi.SS3:
 		key := decodeKey(seq)
 		if vx.pastePending {
 			key.EventType = EventPaste
 		}
-		vx.PostEventBlocking(key)
+		vx.postKey(key)
 	case ansi.CSI:
 		intermediates := seq.Intermediates()
 		switch seq.Final {
@@ -1121,7 +1250,7 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 			vx.mu.Unlock()
 			if reqCursorPos {
 				if seq.NumParameters != 2 {
-					log.Error("not enough DSRCPR params")
+					log.Parser.Error("not enough DSRCPR params")
 					return
 				}
 				vx.chCursorPos <- [2]int{
@@ -1166,13 +1295,13 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 		case 'y':
 			// DECRPM - DEC Report Mode
 			if seq.NumParameters < 1 {
-				log.Error("not enough DECRPM params")
+				log.Parser.Error("not enough DECRPM params")
 				return
 			}
 			switch seq.Param(0) {
 			case 1016:
 				if seq.NumParameters < 2 {
-					log.Error("not enough DECRPM params")
+					log.Parser.Error("not enough DECRPM params")
 					return
 				}
 				switch seq.Param(1) {
@@ -1181,7 +1310,7 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 				}
 			case 2026:
 				if seq.NumParameters < 2 {
-					log.Error("not enough DECRPM params")
+					log.Parser.Error("not enough DECRPM params")
 					return
 				}
 				switch seq.Param(1) {
@@ -1190,7 +1319,7 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 				}
 			case 2027:
 				if seq.NumParameters < 2 {
-					log.Error("not enough DECRPM params")
+					log.Parser.Error("not enough DECRPM params")
 					return
 				}
 				switch seq.Param(1) {
@@ -1199,7 +1328,7 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 				}
 			case 2031:
 				if seq.NumParameters < 2 {
-					log.Error("not enough DECRPM params")
+					log.Parser.Error("not enough DECRPM params")
 					return
 				}
 				switch seq.Param(1) {
@@ -1208,7 +1337,7 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 				}
 			case visibilityReports:
 				if seq.NumParameters < 2 {
-					log.Error("not enough DECRPM params")
+					log.Parser.Error("not enough DECRPM params")
 					return
 				}
 				switch seq.Param(1) {
@@ -1225,7 +1354,7 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 		case '~':
 			if len(intermediates) == 0 {
 				if seq.NumParameters == 0 {
-					log.Error("[CSI] unknown sequence with final '~'")
+					log.Parser.Error("[CSI] unknown sequence with final '~'")
 					return
 				}
 				switch seq.Param(0) {
@@ -1251,7 +1380,7 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 			return
 		case 't':
 			if seq.NumParameters < 3 {
-				log.Error("[CSI] unknown sequence: %s", seq)
+				log.Parser.Error("[CSI] unknown sequence: %s", seq)
 				return
 			}
 			// CSI <type> ; <height> ; <width> t
@@ -1324,7 +1453,7 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 		if vx.pastePending {
 			key.EventType = EventPaste
 		}
-		vx.PostEventBlocking(key)
+		vx.postKey(key)
 	case ansi.DCS:
 		intermediates := seq.Intermediates()
 		switch seq.Final {
@@ -1343,7 +1472,7 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 				}
 				vals := strings.Split(string(seq.Data), "=")
 				if len(vals) != 2 {
-					log.Error("error parsing XTGETTCAP: %s", string(seq.Data))
+					log.Parser.Error("error parsing XTGETTCAP: %s", string(seq.Data))
 				}
 				switch vals[0] {
 				case hexEncode("Smulx"):
@@ -1362,10 +1491,10 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 					cursorStyle := seq.Data[0]
 					// Valid cursor styles are 0-6
 					if cursorStyle < '0' || cursorStyle > '6' {
-						log.Warn("invalid DECSCUSR: %d", cursorStyle)
+						log.Parser.Warn("invalid DECSCUSR: %d", cursorStyle)
 						return
 					}
-					log.Debug("User cursor style discovered: %v",
+					log.Parser.Debug("User cursor style discovered: %v",
 						CursorStyle(cursorStyle-0x30))
 					vx.mu.Lock()
 					vx.userCursorStyle = CursorStyle(cursorStyle - 0x30)
@@ -1423,12 +1552,12 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 		if strings.HasPrefix(string(seq.Payload), "52") {
 			vals := strings.Split(string(seq.Payload), ";")
 			if len(vals) != 3 {
-				log.Error("invalid OSC 52 payload")
+				log.Parser.Error("invalid OSC 52 payload")
 				return
 			}
 			b, err := base64.StdEncoding.DecodeString(vals[2])
 			if err != nil {
-				log.Error("couldn't decode OSC 52: %v", err)
+				log.Parser.Error("couldn't decode OSC 52: %v", err)
 				return
 			}
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
@@ -1441,7 +1570,7 @@ func (vx *Vaxis) handleSequence(seq ansi.Sequence) {
 		if strings.HasPrefix(string(seq.Payload), "176") {
 			vals := strings.Split(string(seq.Payload), ";")
 			if len(vals) != 2 {
-				log.Error("invalid OSC 176 payload")
+				log.Parser.Error("invalid OSC 176 payload")
 				return
 			}
 			vx.PostEvent(appID(vals[1]))
@@ -1506,18 +1635,13 @@ func (vx *Vaxis) QueryColorContext(ctx context.Context, c Color) Color {
 		return Color(0)
 	}
 
-	var r, g, b int
 	prefix := fmt.Sprintf("4;%v;", p[0])
-	_, err := fmt.Sscanf(resp, prefix+"rgb:%x/%x/%x", &r, &g, &b)
-	if err != nil {
-		log.Error("QueryColor: failed to parse the OSC 4 response: %s", err)
+	color, err := ParseColor(strings.TrimPrefix(resp, prefix))
+	if err != nil || !strings.HasPrefix(resp, prefix) {
+		log.Error("QueryColor: failed to parse the OSC 4 response: %q", resp)
 		return Color(0)
 	}
-	// The returned value can in principle be 16 bits per channel, however
-	// we are not aware of any terminal that would do this, foot for
-	// instance just repeats the same 8 bits twice. Hence we only take the
-	// lower 8 bits.
-	return RGBColor(uint8(r), uint8(g), uint8(b))
+	return color
 }
 
 // QueryForeground queries the host terminal for foreground color and returns
@@ -1559,14 +1683,12 @@ func (vx *Vaxis) QueryForegroundContext(ctx context.Context) Color {
 		return Color(0)
 	}
 
-	var r, g, b int
-	_, err := fmt.Sscanf(resp, "10;rgb:%x/%x/%x", &r, &g, &b)
-	if err != nil {
-		log.Error("QueryForeground: failed to parse the OSC 10 response: %s", err)
+	color, err := ParseColor(strings.TrimPrefix(resp, "10;"))
+	if err != nil || !strings.HasPrefix(resp, "10;") {
+		log.Error("QueryForeground: failed to parse the OSC 10 response: %q", resp)
 		return Color(0)
 	}
-	// Similar to QueryColor above.
-	return RGBColor(uint8(r), uint8(g), uint8(b))
+	return color
 }
 
 // QueryBackground queries the host terminal for background color and returns
@@ -1608,14 +1730,12 @@ func (vx *Vaxis) QueryBackgroundContext(ctx context.Context) Color {
 		return Color(0)
 	}
 
-	var r, g, b int
-	_, err := fmt.Sscanf(resp, "11;rgb:%x/%x/%x", &r, &g, &b)
-	if err != nil {
-		log.Error("QueryBackground: failed to parse the OSC 11 response: %s", err)
+	color, err := ParseColor(strings.TrimPrefix(resp, "11;"))
+	if err != nil || !strings.HasPrefix(resp, "11;") {
+		log.Error("QueryBackground: failed to parse the OSC 11 response: %q", resp)
 		return Color(0)
 	}
-	// Similar to QueryColor above.
-	return RGBColor(uint8(r), uint8(g), uint8(b))
+	return color
 }
 
 func drainQueryResponses(ch chan string) {
@@ -1707,30 +1827,32 @@ func (vx *Vaxis) sendQueries() {
 	vx.enterAltScreen()
 	defer vx.exitAltScreen()
 
-	switch os.Getenv("COLORTERM") {
+	vx.diag.Store(newStartupDiagnostics())
+
+	switch vx.getenv("COLORTERM") {
 	case "truecolor", "24bit":
 		vx.PostEvent(truecolor{})
 	}
 
-	_, _ = vx.tw.WriteControlString(userCursorStyle)
-	_, _ = vx.tw.WriteControlString(decrqm(synchronizedUpdate))
-	_, _ = vx.tw.WriteControlString(decrqm(unicodeCore))
-	_, _ = vx.tw.WriteControlString(decrqm(colorThemeUpdates))
-	_, _ = vx.tw.WriteControlString(decrqm(visibilityReports))
-	_, _ = vx.tw.WriteControlString(decrqm(mouseSGRPixels))
+	vx.sendQuery("DECRQSS cursor style", userCursorStyle, matchDCS('r', '$', ""))
+	vx.sendQuery("DECRQM synchronized update", decrqm(synchronizedUpdate), matchCSI('y', '?', synchronizedUpdate))
+	vx.sendQuery("DECRQM unicode core", decrqm(unicodeCore), matchCSI('y', '?', unicodeCore))
+	vx.sendQuery("DECRQM color theme updates", decrqm(colorThemeUpdates), matchCSI('y', '?', colorThemeUpdates))
+	vx.sendQuery("DECRQM visibility reports", decrqm(visibilityReports), matchCSI('y', '?', visibilityReports))
+	vx.sendQuery("DECRQM SGR pixels", decrqm(mouseSGRPixels), matchCSI('y', '?', mouseSGRPixels))
 	// We blindly enable in band resize. We get a response immediately if it
 	// is supported
-	_, _ = vx.tw.WriteControlString(decset(inBandResize))
-	_, _ = vx.tw.WriteControlString(xtversion)
-	_, _ = vx.tw.WriteControlString(kittyKBQuery)
-	_, _ = vx.tw.WriteControlString(kittyGquery)
-	_, _ = vx.tw.WriteControlString(xtsmSixelGeom)
+	vx.sendQuery("In-band resize", decset(inBandResize), matchCSI('t', 0, 48))
+	vx.sendQuery("XTVERSION", xtversion, matchDCS('|', '>', ""))
+	vx.sendQuery("Kitty keyboard", kittyKBQuery, matchCSI('u', '?'))
+	vx.sendQuery("Kitty graphics", kittyGquery, matchAPC("G"))
+	vx.sendQuery("XTSMGRAPHICS sixel geometry", xtsmSixelGeom, matchCSI('S', '?', 2))
 	// Can the terminal report its own size?
-	_, _ = vx.tw.WriteControlString(textAreaSize)
+	vx.sendQuery("Text area size", textAreaSize, matchCSI('t', 0, 4, 8))
 
 	// Explicit width query
 	vx.tw.writeControlCUP(1, 1)
-	vx.tw.writeControlExplicitWidth(1, " ")
+	vx.sendQuery("Explicit width", string(appendExplicitWidth(nil, 1, " ")), matchCSI('R', 0))
 	_, col := vx.CursorPosition()
 	if col == 1 {
 		log.Debug("[capability] explicit width supported")
@@ -1741,29 +1863,29 @@ func (vx *Vaxis) sendQueries() {
 
 	// Query some terminfo capabilities
 	// Just another way to see if we have RGB support
-	_, _ = vx.tw.WriteControlString(xtgettcap("RGB"))
+	vx.sendQuery("XTGETTCAP RGB", xtgettcap("RGB"), matchDCS('r', '+', hexEncode("RGB")))
 	// Does the terminal respond to OSC 4/10/11 queries?
 	// Use color index 8 for OSC 4 to ignore buggy implementations that only respond to 0-7.
-	_, _ = vx.tw.WriteControlString(tparm(osc4, 8))
-	_, _ = vx.tw.WriteControlString(osc10)
-	_, _ = vx.tw.WriteControlString(osc11)
+	vx.sendQuery("OSC 4 palette color", tparm(osc4, 8), matchOSC("4;"))
+	vx.sendQuery("OSC 10 foreground color", osc10, matchOSC("10;"))
+	vx.sendQuery("OSC 11 background color", osc11, matchOSC("11;"))
 	// Back up the current app ID
-	_, _ = vx.tw.WriteControlString(getAppID)
+	vx.sendQuery("OSC 176 app ID", getAppID, matchOSC("176;"))
 	// We request Smulx to check for styled underlines. Technically, Smulx
 	// only means the terminal supports different underline types (curly,
 	// dashed, etc), but we'll assume the terminal also suppports underline
 	// colors (CSI 58 : ...)
-	_, _ = vx.tw.WriteControlString(xtgettcap("Smulx"))
+	vx.sendQuery("XTGETTCAP Smulx", xtgettcap("Smulx"), matchDCS('r', '+', hexEncode("Smulx")))
 	// This Hls term cap has only be adopted officially by tmux but other
 	// terminals are following the trend. If we don't get a reply, we fall
 	// back on heuristics based on terminal ID and name.
-	_, _ = vx.tw.WriteControlString(xtgettcap("Hls"))
+	vx.sendQuery("XTGETTCAP Hls", xtgettcap("Hls"), matchDCS('r', '+', hexEncode("Hls")))
 	// Need to send tertiary for VTE based terminals. These don't respond to
 	// XTGETTCAP
-	_, _ = vx.tw.WriteControlString(tertiaryAttributes)
+	vx.sendQuery("Tertiary device attributes", tertiaryAttributes, matchDCS('|', '!', ""))
 	// Send Device Attributes is last. Everything responds, and when we get
 	// a response we'll return from init
-	_, _ = vx.tw.WriteControlString(primaryAttributes)
+	vx.sendQuery("Primary device attributes", primaryAttributes, matchCSI('c', '?'))
 }
 
 // enableModes enables all the modes we want
@@ -1941,6 +2063,11 @@ func (vx *Vaxis) openTty() error {
 	vx.tw = newWriter(vx)
 	vx.parser = ansi.NewParser(vx.tty, ansi.ParserModeInput)
 
+	var chResize <-chan struct{}
+	if rn, ok := vx.withConsole.(ResizeNotifier); ok {
+		chResize = rn.ResizeNotify()
+	}
+
 	go func() {
 		defer func() {
 			if err := recover(); err != nil {
@@ -1959,6 +2086,8 @@ func (vx *Vaxis) openTty() error {
 				}
 			case <-vx.chSigWinSz:
 				go vx.detectResize(true)
+			case <-chResize:
+				go vx.detectResize(true)
 			case <-vx.chSigKill:
 				vx.Close()
 				return
@@ -2015,12 +2144,19 @@ func (vx *Vaxis) showCursor() string {
 // Reports the current cursor position. 0,0 is the upper left corner. Reports
 // -1,-1 if the query times out or fails
 func (vx *Vaxis) CursorPosition() (row int, col int) {
+	return vx.cursorPosition(50 * time.Millisecond)
+}
+
+// cursorPosition requests the cursor position, waiting up to wait for the
+// reply
+func (vx *Vaxis) cursorPosition(wait time.Duration) (row int, col int) {
 	// DSRCPR - reports cursor position
 	vx.mu.Lock()
 	vx.reqCursorPos = true
 	vx.mu.Unlock()
 	vx.writeControlString(dsrcpr)
-	timeout := time.NewTimer(50 * time.Millisecond)
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
 	select {
 	case <-timeout.C:
 		log.Warn("CursorPosition timed out")
@@ -2146,11 +2282,16 @@ func (vx *Vaxis) RenderedWidth(s string) int {
 // there is likely to only ever be a finite set of characters in the lifetime of
 // an application
 func (vx *Vaxis) characterWidth(s string) int {
+	vx.widthMu.Lock()
+	defer vx.widthMu.Unlock()
 	w, ok := vx.charCache[s]
 	if ok {
 		return w
 	}
-	w = vx.RenderedWidth(s)
+	w, ok = vx.widthTable[s]
+	if !ok {
+		w = vx.RenderedWidth(s)
+	}
 	vx.charCache[s] = w
 	return w
 }
@@ -393,3 +393,16 @@ func TestResizeSameSizeIsIdempotent(t *testing.T) {
 		t.Fatalf("screenLast size = %dx%d, want 80x24", got, want)
 	}
 }
+
+func TestApplyQuirksReadsTerminalEnvironment(t *testing.T) {
+	env := map[string]string{
+		"ASCIINEMA_REC":        "1",
+		"VAXIS_FORCE_UNICODE":  "1",
+		"VAXIS_FORCE_XTWINOPS": "1",
+	}
+	vx := &Vaxis{getenv: func(key string) string { return env[key] }}
+	vx.applyQuirks()
+	if vx.graphicsProtocol != halfBlock || !vx.caps.unicodeCore || !vx.xtwinops {
+		t.Fatalf("quirks not applied: graphics=%d unicode=%v xtwinops=%v", vx.graphicsProtocol, vx.caps.unicodeCore, vx.xtwinops)
+	}
+}
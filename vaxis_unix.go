@@ -98,3 +98,16 @@ func (vx *Vaxis) reportWinsize() (Resize, error) {
 		YPixel: int(ws.Ypixel),
 	}, nil
 }
+
+func (vx *Vaxis) setupJobControl(opts JobControlOptions) {
+	vx.jobControl = newJobControl(opts)
+	signal.Notify(vx.chSigCont, syscall.SIGCONT)
+	go vx.runJobControl()
+}
+
+// raiseSuspend stops the process group, the same as the terminal driver does
+// when the suspend character is typed in a cooked terminal. SIGTSTP must not
+// have been passed to signal.Notify, or it will not stop the process
+func raiseSuspend() error {
+	return unix.Kill(0, unix.SIGTSTP)
+}
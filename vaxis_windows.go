@@ -72,3 +72,11 @@ func (vx *Vaxis) reportWinsize() (Resize, error) {
 	log.Trace("requesting screen size from console")
 	return vx.tty.Size()
 }
+
+func (vx *Vaxis) setupJobControl(JobControlOptions) {
+	log.Info("[job control] not supported on windows")
+}
+
+func raiseSuspend() error {
+	return fmt.Errorf("job control is not supported on windows")
+}
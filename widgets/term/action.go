@@ -760,12 +760,12 @@ func (a sixelAction) apply(vt *Model) {
 	buf.WriteString(string(a.seq.Data))
 	buf.Write([]byte{0x1B, '\\'})
 
-	log.Info("SIXEL %d", buf.Len())
+	log.Term.Info("SIXEL %d", buf.Len())
 	dec := sixel.NewDecoder(buf)
 	img := &Image{}
 	err := dec.Decode(&img.img)
 	if err != nil {
-		log.Error("couldn't decode sixel: %v", err)
+		log.Term.Error("couldn't decode sixel: %v", err)
 		return
 	}
 	vt.positionSixel(img)
@@ -1,7 +1,6 @@
 package term
 
 import (
-	"strconv"
 	"strings"
 
 	"go.rockorager.dev/vaxis"
@@ -100,12 +99,9 @@ func parseOSCColor(s string) (vaxis.Color, bool) {
 		return color, true
 	}
 	switch {
-	case strings.HasPrefix(s, "#"):
-		return parseOSCSharpColor(s[1:])
-	case strings.HasPrefix(s, "rgb:"):
-		return parseOSCRGBColor(s[4:], false)
-	case strings.HasPrefix(s, "rgbi:"):
-		return parseOSCRGBColor(s[5:], true)
+	case strings.HasPrefix(s, "#"), strings.HasPrefix(s, "rgb:"), strings.HasPrefix(s, "rgbi:"):
+		color, err := vaxis.ParseColor(s)
+		return color, err == nil
 	default:
 		return 0, false
 	}
@@ -141,75 +137,3 @@ func oscNamedColor(name string) (vaxis.Color, bool) {
 		return 0, false
 	}
 }
-
-func parseOSCSharpColor(s string) (vaxis.Color, bool) {
-	var size int
-	switch len(s) {
-	case 3:
-		size = 1
-	case 6:
-		size = 2
-	case 9:
-		size = 3
-	case 12:
-		size = 4
-	default:
-		return 0, false
-	}
-	r, ok := parseOSCHexChannel(s[:size])
-	if !ok {
-		return 0, false
-	}
-	g, ok := parseOSCHexChannel(s[size : size*2])
-	if !ok {
-		return 0, false
-	}
-	b, ok := parseOSCHexChannel(s[size*2:])
-	if !ok {
-		return 0, false
-	}
-	return vaxis.RGBColor(r, g, b), true
-}
-
-func parseOSCRGBColor(s string, intensity bool) (vaxis.Color, bool) {
-	parts := strings.Split(s, "/")
-	if len(parts) != 3 {
-		return 0, false
-	}
-	var rgb [3]uint8
-	for i, part := range parts {
-		var ok bool
-		if intensity {
-			rgb[i], ok = parseOSCIntensityChannel(part)
-		} else {
-			rgb[i], ok = parseOSCHexChannel(part)
-		}
-		if !ok {
-			return 0, false
-		}
-	}
-	return vaxis.RGBColor(rgb[0], rgb[1], rgb[2]), true
-}
-
-func parseOSCHexChannel(s string) (uint8, bool) {
-	if len(s) == 0 || len(s) > 4 {
-		return 0, false
-	}
-	v, err := strconv.ParseUint(s, 16, 16)
-	if err != nil {
-		return 0, false
-	}
-	max := uint64(0xf)
-	for i := 1; i < len(s); i += 1 {
-		max = max<<4 | 0xf
-	}
-	return uint8(v * 0xff / max), true
-}
-
-func parseOSCIntensityChannel(s string) (uint8, bool) {
-	v, err := strconv.ParseFloat(s, 64)
-	if err != nil || v < 0 || v > 1 {
-		return 0, false
-	}
-	return uint8(v * 255), true
-}
@@ -49,7 +49,7 @@ func (vt *Model) runReplyWorker(ctx context.Context, pty *os.File) {
 				continue
 			}
 			if _, err := pty.WriteString(resp); err != nil {
-				log.Error("[term] failed to write terminal reply: %v", err)
+				log.Term.Error("failed to write terminal reply: %v", err)
 			}
 		}
 	}
@@ -65,7 +65,7 @@ func (vt *Model) enqueueReply(reply termReply) {
 	select {
 	case vt.replyQueue <- reply:
 	default:
-		log.Warn("[term] terminal reply queue full; dropping reply")
+		log.Term.Warn("terminal reply queue full; dropping reply")
 	}
 }
 
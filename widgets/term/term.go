@@ -607,7 +607,7 @@ func (vt *Model) postEvent(ev vaxis.Event) {
 	select {
 	case vt.events <- ev:
 	default:
-		log.Warn("[term] event queue full; dropping %T", ev)
+		log.Term.Warn("event queue full; dropping %T", ev)
 	}
 }
 
@@ -1620,7 +1620,7 @@ outer:
 		// We haven't encountered this vaxis before
 		vxImg, err := vx.NewImage(img.img)
 		if err != nil {
-			log.Error("couldn't create Vaxis image: %v", err)
+			log.Term.Error("couldn't create Vaxis image: %v", err)
 			continue
 		}
 		// We "resize" the image to the full window size. This will
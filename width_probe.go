@@ -0,0 +1,272 @@
+package vaxis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.rockorager.dev/vaxis/log"
+)
+
+// WidthCorpus is the set of graphemes measured by [Vaxis.ProbeWidths] when no
+// graphemes are given. It covers the areas where terminals commonly disagree
+// with the Unicode standard and with each other
+var WidthCorpus = []string{
+	// Emoji
+	"😀",
+	"🙂",
+	// ZWJ sequences
+	"👩‍🚀",
+	"🧑‍💻",
+	"👨‍👩‍👧‍👦",
+	"🐻‍❄️",
+	"❤️‍🔥",
+	"🏳️‍🌈",
+	"👁️‍🗨️",
+	// VS16: emoji presentation of text-default characters
+	"⚠️",
+	"☺️",
+	"✈️",
+	"❤️",
+	"↔️",
+	"©️",
+	"™️",
+	"1️⃣",
+	// VS15: text presentation of emoji-default characters
+	"⌚︎",
+	"⌛︎",
+	"⚽︎",
+	"☕︎",
+	// East Asian Ambiguous
+	"α",
+	"Ω",
+	"§",
+	"°",
+	"±",
+	"×",
+	"…",
+	"→",
+	"①",
+	"■",
+	"○",
+	"─",
+	"€",
+	// Skin tone modifiers
+	"👋🏿",
+	"👍🏽",
+	"🧑🏻‍🤝‍🧑🏿",
+	"🏽",
+	// Flags
+	"🇺🇸",
+	"🇯🇵",
+	"🇦",
+	"🏴\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F",
+	// East Asian wide and halfwidth forms
+	"한",
+	"漢",
+	"Ａ",
+	"ｱ",
+	// Combining sequences
+	"é",
+	"क्षि",
+}
+
+// WidthTable holds the widths a terminal renders graphemes with. Once set with
+// [Vaxis.SetWidthTable], the widths in the table take precedence over the
+// widths Vaxis computes
+type WidthTable struct {
+	// Terminal identifies the terminal, including its version, which the
+	// table was measured on
+	Terminal string `json:"terminal"`
+	// Corpus is a hash of the graphemes which were measured
+	Corpus string `json:"corpus"`
+	// Widths maps graphemes to their width in cells
+	Widths map[string]int `json:"widths"`
+}
+
+// WidthProbeOptions configures measuring grapheme widths when Vaxis starts
+type WidthProbeOptions struct {
+	// CacheDir is the directory measured tables are cached in, one file
+	// per terminal. Defaults to vaxis/widths in [os.UserCacheDir]
+	CacheDir string
+	// NoCache measures widths every time Vaxis starts
+	NoCache bool
+	// Timeout is how long to wait for the terminal to report the width of
+	// each grapheme. Defaults to one second
+	Timeout time.Duration
+}
+
+// defaultWidthProbeTimeout is how long ProbeWidths waits for each reply. It is
+// longer than CursorPosition waits, since terminals reached over ssh or a
+// multiplexer can take a while to answer
+const defaultWidthProbeTimeout = time.Second
+
+// LoadWidthTable reads a WidthTable saved with [WidthTable.Save]
+func LoadWidthTable(path string) (*WidthTable, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &WidthTable{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, fmt.Errorf("vaxis: invalid width table %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Save writes the table to path as JSON, creating parent directories as
+// needed
+func (t *WidthTable) Save(path string) error {
+	b, err := json.MarshalIndent(t, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// corpusHash identifies a set of graphemes, so cached tables are measured
+// again when the corpus changes
+func corpusHash(graphemes []string) string {
+	h := fnv.New64a()
+	for _, g := range graphemes {
+		_, _ = h.Write([]byte(g))
+		_, _ = h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// ProbeWidths measures the width the terminal renders each grapheme with by
+// printing it and requesting the cursor position. When no graphemes are
+// given, [WidthCorpus] is measured. The table is returned but not installed;
+// see SetWidthTable.
+//
+// Probing writes to the row the cursor is on and erases it afterwards. The
+// next Render redraws the entire screen
+func (vx *Vaxis) ProbeWidths(graphemes ...string) (*WidthTable, error) {
+	return vx.probeWidths(defaultWidthProbeTimeout, graphemes)
+}
+
+// probeWidths measures graphemes, waiting up to timeout for each reply
+func (vx *Vaxis) probeWidths(timeout time.Duration, graphemes []string) (*WidthTable, error) {
+	if len(graphemes) == 0 {
+		graphemes = WidthCorpus
+	}
+	t := &WidthTable{
+		Terminal: vx.terminalKey(),
+		Corpus:   corpusHash(graphemes),
+		Widths:   make(map[string]int, len(graphemes)),
+	}
+	defer func() {
+		vx.mu.Lock()
+		vx.refresh = true
+		vx.mu.Unlock()
+	}()
+	for _, g := range graphemes {
+		vx.writeControlString("\r" + g)
+		_, col := vx.cursorPosition(timeout)
+		vx.writeControlString("\r" + eraseLine)
+		if col < 0 {
+			return nil, errors.New("vaxis: terminal did not report the cursor position")
+		}
+		t.Widths[g] = col
+	}
+	return t, nil
+}
+
+// SetWidthTable makes Vaxis measure graphemes in the table with the widths
+// from the table. Passing nil removes the table
+func (vx *Vaxis) SetWidthTable(t *WidthTable) {
+	vx.widthMu.Lock()
+	defer vx.widthMu.Unlock()
+	vx.widthTable = nil
+	if t != nil {
+		vx.widthTable = t.Widths
+	}
+	// Cached widths may have come from the previous table
+	vx.charCache = make(map[string]int, len(vx.charCache))
+}
+
+// terminalKey identifies the terminal and its version. The XTVERSION reply is
+// preferred, falling back to the environment for a local tty
+func (vx *Vaxis) terminalKey() string {
+	if vx.termID != "" {
+		return string(vx.termID)
+	}
+	if vx.withConsole != nil {
+		return ""
+	}
+	if prog := vx.getenv("TERM_PROGRAM"); prog != "" {
+		return strings.TrimSpace(prog + " " + vx.getenv("TERM_PROGRAM_VERSION"))
+	}
+	return vx.getenv("TERM")
+}
+
+// widthTablePath returns the cache file for the terminal identified by key
+func widthTablePath(dir string, key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	return filepath.Join(dir, name+".json")
+}
+
+// setupWidthTable installs a cached width table for the terminal, measuring
+// and caching one if needed
+func (vx *Vaxis) setupWidthTable(opts WidthProbeOptions) {
+	key := vx.terminalKey()
+	dir := opts.CacheDir
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err == nil {
+			dir = filepath.Join(cache, "vaxis", "widths")
+		}
+	}
+	useCache := !opts.NoCache && key != "" && dir != ""
+	if key == "" {
+		log.Debug("[width probe] terminal can't be identified, not caching widths")
+	}
+	path := widthTablePath(dir, key)
+	if useCache {
+		t, err := LoadWidthTable(path)
+		switch {
+		case err == nil && t.Terminal == key && t.Corpus == corpusHash(WidthCorpus):
+			log.Info("[width probe] using cached widths from %s", path)
+			vx.SetWidthTable(t)
+			return
+		case err != nil && !errors.Is(err, os.ErrNotExist):
+			log.Warn("[width probe] %v", err)
+		}
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultWidthProbeTimeout
+	}
+	t, err := vx.probeWidths(timeout, nil)
+	if err != nil {
+		log.Error("[width probe] %v", err)
+		return
+	}
+	vx.SetWidthTable(t)
+	if !useCache {
+		return
+	}
+	if err := t.Save(path); err != nil {
+		log.Error("[width probe] couldn't cache widths: %v", err)
+		return
+	}
+	log.Info("[width probe] cached widths in %s", path)
+}
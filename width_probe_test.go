@@ -0,0 +1,181 @@
+package vaxis
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// probeTerminal plays the part of a terminal which renders graphemes with the
+// given widths, answering cursor position requests
+type probeTerminal struct {
+	vx     *Vaxis
+	widths map[string]int
+	// delay is how long the terminal takes to answer
+	delay  time.Duration
+	mu     sync.Mutex
+	last   string
+	probed []string
+}
+
+func (p *probeTerminal) Write(b []byte) (int, error) {
+	s := string(b)
+	switch {
+	case s == dsrcpr:
+		p.mu.Lock()
+		col := p.widths[p.last] + 1
+		p.mu.Unlock()
+		go func() {
+			time.Sleep(p.delay)
+			p.vx.chCursorPos <- [2]int{1, col}
+		}()
+	case strings.HasPrefix(s, "\r") && s != "\r"+eraseLine:
+		p.mu.Lock()
+		p.last = strings.TrimPrefix(s, "\r")
+		p.probed = append(p.probed, p.last)
+		p.mu.Unlock()
+	}
+	return len(b), nil
+}
+
+func newProbeTestVaxis(widths map[string]int) (*Vaxis, *probeTerminal) {
+	vx := &Vaxis{
+		charCache:   make(map[string]int),
+		chCursorPos: make(chan [2]int),
+		termID:      "footclient(1.17.2)",
+		getenv:      func(string) string { return "" },
+	}
+	term := &probeTerminal{vx: vx, widths: widths}
+	vx.tw = &writer{
+		buf:      bytes.NewBuffer(nil),
+		terminal: &terminalWriter{w: term},
+		vx:       vx,
+	}
+	return vx, term
+}
+
+func TestProbeWidths(t *testing.T) {
+	vx, _ := newProbeTestVaxis(map[string]int{"⚠️": 1, "🇺🇸": 1, "α": 2})
+	table, err := vx.ProbeWidths("⚠️", "🇺🇸", "α")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Terminal != "footclient(1.17.2)" {
+		t.Fatalf("terminal = %q", table.Terminal)
+	}
+	if !vx.refresh {
+		t.Fatal("probing did not request a full redraw")
+	}
+
+	// The default widths are cached before the table is installed
+	if got := vx.characterWidth("α"); got != 1 {
+		t.Fatalf("default width of α = %d, want 1", got)
+	}
+	vx.SetWidthTable(table)
+	for g, want := range map[string]int{"⚠️": 1, "🇺🇸": 1, "α": 2, "a": 1, "😀": 2} {
+		if got := vx.characterWidth(g); got != want {
+			t.Fatalf("width of %q = %d, want %d", g, got, want)
+		}
+	}
+	vx.SetWidthTable(nil)
+	if got := vx.characterWidth("α"); got != 1 {
+		t.Fatalf("width of α without a table = %d, want 1", got)
+	}
+}
+
+func TestProbeWidthsNoResponse(t *testing.T) {
+	vx, _ := newProbeTestVaxis(nil)
+	vx.tw.terminal.w = &bytes.Buffer{}
+	if _, err := vx.probeWidths(10*time.Millisecond, []string{"a"}); err == nil {
+		t.Fatal("expected an error when the terminal doesn't respond")
+	}
+}
+
+func TestTerminalKeyFromEnvironment(t *testing.T) {
+	vx, _ := newProbeTestVaxis(nil)
+	vx.termID = ""
+	env := map[string]string{"TERM": "xterm-256color"}
+	vx.getenv = func(key string) string { return env[key] }
+	if got := vx.terminalKey(); got != "xterm-256color" {
+		t.Fatalf("key = %q, want TERM", got)
+	}
+	env["TERM_PROGRAM"] = "WezTerm"
+	env["TERM_PROGRAM_VERSION"] = "20240203"
+	if got := vx.terminalKey(); got != "WezTerm 20240203" {
+		t.Fatalf("key = %q, want TERM_PROGRAM and its version", got)
+	}
+}
+
+func TestSetupWidthTableCaches(t *testing.T) {
+	dir := t.TempDir()
+	vx, term := newProbeTestVaxis(map[string]int{"⚠️": 1})
+	vx.setupWidthTable(WidthProbeOptions{CacheDir: dir})
+	if len(term.probed) != len(WidthCorpus) {
+		t.Fatalf("probed %d graphemes, want %d", len(term.probed), len(WidthCorpus))
+	}
+	if got := vx.characterWidth("⚠️"); got != 1 {
+		t.Fatalf("width of ⚠️ = %d, want 1", got)
+	}
+
+	path := filepath.Join(dir, "footclient_1.17.2_.json")
+	table, err := LoadWidthTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Widths["⚠️"] != 1 || table.Corpus != corpusHash(WidthCorpus) {
+		t.Fatalf("cached table = %+v", table)
+	}
+
+	// A second start uses the cache without probing
+	vx, term = newProbeTestVaxis(map[string]int{"⚠️": 1})
+	vx.setupWidthTable(WidthProbeOptions{CacheDir: dir})
+	if len(term.probed) != 0 {
+		t.Fatalf("probed %d graphemes with a cached table", len(term.probed))
+	}
+	if got := vx.characterWidth("⚠️"); got != 1 {
+		t.Fatalf("cached width of ⚠️ = %d, want 1", got)
+	}
+
+	// A different version of the terminal is measured again
+	vx, term = newProbeTestVaxis(nil)
+	vx.termID = "footclient(1.18.0)"
+	vx.setupWidthTable(WidthProbeOptions{CacheDir: dir})
+	if len(term.probed) == 0 {
+		t.Fatal("a new terminal version used another version's table")
+	}
+}
+
+func TestSetWidthTableWhileMeasuring(t *testing.T) {
+	vx, _ := newProbeTestVaxis(nil)
+	table := &WidthTable{Widths: map[string]int{"α": 2}}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			vx.SetWidthTable(table)
+			vx.SetWidthTable(nil)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if w := vx.characterWidth("α"); w != 1 && w != 2 {
+			t.Fatalf("width of α = %d", w)
+		}
+	}
+	wg.Wait()
+}
+
+func TestProbeWidthsWaitsForSlowTerminal(t *testing.T) {
+	vx, term := newProbeTestVaxis(map[string]int{"a": 1})
+	term.delay = 100 * time.Millisecond
+	table, err := vx.ProbeWidths("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Widths["a"] != 1 {
+		t.Fatalf("width of a = %d, want 1", table.Widths["a"])
+	}
+}
@@ -19,6 +19,10 @@ type Window struct {
 	Row    int // row offset from parent
 	Width  int // width of the surface, in cols
 	Height int // height of the surface, in rows
+
+	// layer is the Layer drawn on by a root Window. When nil, the Window
+	// draws on the main screen
+	layer *Layer
 }
 
 // Window returns the root drawing window for the surface currently owned by
@@ -102,16 +106,23 @@ func (win Window) SetCell(col int, row int, cell Cell) {
 			return
 		}
 	}
-	if vx == nil || vx.screenNext == nil {
+	if vx == nil {
+		return
+	}
+	target := vx.screenNext
+	if w.layer != nil {
+		target = w.layer.buf
+	}
+	if target == nil {
 		return
 	}
-	if row >= vx.screenNext.rows || col >= vx.screenNext.cols {
+	if row >= target.rows || col >= target.cols {
 		return
 	}
 	if row < 0 || col < 0 {
 		return
 	}
-	vx.screenNext.setCellDirect(col, row, cell)
+	target.setCellDirect(col, row, cell)
 }
 
 // SetStyle changes the style at a given location, leaving the text in place.
@@ -142,16 +153,23 @@ func (win Window) SetStyle(col int, row int, style Style) {
 			return
 		}
 	}
-	if vx == nil || vx.screenNext == nil {
+	if vx == nil {
+		return
+	}
+	target := vx.screenNext
+	if w.layer != nil {
+		target = w.layer.buf
+	}
+	if target == nil {
 		return
 	}
-	if row >= vx.screenNext.rows || col >= vx.screenNext.cols {
+	if row >= target.rows || col >= target.cols {
 		return
 	}
 	if row < 0 || col < 0 {
 		return
 	}
-	vx.screenNext.setStyleDirect(col, row, style)
+	target.setStyleDirect(col, row, style)
 }
 
 // ShowCursor shows the cursor at colxrow, relative to this Window's location
@@ -159,6 +177,11 @@ func (win Window) ShowCursor(col int, row int, style CursorStyle) {
 	col += win.Column
 	row += win.Row
 	if win.Parent == nil {
+		if win.layer != nil {
+			lcol, lrow := win.layer.Offset()
+			col += lcol
+			row += lrow
+		}
 		win.Vx.ShowCursor(col, row, style)
 		return
 	}
@@ -175,7 +198,16 @@ func (win Window) Fill(cell Cell) {
 	}
 }
 
-// returns the Origin of the window, column x row, 0-indexed
+// root returns the Window at the top of the parent chain
+func (win Window) root() Window {
+	for win.Parent != nil {
+		win = *win.Parent
+	}
+	return win
+}
+
+// returns the Origin of the window, column x row, 0-indexed. Windows on a
+// Layer include the layer's offset
 func (win Window) Origin() (int, int) {
 	w := win
 	col := 0
@@ -184,6 +216,11 @@ func (win Window) Origin() (int, int) {
 		col += w.Column
 		row += w.Row
 		if w.Parent == nil {
+			if w.layer != nil {
+				lcol, lrow := w.layer.Offset()
+				col += lcol
+				row += lrow
+			}
 			return col, row
 		}
 		w = *w.Parent
@@ -197,6 +234,9 @@ func (win Window) Clear() {
 	// space and a cleared cell. \x00 is rendered as a space, but the
 	// internal model will differentiate
 	win.Fill(Cell{Character: Character{" ", 1}, Style: Style{}})
+	if win.root().layer != nil {
+		return
+	}
 	win.Vx.graphicsNext = []*placement{}
 }
 
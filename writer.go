@@ -67,13 +67,18 @@ func (w *writer) writeOSC8(params string, link string) {
 }
 
 func (w *writer) writeExplicitWidth(width int, grapheme string) {
-	_, _ = w.WriteString("\x1b]66;w=")
-	buf := [24]byte{}
-	b := strconv.AppendInt(buf[:0], int64(width), 10)
-	_, _ = w.Write(b)
-	_, _ = w.WriteString(";")
-	_, _ = w.WriteString(grapheme)
-	_, _ = w.WriteString("\x1b\\")
+	buf := [64]byte{}
+	_, _ = w.Write(appendExplicitWidth(buf[:0], width, grapheme))
+}
+
+// appendExplicitWidth appends an OSC 66 sequence drawing grapheme in width
+// cells. The explicit width query sends the same sequence
+func appendExplicitWidth(b []byte, width int, grapheme string) []byte {
+	b = append(b, "\x1b]66;w="...)
+	b = strconv.AppendInt(b, int64(width), 10)
+	b = append(b, ';')
+	b = append(b, grapheme...)
+	return append(b, "\x1b\\"...)
 }
 
 func (w *writer) writeUnderlineStyle(style UnderlineStyle) {
@@ -170,17 +175,6 @@ func (w *writer) writeControlCUP(row int, col int) {
 	_, _ = w.WriteControl(b)
 }
 
-func (w *writer) writeControlExplicitWidth(width int, grapheme string) {
-	buf := bytes.NewBuffer(make([]byte, 0, 16+len(grapheme)))
-	buf.WriteString("\x1b]66;w=")
-	tmp := [24]byte{}
-	buf.Write(strconv.AppendInt(tmp[:0], int64(width), 10))
-	buf.WriteByte(';')
-	buf.WriteString(grapheme)
-	buf.WriteString("\x1b\\")
-	_, _ = w.WriteControl(buf.Bytes())
-}
-
 func (w *writer) Flush() (n int, err error) {
 	if w.buf.Len() == 0 {
 		// If we didn't write any visual changes, make sure we make any
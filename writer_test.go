@@ -238,3 +238,16 @@ func TestFlushDoesNotShowCursorForHiddenCursorPositionChange(t *testing.T) {
 		t.Fatalf("hidden cursor position change wrote %q, want no output", got)
 	}
 }
+
+func TestExplicitWidthSequence(t *testing.T) {
+	want := "\x1b]66;w=2;字\x1b\\"
+	if got := string(appendExplicitWidth(nil, 2, "字")); got != want {
+		t.Fatalf("explicit width = %q, want %q", got, want)
+	}
+	var out bytes.Buffer
+	vx := newWriterTestVaxis(&out)
+	vx.tw.writeExplicitWidth(2, "字")
+	if got := vx.tw.buf.String(); !strings.HasSuffix(got, want) {
+		t.Fatalf("written explicit width = %q, want %q", got, want)
+	}
+}